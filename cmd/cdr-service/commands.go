@@ -0,0 +1,141 @@
+// sentiric-cdr-service/cmd/cdr-service/commands.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/config"
+	"github.com/sentiric/sentiric-cdr-service/internal/database"
+	"github.com/sentiric/sentiric-cdr-service/internal/logger"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// command: Servis ikilisinin yönetim alt komutu (örn: "cdr-service rerate ...").
+// parse bayrakları veritabanına bağlanmadan önce doğrular ve çalıştırılacak eylemi döner.
+type command struct {
+	summary string
+	parse   func(args []string) (action, error)
+}
+
+type action func(ctx context.Context, env *cliEnv) error
+
+var commands = map[string]command{
+	"rerate":        {summary: "Geçmiş çağrıları seçilen fiyat sürümüyle yeniden derecelendirir", parse: parseRerate},
+	"delivery":      {summary: "Bir CDR teslim işini verilen aralık için hemen çalıştırır (run)", parse: parseDelivery},
+	"erasure":       {summary: "KVKK / GDPR ilgili kişi silme talebini yürütür (run), gösterir (show) veya listeler (list)", parse: parseErasure},
+	"export":        {summary: "Tenant'ın CDR'larını CSV, NDJSON veya Parquet olarak dışa aktarır", parse: parseExport},
+	"fraud":         {summary: "Dolandırıcılık kuralı eşiklerini tanımlar (set), kaldırır (remove) veya listeler (list)", parse: parseFraud},
+	"invoice":       {summary: "Faturalama dönemini kapatır (close) veya fatura verisini yazdırır (show)", parse: parseInvoice},
+	"keys":          {summary: "Alan şifrelemesi anahtar rotasyonu (rotate), yeniden şifreleme (reencrypt), ana anahtar değişimi (rewrap) ve durum (status)", parse: parseKeys},
+	"kpi":           {summary: "Aralıktaki çağrıların KPI rollup katkılarını yeniden hesaplar (rebuild)", parse: parseKPI},
+	"masking":       {summary: "Numara maskeleme politikasını tanımlar (set), kaldırır (remove) veya listeler (list)", parse: parseMasking},
+	"rating-policy": {summary: "Tenant'ın süre yuvarlama politikasını tanımlar (set), kaldırır (remove) veya listeler (list)", parse: parseRatingPolicy},
+	"retention":     {summary: "Saklama çalışması (run), partition dönüşümü (partition), politika (policy) ve yasal saklama (hold/release)", parse: parseRetention},
+	"verify":        {summary: "CDR hash zincirini satırlara ve imzalı kontrol noktalarına karşı doğrular", parse: parseVerify},
+}
+
+// cliEnv: Alt komutların paylaştığı konfigürasyon, logger ve veritabanı bağlantısı.
+type cliEnv struct {
+	cfg  *config.Config
+	log  zerolog.Logger
+	db   *sql.DB
+	keys *repository.Keyring // nil: alan şifrelemesi kapalı
+}
+
+// runCommand: Alt komutu çalıştırır ve süreç çıkış kodunu döner.
+func runCommand(name string, args []string) int {
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage()
+		return 2
+	}
+	run, err := cmd.parse(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 2
+	}
+
+	cfg, err := config.LoadCLI(ServiceVersion)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Kritik Hata: Konfigürasyon yüklenemedi: %v\n", err)
+		return 1
+	}
+	log := logger.New(serviceName, cfg.ServiceVersion, cfg.Env, cfg.NodeHostname, cfg.LogLevel, cfg.LogFormat).
+		With().Str("command", name).Logger()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	connectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	db, err := database.Connect(connectCtx, cfg.PostgresURL, log)
+	cancel()
+	if err != nil {
+		log.Error().Err(err).Msg("Veritabanına bağlanılamadı.")
+		return 1
+	}
+	defer db.Close()
+
+	if err := database.Migrate(ctx, db, log); err != nil {
+		log.Error().Err(err).Msg("Şema migration'ları uygulanamadı.")
+		return 1
+	}
+
+	master, err := openMasterKey(cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Alan şifrelemesi ana anahtarı açılamadı.")
+		return 1
+	}
+	env := &cliEnv{cfg: cfg, log: log, db: db}
+	if master != nil {
+		env.keys = repository.NewKeyring(db, master)
+	}
+
+	if err := run(ctx, env); err != nil {
+		log.Error().Err(err).Msg("Komut başarısız oldu.")
+		return 1
+	}
+	return 0
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Kullanım: cdr-service [komut] [bayraklar]")
+	fmt.Fprintln(os.Stderr, "Komut verilmezse servis olay tüketicisi olarak başlar.")
+	fmt.Fprintln(os.Stderr, "\nKomutlar:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
+
+// parseDateFlag: "2025-01-31" veya RFC3339 biçimini kabul eder.
+func parseDateFlag(name, value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("--%s tarih (YYYY-MM-DD) veya RFC3339 olmalı: %q", name, value)
+	}
+	return t, nil
+}
+
+// printJSON: Komut çıktısını girintili JSON olarak yazdırır.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
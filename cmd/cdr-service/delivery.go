@@ -0,0 +1,57 @@
+// sentiric-cdr-service/cmd/cdr-service/delivery.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/delivery"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseDelivery: "delivery run" bir teslim işini zamanlamayı beklemeden verilen aralık için çalıştırır.
+// Teslim kaydı oluşturulmaz; hedef bağlantısını (örn: yerel MinIO veya SFTP) denemek için kullanılır.
+func parseDelivery(args []string) (action, error) {
+	if len(args) == 0 || args[0] != "run" {
+		return nil, errors.New("alt komut gerekli: run")
+	}
+
+	fs := flag.NewFlagSet("delivery run", flag.ContinueOnError)
+	jobID := fs.Int64("job", 0, "cdr_delivery_jobs.id")
+	from := fs.String("from", "", "Başlangıç, dahil (YYYY-MM-DD veya RFC3339)")
+	to := fs.String("to", "", "Bitiş, hariç (YYYY-MM-DD veya RFC3339)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	if *jobID == 0 || *from == "" || *to == "" {
+		fs.Usage()
+		return nil, errors.New("--job, --from ve --to zorunludur")
+	}
+	start, err := parseDateFlag("from", *from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDateFlag("to", *to)
+	if err != nil {
+		return nil, err
+	}
+	if !start.Before(end) {
+		return nil, errors.New("--from, --to'dan önce olmalı")
+	}
+
+	return func(ctx context.Context, env *cliEnv) error {
+		job, err := repository.NewDeliveryRepository(env.db).GetJob(ctx, *jobID)
+		if err != nil {
+			return err
+		}
+		res, err := delivery.NewScheduler(env.db, env.keys, newMaskingEngine(env.cfg, env.db), env.log).Deliver(ctx, job, start, end)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%d rows\t%d bytes\tsha256:%s\n", res.ObjectName, res.Rows, res.Size, res.SHA256)
+		return nil
+	}, nil
+}
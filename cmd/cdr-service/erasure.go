@@ -0,0 +1,150 @@
+// sentiric-cdr-service/cmd/cdr-service/erasure.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/erasure"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseErasure: "erasure run|show|list" alt komutlarını ayrıştırır.
+func parseErasure(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: run, show veya list")
+	}
+	fs := flag.NewFlagSet("erasure "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "run":
+		phone := fs.String("phone", "", "Silinecek kişinin telefon numarası")
+		userID := fs.String("user-id", "", "Silinecek kişinin kullanıcı ID'si")
+		tenantID := fs.String("tenant", "", "Tenant ID (boşsa tüm tenant'lar)")
+		regulation := fs.String("regulation", privacy.RegulationKVKK, "Dayanak: KVKK veya GDPR")
+		reference := fs.String("reference", "", "Başvuru/talep numarası")
+		requestedBy := fs.String("requested-by", "", "Talebi işleyen kişi (örn: veri sorumlusu irtibat kişisi)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		subject, err := parseSubject(*phone, *userID)
+		if err != nil {
+			return nil, err
+		}
+		req := erasure.Request{TenantID: *tenantID, Regulation: *regulation, Reference: *reference,
+			RequestedBy: *requestedBy, Subject: subject}
+		return func(ctx context.Context, env *cliEnv) error {
+			svc, err := newErasureService(env)
+			if err != nil {
+				return err
+			}
+			done, err := svc.Erase(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printJSON(done)
+		}, nil
+
+	case "show":
+		id := fs.Int64("id", 0, "Silme talebi ID")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *id <= 0 {
+			return nil, errors.New("--id zorunludur")
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			rec, err := repository.NewErasureRepository(env.db, env.keys).GetRequest(ctx, *id)
+			if err != nil {
+				return err
+			}
+			if err := checkCertificate(env, rec); err != nil {
+				env.log.Warn().Err(err).Int64("erasure_id", rec.ID).Msg("Silme sertifikası doğrulanamadı.")
+			}
+			return printJSON(rec)
+		}, nil
+
+	case "list":
+		phone := fs.String("phone", "", "Kişinin telefon numarasına ait talepler")
+		userID := fs.String("user-id", "", "Kişinin kullanıcı ID'sine ait talepler")
+		f := repository.ErasureFilter{}
+		fs.StringVar(&f.TenantID, "tenant", "", "Tenant ID")
+		fs.StringVar(&f.Regulation, "regulation", "", "KVKK veya GDPR")
+		fs.StringVar(&f.Reference, "reference", "", "Başvuru/talep numarası")
+		fs.IntVar(&f.Limit, "limit", 100, "En fazla talep sayısı (0: tümü)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		var subject *privacy.Subject
+		if *phone != "" || *userID != "" {
+			s, err := parseSubject(*phone, *userID)
+			if err != nil {
+				return nil, err
+			}
+			subject = &s
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			if subject != nil {
+				f.SubjectHash = subject.Hash([]byte(env.cfg.PseudonymKey))
+			}
+			list, err := repository.NewErasureRepository(env.db, env.keys).ListRequests(ctx, f)
+			if err != nil {
+				return err
+			}
+			return printJSON(list)
+		}, nil
+	}
+	return nil, fmt.Errorf("bilinmeyen alt komut: %s", args[0])
+}
+
+// parseSubject: --phone ve --user-id bayraklarından tam olarak birini bekler.
+func parseSubject(phone, userID string) (privacy.Subject, error) {
+	switch {
+	case phone != "" && userID != "":
+		return privacy.Subject{}, errors.New("--phone ve --user-id birlikte kullanılamaz")
+	case phone != "":
+		return privacy.NewSubject(privacy.SubjectPhone, phone)
+	case userID != "":
+		return privacy.NewSubject(privacy.SubjectUserID, userID)
+	}
+	return privacy.Subject{}, errors.New("--phone veya --user-id zorunludur")
+}
+
+// newErasureService: Hash zinciri anahtarı tanımlıysa sertifikalar onunla imzalanır.
+func newErasureService(env *cliEnv) (*erasure.Service, error) {
+	var signer *chain.Signer
+	if env.cfg.ChainSigningKey != "" {
+		var err error
+		if signer, err = chain.NewSigner(env.cfg.ChainKeyID, env.cfg.ChainSigningKey); err != nil {
+			return nil, err
+		}
+	} else {
+		env.log.Warn().Msg("CDR_CHAIN_SIGNING_KEY tanımlı değil; silme sertifikası imzasız üretilecek.")
+	}
+	if env.cfg.PseudonymKey == "" {
+		env.log.Warn().Msg("CDR_PSEUDONYM_KEY tanımlı değil; takma ad rastgele üretilecek.")
+	}
+	return erasure.NewService(env.db, env.keys, signer, env.cfg.PseudonymKey, env.log), nil
+}
+
+// checkCertificate: İmza anahtarı tanımlıysa tamamlanmış talebin sertifika imzasını doğrular.
+func checkCertificate(env *cliEnv, rec repository.ErasureRequest) error {
+	if len(rec.Certificate) == 0 || env.cfg.ChainSigningKey == "" {
+		return nil
+	}
+	signer, err := chain.NewSigner(env.cfg.ChainKeyID, env.cfg.ChainSigningKey)
+	if err != nil {
+		return err
+	}
+	pub, _ := chain.ParsePublicKey(signer.PublicKey())
+	var cert privacy.Certificate
+	if err := json.Unmarshal(rec.Certificate, &cert); err != nil {
+		return err
+	}
+	return privacy.VerifyCertificate(pub, cert)
+}
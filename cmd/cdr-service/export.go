@@ -0,0 +1,103 @@
+// sentiric-cdr-service/cmd/cdr-service/export.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/export"
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func parseExport(args []string) (action, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	tenantID := fs.String("tenant", "", "Tenant ID")
+	from := fs.String("from", "", "Başlangıç tarihi, dahil (YYYY-MM-DD veya RFC3339)")
+	to := fs.String("to", "", "Bitiş tarihi, hariç (YYYY-MM-DD veya RFC3339)")
+	format := fs.String("format", export.FormatCSV, "Çıktı formatı: csv, ndjson veya parquet")
+	view := fs.String("view", export.ViewLegs, "Görünüm: legs (bacak başına satır) veya journey (etkileşim başına satır)")
+	columns := fs.String("columns", "", "Virgülle ayrılmış kolon listesi (boşsa varsayılan set)")
+	tz := fs.String("tz", "UTC", "CSV/NDJSON zaman damgalarının saat dilimi (örn: Europe/Istanbul)")
+	mask := fs.Bool("mask-numbers", false, "Arayan/aranan numaraları son 4 hane dışında maskele")
+	role := fs.String("role", "support", "Maskeleme politikası çözülecek tüketici rolü")
+	unmasked := fs.Bool("unmasked", false, "Açık numara yetkisiyle çalış (politika NONE ise numaralar açık yazılır)")
+	withEvents := fs.Bool("include-events", false, "Her satıra çağrının olaylarını ekle")
+	output := fs.String("output", "", "Çıktı dosyası (boşsa stdout)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *tenantID == "" || *from == "" || *to == "" {
+		fs.Usage()
+		return nil, errors.New("--tenant, --from ve --to zorunludur")
+	}
+	f := repository.CallFilter{TenantID: *tenantID}
+	var err error
+	if f.From, err = parseDateFlag("from", *from); err != nil {
+		return nil, err
+	}
+	if f.To, err = parseDateFlag("to", *to); err != nil {
+		return nil, err
+	}
+	if !f.From.Before(f.To) {
+		return nil, errors.New("--from, --to'dan önce olmalı")
+	}
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return nil, fmt.Errorf("geçersiz saat dilimi: %q", *tz)
+	}
+	opts := export.Options{
+		Format:        *format,
+		View:          *view,
+		Columns:       export.ParseColumns(*columns),
+		Location:      loc,
+		MaskNumbers:   *mask,
+		IncludeEvents: *withEvents,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, env *cliEnv) error {
+		c := masking.Consumer{Role: *role, Unmasked: *unmasked}
+		var err error
+		if opts.Numbers, err = newMaskingEngine(env.cfg, env.db).For(ctx, f.TenantID, c); err != nil {
+			return fmt.Errorf("maskeleme politikası okunamadı: %w", err)
+		}
+		return executeExport(ctx, env, f, opts, *output)
+	}, nil
+}
+
+func executeExport(ctx context.Context, env *cliEnv, f repository.CallFilter, opts export.Options, output string) error {
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	bw := bufio.NewWriterSize(out, 64*1024)
+
+	exporter := export.New(repository.NewCallRepository(env.db, env.keys, env.log))
+	count, err := exporter.Export(ctx, f, opts, bw)
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	env.log.Info().Str("tenant_id", f.TenantID).Str("format", opts.Format).Int("rows", count).
+		Msg("📤 CDR export tamamlandı.")
+	return nil
+}
@@ -0,0 +1,111 @@
+// sentiric-cdr-service/cmd/cdr-service/fraud.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/fraud"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseFraud: "fraud set|remove|list" alt komutlarını ayrıştırır. set yalnızca verilen eşikleri değiştirir;
+// diğerleri tenant'ın mevcut (yoksa varsayılan) eşiklerinden alınır.
+func parseFraud(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: set, remove veya list")
+	}
+	fs := flag.NewFlagSet("fraud "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "set":
+		tenantID := fs.String("tenant", "", "Tenant ID ('*' varsayılan eşikler)")
+		enabled := fs.Bool("enabled", true, "Kurallar bu tenant için çalışsın mı")
+		spend := fs.Float64("spend-per-hour", 0, "Son bir saatte biten çağrıların toplam maliyet eşiği (0 kapatır)")
+		riskCalls := fs.Int("high-risk-calls", 0, "Son bir saatte yüksek riskli öneklere çağrı eşiği (0 kapatır)")
+		prefixes := fs.String("high-risk-prefixes", "", "Virgülle ayrılmış ülke kodlu önekler (örn: 882,883)")
+		burst := fs.Int("burst-calls", 0, "Patlama penceresinde tek hedefe kısa çağrı eşiği (0 kapatır)")
+		burstWindow := fs.Int("burst-window", 10, "Patlama penceresi (dakika, 1-60)")
+		short := fs.Int("short-call-seconds", 10, "Kısa çağrı sayılacak en uzun süre (saniye)")
+		offStart := fs.Int("off-hours-start", 22, "Mesai dışı başlangıç saati (yerel, 0-23)")
+		offEnd := fs.Int("off-hours-end", 6, "Mesai dışı bitiş saati (yerel, 0-23)")
+		offCalls := fs.Int("off-hours-calls", 0, "Son bir saatte mesai dışında başlayan çağrı eşiği (0 kapatır)")
+		tz := fs.String("timezone", "UTC", "Mesai saatlerinin saat dilimi (örn: Europe/Istanbul)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *tenantID == "" {
+			return nil, errors.New("--tenant zorunludur")
+		}
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+		return func(ctx context.Context, env *cliEnv) error {
+			repo := repository.NewFraudRepository(env.db, env.keys)
+			t, err := repo.GetThresholds(ctx, *tenantID)
+			if err != nil {
+				return err
+			}
+			if t.Timezone == "" {
+				// Hiç tanım yok: tablo varsayılanlarıyla başlanır.
+				t = repository.FraudThresholds{Enabled: true, BurstWindowMinutes: 10, ShortCallSeconds: 10,
+					OffHoursStart: 22, OffHoursEnd: 6, Timezone: "UTC"}
+			}
+			t.TenantID = *tenantID
+			for name, apply := range map[string]func(){
+				"enabled":            func() { t.Enabled = *enabled },
+				"spend-per-hour":     func() { t.SpendPerHour = *spend },
+				"high-risk-calls":    func() { t.HighRiskCallsPerHour = *riskCalls },
+				"high-risk-prefixes": func() { t.HighRiskPrefixes = *prefixes },
+				"burst-calls":        func() { t.BurstCalls = *burst },
+				"burst-window":       func() { t.BurstWindowMinutes = *burstWindow },
+				"short-call-seconds": func() { t.ShortCallSeconds = *short },
+				"off-hours-start":    func() { t.OffHoursStart = *offStart },
+				"off-hours-end":      func() { t.OffHoursEnd = *offEnd },
+				"off-hours-calls":    func() { t.OffHoursCallsPerHour = *offCalls },
+				"timezone":           func() { t.Timezone = *tz },
+			} {
+				if set[name] {
+					apply()
+				}
+			}
+			if err := fraud.Validate(t); err != nil {
+				return err
+			}
+			saved, err := repo.SetThresholds(ctx, t)
+			if err != nil {
+				return err
+			}
+			return printJSON(saved)
+		}, nil
+
+	case "remove":
+		tenantID := fs.String("tenant", "", "Tenant ID")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *tenantID == "" {
+			return nil, errors.New("--tenant zorunludur")
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			return repository.NewFraudRepository(env.db, env.keys).DeleteThresholds(ctx, *tenantID)
+		}, nil
+
+	case "list":
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			list, err := repository.NewFraudRepository(env.db, env.keys).ListThresholds(ctx)
+			if err != nil {
+				return err
+			}
+			return printJSON(list)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("bilinmeyen alt komut: %q (set, remove veya list)", args[0])
+	}
+}
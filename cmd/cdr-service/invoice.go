@@ -0,0 +1,108 @@
+// sentiric-cdr-service/cmd/cdr-service/invoice.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/invoice"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseInvoice: "invoice close" ve "invoice show" alt komutlarını ayrıştırır.
+func parseInvoice(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: close veya show")
+	}
+
+	fs := flag.NewFlagSet("invoice "+args[0], flag.ContinueOnError)
+	tenantID := fs.String("tenant", "", "Tenant ID (close için boşsa faturalanmamış kullanımı olan tüm tenant'lar)")
+	period := fs.String("period", "", "Faturalama dönemi (YYYY-MM, UTC)")
+	format := fs.String("format", "json", "Çıktı formatı: json veya csv")
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	if *period == "" {
+		return nil, errors.New("--period zorunludur")
+	}
+	start, end, err := invoice.ParsePeriod(*period)
+	if err != nil {
+		return nil, err
+	}
+	if *format != "json" && *format != "csv" {
+		return nil, fmt.Errorf("desteklenmeyen format: %q", *format)
+	}
+
+	switch args[0] {
+	case "close":
+		if end.After(time.Now().UTC()) {
+			return nil, fmt.Errorf("%s dönemi henüz bitmedi, kapatılamaz", *period)
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			return closeInvoices(ctx, env, *tenantID, start, end, *format)
+		}, nil
+	case "show":
+		if *tenantID == "" {
+			return nil, errors.New("show için --tenant zorunludur")
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			inv, err := loadInvoice(ctx, repository.NewInvoiceRepository(env.db), *tenantID, start, end)
+			if err != nil {
+				return err
+			}
+			return invoice.Write(os.Stdout, *format, inv)
+		}, nil
+	default:
+		return nil, fmt.Errorf("bilinmeyen alt komut: %q (close veya show)", args[0])
+	}
+}
+
+func closeInvoices(ctx context.Context, env *cliEnv, tenantID string, start, end time.Time, format string) error {
+	repo := repository.NewInvoiceRepository(env.db)
+
+	tenants := []string{tenantID}
+	if tenantID == "" {
+		var err error
+		if tenants, err = repo.ListUnbilledTenants(ctx, end); err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	for _, t := range tenants {
+		inv, err := repo.ClosePeriod(ctx, t, start, end)
+		if errors.Is(err, repository.ErrPeriodClosed) {
+			env.log.Warn().Str("tenant_id", t).Msg("Dönem zaten kapatılmış, atlanıyor.")
+			continue
+		}
+		if err != nil {
+			env.log.Error().Err(err).Str("tenant_id", t).Msg("Dönem kapatılamadı.")
+			failed++
+			continue
+		}
+		env.log.Info().Str("tenant_id", t).Str("period", invoice.FormatPeriod(start)).
+			Float64("total", inv.Total).Int("lines", len(inv.Lines)).Msg("🧾 Faturalama dönemi kapatıldı.")
+		if err := invoice.Write(os.Stdout, format, inv); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d tenant için dönem kapatılamadı", failed)
+	}
+	return nil
+}
+
+// loadInvoice: Kapatılmış dönemin dondurulmuş verisini, yoksa taslak önizlemesini döner.
+func loadInvoice(ctx context.Context, repo *repository.InvoiceRepository, tenantID string, start, end time.Time) (repository.Invoice, error) {
+	inv, err := repo.GetInvoice(ctx, tenantID, start)
+	if errors.Is(err, repository.ErrPeriodNotFound) {
+		return repo.PreviewInvoice(ctx, tenantID, start, end)
+	}
+	return inv, err
+}
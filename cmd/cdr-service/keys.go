@@ -0,0 +1,116 @@
+// sentiric-cdr-service/cmd/cdr-service/keys.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/config"
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseKeys: "keys rotate|reencrypt|rewrap|status" alt komutlarını ayrıştırır. Yeniden şifreleme, servis
+// içindeki çalışan tarafından arka planda yapılır; komutlar yalnızca işi açar.
+func parseKeys(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: rotate, reencrypt, rewrap veya status")
+	}
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "rotate", "reencrypt":
+		tenantID := fs.String("tenant", "", "Tenant ID (boşsa tüm tenant'lar)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		rotate := args[0] == "rotate"
+		return func(ctx context.Context, env *cliEnv) error {
+			keyring, err := requireKeyring(env)
+			if err != nil {
+				return err
+			}
+			var job repository.RekeyJob
+			if rotate {
+				job, err = keyring.Rotate(ctx, *tenantID)
+			} else {
+				job, err = repository.NewRekeyRepository(env.db, env.keys).CreateJob(ctx, *tenantID)
+			}
+			if err != nil {
+				return err
+			}
+			env.log.Info().Int64("job_id", job.ID).Str("tenant_id", *tenantID).
+				Msg("Yeniden şifreleme işi açıldı; servis arka planda yürütecek.")
+			return printJSON(job)
+		}, nil
+
+	case "rewrap":
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			keyring, err := requireKeyring(env)
+			if err != nil {
+				return err
+			}
+			n, err := keyring.Rewrap(ctx)
+			if err != nil {
+				return err
+			}
+			return printJSON(map[string]interface{}{"master_key_id": keyring.MasterKeyID(), "rewrapped": n})
+		}, nil
+
+	case "status":
+		tenantID := fs.String("tenant", "", "Tenant ID (boşsa tüm tenant'lar)")
+		limit := fs.Int("limit", 20, "Listelenecek en fazla iş sayısı (0: tümü)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			keyring, err := requireKeyring(env)
+			if err != nil {
+				return err
+			}
+			keys, err := keyring.ListDataKeys(ctx, *tenantID)
+			if err != nil {
+				return err
+			}
+			jobs, err := repository.NewRekeyRepository(env.db, env.keys).ListJobs(ctx, *limit)
+			if err != nil {
+				return err
+			}
+			return printJSON(map[string]interface{}{
+				"master_key_id": keyring.MasterKeyID(),
+				"data_keys":     keys,
+				"rekey_jobs":    jobs,
+			})
+		}, nil
+	}
+	return nil, fmt.Errorf("bilinmeyen alt komut: %s", args[0])
+}
+
+// requireKeyring: Anahtar komutları ana anahtar yapılandırılmadan çalışmaz.
+func requireKeyring(env *cliEnv) (*repository.Keyring, error) {
+	if env.keys == nil {
+		return nil, errors.New("CDR_MASTER_KEY_FILE veya CDR_KMS_ADDR tanımlı değil")
+	}
+	return env.keys, nil
+}
+
+// openMasterKey: Yapılandırılan ana anahtar sağlayıcısını döner; ikisi de tanımlı değilse nil (şifreleme kapalı).
+func openMasterKey(cfg *config.Config) (fieldcrypt.MasterKey, error) {
+	switch {
+	case cfg.MasterKeyFile != "" && cfg.KMSAddr != "":
+		return nil, errors.New("CDR_MASTER_KEY_FILE ve CDR_KMS_ADDR birlikte tanımlanamaz")
+	case cfg.MasterKeyFile != "":
+		return fieldcrypt.LoadFileMasterKey(cfg.MasterKeyFile)
+	case cfg.KMSAddr != "":
+		if cfg.KMSToken == "" {
+			return nil, errors.New("CDR_KMS_ADDR için CDR_KMS_TOKEN zorunludur")
+		}
+		return fieldcrypt.NewTransitMasterKey(cfg.KMSAddr, cfg.KMSToken, cfg.KMSKey), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,55 @@
+// sentiric-cdr-service/cmd/cdr-service/kpi.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseKPI: "kpi rebuild" aralıktaki tamamlanmış çağrıların KPI ve temsilci rollup katkılarını yeniden hesaplar. Geçmiş
+// verinin rollup'a alınması veya veritabanında elle düzeltilen CDR'ların yansıtılması için kullanılır.
+func parseKPI(args []string) (action, error) {
+	if len(args) == 0 || args[0] != "rebuild" {
+		return nil, errors.New("alt komut gerekli: rebuild")
+	}
+	fs := flag.NewFlagSet("kpi rebuild", flag.ContinueOnError)
+	tenantID := fs.String("tenant", "", "Tenant ID (boşsa tüm tenant'lar)")
+	from := fs.String("from", "", "Başlangıç, dahil (YYYY-MM-DD veya RFC3339)")
+	to := fs.String("to", "", "Bitiş, hariç (YYYY-MM-DD veya RFC3339)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	if *from == "" || *to == "" {
+		fs.Usage()
+		return nil, errors.New("--from ve --to zorunludur")
+	}
+	start, err := parseDateFlag("from", *from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDateFlag("to", *to)
+	if err != nil {
+		return nil, err
+	}
+	if !start.Before(end) {
+		return nil, errors.New("--from, --to'dan önce olmalı")
+	}
+
+	return func(ctx context.Context, env *cliEnv) error {
+		done := 0
+		n, err := repository.NewKPIRepository(env.db).Rebuild(ctx, *tenantID, start, end, func(string) {
+			if done++; done%1000 == 0 {
+				env.log.Info().Int("calls", done).Msg("KPI katkıları yenileniyor...")
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("%d çağrı işlendikten sonra durdu: %w", n, err)
+		}
+		env.log.Info().Int("calls", n).Msg("📊 KPI rollup'ları yeniden hesaplandı.")
+		return nil
+	}, nil
+}
@@ -13,12 +13,24 @@ import (
 	"github.com/rabbitmq/amqp091-go"
 	"github.com/rs/zerolog"
 
+	"github.com/sentiric/sentiric-cdr-service/internal/anomaly"
+	"github.com/sentiric/sentiric-cdr-service/internal/api"
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/concurrency"
 	"github.com/sentiric/sentiric-cdr-service/internal/config"
 	"github.com/sentiric/sentiric-cdr-service/internal/database"
+	"github.com/sentiric/sentiric-cdr-service/internal/delivery"
 	"github.com/sentiric/sentiric-cdr-service/internal/handler"
+	"github.com/sentiric/sentiric-cdr-service/internal/integrity"
 	"github.com/sentiric/sentiric-cdr-service/internal/logger"
 	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/outbox"
 	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/rekey"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	"github.com/sentiric/sentiric-cdr-service/internal/retention"
+	"github.com/sentiric/sentiric-cdr-service/internal/stream"
+	"github.com/sentiric/sentiric-cdr-service/internal/webhook"
 )
 
 var (
@@ -30,6 +42,10 @@ var (
 const serviceName = "cdr-service"
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	cfg, err := config.Load(ServiceVersion)
 	if err != nil {
 		log.Fatalf("Kritik Hata: Konfigürasyon yüklenemedi: %v", err)
@@ -71,8 +87,72 @@ func main() {
 			defer rabbitConn.Close()
 		}
 
+		if err := database.Migrate(ctx, db, appLog); err != nil {
+			appLog.Error().Err(err).Msg("Şema migration'ları uygulanamadı, servis durduruluyor.")
+			cancel()
+			return
+		}
+
+		master, err := openMasterKey(cfg)
+		if err != nil {
+			appLog.Error().Err(err).Msg("Alan şifrelemesi ana anahtarı açılamadı, servis durduruluyor.")
+			cancel()
+			return
+		}
+		var keys *repository.Keyring
+		if master != nil {
+			keys = repository.NewKeyring(db, master)
+			appLog.Info().Str("master_key_id", master.ID()).Msg("🔐 Numaralar ve olay gövdeleri şifreli yazılacak.")
+			go rekey.NewWorker(db, keys, appLog).Run(ctx)
+		} else {
+			appLog.Warn().Msg("CDR_MASTER_KEY_FILE / CDR_KMS_ADDR tanımlı değil; numaralar ve olay gövdeleri düz metin yazılacak.")
+		}
+
+		// Eşzamanlılık durumu tüketici başlamadan açık çağrılardan kurulur; başarısız olursa periyodik eşitleme tamamlar.
+		tracker := concurrency.NewTracker(db, appLog)
+		if err := tracker.Sync(ctx); err != nil {
+			appLog.Error().Err(err).Msg("Açık çağrılar okunamadı; eşzamanlılık sayımı ilk eşitlemeye kadar eksik olabilir.")
+		}
+		go tracker.Run(ctx)
+
+		hub := stream.NewHub(db, keys, appLog)
+		go hub.Run(ctx)
+		live := stream.NewPublisher(db, keys, appLog)
+		go live.Run(ctx)
+
+		masks := newMaskingEngine(cfg, db)
+		gateway := api.Gateway{Secret: []byte(cfg.GatewaySecret), TrustHeaders: cfg.TrustGatewayHeaders}
+		if len(gateway.Secret) == 0 && !gateway.TrustHeaders {
+			appLog.Warn().Msg("CDR_GATEWAY_SECRET / CDR_TRUST_GATEWAY_HEADERS tanımlı değil; rol ve yetki başlıkları yok sayılacak.")
+		}
+		go api.NewServer(db, keys, masks, tracker, hub, gateway, appLog).Start(ctx, cfg.HTTPPort)
+
 		// [GÜNCELLEME]: NewEventHandler artık database.DB nesnesini alıyor.
-		eventHandler := handler.NewEventHandler(db, appLog, metrics.EventsProcessed, metrics.EventsFailed)
+		eventHandler := handler.NewEventHandler(db, keys, masks, tracker, live, appLog, metrics.EventsProcessed, metrics.EventsFailed)
+
+		publisher, err := queue.NewPublisher(rabbitConn)
+		if err != nil {
+			appLog.Error().Err(err).Msg("RabbitMQ yayın kanalı oluşturulamadı, servis durduruluyor.")
+			cancel()
+			return
+		}
+		defer publisher.Close()
+		go outbox.NewRelay(repository.NewOutboxRepository(db), publisher, appLog).Run(ctx)
+		go delivery.NewScheduler(db, keys, masks, appLog).Run(ctx)
+		go webhook.NewDispatcher(db, appLog).Run(ctx)
+		go retention.NewManager(db, appLog).Run(ctx)
+		go anomaly.NewMonitor(db, appLog).Run(ctx)
+		if cfg.ChainSigningKey != "" {
+			signer, err := chain.NewSigner(cfg.ChainKeyID, cfg.ChainSigningKey)
+			if err != nil {
+				appLog.Error().Err(err).Msg("Hash zinciri imza anahtarı geçersiz, servis durduruluyor.")
+				cancel()
+				return
+			}
+			go integrity.NewCheckpointer(db, keys, signer, appLog).Run(ctx)
+		} else {
+			appLog.Warn().Msg("CDR_CHAIN_SIGNING_KEY tanımlı değil; hash zinciri kontrol noktaları imzalanmayacak.")
+		}
 
 		var consumerWg sync.WaitGroup
 		go queue.StartConsumer(ctx, rabbitConn, eventHandler.HandleEvent, appLog, &consumerWg)
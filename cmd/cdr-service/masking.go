@@ -0,0 +1,78 @@
+// sentiric-cdr-service/cmd/cdr-service/masking.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/config"
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// newMaskingEngine: Servis ve yönetim komutlarının paylaştığı maskeleme motoru.
+func newMaskingEngine(cfg *config.Config, db *sql.DB) *masking.Engine {
+	return masking.NewEngine(repository.NewMaskingRepository(db), []byte(cfg.MaskingKey))
+}
+
+// parseMasking: "masking set|remove|list" alt komutlarını ayrıştırır.
+func parseMasking(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: set, remove veya list")
+	}
+	fs := flag.NewFlagSet("masking "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "set":
+		tenantID := fs.String("tenant", "", "Tenant ID ('*' tüm tenant'lar)")
+		role := fs.String("role", "", "Tüketici rolü: log, webhook, delivery, api, serbest rol veya '*'")
+		mode := fs.String("mode", masking.ModeKeepLast, "Maskeleme biçimi: KEEP_LAST, HASH, REDACT veya NONE")
+		keep := fs.Int("keep-last", 4, "KEEP_LAST için açık kalacak son hane sayısı (0-8)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		p := masking.Policy{TenantID: *tenantID, Role: *role, Mode: strings.ToUpper(*mode), KeepLast: *keep}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			saved, err := repository.NewMaskingRepository(env.db).SetPolicy(ctx, p)
+			if err != nil {
+				return err
+			}
+			return printJSON(saved)
+		}, nil
+
+	case "remove":
+		tenantID := fs.String("tenant", "", "Tenant ID")
+		role := fs.String("role", "", "Tüketici rolü")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *tenantID == "" || *role == "" {
+			return nil, errors.New("--tenant ve --role zorunludur")
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			return repository.NewMaskingRepository(env.db).DeletePolicy(ctx, *tenantID, *role)
+		}, nil
+
+	case "list":
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			policies, err := repository.NewMaskingRepository(env.db).ListPolicies(ctx)
+			if err != nil {
+				return err
+			}
+			return printJSON(policies)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("bilinmeyen alt komut: %q (set, remove veya list)", args[0])
+	}
+}
@@ -0,0 +1,77 @@
+// sentiric-cdr-service/cmd/cdr-service/rating.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// parseRatingPolicy: "rating-policy set|remove|list" alt komutlarını ayrıştırır.
+func parseRatingPolicy(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: set, remove veya list")
+	}
+	fs := flag.NewFlagSet("rating-policy "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "set":
+		tenantID := fs.String("tenant", "", "Tenant ID")
+		mode := fs.String("mode", string(billing.RoundTruncate), "Yuvarlama modu: truncate, round veya ceil")
+		increment := fs.Int("increment", 1, "Minimum bloktan sonraki artım (saniye)")
+		minimum := fs.Int("minimum", 0, "İlk faturalanan blok (saniye); 0 ise yok")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *tenantID == "" {
+			return nil, errors.New("--tenant zorunludur")
+		}
+		p := billing.RoundingPolicy{
+			Mode:             billing.RoundingMode(strings.ToLower(*mode)),
+			IncrementSeconds: *increment,
+			MinimumSeconds:   *minimum,
+		}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			saved, err := repository.NewCallRepository(env.db, env.keys, env.log).SetRoundingPolicy(ctx, *tenantID, p)
+			if err != nil {
+				return err
+			}
+			return printJSON(saved)
+		}, nil
+
+	case "remove":
+		tenantID := fs.String("tenant", "", "Tenant ID")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *tenantID == "" {
+			return nil, errors.New("--tenant zorunludur")
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			return repository.NewCallRepository(env.db, env.keys, env.log).DeleteRoundingPolicy(ctx, *tenantID)
+		}, nil
+
+	case "list":
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			policies, err := repository.NewCallRepository(env.db, env.keys, env.log).ListRoundingPolicies(ctx)
+			if err != nil {
+				return err
+			}
+			return printJSON(policies)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("bilinmeyen alt komut: %q (set, remove veya list)", args[0])
+	}
+}
@@ -0,0 +1,68 @@
+// sentiric-cdr-service/cmd/cdr-service/rerate.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/rerate"
+)
+
+func parseRerate(args []string) (action, error) {
+	fs := flag.NewFlagSet("rerate", flag.ContinueOnError)
+	tenantID := fs.String("tenant", "", "Tenant ID (boşsa tüm tenant'lar)")
+	from := fs.String("from", "", "Başlangıç tarihi, dahil (YYYY-MM-DD veya RFC3339)")
+	to := fs.String("to", "", "Bitiş tarihi, hariç (YYYY-MM-DD veya RFC3339)")
+	rateVersion := fs.String("rate-version", "", "rate_cards.rate_version değeri")
+	dryRun := fs.Bool("dry-run", false, "Yalnızca farkları raporla, yazma")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *from == "" || *to == "" || *rateVersion == "" {
+		fs.Usage()
+		return nil, errors.New("--from, --to ve --rate-version zorunludur")
+	}
+	opts := rerate.Options{TenantID: *tenantID, RateVersion: *rateVersion, DryRun: *dryRun}
+	var err error
+	if opts.From, err = parseDateFlag("from", *from); err != nil {
+		return nil, err
+	}
+	if opts.To, err = parseDateFlag("to", *to); err != nil {
+		return nil, err
+	}
+	if !opts.From.Before(opts.To) {
+		return nil, errors.New("--from, --to'dan önce olmalı")
+	}
+
+	return func(ctx context.Context, env *cliEnv) error {
+		return executeRerate(ctx, env, opts)
+	}, nil
+}
+
+func executeRerate(ctx context.Context, env *cliEnv, opts rerate.Options) error {
+	env.log.Info().Str("tenant_id", opts.TenantID).Str("rate_version", opts.RateVersion).
+		Time("from", opts.From).Time("to", opts.To).Bool("dry_run", opts.DryRun).
+		Msg("Yeniden derecelendirme başlatılıyor...")
+
+	batchID, summaries, runErr := rerate.New(env.db, env.keys, env.log).Run(ctx, opts)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "TENANT\tCALLS\tCHANGED\tLINES\tOLD_COST\tNEW_COST\tDELTA\t\n")
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.6f\t%.6f\t%+.6f\t\n",
+			s.TenantID, s.CallsScanned, s.CallsChanged, s.LinesChanged, s.OldCost, s.NewCost, s.Delta())
+	}
+	_ = w.Flush()
+
+	if opts.DryRun {
+		fmt.Println("Dry-run: hiçbir değişiklik yazılmadı.")
+	} else {
+		fmt.Printf("Batch: %s\n", batchID)
+	}
+	return runErr
+}
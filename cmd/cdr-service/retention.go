@@ -0,0 +1,136 @@
+// sentiric-cdr-service/cmd/cdr-service/retention.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	"github.com/sentiric/sentiric-cdr-service/internal/retention"
+)
+
+// parseRetention: "retention run|partition|policy|hold|release" alt komutlarını ayrıştırır.
+func parseRetention(args []string) (action, error) {
+	if len(args) == 0 {
+		return nil, errors.New("alt komut gerekli: run, partition, policy, hold veya release")
+	}
+	fs := flag.NewFlagSet("retention "+args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "run":
+		dryRun := fs.Bool("dry-run", false, "Hiçbir şeyi değiştirmeden yapılacak işleri raporla")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			run, err := retention.NewManager(env.db, env.log).RunOnce(ctx, *dryRun)
+			if err != nil {
+				return err
+			}
+			return printJSON(run)
+		}, nil
+
+	case "partition":
+		table := fs.String("table", "", "Dönüştürülecek tablo: calls, call_events veya usage_records")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if _, ok := repository.PartitionKeys[*table]; !ok {
+			return nil, fmt.Errorf("--table calls, call_events veya usage_records olmalı: %q", *table)
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			now := time.Now().UTC()
+			until := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			repo := repository.NewRetentionRepository(env.db)
+			if err := repo.ConvertToPartitioned(ctx, *table, until); err != nil {
+				return err
+			}
+			env.log.Info().Str("table", *table).Time("legacy_until", until).Msg("Tablo aylık partition'lı yapıya dönüştürüldü.")
+			for _, p := range retention.MonthlyPartitions(*table, []repository.Partition{{RangeEnd: until}}, now, retention.PartitionsAhead) {
+				if err := repo.CreatePartition(ctx, p); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+
+	case "policy":
+		tenantID := fs.String("tenant", "", "Tenant ID ('*' platform varsayılanı)")
+		days := fs.Int("days", 0, "Saklama süresi (gün)")
+		act := fs.String("action", repository.RetentionArchive, "Süresi dolan veriye uygulanacak aksiyon: ARCHIVE veya DELETE")
+		remove := fs.Bool("remove", false, "Tenant politikasını kaldır")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *tenantID == "" {
+			return nil, errors.New("--tenant zorunludur")
+		}
+		if *remove {
+			return func(ctx context.Context, env *cliEnv) error {
+				return repository.NewRetentionRepository(env.db).DeletePolicy(ctx, *tenantID)
+			}, nil
+		}
+		p := repository.RetentionPolicy{TenantID: *tenantID, RetentionDays: *days, Action: strings.ToUpper(*act)}
+		if p.RetentionDays <= 0 {
+			return nil, errors.New("--days pozitif olmalı")
+		}
+		if p.Action != repository.RetentionArchive && p.Action != repository.RetentionDelete {
+			return nil, fmt.Errorf("--action ARCHIVE veya DELETE olmalı: %q", *act)
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			return repository.NewRetentionRepository(env.db).UpsertPolicy(ctx, p)
+		}, nil
+
+	case "hold":
+		tenantID := fs.String("tenant", "", "Tenant ID (boşsa tüm tenant'lar)")
+		from := fs.String("from", "", "Başlangıç, dahil (boşsa başlangıçsız)")
+		to := fs.String("to", "", "Bitiş, hariç (boşsa süresiz)")
+		reason := fs.String("reason", "", "Gerekçe (örn: dava/talep numarası)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *reason == "" {
+			return nil, errors.New("--reason zorunludur")
+		}
+		h := repository.LegalHold{TenantID: *tenantID, Reason: *reason}
+		for _, f := range []struct {
+			name, value string
+			dst         **time.Time
+		}{{"from", *from, &h.HoldFrom}, {"to", *to, &h.HoldTo}} {
+			if f.value == "" {
+				continue
+			}
+			t, err := parseDateFlag(f.name, f.value)
+			if err != nil {
+				return nil, err
+			}
+			*f.dst = &t
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			created, err := repository.NewRetentionRepository(env.db).CreateHold(ctx, h)
+			if err != nil {
+				return err
+			}
+			return printJSON(created)
+		}, nil
+
+	case "release":
+		id := fs.Int64("hold", 0, "cdr_legal_holds.id")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		if *id == 0 {
+			return nil, errors.New("--hold zorunludur")
+		}
+		return func(ctx context.Context, env *cliEnv) error {
+			return repository.NewRetentionRepository(env.db).ReleaseHold(ctx, *id)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("bilinmeyen alt komut: %q (run, partition, policy, hold veya release)", args[0])
+	}
+}
@@ -0,0 +1,225 @@
+// AÇIKLAMA: Bu paket, tenant ve trunk başına olay akışını ve çağrı sonucu dağılımını taban çizgisiyle karşılaştırır:
+// call.ended gelmemesi, başarısız/meşgul veya cevapsız payının sıçraması ve sıfır süreli çağrı artışı anomali
+// olarak açılır, durum düzelince kapatılır. Her geçiş outbox üzerinden yayınlanır ve metrik olarak sunulur.
+package anomaly
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Anomali olayları.
+const (
+	EventAnomalyDetected = "cdr.anomaly.detected"
+	EventAnomalyResolved = "cdr.anomaly.resolved"
+)
+
+// Anomali türleri.
+const (
+	// KindEndedStalled: Pencerede çağrılar başlıyor ama hiç call.ended işlenmiyor.
+	KindEndedStalled = "ended_stalled"
+	// KindFailureShare: Biten çağrılarda FAILED + BUSY payı taban çizgisine göre sıçradı.
+	KindFailureShare = "failure_share_jump"
+	// KindNoAnswerShare: Biten çağrılarda NO_ANSWER payı taban çizgisine göre sıçradı.
+	KindNoAnswerShare = "no_answer_share_jump"
+	// KindZeroDuration: Sıfır süreli çağrıların payı taban çizgisine göre sıçradı.
+	KindZeroDuration = "zero_duration_spike"
+)
+
+// Kinds: Değerlendirilen türler, sabit sırayla.
+var Kinds = []string{KindEndedStalled, KindFailureShare, KindNoAnswerShare, KindZeroDuration}
+
+const (
+	// evalInterval: Değerlendirme sıklığı. Tek kopya değerlendirir; diğerleri yalnızca metrikleri tazeler.
+	evalInterval = time.Minute
+	// window: Son durumun ölçüldüğü pencere.
+	window = 15 * time.Minute
+	// baseline: Pencereden önceki, olağan durumun ölçüldüğü aralık. Bu süreden uzun süren bir sapma taban çizgisine
+	// girer ve kendiliğinden kapanır.
+	baseline = 24 * time.Hour
+	// minCalls: Pencere ve taban çizgisinde karar verilebilmesi için gereken en az çağrı.
+	minCalls = 20
+	// shareJump: Payın taban çizgisine göre anomali sayılan mutlak artışı (0.30 = 30 puan).
+	shareJump = 0.30
+)
+
+type verdict int
+
+const (
+	// unknown: Karar için yeterli veri yok; açık anomali olduğu gibi kalır.
+	unknown verdict = iota
+	firing
+	clear
+)
+
+// evaluate: Tenant ve trunk sayılarını türün kuralına göre değerlendirir ve kanıtı döner.
+func evaluate(kind string, s repository.FlowStats) (verdict, map[string]interface{}) {
+	evidence := map[string]interface{}{
+		"window_minutes":  int(window / time.Minute),
+		"baseline_hours":  int(baseline / time.Hour),
+		"started":         s.Started,
+		"ended":           s.Ended,
+		"baseline_ended":  s.BaseEnded,
+		"min_calls":       minCalls,
+		"share_threshold": shareJump,
+	}
+	if s.LastEndedAt != nil {
+		evidence["last_ended_at"] = s.LastEndedAt.UTC()
+	}
+
+	if kind == KindEndedStalled {
+		switch {
+		case s.Ended > 0 || (s.Started == 0 && s.Ended == 0):
+			return clear, evidence
+		case s.Started >= minCalls && s.BaseEnded >= minCalls:
+			return firing, evidence
+		}
+		return unknown, evidence
+	}
+
+	var count, base int
+	switch kind {
+	case KindFailureShare:
+		count, base = s.FailedBusy, s.BaseFailedBusy
+	case KindNoAnswerShare:
+		count, base = s.NoAnswer, s.BaseNoAnswer
+	case KindZeroDuration:
+		count, base = s.ZeroDuration, s.BaseZeroDuration
+	}
+	if s.Started == 0 && s.Ended == 0 {
+		return clear, evidence
+	}
+	if s.Ended < minCalls || s.BaseEnded < minCalls {
+		return unknown, evidence
+	}
+	share := float64(count) / float64(s.Ended)
+	baseShare := float64(base) / float64(s.BaseEnded)
+	evidence["count"], evidence["share"], evidence["baseline_share"] = count, share, baseShare
+	if share-baseShare >= shareJump {
+		return firing, evidence
+	}
+	return clear, evidence
+}
+
+type anomalyKey struct {
+	tenantID, trunk, kind string
+}
+
+type Monitor struct {
+	repo *repository.AnomalyRepository
+	log  zerolog.Logger
+}
+
+func NewMonitor(db *sql.DB, log zerolog.Logger) *Monitor {
+	return &Monitor{repo: repository.NewAnomalyRepository(db), log: log}
+}
+
+// Run: Context iptal edilene kadar periyodik olarak değerlendirir ve açık anomali metriklerini tazeler.
+func (m *Monitor) Run(ctx context.Context) {
+	m.log.Info().Msg("🩺 Anomali izleyicisi aktif")
+	ticker := time.NewTicker(evalInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := m.RunOnce(ctx, time.Now().UTC()); err != nil && !errors.Is(err, repository.ErrAnomalyBusy) && ctx.Err() == nil {
+			m.log.Error().Err(err).Msg("Anomali değerlendirmesi başarısız oldu.")
+		}
+		if err := m.refreshMetrics(ctx); err != nil && ctx.Err() == nil {
+			m.log.Error().Err(err).Msg("Açık anomaliler okunamadı.")
+		}
+	}
+}
+
+// RunOnce: Tüm tenant ve trunk'ları değerlendirir; yeni anomalileri açar, düzelenleri kapatır. Trafiği pencere ve
+// taban çizgisinden tamamen çekilmiş trunk'ların açık anomalileri de kapatılır.
+func (m *Monitor) RunOnce(ctx context.Context, now time.Time) error {
+	unlock, err := m.repo.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stats, err := m.repo.FlowStats(ctx, now.Add(-window-baseline), now.Add(-window), now)
+	if err != nil {
+		return err
+	}
+	open, err := m.repo.ListAnomalies(ctx, repository.AnomalyFilter{Status: repository.AnomalyOpen})
+	if err != nil {
+		return err
+	}
+	openByKey := make(map[anomalyKey]repository.Anomaly, len(open))
+	for _, a := range open {
+		openByKey[anomalyKey{a.TenantID, a.Trunk, a.Kind}] = a
+	}
+
+	for _, s := range stats {
+		for _, kind := range Kinds {
+			k := anomalyKey{s.TenantID, s.Trunk, kind}
+			v, evidence := evaluate(kind, s)
+			existing, isOpen := openByKey[k]
+			delete(openByKey, k)
+
+			switch {
+			case v == firing:
+				a := repository.Anomaly{TenantID: s.TenantID, Trunk: s.Trunk, Kind: kind, Evidence: evidence}
+				created, err := m.repo.OpenAnomaly(ctx, &a, EventAnomalyDetected)
+				if err != nil {
+					return err
+				}
+				if created {
+					metrics.AnomalyAlerts.WithLabelValues(kind).Inc()
+					m.log.Warn().Int64("anomaly_id", a.ID).Str("tenant_id", a.TenantID).Str("trunk", a.Trunk).
+						Str("kind", kind).Interface("evidence", evidence).Msg("⚠️ Anomali tespit edildi")
+				}
+			case v == clear && isOpen:
+				if err := m.resolve(ctx, existing, evidence); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, a := range openByKey {
+		if err := m.resolve(ctx, a, map[string]interface{}{"reason": "no_traffic"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Monitor) resolve(ctx context.Context, a repository.Anomaly, evidence map[string]interface{}) error {
+	resolved, err := m.repo.ResolveAnomaly(ctx, a.ID, evidence, EventAnomalyResolved)
+	if err != nil {
+		return err
+	}
+	if resolved {
+		m.log.Info().Int64("anomaly_id", a.ID).Str("tenant_id", a.TenantID).Str("trunk", a.Trunk).
+			Str("kind", a.Kind).Msg("✅ Anomali kapandı")
+	}
+	return nil
+}
+
+// refreshMetrics: Açık anomali göstergesini veritabanındaki duruma eşitler; değerlendirmeyi yapmayan kopyalar da
+// aynı değeri sunar.
+func (m *Monitor) refreshMetrics(ctx context.Context) error {
+	open, err := m.repo.ListAnomalies(ctx, repository.AnomalyFilter{Status: repository.AnomalyOpen})
+	if err != nil {
+		return err
+	}
+	metrics.AnomaliesOpen.Reset()
+	for _, a := range open {
+		metrics.AnomaliesOpen.WithLabelValues(a.TenantID, a.Trunk, a.Kind).Set(1)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// sentiric-cdr-service/internal/anomaly/anomaly_test.go
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		name string
+		kind string
+		s    repository.FlowStats
+		want verdict
+	}{
+		{"trafik yok", KindEndedStalled, repository.FlowStats{BaseEnded: 100}, clear},
+		{"biten çağrı var", KindEndedStalled, repository.FlowStats{Started: 50, Ended: 1, BaseEnded: 100}, clear},
+		{"call.ended durdu", KindEndedStalled, repository.FlowStats{Started: 20, BaseEnded: 20}, firing},
+		{"durma için az çağrı", KindEndedStalled, repository.FlowStats{Started: 19, BaseEnded: 100}, unknown},
+		{"durma için taban çizgisi yok", KindEndedStalled, repository.FlowStats{Started: 50, BaseEnded: 5}, unknown},
+
+		{"başarısız payı sıçradı", KindFailureShare,
+			repository.FlowStats{Started: 40, Ended: 40, FailedBusy: 16, BaseEnded: 100, BaseFailedBusy: 10}, firing},
+		{"başarısız payı eşiğin altında", KindFailureShare,
+			repository.FlowStats{Started: 40, Ended: 40, FailedBusy: 15, BaseEnded: 100, BaseFailedBusy: 10}, clear},
+		{"cevapsız payı sıçradı", KindNoAnswerShare,
+			repository.FlowStats{Started: 20, Ended: 20, NoAnswer: 10, BaseEnded: 100, BaseNoAnswer: 5}, firing},
+		{"sıfır süre payı sıçradı", KindZeroDuration,
+			repository.FlowStats{Started: 20, Ended: 20, ZeroDuration: 8, BaseEnded: 50, BaseZeroDuration: 0}, firing},
+		{"pay düştü", KindFailureShare,
+			repository.FlowStats{Started: 20, Ended: 20, BaseEnded: 100, BaseFailedBusy: 90}, clear},
+		{"pencerede az çağrı", KindFailureShare,
+			repository.FlowStats{Started: 19, Ended: 19, FailedBusy: 19, BaseEnded: 100}, unknown},
+		{"taban çizgisinde az çağrı", KindNoAnswerShare,
+			repository.FlowStats{Started: 40, Ended: 40, NoAnswer: 40, BaseEnded: 19}, unknown},
+		{"pay türünde trafik yok", KindZeroDuration, repository.FlowStats{BaseEnded: 100}, clear},
+	}
+	for _, c := range cases {
+		if got, _ := evaluate(c.kind, c.s); got != c.want {
+			t.Errorf("%s: evaluate = %d, beklenen %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEvaluateEvidence(t *testing.T) {
+	last := time.Date(2025, 3, 10, 12, 0, 0, 0, time.FixedZone("TRT", 3*60*60))
+	s := repository.FlowStats{Started: 40, Ended: 40, FailedBusy: 20, BaseEnded: 100, BaseFailedBusy: 10, LastEndedAt: &last}
+
+	_, evidence := evaluate(KindFailureShare, s)
+	if got := evidence["count"]; got != 20 {
+		t.Errorf("count = %v, beklenen 20", got)
+	}
+	if got := evidence["share"]; got != 0.5 {
+		t.Errorf("share = %v, beklenen 0.5", got)
+	}
+	if got := evidence["baseline_share"]; got != 0.1 {
+		t.Errorf("baseline_share = %v, beklenen 0.1", got)
+	}
+	if got, _ := evidence["last_ended_at"].(time.Time); got.Location() != time.UTC || !got.Equal(last) {
+		t.Errorf("last_ended_at = %v, UTC olarak %v bekleniyor", got, last.UTC())
+	}
+
+	_, evidence = evaluate(KindEndedStalled, repository.FlowStats{Started: 40, BaseEnded: 40})
+	if _, ok := evidence["share"]; ok {
+		t.Error("ended_stalled kanıtı pay içermemeli")
+	}
+	if _, ok := evidence["last_ended_at"]; ok {
+		t.Error("son bitiş yoksa last_ended_at kanıtta olmamalı")
+	}
+}
@@ -0,0 +1,36 @@
+// sentiric-cdr-service/internal/api/anomaly.go
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// handleListAnomalies: Olay akışı ve sonuç dağılımı anomalilerini kanıtlarıyla, en yeni önce döner. tenant_id ve
+// status (OPEN, RESOLVED) ile süzülebilir.
+func (s *Server) handleListAnomalies(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	status := strings.ToUpper(q.Get("status"))
+	if status != "" && status != repository.AnomalyOpen && status != repository.AnomalyResolved {
+		writeError(w, http.StatusBadRequest, "status OPEN veya RESOLVED olmalı")
+		return
+	}
+	list, err := s.anomalies.ListAnomalies(r.Context(), repository.AnomalyFilter{
+		TenantID: q.Get("tenant_id"),
+		Status:   status,
+		Limit:    limit,
+	})
+	if err != nil {
+		s.log.Error().Err(err).Msg("Anomaliler listelenemedi")
+		writeError(w, http.StatusInternalServerError, "anomaliler listelenemedi")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"anomalies": list})
+}
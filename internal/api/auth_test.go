@@ -0,0 +1,51 @@
+// sentiric-cdr-service/internal/api/auth_test.go
+package api
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGatewayIdentity(t *testing.T) {
+	secret := []byte("gateway-secret")
+	now := time.Unix(1_700_000_000, 0)
+	const perms = "cdr.billing.write,cdr.numbers.unmasked"
+
+	cases := []struct {
+		name      string
+		gw        Gateway
+		ts        int64
+		signWith  []byte
+		signPath  string
+		signQuery string
+		wantPerms string
+	}{
+		{"anahtar yok, güven yok", Gateway{}, 0, nil, "", "", ""},
+		{"anahtar yok, güven var", Gateway{TrustHeaders: true}, 0, nil, "", "", perms},
+		{"geçerli imza", Gateway{Secret: secret}, now.Unix(), secret, "/v1/tenants/t1/balance/credits", "tenant_id=t1&limit=10", perms},
+		{"parametre sırası imzayı bozmaz", Gateway{Secret: secret}, now.Unix(), secret, "/v1/tenants/t1/balance/credits", "limit=10&tenant_id=t1", perms},
+		{"imza yok", Gateway{Secret: secret}, now.Unix(), nil, "", "", ""},
+		{"yanlış anahtar", Gateway{Secret: secret}, now.Unix(), []byte("other"), "/v1/tenants/t1/balance/credits", "tenant_id=t1&limit=10", ""},
+		{"başka yol için imza", Gateway{Secret: secret}, now.Unix(), secret, "/v1/tenants/t2/balance/credits", "tenant_id=t1&limit=10", ""},
+		{"başka tenant sorgusu için imza", Gateway{Secret: secret}, now.Unix(), secret, "/v1/tenants/t1/balance/credits", "tenant_id=t2&limit=10", ""},
+		{"sorgusuz imza", Gateway{Secret: secret}, now.Unix(), secret, "/v1/tenants/t1/balance/credits", "", ""},
+		{"eski zaman damgası", Gateway{Secret: secret}, now.Add(-6 * time.Minute).Unix(), secret, "/v1/tenants/t1/balance/credits", "tenant_id=t1&limit=10", ""},
+		{"güven bayrağı imzayı atlatmaz", Gateway{Secret: secret, TrustHeaders: true}, now.Unix(), nil, "", "", ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("POST", "/v1/tenants/t1/balance/credits?tenant_id=t1&limit=10", nil)
+		r.Header.Set(headerRole, "billing")
+		r.Header.Set(headerPermissions, perms)
+		if c.ts != 0 {
+			r.Header.Set(headerGatewayTimestamp, strconv.FormatInt(c.ts, 10))
+		}
+		if c.signWith != nil {
+			r.Header.Set(headerGatewaySignature, SignGateway(c.signWith, c.ts, "billing", perms, "POST", c.signPath, c.signQuery))
+		}
+		if _, got := c.gw.identity(r, now); got != c.wantPerms {
+			t.Errorf("%s: yetkiler = %q, beklenen %q", c.name, got, c.wantPerms)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// sentiric-cdr-service/internal/api/balance.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+type creditRequest struct {
+	Amount    float64 `json:"amount"`
+	EntryType string  `json:"entry_type"` // CREDIT (varsayılan) veya ADJUSTMENT
+	Reference string  `json:"reference"`
+}
+
+// handleGetBalance: Ön ödemeli tenant'ın güncel bakiyesini ve durumunu döner.
+func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	balance, err := s.balances.Get(r.Context(), r.PathValue("tenant_id"))
+	if errors.Is(err, repository.ErrNoPrepaidBalance) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Msg("Bakiye okunamadı")
+		writeError(w, http.StatusInternalServerError, "bakiye okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, balance)
+}
+
+// handleListLedger: Bakiye defterini en yeni hareketten başlayarak döner.
+func (s *Server) handleListLedger(w http.ResponseWriter, r *http.Request) {
+	f, err := parseTenantFilter(r, r.PathValue("tenant_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lines, err := s.balances.ListLedger(r.Context(), f.TenantID, f.From, f.To, f.Limit)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Bakiye defteri okunamadı")
+		writeError(w, http.StatusInternalServerError, "bakiye defteri okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"entries": lines})
+}
+
+// handleCreditBalance: Bakiyeye yükleme veya düzeltme kaydı ekler.
+func (s *Server) handleCreditBalance(w http.ResponseWriter, r *http.Request) {
+	var req creditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz JSON gövdesi")
+		return
+	}
+	if req.EntryType == "" {
+		req.EntryType = repository.LedgerCredit
+	}
+	if req.EntryType != repository.LedgerCredit && req.EntryType != repository.LedgerAdjustment {
+		writeError(w, http.StatusBadRequest, "entry_type 'CREDIT' veya 'ADJUSTMENT' olmalı")
+		return
+	}
+	if req.Amount == 0 || (req.EntryType == repository.LedgerCredit && req.Amount < 0) {
+		writeError(w, http.StatusBadRequest, "geçersiz tutar")
+		return
+	}
+	if req.Reference == "" {
+		writeError(w, http.StatusBadRequest, "reference zorunludur")
+		return
+	}
+
+	tenantID := r.PathValue("tenant_id")
+	balance, err := s.balances.Credit(r.Context(), tenantID, req.EntryType, req.Amount, req.Reference)
+	if errors.Is(err, repository.ErrNoPrepaidBalance) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", tenantID).Msg("Bakiye yüklemesi yazılamadı")
+		writeError(w, http.StatusInternalServerError, "bakiye güncellenemedi")
+		return
+	}
+
+	s.log.Info().Str("tenant_id", tenantID).Float64("amount", req.Amount).Str("reference", req.Reference).Msg("💳 Bakiye hareketi kaydedildi.")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tenant_id":  tenantID,
+		"balance":    balance,
+		"updated_at": time.Now().UTC(),
+	})
+}
@@ -0,0 +1,128 @@
+// sentiric-cdr-service/internal/api/calls.go
+package api
+
+import (
+	"net/http"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// handleListCalls: view=legs (varsayılan) bacak bazlı CDR'ları, view=journey konsolide müşteri yolculuklarını döner.
+func (s *Server) handleListCalls(w http.ResponseWriter, r *http.Request) {
+	f, err := parseCallFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.URL.Query().Get("view") {
+	case "", "legs":
+		calls, err := s.repo.ListCalls(r.Context(), f)
+		if err != nil {
+			s.log.Error().Err(err).Msg("Çağrı listesi okunamadı")
+			writeError(w, http.StatusInternalServerError, "çağrılar okunamadı")
+			return
+		}
+		maskCalls(s.numberMasker(r, f.TenantID), calls)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"calls": calls})
+	case "journey":
+		journeys, err := s.repo.ListJourneys(r.Context(), f)
+		if err != nil {
+			s.log.Error().Err(err).Msg("Yolculuk listesi okunamadı")
+			writeError(w, http.StatusInternalServerError, "yolculuklar okunamadı")
+			return
+		}
+		maskJourneys(s.numberMasker(r, f.TenantID), journeys)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"journeys": journeys})
+	default:
+		writeError(w, http.StatusBadRequest, "view parametresi 'legs' veya 'journey' olmalı")
+	}
+}
+
+// handleGetInteraction: Tek bir etkileşimin bacaklarını ve konsolide özetini döner.
+func (s *Server) handleGetInteraction(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.URL.Query().Get("tenant_id")
+	if tenantID == "" {
+		writeError(w, http.StatusBadRequest, "tenant_id parametresi zorunludur")
+		return
+	}
+	f := repository.CallFilter{
+		TenantID:      tenantID,
+		InteractionID: r.PathValue("interaction_id"),
+	}
+
+	legs, err := s.repo.ListCalls(r.Context(), f)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Etkileşim bacakları okunamadı")
+		writeError(w, http.StatusInternalServerError, "etkileşim okunamadı")
+		return
+	}
+	if len(legs) == 0 {
+		writeError(w, http.StatusNotFound, "etkileşim bulunamadı")
+		return
+	}
+
+	journeys, err := s.repo.ListJourneys(r.Context(), f)
+	if err != nil || len(journeys) == 0 {
+		s.log.Error().Err(err).Msg("Etkileşim özeti okunamadı")
+		writeError(w, http.StatusInternalServerError, "etkileşim okunamadı")
+		return
+	}
+
+	m := s.numberMasker(r, tenantID)
+	maskCalls(m, legs)
+	maskJourneys(m, journeys)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"journey": journeys[0],
+		"legs":    legs,
+	})
+}
+
+// handleGetCallCost: Çağrının telefon ve AI kaynaklarına göre maliyet kırılımını döner.
+func (s *Server) handleGetCallCost(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.URL.Query().Get("tenant_id")
+	if tenantID == "" {
+		writeError(w, http.StatusBadRequest, "tenant_id parametresi zorunludur")
+		return
+	}
+	callID := r.PathValue("call_id")
+
+	lines, err := s.repo.ListCallUsage(r.Context(), tenantID, callID)
+	if err != nil {
+		s.log.Error().Err(err).Str("call_id", callID).Msg("Maliyet kırılımı okunamadı")
+		writeError(w, http.StatusInternalServerError, "maliyet kırılımı okunamadı")
+		return
+	}
+
+	total := 0.0
+	for _, line := range lines {
+		total += line.Cost
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"call_id":    callID,
+		"total_cost": total,
+		"lines":      lines,
+	})
+}
+
+// handleListCallRecordings: Çağrının segment ve kanal bazlı ses kayıtlarını döner. Silinmiş kayıtlar silinme
+// nedeniyle birlikte listelenir.
+func (s *Server) handleListCallRecordings(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.URL.Query().Get("tenant_id")
+	if tenantID == "" {
+		writeError(w, http.StatusBadRequest, "tenant_id parametresi zorunludur")
+		return
+	}
+	callID := r.PathValue("call_id")
+
+	recordings, err := s.recordings.ListRecordings(r.Context(), tenantID, callID)
+	if err != nil {
+		s.log.Error().Err(err).Str("call_id", callID).Msg("Ses kayıtları okunamadı")
+		writeError(w, http.StatusInternalServerError, "ses kayıtları okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"call_id":    callID,
+		"recordings": recordings,
+	})
+}
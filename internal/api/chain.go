@@ -0,0 +1,21 @@
+// sentiric-cdr-service/internal/api/chain.go
+package api
+
+import "net/http"
+
+// handleListChainCheckpoints: Tenant hash zincirinin en yeni imzalı kontrol noktalarını döner (eskiden yeniye).
+// Çıktı "verify --checkpoints" ile zincire karşı doğrulanabilecek biçimdedir.
+func (s *Server) handleListChainCheckpoints(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	checkpoints, err := s.chain.ListCheckpoints(r.Context(), r.PathValue("tenant_id"), limit)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Zincir kontrol noktaları listelenemedi")
+		writeError(w, http.StatusInternalServerError, "kontrol noktaları listelenemedi")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"checkpoints": checkpoints})
+}
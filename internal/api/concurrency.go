@@ -0,0 +1,47 @@
+// sentiric-cdr-service/internal/api/concurrency.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// handleListConcurrency: Çağrısı görülmüş tüm tenant'ların anlık ve bugünkü en yüksek eşzamanlı çağrı sayısı.
+func (s *Server) handleListConcurrency(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tenants": s.live.Snapshot()})
+}
+
+// handleGetConcurrency: Tenant'ın anlık ve bugünkü (UTC) en yüksek eşzamanlı çağrı sayısı.
+func (s *Server) handleGetConcurrency(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.live.Tenant(r.PathValue("tenant_id")))
+}
+
+// handleListDailyConcurrency: Tenant'ın günlük eşzamanlılık tepeleri; varsayılan aralık son 30 gündür.
+// from dahil, to hariç YYYY-MM-DD (UTC) günleridir.
+func (s *Server) handleListDailyConcurrency(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -30)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("%s parametresi YYYY-MM-DD olmalı", p.name))
+				return
+			}
+			*p.dst = t
+		}
+	}
+
+	peaks, err := s.peaks.ListDailyPeaks(r.Context(), r.PathValue("tenant_id"), from, to)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Günlük eşzamanlılık tepeleri okunamadı")
+		writeError(w, http.StatusInternalServerError, "günlük tepeler okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"days": peaks})
+}
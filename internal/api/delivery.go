@@ -0,0 +1,101 @@
+// sentiric-cdr-service/internal/api/delivery.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/delivery"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// handleListDeliveryJobs: Tenant'ın zamanlanmış teslim işlerini gizli alanları maskelenmiş olarak döner.
+func (s *Server) handleListDeliveryJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.deliveries.ListJobs(r.Context(), r.PathValue("tenant_id"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("Teslim işleri okunamadı")
+		writeError(w, http.StatusInternalServerError, "teslim işleri okunamadı")
+		return
+	}
+	for i := range jobs {
+		jobs[i].Destination = delivery.RedactDestination(jobs[i].Destination)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+}
+
+// handleCreateDeliveryJob: Yeni teslim işi tanımlar. İlk çalışma anı zamanlamadan hesaplanır.
+func (s *Server) handleCreateDeliveryJob(w http.ResponseWriter, r *http.Request) {
+	job := repository.DeliveryJob{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz JSON gövdesi")
+		return
+	}
+	job.TenantID = r.PathValue("tenant_id")
+	delivery.ApplyDefaults(&job)
+	if err := delivery.Validate(job); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	next, err := delivery.FirstRun(job, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	job.NextRunAt = &next
+
+	created, err := s.deliveries.CreateJob(r.Context(), job)
+	if errors.Is(err, repository.ErrDeliveryJobExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", job.TenantID).Msg("Teslim işi kaydedilemedi")
+		writeError(w, http.StatusInternalServerError, "teslim işi kaydedilemedi")
+		return
+	}
+
+	s.log.Info().Str("tenant_id", created.TenantID).Int64("job_id", created.ID).Str("schedule", created.Schedule).
+		Str("destination_type", created.DestinationType).Msg("📦 CDR teslim işi tanımlandı.")
+	created.Destination = delivery.RedactDestination(created.Destination)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// handleListDeliveries: Teslim kayıtlarını durum, sağlama toplamı ve son hatayla döner.
+func (s *Server) handleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, err := s.deliveries.ListDeliveries(r.Context(), r.PathValue("tenant_id"), limit)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Teslim kayıtları okunamadı")
+		writeError(w, http.StatusInternalServerError, "teslim kayıtları okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// handleRetryDelivery: Kalıcı olarak başarısız olmuş teslimi yeniden kuyruğa alır.
+func (s *Server) handleRetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("delivery_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz delivery_id")
+		return
+	}
+	err = s.deliveries.RetryDelivery(r.Context(), r.PathValue("tenant_id"), id)
+	if errors.Is(err, repository.ErrDeliveryNotFound) {
+		writeError(w, http.StatusNotFound, "başarısız durumda teslim bulunamadı")
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int64("delivery_id", id).Msg("Teslim yeniden kuyruğa alınamadı")
+		writeError(w, http.StatusInternalServerError, "teslim yeniden kuyruğa alınamadı")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"delivery_id": id, "status": repository.DeliveryPending})
+}
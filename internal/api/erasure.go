@@ -0,0 +1,54 @@
+// sentiric-cdr-service/internal/api/erasure.go
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// handleListErasureRequests: KVKK / GDPR silme taleplerini listeler; regulation ve reference ile başvuru
+// numarasından talep ve sertifikasına ulaşılır. Talepler "erasure run" komutuyla yürütülür.
+func (s *Server) handleListErasureRequests(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	list, err := s.erasures.ListRequests(r.Context(), repository.ErasureFilter{
+		TenantID:   q.Get("tenant_id"),
+		Regulation: strings.ToUpper(q.Get("regulation")),
+		Reference:  q.Get("reference"),
+		Limit:      limit,
+	})
+	if err != nil {
+		s.log.Error().Err(err).Msg("Silme talepleri listelenemedi")
+		writeError(w, http.StatusInternalServerError, "silme talepleri listelenemedi")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"erasure_requests": list})
+}
+
+// handleGetErasureRequest: Silme talebini sayıları ve sertifikasıyla döner.
+func (s *Server) handleGetErasureRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("erasure_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz erasure_id")
+		return
+	}
+	rec, err := s.erasures.GetRequest(r.Context(), id)
+	if errors.Is(err, repository.ErrErasureNotFound) {
+		writeError(w, http.StatusNotFound, "silme talebi bulunamadı")
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int64("erasure_id", id).Msg("Silme talebi okunamadı")
+		writeError(w, http.StatusInternalServerError, "silme talebi okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
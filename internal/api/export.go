@@ -0,0 +1,69 @@
+// sentiric-cdr-service/internal/api/export.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/export"
+)
+
+// handleExportCalls: Filtreye uyan CDR'ları akış halinde dosya olarak döner; view=journey her etkileşimi tek
+// satırda konsolide eder. Sayfalama uygulanmaz;
+// satırlar veritabanından okundukça yanıta yazılır. Akış başladıktan sonra oluşan hata yalnızca loglanır.
+func (s *Server) handleExportCalls(w http.ResponseWriter, r *http.Request) {
+	f, err := parseCallFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	opts := export.Options{
+		Format:   q.Get("format"),
+		View:     q.Get("view"),
+		Columns:  export.ParseColumns(q.Get("columns")),
+		Location: time.UTC,
+	}
+	if opts.Format == "" {
+		opts.Format = export.FormatCSV
+	}
+	if v := q.Get("tz"); v != "" {
+		if opts.Location, err = time.LoadLocation(v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("geçersiz saat dilimi: %q", v))
+			return
+		}
+	}
+	if opts.MaskNumbers, err = parseBoolParam(q.Get("mask_numbers")); err != nil {
+		writeError(w, http.StatusBadRequest, "mask_numbers true veya false olmalı")
+		return
+	}
+	if opts.IncludeEvents, err = parseBoolParam(q.Get("include_events")); err != nil {
+		writeError(w, http.StatusBadRequest, "include_events true veya false olmalı")
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.Numbers = s.numberMasker(r, f.TenantID)
+
+	w.Header().Set("Content-Type", export.ContentType(opts.Format))
+	setAttachment(w, fmt.Sprintf("cdr-%s-%s.%s", f.TenantID, f.From.Format("20060102"), opts.Format))
+
+	count, err := export.New(s.repo).Export(r.Context(), f, opts, w)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", f.TenantID).Int("rows", count).Msg("CDR export yarıda kesildi")
+		return
+	}
+	s.log.Debug().Str("tenant_id", f.TenantID).Str("format", opts.Format).Int("rows", count).Msg("CDR export tamamlandı")
+}
+
+func parseBoolParam(v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
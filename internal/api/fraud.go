@@ -0,0 +1,45 @@
+// sentiric-cdr-service/internal/api/fraud.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// handleListFraudAlerts: Tenant'ın dolandırıcılık alarmlarını kanıtlarıyla, en yeni önce döner. rule ile süzülebilir;
+// zaman aralığı (alarmın üretildiği an) varsayılan olarak son 7 gündür.
+func (s *Server) handleListFraudAlerts(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	f := repository.FraudAlertFilter{TenantID: r.PathValue("tenant_id"), Rule: q.Get("rule"), Limit: limit}
+	f.To = time.Now().UTC()
+	f.From = f.To.AddDate(0, 0, -7)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("%s parametresi RFC3339 olmalı: %v", p.name, err))
+				return
+			}
+			*p.dst = t
+		}
+	}
+
+	alerts, err := s.fraud.ListAlerts(r.Context(), f)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", f.TenantID).Msg("Dolandırıcılık alarmları okunamadı")
+		writeError(w, http.StatusInternalServerError, "dolandırıcılık alarmları okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+}
@@ -0,0 +1,57 @@
+// sentiric-cdr-service/internal/api/invoices.go
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/invoice"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// handleListInvoices: Tenant'ın kapatılmış faturalama dönemlerini listeler.
+func (s *Server) handleListInvoices(w http.ResponseWriter, r *http.Request) {
+	invoices, err := s.invoices.ListInvoices(r.Context(), r.PathValue("tenant_id"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("Faturalar listelenemedi")
+		writeError(w, http.StatusInternalServerError, "faturalar listelenemedi")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"invoices": invoices})
+}
+
+// handleGetInvoice: Dönemin fatura kalemlerini döner. Kapatılmamış dönem DRAFT önizleme olarak hesaplanır.
+// ?format=csv ile muhasebe aktarımı için CSV üretilir.
+func (s *Server) handleGetInvoice(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.PathValue("tenant_id")
+	start, end, err := invoice.ParsePeriod(r.PathValue("period"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "format json veya csv olmalı")
+		return
+	}
+
+	inv, err := s.invoices.GetInvoice(r.Context(), tenantID, start)
+	if errors.Is(err, repository.ErrPeriodNotFound) {
+		inv, err = s.invoices.PreviewInvoice(r.Context(), tenantID, start, end)
+	}
+	if err != nil {
+		s.log.Error().Err(err).Msg("Fatura okunamadı")
+		writeError(w, http.StatusInternalServerError, "fatura okunamadı")
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		setAttachment(w, "invoice-"+tenantID+"-"+invoice.FormatPeriod(start)+".csv")
+		if err := invoice.WriteCSV(w, inv); err != nil {
+			s.log.Error().Err(err).Msg("Fatura CSV yazılamadı")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
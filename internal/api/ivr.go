@@ -0,0 +1,69 @@
+// sentiric-cdr-service/internal/api/ivr.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// handleGetIVRPath: Çağrının IVR yolunu (düğümler, tuşlamalar, çıkış) zaman sırasıyla döner. PIN türü girişlerin
+// tuşları maskelenmiş döner.
+func (s *Server) handleGetIVRPath(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.URL.Query().Get("tenant_id")
+	if tenantID == "" {
+		writeError(w, http.StatusBadRequest, "tenant_id parametresi zorunludur")
+		return
+	}
+	callID := r.PathValue("call_id")
+
+	steps, err := s.repo.ListIVRPath(r.Context(), tenantID, callID)
+	if err != nil {
+		s.log.Error().Err(err).Str("call_id", callID).Msg("IVR yolu okunamadı")
+		writeError(w, http.StatusInternalServerError, "IVR yolu okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"call_id": callID,
+		"steps":   steps,
+	})
+}
+
+// handleIVRDropOff: Zaman aralığında başlayan çağrılar için IVR düğümlerinin giriş sayılarını, çıkış nedenlerini
+// ve terk oranlarını döner. Aralık varsayılan olarak son 24 saattir.
+func (s *Server) handleIVRDropOff(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	tenantID := r.PathValue("tenant_id")
+
+	to := time.Now().UTC()
+	from := to.Add(-24 * time.Hour)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("%s parametresi RFC3339 olmalı: %v", p.name, err))
+				return
+			}
+			*p.dst = t
+		}
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, "from, to'dan önce olmalı")
+		return
+	}
+
+	nodes, err := s.repo.IVRDropOff(r.Context(), tenantID, from, to)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", tenantID).Msg("IVR terk analizi okunamadı")
+		writeError(w, http.StatusInternalServerError, "IVR terk analizi okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"nodes": nodes,
+	})
+}
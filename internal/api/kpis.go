@@ -0,0 +1,149 @@
+// sentiric-cdr-service/internal/api/kpis.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// maxKPIBuckets: Tek sorguda dönülebilecek en fazla kova (gruplama boyutu hariç).
+const maxKPIBuckets = 10000
+
+// handleListKPIs: Tenant'ın ASR, ACD, NER, terk oranı, ortalama çalma süresi ve faturalanabilir dakikalarını
+// rollup tablolarından döner. granularity 5m, 1h (varsayılan) veya 1d; group_by direction veya user olabilir.
+// Zaman aralığı varsayılan olarak son 24 saattir; kovalar UTC'dir.
+func (s *Server) handleListKPIs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := repository.KPIFilter{
+		TenantID:    r.PathValue("tenant_id"),
+		Granularity: q.Get("granularity"),
+		Direction:   q.Get("direction"),
+		UserID:      q.Get("user_id"),
+		GroupBy:     q.Get("group_by"),
+	}
+	if f.Granularity == "" {
+		f.Granularity = repository.Granularity1h
+	}
+	var bucket time.Duration
+	for _, g := range repository.Granularities {
+		if g.Name == f.Granularity {
+			bucket = g.Bucket
+		}
+	}
+	if bucket == 0 {
+		writeError(w, http.StatusBadRequest, "granularity 5m, 1h veya 1d olmalı")
+		return
+	}
+	switch f.GroupBy {
+	case "", "direction", "user":
+	default:
+		writeError(w, http.StatusBadRequest, "group_by direction veya user olmalı")
+		return
+	}
+
+	f.To = time.Now().UTC()
+	f.From = f.To.Add(-24 * time.Hour)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("%s parametresi RFC3339 olmalı: %v", p.name, err))
+				return
+			}
+			*p.dst = t
+		}
+	}
+	if !f.From.Before(f.To) {
+		writeError(w, http.StatusBadRequest, "from, to'dan önce olmalı")
+		return
+	}
+	if f.To.Sub(f.From)/bucket > maxKPIBuckets {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("aralık en fazla %d kova içerebilir; daha büyük granularity seçin", maxKPIBuckets))
+		return
+	}
+
+	buckets, err := s.kpis.ListKPIs(r.Context(), f)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", f.TenantID).Msg("KPI rollup'ları okunamadı")
+		writeError(w, http.StatusInternalServerError, "KPI'lar okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"granularity": f.Granularity,
+		"buckets":     buckets,
+	})
+}
+
+// handleListAgentKPIs: Temsilcilerin karşıladığı çağrı sayısını, ortalama kuyruk beklemesini, konuşma ve çağrı
+// sonrası iş sürelerini ve AHT'yi rollup tablolarından döner. granularity 5m, 1h (varsayılan) veya 1d; group_by
+// queue ise temsilci ve kuyruk bazında gruplanır. Zaman aralığı varsayılan olarak son 24 saattir.
+func (s *Server) handleListAgentKPIs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := repository.AgentKPIFilter{
+		TenantID:    r.PathValue("tenant_id"),
+		Granularity: q.Get("granularity"),
+		AgentUserID: q.Get("agent_id"),
+		Queue:       q.Get("queue"),
+		GroupBy:     q.Get("group_by"),
+	}
+	if f.Granularity == "" {
+		f.Granularity = repository.Granularity1h
+	}
+	var bucket time.Duration
+	for _, g := range repository.Granularities {
+		if g.Name == f.Granularity {
+			bucket = g.Bucket
+		}
+	}
+	if bucket == 0 {
+		writeError(w, http.StatusBadRequest, "granularity 5m, 1h veya 1d olmalı")
+		return
+	}
+	switch f.GroupBy {
+	case "", "queue":
+	default:
+		writeError(w, http.StatusBadRequest, "group_by queue olmalı")
+		return
+	}
+
+	f.To = time.Now().UTC()
+	f.From = f.To.Add(-24 * time.Hour)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("%s parametresi RFC3339 olmalı: %v", p.name, err))
+				return
+			}
+			*p.dst = t
+		}
+	}
+	if !f.From.Before(f.To) {
+		writeError(w, http.StatusBadRequest, "from, to'dan önce olmalı")
+		return
+	}
+	if f.To.Sub(f.From)/bucket > maxKPIBuckets {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("aralık en fazla %d kova içerebilir; daha büyük granularity seçin", maxKPIBuckets))
+		return
+	}
+
+	buckets, err := s.kpis.ListAgentKPIs(r.Context(), f)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", f.TenantID).Msg("Temsilci rollup'ları okunamadı")
+		writeError(w, http.StatusInternalServerError, "temsilci KPI'ları okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"granularity": f.Granularity,
+		"buckets":     buckets,
+	})
+}
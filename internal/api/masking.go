@@ -0,0 +1,50 @@
+// sentiric-cdr-service/internal/api/masking.go
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Tüketicinin rolü ve yetkileri API geçidi tarafından doğrulanıp bu başlıklarla iletilir; başlıklara ancak geçit
+// doğrulanabildiğinde güvenilir (bkz. Gateway).
+const (
+	headerRole        = "X-Sentiric-Role"
+	headerPermissions = "X-Sentiric-Permissions"
+)
+
+// numberMasker: İsteği yapan tüketici için tenant'ın maskeleme politikasını çözer. Rol başlığı yoksa veya geçit
+// doğrulanamazsa "api" rolü kullanılır; açık numara yalnızca cdr.numbers.unmasked yetkisiyle verilir.
+// Politika okunamazsa istek reddedilmez, varsayılan maskeleme uygulanır.
+func (s *Server) numberMasker(r *http.Request, tenantID string) masking.Masker {
+	role, perms := s.gateway.identity(r, time.Now())
+	c := masking.Consumer{
+		Role:     role,
+		Unmasked: masking.ParsePermissions(perms),
+	}
+	if c.Role == "" {
+		c.Role = masking.RoleAPI
+	}
+	m, err := s.masks.For(r.Context(), tenantID, c)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", tenantID).Msg("Maskeleme politikası okunamadı, varsayılan maskeleme kullanılıyor")
+	}
+	return m
+}
+
+func maskCalls(m masking.Masker, calls []repository.CallRecord) {
+	for i := range calls {
+		calls[i].CallerNumber = m.Mask(calls[i].CallerNumber)
+		calls[i].CalleeNumber = m.Mask(calls[i].CalleeNumber)
+	}
+}
+
+func maskJourneys(m masking.Masker, journeys []repository.JourneyRecord) {
+	for i := range journeys {
+		journeys[i].CallerNumber = m.Mask(journeys[i].CallerNumber)
+		journeys[i].CalleeNumber = m.Mask(journeys[i].CalleeNumber)
+	}
+}
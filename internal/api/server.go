@@ -0,0 +1,241 @@
+// AÇIKLAMA: Bu paket, CDR verileri için HTTP sorgu API'sini ve bakiye, teslim işi ve webhook yönetimi için
+// yetki gerektiren yazma uç noktalarını sunar.
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/concurrency"
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	"github.com/sentiric/sentiric-cdr-service/internal/stream"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type Server struct {
+	repo       *repository.CallRepository
+	balances   *repository.BalanceRepository
+	invoices   *repository.InvoiceRepository
+	deliveries *repository.DeliveryRepository
+	webhooks   *repository.WebhookRepository
+	chain      *repository.ChainRepository
+	erasures   *repository.ErasureRepository
+	masks      *masking.Engine
+	live       *concurrency.Tracker
+	peaks      *repository.ConcurrencyRepository
+	stream     *stream.Hub
+	kpis       *repository.KPIRepository
+	fraud      *repository.FraudRepository
+	anomalies  *repository.AnomalyRepository
+	recordings *repository.RecordingRepository
+	gateway    Gateway
+	log        zerolog.Logger
+	mux        *http.ServeMux
+}
+
+func NewServer(db *sql.DB, keys *repository.Keyring, masks *masking.Engine, live *concurrency.Tracker, hub *stream.Hub, gateway Gateway, log zerolog.Logger) *Server {
+	s := &Server{
+		repo:       repository.NewCallRepository(db, keys, log),
+		balances:   repository.NewBalanceRepository(db),
+		invoices:   repository.NewInvoiceRepository(db),
+		deliveries: repository.NewDeliveryRepository(db),
+		webhooks:   repository.NewWebhookRepository(db),
+		chain:      repository.NewChainRepository(db, keys),
+		erasures:   repository.NewErasureRepository(db, keys),
+		masks:      masks,
+		live:       live,
+		peaks:      repository.NewConcurrencyRepository(db),
+		stream:     hub,
+		kpis:       repository.NewKPIRepository(db),
+		fraud:      repository.NewFraudRepository(db, keys),
+		anomalies:  repository.NewAnomalyRepository(db),
+		recordings: repository.NewRecordingRepository(db),
+		gateway:    gateway,
+		log:        log,
+		mux:        http.NewServeMux(),
+	}
+	s.routes()
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /v1/calls", s.handleListCalls)
+	s.mux.HandleFunc("GET /v1/calls/export", s.handleExportCalls)
+	s.mux.HandleFunc("GET /v1/calls/stream", s.handleStreamCalls)
+	s.mux.HandleFunc("GET /v1/calls/{call_id}/cost", s.handleGetCallCost)
+	s.mux.HandleFunc("GET /v1/calls/{call_id}/recordings", s.handleListCallRecordings)
+	s.mux.HandleFunc("GET /v1/calls/{call_id}/ivr-path", s.handleGetIVRPath)
+	s.mux.HandleFunc("GET /v1/interactions/{interaction_id}", s.handleGetInteraction)
+	s.mux.HandleFunc("GET /v1/concurrency", s.handleListConcurrency)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/concurrency", s.handleGetConcurrency)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/concurrency/daily", s.handleListDailyConcurrency)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/kpis", s.handleListKPIs)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/agent-kpis", s.handleListAgentKPIs)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/ivr/drop-off", s.handleIVRDropOff)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/fraud-alerts", s.handleListFraudAlerts)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/balance", s.handleGetBalance)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/balance/ledger", s.handleListLedger)
+	s.mux.HandleFunc("POST /v1/tenants/{tenant_id}/balance/credits", s.require(permBillingWrite, s.handleCreditBalance))
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/invoices", s.handleListInvoices)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/invoices/{period}", s.handleGetInvoice)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/delivery-jobs", s.handleListDeliveryJobs)
+	s.mux.HandleFunc("POST /v1/tenants/{tenant_id}/delivery-jobs", s.require(permDeliveryWrite, s.handleCreateDeliveryJob))
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/deliveries", s.handleListDeliveries)
+	s.mux.HandleFunc("POST /v1/tenants/{tenant_id}/deliveries/{delivery_id}/retry", s.require(permDeliveryWrite, s.handleRetryDelivery))
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/webhooks", s.handleListWebhooks)
+	s.mux.HandleFunc("POST /v1/tenants/{tenant_id}/webhooks", s.require(permWebhooksWrite, s.handleCreateWebhook))
+	s.mux.HandleFunc("DELETE /v1/tenants/{tenant_id}/webhooks/{webhook_id}", s.require(permWebhooksWrite, s.handleDeleteWebhook))
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/webhook-deliveries", s.handleListWebhookDeliveries)
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/webhook-deliveries/{delivery_id}/attempts", s.handleListWebhookAttempts)
+	s.mux.HandleFunc("POST /v1/tenants/{tenant_id}/webhook-deliveries/{delivery_id}/replay", s.require(permWebhooksWrite, s.handleReplayWebhookDelivery))
+	s.mux.HandleFunc("GET /v1/tenants/{tenant_id}/chain/checkpoints", s.handleListChainCheckpoints)
+	s.mux.HandleFunc("GET /v1/anomalies", s.handleListAnomalies)
+	s.mux.HandleFunc("GET /v1/erasure-requests", s.handleListErasureRequests)
+	s.mux.HandleFunc("GET /v1/erasure-requests/{erasure_id}", s.handleGetErasureRequest)
+}
+
+// Start: API sunucusunu başlatır ve context iptal edildiğinde kibarca kapatır.
+func (s *Server) Start(ctx context.Context, port string) {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	s.log.Info().Str("address", srv.Addr).Msg("Sorgu API sunucusu başlatılıyor...")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.log.Error().Err(err).Msg("Sorgu API sunucusu başlatılamadı")
+	}
+}
+
+// parseCallFilter: Ortak sorgu parametrelerini okur. tenant_id zorunludur, zaman aralığı varsayılan olarak son 24 saattir.
+func parseCallFilter(r *http.Request) (repository.CallFilter, error) {
+	return parseTenantFilter(r, r.URL.Query().Get("tenant_id"))
+}
+
+// parseTenantFilter: Tenant'ı path'ten alan uç noktalar için parseCallFilter'ın eşdeğeri.
+func parseTenantFilter(r *http.Request, tenantID string) (repository.CallFilter, error) {
+	q := r.URL.Query()
+	f := repository.CallFilter{
+		TenantID:  tenantID,
+		Direction: q.Get("direction"),
+		UserID:    q.Get("user_id"),
+		Number:    q.Get("number"),
+		Limit:     defaultPageSize,
+	}
+	if f.TenantID == "" {
+		return f, errors.New("tenant_id parametresi zorunludur")
+	}
+
+	f.To = time.Now().UTC()
+	f.From = f.To.Add(-24 * time.Hour)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("from parametresi RFC3339 olmalı: %w", err)
+		}
+		f.From = t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, fmt.Errorf("to parametresi RFC3339 olmalı: %w", err)
+		}
+		f.To = t
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, errors.New("limit pozitif bir tamsayı olmalı")
+		}
+		f.Limit = min(n, maxPageSize)
+	}
+	if v := q.Get("low_mos"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("low_mos true veya false olmalı")
+		}
+		f.LowMOS = b
+	}
+	f.Intent = strings.ToLower(q.Get("intent"))
+	switch f.Sentiment = strings.ToLower(q.Get("sentiment")); f.Sentiment {
+	case "", repository.SentimentNegative, repository.SentimentNeutral, repository.SentimentPositive:
+	default:
+		return f, errors.New("sentiment negative, neutral veya positive olmalı")
+	}
+	if v := q.Get("agent_handoff"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("agent_handoff true veya false olmalı")
+		}
+		f.AgentHandoff = &b
+	}
+	f.AgentUserID = q.Get("agent_id")
+	f.Queue = q.Get("queue")
+	f.IVRLastNode = q.Get("ivr_last_node")
+	if f.IVRExitReason = strings.ToLower(q.Get("ivr_exit_reason")); f.IVRExitReason != "" &&
+		!slices.Contains(repository.IVRExitReasons, f.IVRExitReason) {
+		return f, fmt.Errorf("ivr_exit_reason şunlardan biri olmalı: %s", strings.Join(repository.IVRExitReasons, ", "))
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, errors.New("offset negatif olmayan bir tamsayı olmalı")
+		}
+		f.Offset = n
+	}
+	return f, nil
+}
+
+// parseLimit: Filtre gerektirmeyen listeleme uç noktalarının limit parametresi.
+func parseLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, errors.New("limit pozitif bir tamsayı olmalı")
+	}
+	return min(n, maxPageSize), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// setAttachment: Yanıtı dosya olarak indirilecek şekilde işaretler. Dosya adı tenant kimliği gibi istemciden gelen
+// değerler içerdiğinden tırnak ve satır sonları başlığı bozmayacak biçimde kodlanır.
+func setAttachment(w http.ResponseWriter, filename string) {
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
@@ -0,0 +1,39 @@
+// sentiric-cdr-service/internal/api/server_test.go
+package api
+
+import (
+	"mime"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetAttachment(t *testing.T) {
+	cases := []struct {
+		name     string
+		filename string
+	}{
+		{"düz ad", "invoice-acme-2025-03.csv"},
+		{"tırnak", `invoice-a"b-2025-03.csv`},
+		{"satır sonu", "invoice-a\r\nX-Evil: 1-2025-03.csv"},
+		{"noktalı virgül", "invoice-a; filename=x.exe-2025-03.csv"},
+		{"Türkçe karakter", "invoice-şirket-2025-03.csv"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		setAttachment(w, c.filename)
+		header := w.Header().Get("Content-Disposition")
+		if strings.ContainsAny(header, "\r\n") {
+			t.Errorf("%s: başlık satır sonu içeriyor: %q", c.name, header)
+			continue
+		}
+		disposition, params, err := mime.ParseMediaType(header)
+		if err != nil {
+			t.Errorf("%s: başlık çözümlenemedi (%q): %v", c.name, header, err)
+			continue
+		}
+		if disposition != "attachment" || params["filename"] != c.filename {
+			t.Errorf("%s: %s, filename = %q, beklenen attachment, %q", c.name, disposition, params["filename"], c.filename)
+		}
+	}
+}
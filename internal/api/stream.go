@@ -0,0 +1,125 @@
+// sentiric-cdr-service/internal/api/stream.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// streamHeartbeat: Ara katmanların boştaki bağlantıyı kapatmaması için yorum satırı gönderme sıklığı.
+const streamHeartbeat = 15 * time.Second
+
+// handleStreamCalls: Çağrı yaşam döngüsü değişikliklerini Server-Sent Events olarak yayınlar. tenant_id
+// zorunludur; user_id ve direction ile süzülebilir. Last-Event-ID başlığı (veya last_event_id parametresi)
+// verilirse kaçırılan olaylar önce gönderilir. Kaçırılan olaylar günlükten silinmişse "reset" olayı
+// gönderilir; istemci durumu /v1/calls'tan yeniden kurmalıdır. Olaylara yetişemeyen bağlantı kapatılır.
+func (s *Server) handleStreamCalls(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := repository.StreamFilter{TenantID: q.Get("tenant_id"), UserID: q.Get("user_id"), Direction: q.Get("direction")}
+	if f.TenantID == "" {
+		writeError(w, http.StatusBadRequest, "tenant_id parametresi zorunludur")
+		return
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = q.Get("last_event_id")
+	}
+	var after int64
+	if lastID != "" {
+		n, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "Last-Event-ID negatif olmayan bir tamsayı olmalı")
+			return
+		}
+		after = n
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Abonelik geçmiş okunmadan açılır; aradaki olaylar tamponda bekler. Hub geç commit edilen olayları sırası
+	// geçmiş olarak da gönderebildiğinden tekrarlar en yüksek id ile değil, geçmişten gönderilen id'lerle ayıklanır.
+	sub := s.stream.Subscribe(f)
+	defer s.stream.Unsubscribe(sub)
+
+	m := s.numberMasker(r, f.TenantID)
+	sent := after
+	replayed := map[int64]struct{}{}
+	send := func(e repository.StreamEvent) error {
+		if _, ok := replayed[e.ID]; ok {
+			return nil
+		}
+		if err := writeStreamEvent(w, m, e); err != nil {
+			return err
+		}
+		sent = max(sent, e.ID)
+		return rc.Flush()
+	}
+
+	if lastID != "" {
+		reset, err := s.stream.Replay(r.Context(), f, after, func(e repository.StreamEvent) error {
+			if err := send(e); err != nil {
+				return err
+			}
+			replayed[e.ID] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			if r.Context().Err() == nil {
+				s.log.Error().Err(err).Str("tenant_id", f.TenantID).Msg("Canlı akış geçmişi okunamadı")
+			}
+			return
+		}
+		if reset {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Lagged() {
+					s.log.Warn().Str("tenant_id", f.TenantID).Int64("last_event_id", sent).Msg("Canlı akış istemcisi yetişemedi, bağlantı kapatılıyor")
+				}
+				return
+			}
+			if err := send(e); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, m masking.Masker, e repository.StreamEvent) error {
+	e.CallerNumber = m.Mask(e.CallerNumber)
+	e.CalleeNumber = m.Mask(e.CalleeNumber)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.New("canlı akış olayı kodlanamadı")
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.EventType, data)
+	return err
+}
@@ -0,0 +1,159 @@
+// sentiric-cdr-service/internal/api/webhooks.go
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	"github.com/sentiric/sentiric-cdr-service/internal/webhook"
+)
+
+type createWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"` // Boşsa üretilir
+}
+
+// handleCreateWebhook: Abonelik oluşturur. İmza secret'ı yalnızca bu yanıtta döner.
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz JSON gövdesi")
+		return
+	}
+	sub := repository.WebhookSubscription{
+		TenantID:   r.PathValue("tenant_id"),
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Enabled:    true,
+	}
+	if len(sub.EventTypes) == 0 {
+		sub.EventTypes = webhook.EventTypes
+	}
+	if err := webhook.ValidateSubscription(sub); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if sub.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "secret üretilemedi")
+			return
+		}
+		sub.Secret = secret
+	}
+
+	created, err := s.webhooks.CreateSubscription(r.Context(), sub)
+	if err != nil {
+		s.log.Error().Err(err).Str("tenant_id", sub.TenantID).Msg("Webhook aboneliği kaydedilemedi")
+		writeError(w, http.StatusInternalServerError, "webhook aboneliği kaydedilemedi")
+		return
+	}
+	s.log.Info().Str("tenant_id", created.TenantID).Int64("webhook_id", created.ID).Str("url", created.URL).
+		Msg("🔔 Webhook aboneliği oluşturuldu.")
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// handleListWebhooks: Tenant'ın aboneliklerini secret olmadan döner.
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := s.webhooks.ListSubscriptions(r.Context(), r.PathValue("tenant_id"))
+	if err != nil {
+		s.log.Error().Err(err).Msg("Webhook abonelikleri okunamadı")
+		writeError(w, http.StatusInternalServerError, "webhook abonelikleri okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": subs})
+}
+
+// handleDeleteWebhook: Aboneliği ve teslim geçmişini siler.
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("webhook_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz webhook_id")
+		return
+	}
+	err = s.webhooks.DeleteSubscription(r.Context(), r.PathValue("tenant_id"), id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int64("webhook_id", id).Msg("Webhook aboneliği silinemedi")
+		writeError(w, http.StatusInternalServerError, "webhook aboneliği silinemedi")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListWebhookDeliveries: Teslimleri durum filtresiyle (?status=PENDING|DELIVERED|FAILED) döner.
+func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", repository.WebhookPending, repository.WebhookDelivered, repository.WebhookFailed:
+	default:
+		writeError(w, http.StatusBadRequest, "status PENDING, DELIVERED veya FAILED olmalı")
+		return
+	}
+
+	deliveries, err := s.webhooks.ListDeliveries(r.Context(), r.PathValue("tenant_id"), status, limit)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Webhook teslimleri okunamadı")
+		writeError(w, http.StatusInternalServerError, "webhook teslimleri okunamadı")
+		return
+	}
+	// Gövde kuyruğa webhook politikasıyla yazılmıştır; API tüketicisine ayrıca kendi politikası uygulanır.
+	m := s.numberMasker(r, r.PathValue("tenant_id"))
+	for i := range deliveries {
+		deliveries[i].Payload = m.MaskJSON(deliveries[i].Payload)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// handleListWebhookAttempts: Teslimin HTTP deneme geçmişini döner.
+func (s *Server) handleListWebhookAttempts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("delivery_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz delivery_id")
+		return
+	}
+	attempts, err := s.webhooks.ListAttempts(r.Context(), r.PathValue("tenant_id"), id)
+	if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int64("delivery_id", id).Msg("Webhook denemeleri okunamadı")
+		writeError(w, http.StatusInternalServerError, "webhook denemeleri okunamadı")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"attempts": attempts})
+}
+
+// handleReplayWebhookDelivery: Teslimi (başarılı olmuş olsa bile) yeniden gönderilmek üzere kuyruğa alır.
+func (s *Server) handleReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("delivery_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "geçersiz delivery_id")
+		return
+	}
+	err = s.webhooks.ReplayDelivery(r.Context(), r.PathValue("tenant_id"), id)
+	if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int64("delivery_id", id).Msg("Webhook teslimi yeniden kuyruğa alınamadı")
+		writeError(w, http.StatusInternalServerError, "webhook teslimi yeniden kuyruğa alınamadı")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"delivery_id": id, "status": repository.WebhookPending})
+}
@@ -0,0 +1,40 @@
+// sentiric-cdr-service/internal/billing/balance.go
+package billing
+
+// Ön ödemeli bakiye durumları (tenant_balances.state).
+const (
+	BalanceOK        = "OK"
+	BalanceLow       = "LOW"
+	BalanceExhausted = "EXHAUSTED"
+)
+
+// Bakiye durum geçişlerinde sentiric_events'e yayınlanan olay tipleri.
+const (
+	EventTenantBalanceLow       = "tenant.balance.low"
+	EventTenantBalanceExhausted = "tenant.balance.exhausted"
+	EventTenantBalanceRestored  = "tenant.balance.restored"
+)
+
+// BalanceStateEvent: Yeni duruma geçişte yayınlanacak olay tipini döner.
+func BalanceStateEvent(state string) string {
+	switch state {
+	case BalanceLow:
+		return EventTenantBalanceLow
+	case BalanceExhausted:
+		return EventTenantBalanceExhausted
+	default:
+		return EventTenantBalanceRestored
+	}
+}
+
+// BalanceState: Bakiyenin eşiğe göre durumunu belirler. Sıfır ve altı her zaman tükenmiş sayılır.
+func BalanceState(balance, lowThreshold float64) string {
+	switch {
+	case balance <= 0:
+		return BalanceExhausted
+	case balance <= lowThreshold:
+		return BalanceLow
+	default:
+		return BalanceOK
+	}
+}
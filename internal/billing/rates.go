@@ -0,0 +1,28 @@
+// sentiric-cdr-service/internal/billing/rates.go
+package billing
+
+// Faturalanabilir kaynak tipleri (usage_records.resource_type).
+const (
+	ResourceTelephonyMinute    = "telephony_minute"
+	ResourceSTTSecond          = "stt_second"
+	ResourceTTSCharacter       = "tts_character"
+	ResourceLLMPromptToken     = "llm_prompt_token"
+	ResourceLLMCompletionToken = "llm_completion_token"
+)
+
+// DefaultRateVersion: rate_cards tablosunda eşleşen fiyat yoksa kullanılan gömülü fiyat listesinin adı.
+const DefaultRateVersion = "builtin"
+
+// defaultUnitPrices: Birim başına varsayılan fiyatlar (USD).
+var defaultUnitPrices = map[string]float64{
+	ResourceTelephonyMinute:    0.005,
+	ResourceSTTSecond:          0.0004,
+	ResourceTTSCharacter:       0.000016,
+	ResourceLLMPromptToken:     0.0000005,
+	ResourceLLMCompletionToken: 0.0000015,
+}
+
+// DefaultUnitPrice: Kaynak için gömülü fiyatı döner; bilinmeyen kaynak ücretsizdir.
+func DefaultUnitPrice(resourceType string) float64 {
+	return defaultUnitPrices[resourceType]
+}
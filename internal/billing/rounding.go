@@ -0,0 +1,78 @@
+// AÇIKLAMA: Bu paket, ham kullanım miktarlarını faturalanabilir miktarlara çeviren
+// saf (DB'den bağımsız) derecelendirme (rating) kurallarını içerir.
+package billing
+
+import "fmt"
+
+type RoundingMode string
+
+const (
+	RoundTruncate RoundingMode = "truncate" // Artık kesilir (eski davranış)
+	RoundNearest  RoundingMode = "round"    // En yakın artıma yuvarlanır
+	RoundCeil     RoundingMode = "ceil"     // Bir üst artıma yuvarlanır
+)
+
+// RoundingPolicy: Operatörlerin "60/6" gibi faturalama kurallarını ifade eder:
+// MinimumSeconds ilk faturalanan blok, IncrementSeconds sonraki adımlardır.
+type RoundingPolicy struct {
+	Mode             RoundingMode `json:"mode"`
+	IncrementSeconds int          `json:"increment_seconds"`
+	MinimumSeconds   int          `json:"minimum_seconds"`
+}
+
+// DefaultRoundingPolicy: Tenant'a özel kural yoksa saniyeye kesme uygulanır.
+func DefaultRoundingPolicy() RoundingPolicy {
+	return RoundingPolicy{Mode: RoundTruncate, IncrementSeconds: 1}
+}
+
+func (p RoundingPolicy) Validate() error {
+	switch p.Mode {
+	case RoundTruncate, RoundNearest, RoundCeil:
+	default:
+		return fmt.Errorf("geçersiz yuvarlama modu: %q", p.Mode)
+	}
+	if p.IncrementSeconds <= 0 {
+		return fmt.Errorf("increment_seconds pozitif olmalı: %d", p.IncrementSeconds)
+	}
+	if p.MinimumSeconds < 0 {
+		return fmt.Errorf("minimum_seconds negatif olamaz: %d", p.MinimumSeconds)
+	}
+	return nil
+}
+
+// BillableSeconds: Milisaniye hassasiyetindeki süreye politikayı uygular.
+// Sıfır veya negatif süre her zaman 0 döner; minimum blok yalnızca gerçek süre varsa uygulanır.
+func (p RoundingPolicy) BillableSeconds(durationMs int64) int64 {
+	if durationMs <= 0 {
+		return 0
+	}
+
+	incMs := int64(p.IncrementSeconds) * 1000
+	if incMs <= 0 {
+		incMs = 1000
+	}
+
+	units := durationMs / incMs
+	rem := durationMs % incMs
+	switch p.Mode {
+	case RoundCeil:
+		if rem > 0 {
+			units++
+		}
+	case RoundNearest:
+		if rem*2 >= incMs {
+			units++
+		}
+	}
+
+	seconds := units * incMs / 1000
+	if seconds < int64(p.MinimumSeconds) {
+		seconds = int64(p.MinimumSeconds)
+	}
+	return seconds
+}
+
+// String: Usage kaydında denetim için saklanan kısa gösterim (örn: "ceil/60/6").
+func (p RoundingPolicy) String() string {
+	return fmt.Sprintf("%s/%d/%d", p.Mode, p.MinimumSeconds, p.IncrementSeconds)
+}
@@ -0,0 +1,51 @@
+// sentiric-cdr-service/internal/billing/rounding_test.go
+package billing
+
+import "testing"
+
+func TestBillableSeconds(t *testing.T) {
+	ceil606 := RoundingPolicy{Mode: RoundCeil, IncrementSeconds: 6, MinimumSeconds: 60}
+	cases := []struct {
+		name       string
+		policy     RoundingPolicy
+		durationMs int64
+		want       int64
+	}{
+		{"varsayılan kesme", DefaultRoundingPolicy(), 61_999, 61},
+		{"sıfır süre", ceil606, 0, 0},
+		{"negatif süre", ceil606, -500, 0},
+		{"60/6 minimum blok", ceil606, 1, 60},
+		{"60/6 tam minimum", ceil606, 60_000, 60},
+		{"60/6 üst artım", ceil606, 60_001, 66},
+		{"60/6 tam artım", ceil606, 66_000, 66},
+		{"ceil saniye", RoundingPolicy{Mode: RoundCeil, IncrementSeconds: 1}, 1_001, 2},
+		{"round aşağı", RoundingPolicy{Mode: RoundNearest, IncrementSeconds: 1}, 1_499, 1},
+		{"round yarım yukarı", RoundingPolicy{Mode: RoundNearest, IncrementSeconds: 1}, 1_500, 2},
+		{"round 30 sn", RoundingPolicy{Mode: RoundNearest, IncrementSeconds: 30}, 44_000, 30},
+		{"truncate 30 sn", RoundingPolicy{Mode: RoundTruncate, IncrementSeconds: 30}, 59_999, 30},
+		{"geçersiz artım saniye sayılır", RoundingPolicy{Mode: RoundCeil}, 2_100, 3},
+	}
+	for _, c := range cases {
+		if got := c.policy.BillableSeconds(c.durationMs); got != c.want {
+			t.Errorf("%s: BillableSeconds(%d) = %d, beklenen %d", c.name, c.durationMs, got, c.want)
+		}
+	}
+}
+
+func TestRoundingPolicyValidate(t *testing.T) {
+	cases := []struct {
+		policy  RoundingPolicy
+		wantErr bool
+	}{
+		{DefaultRoundingPolicy(), false},
+		{RoundingPolicy{Mode: RoundCeil, IncrementSeconds: 6, MinimumSeconds: 60}, false},
+		{RoundingPolicy{Mode: "floor", IncrementSeconds: 1}, true},
+		{RoundingPolicy{Mode: RoundCeil}, true},
+		{RoundingPolicy{Mode: RoundCeil, IncrementSeconds: 1, MinimumSeconds: -1}, true},
+	}
+	for _, c := range cases {
+		if err := c.policy.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("Validate(%s) hata = %v, hata bekleniyor = %v", c.policy, err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// AÇIKLAMA: Bu paket, CDR ve çağrı olayı satırlarının tenant bazlı hash zincirini tanımlar:
+// kayıt özetlerinin kanonik biçimi, zincir bağlantısı ve imzalı kontrol noktaları. Veritabanına dokunmaz.
+package chain
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Zincirdeki kayıt tipleri.
+const (
+	RecordCall    = "CALL"
+	RecordEvent   = "EVENT"
+	RecordErasure = "ERASURE"
+)
+
+// EventCheckpoint: Yeni kontrol noktası oluşturulduğunda outbox üzerinden yayınlanan olay.
+const EventCheckpoint = "cdr.chain.checkpoint"
+
+// GenesisHash: Tenant zincirinin ilk kaydının önceki hash'i.
+var GenesisHash = strings.Repeat("0", 64)
+
+// CallRecord: Zincire alınan, çağrı bittiğinde kesinleşen CDR alanları. Sonradan meşru olarak değişen
+// alanlar (total_cost, recording_url) özete dahil edilmez.
+type CallRecord struct {
+	CallID             string
+	TenantID           string
+	CallerNumber       string
+	CalleeNumber       string
+	Direction          string
+	StartTime          *time.Time
+	AnswerTime         *time.Time
+	EndTime            *time.Time
+	TotalDurationMs    int64
+	BillableDurationMs int64
+	Disposition        string
+	HangupSource       string
+}
+
+// EventRecord: Zincire alınan çağrı olayı. Payload, PostgreSQL'in jsonb metin gösterimidir (normalize edilmiş).
+type EventRecord struct {
+	CallID         string
+	EventType      string
+	EventTimestamp time.Time
+	Payload        string
+}
+
+// Hash: Kaydın kanonik "alan=değer" satırlarının SHA-256 özeti.
+func (c CallRecord) Hash() string {
+	return digest(
+		"type="+RecordCall,
+		"call_id="+c.CallID,
+		"tenant_id="+c.TenantID,
+		"caller_number="+c.CallerNumber,
+		"callee_number="+c.CalleeNumber,
+		"direction="+c.Direction,
+		"start_time="+formatTime(c.StartTime),
+		"answer_time="+formatTime(c.AnswerTime),
+		"end_time="+formatTime(c.EndTime),
+		"total_duration_ms="+strconv.FormatInt(c.TotalDurationMs, 10),
+		"billable_duration_ms="+strconv.FormatInt(c.BillableDurationMs, 10),
+		"disposition="+c.Disposition,
+		"hangup_source="+c.HangupSource,
+	)
+}
+
+func (e EventRecord) Hash() string {
+	ts := e.EventTimestamp
+	return digest(
+		"type="+RecordEvent,
+		"call_id="+e.CallID,
+		"event_type="+e.EventType,
+		"event_timestamp="+formatTime(&ts),
+		"payload="+e.Payload,
+	)
+}
+
+// ErasureHash: Silme talebiyle anonimleştirilen satırın zincirdeki kaydını yeni içeriğine bağlayan ERASURE kaydının
+// özeti. Orijinal kayıt değişmez; satırın yeni hash'i yalnızca zincire eklenmiş bir ERASURE kaydıyla meşrulaşır.
+func ErasureHash(erasureID, targetSeq int64, erasedHash string) string {
+	return digest(
+		"type="+RecordErasure,
+		"erasure_id="+strconv.FormatInt(erasureID, 10),
+		"target_seq="+strconv.FormatInt(targetSeq, 10),
+		"erased_hash="+erasedHash,
+	)
+}
+
+// Link: Zincir hash'i; önceki hash'i, sıra numarasını ve kaydın özetini bağlar. Bir kaydın silinmesi,
+// değiştirilmesi veya yer değiştirmesi sonraki tüm zincir hash'lerini geçersiz kılar.
+func Link(prevHash string, seq int64, recordType, callID, recordHash string) string {
+	return digest(prevHash, strconv.FormatInt(seq, 10), recordType, callID, recordHash)
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// formatTime: PostgreSQL mikro saniye hassasiyetindedir; UTC ve sabit biçim kullanılır.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+// Checkpoint: Tenant zincirinin belirli bir sıra numarasındaki hash'inin imzalı kaydı.
+type Checkpoint struct {
+	TenantID  string    `json:"tenant_id"`
+	Seq       int64     `json:"seq"`
+	ChainHash string    `json:"chain_hash"`
+	KeyID     string    `json:"key_id"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// message: İmzalanan içerik. Biçim değişirse sürüm öneki artırılmalıdır.
+func (c Checkpoint) message() []byte {
+	return []byte(fmt.Sprintf("sentiric-cdr-chain/v1|%s|%d|%s|%d", c.TenantID, c.Seq, c.ChainHash, c.CreatedAt.Unix()))
+}
+
+// Signer: Ed25519 kontrol noktası imzalayıcısı.
+type Signer struct {
+	KeyID string
+	key   ed25519.PrivateKey
+}
+
+// NewSigner: Base64 kodlu 32 baytlık Ed25519 seed'inden imzalayıcı oluşturur.
+func NewSigner(keyID, seedB64 string) (*Signer, error) {
+	seed, err := base64.StdEncoding.DecodeString(seedB64)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("imza anahtarı base64 kodlu 32 baytlık Ed25519 seed olmalı")
+	}
+	return &Signer{KeyID: keyID, key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// PublicKey: Doğrulama için paylaşılacak açık anahtar (base64).
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Sign: Kontrol noktasını imzalar; KeyID ve Signature alanlarını doldurur.
+func (s *Signer) Sign(c Checkpoint) Checkpoint {
+	c.KeyID = s.KeyID
+	c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, c.message()))
+	return c
+}
+
+// SignMessage: Kontrol noktası dışındaki belgeleri (örn: silme sertifikası) aynı anahtarla imzalar (base64).
+func (s *Signer) SignMessage(msg []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, msg))
+}
+
+// ParsePublicKey: Base64 kodlu Ed25519 açık anahtarını çözer.
+func ParsePublicKey(b64 string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("açık anahtar base64 kodlu 32 bayt olmalı")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// VerifySignature: Kontrol noktası imzasını açık anahtarla doğrular.
+func VerifySignature(pub ed25519.PublicKey, c Checkpoint) bool {
+	sig, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, c.message(), sig)
+}
+
+// VerifyMessage: SignMessage ile üretilmiş imzayı doğrular.
+func VerifyMessage(pub ed25519.PublicKey, msg []byte, signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, msg, sig)
+}
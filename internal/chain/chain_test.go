@@ -0,0 +1,135 @@
+// sentiric-cdr-service/internal/chain/chain_test.go
+package chain
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCall() CallRecord {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 123456000, time.UTC)
+	end := start.Add(95 * time.Second)
+	return CallRecord{
+		CallID: "c1", TenantID: "acme", CallerNumber: "+905551112233", CalleeNumber: "+902121234567",
+		Direction: "inbound", StartTime: &start, EndTime: &end,
+		TotalDurationMs: 95_000, BillableDurationMs: 90_000, Disposition: "ANSWERED", HangupSource: "caller",
+	}
+}
+
+func TestCallRecordHash(t *testing.T) {
+	base := testCall()
+	baseHash := base.Hash()
+	if len(baseHash) != 64 || baseHash != testCall().Hash() {
+		t.Fatalf("özet kararlı 64 haneli hex olmalı: %q", baseHash)
+	}
+
+	istanbul := time.FixedZone("TRT", 3*3600)
+	cases := []struct {
+		name     string
+		modify   func(*CallRecord)
+		wantSame bool
+	}{
+		{"aynı an başka saat diliminde", func(c *CallRecord) { t := c.StartTime.In(istanbul); c.StartTime = &t }, true},
+		{"numara", func(c *CallRecord) { c.CallerNumber = "+905551112234" }, false},
+		{"tenant", func(c *CallRecord) { c.TenantID = "other" }, false},
+		{"bitiş zamanı", func(c *CallRecord) { t := c.EndTime.Add(time.Microsecond); c.EndTime = &t }, false},
+		{"cevap zamanı eklendi", func(c *CallRecord) { t := *c.StartTime; c.AnswerTime = &t }, false},
+		{"faturalanan süre", func(c *CallRecord) { c.BillableDurationMs++ }, false},
+		{"disposition", func(c *CallRecord) { c.Disposition = "NO_ANSWER" }, false},
+		{"hangup kaynağı", func(c *CallRecord) { c.HangupSource = "callee" }, false},
+	}
+	for _, c := range cases {
+		rec := testCall()
+		c.modify(&rec)
+		if same := rec.Hash() == baseHash; same != c.wantSame {
+			t.Errorf("%s: özet aynı = %v, beklenen %v", c.name, same, c.wantSame)
+		}
+	}
+}
+
+func TestEventRecordHash(t *testing.T) {
+	ts := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	base := EventRecord{CallID: "c1", EventType: "call.started", EventTimestamp: ts, Payload: `{"a": 1}`}
+	cases := []struct {
+		name     string
+		rec      EventRecord
+		wantSame bool
+	}{
+		{"aynı kayıt", base, true},
+		{"saat dilimi", EventRecord{CallID: "c1", EventType: "call.started", EventTimestamp: ts.In(time.FixedZone("X", 3600)), Payload: `{"a": 1}`}, true},
+		{"gövde", EventRecord{CallID: "c1", EventType: "call.started", EventTimestamp: ts, Payload: `{"a": 2}`}, false},
+		{"tip", EventRecord{CallID: "c1", EventType: "call.ended", EventTimestamp: ts, Payload: `{"a": 1}`}, false},
+		{"çağrı", EventRecord{CallID: "c2", EventType: "call.started", EventTimestamp: ts, Payload: `{"a": 1}`}, false},
+	}
+	for _, c := range cases {
+		if same := c.rec.Hash() == base.Hash(); same != c.wantSame {
+			t.Errorf("%s: özet aynı = %v, beklenen %v", c.name, same, c.wantSame)
+		}
+	}
+	if base.Hash() == testCall().Hash() {
+		t.Error("olay ve çağrı özetleri farklı olmalı")
+	}
+}
+
+func TestLink(t *testing.T) {
+	rec := testCall().Hash()
+	base := Link(GenesisHash, 1, RecordCall, "c1", rec)
+	cases := []struct {
+		name string
+		got  string
+	}{
+		{"önceki hash", Link(strings.Repeat("1", 64), 1, RecordCall, "c1", rec)},
+		{"sıra", Link(GenesisHash, 2, RecordCall, "c1", rec)},
+		{"tip", Link(GenesisHash, 1, RecordEvent, "c1", rec)},
+		{"çağrı", Link(GenesisHash, 1, RecordCall, "c2", rec)},
+		{"kayıt özeti", Link(GenesisHash, 1, RecordCall, "c1", ErasureHash(1, 1, rec))},
+	}
+	if base != Link(GenesisHash, 1, RecordCall, "c1", rec) {
+		t.Fatal("Link kararlı olmalı")
+	}
+	for _, c := range cases {
+		if c.got == base {
+			t.Errorf("%s değişince zincir hash'i değişmeli", c.name)
+		}
+	}
+}
+
+func TestCheckpointSignature(t *testing.T) {
+	seed := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	signer, err := NewSigner("k1", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(signer.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := signer.Sign(Checkpoint{TenantID: "acme", Seq: 10, ChainHash: GenesisHash, CreatedAt: time.Unix(1_700_000_000, 0)})
+	if cp.KeyID != "k1" || !VerifySignature(pub, cp) {
+		t.Fatalf("imzalı kontrol noktası doğrulanamadı: %+v", cp)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(*Checkpoint)
+	}{
+		{"tenant", func(c *Checkpoint) { c.TenantID = "other" }},
+		{"sıra", func(c *Checkpoint) { c.Seq++ }},
+		{"hash", func(c *Checkpoint) { c.ChainHash = strings.Repeat("1", 64) }},
+		{"zaman", func(c *Checkpoint) { c.CreatedAt = c.CreatedAt.Add(time.Second) }},
+		{"bozuk imza", func(c *Checkpoint) { c.Signature = "!" }},
+	}
+	for _, c := range cases {
+		mod := cp
+		c.modify(&mod)
+		if VerifySignature(pub, mod) {
+			t.Errorf("%s değişen kontrol noktası doğrulanmamalı", c.name)
+		}
+	}
+
+	if _, err := NewSigner("k1", "kısa"); err == nil {
+		t.Error("geçersiz seed reddedilmeli")
+	}
+}
@@ -0,0 +1,280 @@
+// AÇIKLAMA: Bu paket, tenant başına anlık ve günlük en yüksek eşzamanlı çağrı sayısını bellekte izler.
+// Durum açılışta veritabanındaki açık çağrılardan kurulur ve periyodik olarak onunla eşitlenir.
+package concurrency
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+const (
+	// syncInterval: Veritabanıyla eşitleme ve günlük tepelerin yazılma sıklığı. Birden fazla servis kopyası
+	// olduğunda diğer kopyaların işlediği olaylar en geç bu süre sonra sayıma yansır.
+	syncInterval = 15 * time.Second
+	// maxCallAge: Bu süreden uzun açık kalan çağrının call.ended olayı kaybolmuş sayılır ve sayılmaz.
+	maxCallAge = 12 * time.Hour
+)
+
+// Usage: Tenant'ın anlık ve bugünkü (UTC) en yüksek eşzamanlı çağrı sayısı.
+type Usage struct {
+	TenantID string     `json:"tenant_id"`
+	Current  int        `json:"current"`
+	Peak     int        `json:"peak"`
+	PeakAt   *time.Time `json:"peak_at,omitempty"`
+	Day      time.Time  `json:"day"`
+}
+
+type tenantCalls struct {
+	// open: call_id -> çağrının bu kopyada görüldüğü an.
+	open   map[string]time.Time
+	day    time.Time
+	peak   int
+	peakAt time.Time
+}
+
+type peakKey struct {
+	tenantID string
+	day      time.Time
+}
+
+type Tracker struct {
+	repo *repository.ConcurrencyRepository
+	log  zerolog.Logger
+
+	mu      sync.Mutex
+	tenants map[string]*tenantCalls
+	// ended: Son eşitlemeden beri bu kopyada biten çağrılar; eşitleme sorgusuyla yarışan bitişler kaybolmaz.
+	ended map[string]time.Time
+	// pending: Henüz yazılmamış günlük tepeler.
+	pending map[peakKey]repository.DailyPeak
+}
+
+func NewTracker(db *sql.DB, log zerolog.Logger) *Tracker {
+	return &Tracker{
+		repo:    repository.NewConcurrencyRepository(db),
+		log:     log,
+		tenants: map[string]*tenantCalls{},
+		ended:   map[string]time.Time{},
+		pending: map[peakKey]repository.DailyPeak{},
+	}
+}
+
+// Run: Context iptal edilene kadar durumu veritabanıyla eşitler ve günlük tepeleri yazar.
+func (t *Tracker) Run(ctx context.Context) {
+	t.log.Info().Msg("📈 Eşzamanlı çağrı izleyicisi aktif")
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := t.Flush(context.WithoutCancel(ctx)); err != nil {
+				t.log.Error().Err(err).Msg("Günlük eşzamanlılık tepeleri yazılamadı.")
+			}
+			return
+		case <-ticker.C:
+		}
+		if err := t.Sync(ctx); err != nil && ctx.Err() == nil {
+			t.log.Error().Err(err).Msg("Açık çağrılar veritabanıyla eşitlenemedi.")
+		}
+		if err := t.Flush(ctx); err != nil && ctx.Err() == nil {
+			t.log.Error().Err(err).Msg("Günlük eşzamanlılık tepeleri yazılamadı.")
+		}
+	}
+}
+
+// Sync: Açık çağrıları veritabanından okuyup bellekteki durumu onunla değiştirir. Sorgu sürerken bu
+// kopyada başlayan veya biten çağrılar korunur. Servis açılışında tüketici başlamadan önce çağrılır.
+func (t *Tracker) Sync(ctx context.Context) error {
+	began := time.Now()
+	calls, err := t.repo.ListOpenCalls(ctx, began.Add(-maxCallAge))
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	open := map[string]map[string]time.Time{}
+	for _, c := range calls {
+		if at, ok := t.ended[c.CallID]; ok && !at.Before(began) {
+			continue
+		}
+		if open[c.TenantID] == nil {
+			open[c.TenantID] = map[string]time.Time{}
+		}
+		seen := began
+		if prev, ok := t.tenants[c.TenantID]; ok {
+			if at, ok := prev.open[c.CallID]; ok {
+				seen = at
+			}
+		}
+		open[c.TenantID][c.CallID] = seen
+	}
+	for tenantID, tc := range t.tenants {
+		for callID, at := range tc.open {
+			if !at.Before(began) {
+				if open[tenantID] == nil {
+					open[tenantID] = map[string]time.Time{}
+				}
+				open[tenantID][callID] = at
+			}
+		}
+	}
+	for callID, at := range t.ended {
+		if at.Before(began) {
+			delete(t.ended, callID)
+		}
+	}
+
+	for tenantID := range open {
+		t.tenant(tenantID)
+	}
+	now := time.Now()
+	for tenantID, tc := range t.tenants {
+		tc.open = open[tenantID]
+		if tc.open == nil {
+			tc.open = map[string]time.Time{}
+		}
+		t.observe(tenantID, tc, now)
+	}
+	return nil
+}
+
+// Started: call.started kalıcı yazıldıktan sonra çağrılır. Aynı çağrının tekrar işlenmesi sayımı değiştirmez.
+func (t *Tracker) Started(tenantID, callID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tc := t.tenant(tenantID)
+	if _, ok := tc.open[callID]; ok {
+		return
+	}
+	now := time.Now()
+	tc.open[callID] = now
+	delete(t.ended, callID)
+	t.observe(tenantID, tc, now)
+}
+
+// Ended: call.ended kalıcı yazıldıktan sonra çağrılır.
+func (t *Tracker) Ended(tenantID, callID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tc := t.tenant(tenantID)
+	delete(tc.open, callID)
+	now := time.Now()
+	t.ended[callID] = now
+	t.observe(tenantID, tc, now)
+}
+
+// Flush: Yazılmamış günlük tepeleri veritabanına yazar. Hata olursa tepeler bir sonraki denemeye kalır.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.pending) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	peaks := make([]repository.DailyPeak, 0, len(t.pending))
+	for _, p := range t.pending {
+		peaks = append(peaks, p)
+	}
+	t.pending = map[peakKey]repository.DailyPeak{}
+	t.mu.Unlock()
+
+	err := t.repo.RecordDailyPeaks(ctx, peaks)
+	if err != nil {
+		t.mu.Lock()
+		for _, p := range peaks {
+			t.recordPeak(p)
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// Tenant: Tenant'ın anlık durumu; hiç çağrı görülmemişse sıfırdır.
+func (t *Tracker) Tenant(tenantID string) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tc, ok := t.tenants[tenantID]
+	if !ok {
+		return Usage{TenantID: tenantID, Day: day(time.Now())}
+	}
+	return usage(tenantID, tc, time.Now())
+}
+
+// Snapshot: Çağrısı görülmüş tüm tenant'ların anlık durumu, tenant sırasıyla.
+func (t *Tracker) Snapshot() []Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	out := make([]Usage, 0, len(t.tenants))
+	for tenantID, tc := range t.tenants {
+		out = append(out, usage(tenantID, tc, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TenantID < out[j].TenantID })
+	return out
+}
+
+func (t *Tracker) tenant(tenantID string) *tenantCalls {
+	tc, ok := t.tenants[tenantID]
+	if !ok {
+		tc = &tenantCalls{open: map[string]time.Time{}}
+		t.tenants[tenantID] = tc
+	}
+	return tc
+}
+
+// observe: Gün değiştiyse tepeyi sıfırlar, yeni tepeyi kaydeder ve metrikleri günceller.
+func (t *Tracker) observe(tenantID string, tc *tenantCalls, now time.Time) {
+	if d := day(now); !tc.day.Equal(d) {
+		tc.day, tc.peak, tc.peakAt = d, 0, time.Time{}
+	}
+	if n := len(tc.open); n > tc.peak {
+		tc.peak, tc.peakAt = n, now.UTC()
+		t.recordPeak(repository.DailyPeak{TenantID: tenantID, Day: tc.day, PeakCalls: n, PeakAt: tc.peakAt})
+	}
+	metrics.ConcurrentCalls.WithLabelValues(tenantID).Set(float64(len(tc.open)))
+	metrics.ConcurrentCallsPeak.WithLabelValues(tenantID).Set(float64(tc.peak))
+}
+
+func (t *Tracker) recordPeak(p repository.DailyPeak) {
+	k := peakKey{tenantID: p.TenantID, day: p.Day}
+	if prev, ok := t.pending[k]; ok && prev.PeakCalls >= p.PeakCalls {
+		return
+	}
+	t.pending[k] = p
+}
+
+func usage(tenantID string, tc *tenantCalls, now time.Time) Usage {
+	u := Usage{TenantID: tenantID, Current: len(tc.open), Day: day(now)}
+	if tc.day.Equal(u.Day) {
+		u.Peak = tc.peak
+	}
+	if u.Peak > 0 {
+		at := tc.peakAt
+		u.PeakAt = &at
+	}
+	// Gün değiştiği halde henüz olay veya eşitleme gelmediyse tepe en az anlık sayı kadardır.
+	if u.Current > u.Peak {
+		u.Peak = u.Current
+		u.PeakAt = nil
+	}
+	return u
+}
+
+// day: Zamanın UTC gün başlangıcı.
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
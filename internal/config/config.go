@@ -17,26 +17,29 @@ type Config struct {
 	PostgresURL    string
 	RabbitMQURL    string
 	MetricsPort    string
+	HTTPPort       string
+	// ChainSigningKey: Hash zinciri kontrol noktalarını imzalayan base64 Ed25519 seed; boşsa kontrol noktası üretilmez.
+	ChainSigningKey string
+	ChainKeyID      string
+	// PseudonymKey: Silme taleplerinde takma ad ve kişi özeti üreten HMAC anahtarı; boşsa takma ad her talepte rastgeledir.
+	PseudonymKey string
+	// MasterKeyFile: Alan şifrelemesi ana anahtar dosyası; KMSAddr ile birlikte tanımlanamaz. İkisi de boşsa
+	// numaralar ve olay gövdeleri düz metin yazılır.
+	MasterKeyFile string
+	// KMSAddr, KMSToken, KMSKey: Vault Transit uyumlu KMS'teki ana anahtar.
+	KMSAddr  string
+	KMSToken string
+	KMSKey   string
+	// MaskingKey: HASH maskeleme politikasının HMAC anahtarı; boşsa özet sabit bir bağlamla alınır.
+	MaskingKey string
+	// GatewaySecret: API geçidinin rol ve yetki başlıklarını imzaladığı HMAC anahtarı. TrustGatewayHeaders, anahtar
+	// yokken başlıklara imzasız güvenilip güvenilmeyeceğini belirler (API yalnızca geçit arkasındaysa).
+	GatewaySecret       string
+	TrustGatewayHeaders bool
 }
 
 func Load(version string) (*Config, error) {
-	_ = godotenv.Load()
-
-	// ServiceVersion artık build-time'dan geliyor, eğer boşsa default kullanılıyor.
-	if version == "" {
-		version = "0.0.0-dev"
-	}
-
-	cfg := &Config{
-		Env:            getEnvWithDefault("ENV", "production"),
-		LogLevel:       getEnvWithDefault("LOG_LEVEL", "info"),
-		LogFormat:      getEnvWithDefault("LOG_FORMAT", "json"),
-		NodeHostname:   getEnvWithDefault("NODE_HOSTNAME", "localhost"),
-		ServiceVersion: version,
-		PostgresURL:    getEnv("POSTGRES_URL"),
-		RabbitMQURL:    getEnv("RABBITMQ_URL"),
-		MetricsPort:    getEnvWithDefault("CDR_SERVICE_METRICS_PORT", "12052"),
-	}
+	cfg := load(version)
 
 	if cfg.PostgresURL == "" || cfg.RabbitMQURL == "" {
 		missingVars := ""
@@ -52,6 +55,46 @@ func Load(version string) (*Config, error) {
 	return cfg, nil
 }
 
+// LoadCLI: Yönetim komutları (rerate vb.) yalnızca veritabanına ihtiyaç duyar; RabbitMQ zorunlu değildir.
+func LoadCLI(version string) (*Config, error) {
+	cfg := load(version)
+	if cfg.PostgresURL == "" {
+		return nil, fmt.Errorf("kritik ortam değişkenleri eksik: POSTGRES_URL")
+	}
+	return cfg, nil
+}
+
+func load(version string) *Config {
+	_ = godotenv.Load()
+
+	// ServiceVersion artık build-time'dan geliyor, eğer boşsa default kullanılıyor.
+	if version == "" {
+		version = "0.0.0-dev"
+	}
+
+	return &Config{
+		Env:                 getEnvWithDefault("ENV", "production"),
+		LogLevel:            getEnvWithDefault("LOG_LEVEL", "info"),
+		LogFormat:           getEnvWithDefault("LOG_FORMAT", "json"),
+		NodeHostname:        getEnvWithDefault("NODE_HOSTNAME", "localhost"),
+		ServiceVersion:      version,
+		PostgresURL:         getEnv("POSTGRES_URL"),
+		RabbitMQURL:         getEnv("RABBITMQ_URL"),
+		MetricsPort:         getEnvWithDefault("CDR_SERVICE_METRICS_PORT", "12052"),
+		HTTPPort:            getEnvWithDefault("CDR_SERVICE_HTTP_PORT", "12050"),
+		ChainSigningKey:     getEnv("CDR_CHAIN_SIGNING_KEY"),
+		ChainKeyID:          getEnvWithDefault("CDR_CHAIN_KEY_ID", "default"),
+		PseudonymKey:        getEnv("CDR_PSEUDONYM_KEY"),
+		MasterKeyFile:       getEnv("CDR_MASTER_KEY_FILE"),
+		KMSAddr:             getEnv("CDR_KMS_ADDR"),
+		KMSToken:            getEnv("CDR_KMS_TOKEN"),
+		KMSKey:              getEnvWithDefault("CDR_KMS_KEY", "sentiric-cdr"),
+		MaskingKey:          getEnv("CDR_MASKING_KEY"),
+		GatewaySecret:       getEnv("CDR_GATEWAY_SECRET"),
+		TrustGatewayHeaders: getEnv("CDR_TRUST_GATEWAY_HEADERS") == "true",
+	}
+}
+
 func getEnv(key string) string {
 	return os.Getenv(key)
 }
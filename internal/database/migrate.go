@@ -0,0 +1,90 @@
+// sentiric-cdr-service/internal/database/migrate.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Ana tablolar (calls, call_events, usage_records) altyapı reposunda oluşturulur.
+// Bu dizindeki dosyalar yalnızca bu servisin eklediği kolon ve tabloları içerir
+// ve her biri idempotent (IF NOT EXISTS) yazılmalıdır.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Replikaların aynı anda şema değiştirmesini engelleyen advisory lock anahtarı.
+const migrationLockKey = 72052
+
+// Migrate: Uygulanmamış şema dosyalarını isim sırasıyla ve her birini tek transaction içinde uygular.
+func Migrate(ctx context.Context, db *sql.DB, log zerolog.Logger) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migration bağlantısı alınamadı: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("migration kilidi alınamadı: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}()
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS cdr_schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`)
+	if err != nil {
+		return fmt.Errorf("migration tablosu oluşturulamadı: %w", err)
+	}
+
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+
+		var applied bool
+		if err := conn.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM cdr_schema_migrations WHERE version = $1)", version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		body, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %s uygulanamadı: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO cdr_schema_migrations (version) VALUES ($1)", version); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Info().Str("version", version).Msg("Şema migration'ı uygulandı.")
+	}
+	return nil
+}
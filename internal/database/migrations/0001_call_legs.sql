@@ -0,0 +1,16 @@
+-- Çoklu bacak (leg) korelasyonu: transfer, fork, bridge ve konferanslar.
+-- Bir müşteri etkileşimi (interaction) birden fazla call_id'den oluşabilir.
+-- Satır, ilgili call.started olayından önce de oluşabilir (sıra bağımsızlığı).
+CREATE TABLE IF NOT EXISTS call_legs (
+    call_id                  TEXT PRIMARY KEY,
+    interaction_id           TEXT NOT NULL,
+    parent_call_id           TEXT,
+    leg_sequence             INTEGER NOT NULL DEFAULT 1,
+    leg_type                 TEXT NOT NULL DEFAULT 'PRIMARY',
+    transferred_from_call_id TEXT,
+    transferred_to_call_id   TEXT,
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_call_legs_interaction ON call_legs (interaction_id, leg_sequence);
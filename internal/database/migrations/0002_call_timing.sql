@@ -0,0 +1,12 @@
+-- Çağrı zamanlama kırılımı: çalma, toplam, faturalanabilir, beklemede ve konuşma süreleri.
+-- duration_seconds geriye dönük uyumluluk için korunur (cevaplandıysa billsec, değilse toplam süre).
+ALTER TABLE calls
+    ADD COLUMN IF NOT EXISTS ringing_time TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS post_dial_delay_ms INTEGER,
+    ADD COLUMN IF NOT EXISTS ring_seconds INTEGER,
+    ADD COLUMN IF NOT EXISTS total_duration_seconds INTEGER,
+    ADD COLUMN IF NOT EXISTS billable_seconds INTEGER,
+    ADD COLUMN IF NOT EXISTS hold_seconds INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS hold_started_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS hold_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS talk_seconds INTEGER;
@@ -0,0 +1,20 @@
+-- Milisaniye hassasiyetinde süreler. Saniye kolonları ekran ve geriye uyumluluk içindir;
+-- yuvarlama yalnızca derecelendirmede (rating) tenant politikasına göre uygulanır.
+ALTER TABLE calls
+    ADD COLUMN IF NOT EXISTS total_duration_ms BIGINT,
+    ADD COLUMN IF NOT EXISTS billable_duration_ms BIGINT;
+
+-- Tenant bazlı yuvarlama politikası. Kaydı olmayan tenant'a saniyeye kesme uygulanır.
+CREATE TABLE IF NOT EXISTS tenant_rating_policies (
+    tenant_id         TEXT PRIMARY KEY,
+    rounding_mode     TEXT NOT NULL DEFAULT 'truncate' CHECK (rounding_mode IN ('truncate', 'round', 'ceil')),
+    increment_seconds INTEGER NOT NULL DEFAULT 1 CHECK (increment_seconds > 0),
+    minimum_seconds   INTEGER NOT NULL DEFAULT 0 CHECK (minimum_seconds >= 0),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Faturalanan usage satırının hangi ham süre ve kuralla üretildiği denetim için saklanır.
+ALTER TABLE usage_records
+    ADD COLUMN IF NOT EXISTS raw_duration_ms BIGINT,
+    ADD COLUMN IF NOT EXISTS rated_seconds BIGINT,
+    ADD COLUMN IF NOT EXISTS rounding_policy TEXT;
@@ -0,0 +1,25 @@
+-- Kaynak bazlı fiyat listeleri. tenant_id NULL olan satırlar platform geneli fiyattır;
+-- tenant'a özel satır önceliklidir. Eşleşme yoksa servis içindeki gömülü fiyatlar kullanılır.
+CREATE TABLE IF NOT EXISTS rate_cards (
+    id            BIGSERIAL PRIMARY KEY,
+    rate_version  TEXT NOT NULL,
+    tenant_id     TEXT,
+    resource_type TEXT NOT NULL,
+    unit_price    NUMERIC(18, 10) NOT NULL CHECK (unit_price >= 0),
+    currency      TEXT NOT NULL DEFAULT 'USD',
+    valid_from    TIMESTAMPTZ NOT NULL DEFAULT '-infinity',
+    valid_to      TIMESTAMPTZ NOT NULL DEFAULT 'infinity'
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_cards_lookup ON rate_cards (resource_type, tenant_id, valid_from);
+
+-- AI servisleri bir çağrı için çok sayıda usage olayı üretir; tekrar gelen olay source_event_id ile elenir.
+ALTER TABLE usage_records
+    ADD COLUMN IF NOT EXISTS unit_price NUMERIC(18, 10),
+    ADD COLUMN IF NOT EXISTS rate_version TEXT,
+    ADD COLUMN IF NOT EXISTS source_event_id TEXT,
+    ADD COLUMN IF NOT EXISTS metadata JSONB,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_usage_records_source_event ON usage_records (source_event_id) WHERE source_event_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_usage_records_call ON usage_records (call_id);
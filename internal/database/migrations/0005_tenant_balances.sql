@@ -0,0 +1,40 @@
+-- Ön ödemeli (prepaid) tenant bakiyeleri. Satırı olmayan veya is_prepaid=false tenant'lar bakiye takibine girmez.
+CREATE TABLE IF NOT EXISTS tenant_balances (
+    tenant_id             TEXT PRIMARY KEY,
+    balance               NUMERIC(18, 6) NOT NULL DEFAULT 0,
+    currency              TEXT NOT NULL DEFAULT 'USD',
+    is_prepaid            BOOLEAN NOT NULL DEFAULT TRUE,
+    low_balance_threshold NUMERIC(18, 6) NOT NULL DEFAULT 10,
+    state                 TEXT NOT NULL DEFAULT 'OK' CHECK (state IN ('OK', 'LOW', 'EXHAUSTED')),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Değiştirilemez bakiye defteri: her usage satırı ve her yükleme bir kayıt üretir.
+CREATE TABLE IF NOT EXISTS tenant_balance_ledger (
+    id              BIGSERIAL PRIMARY KEY,
+    tenant_id       TEXT NOT NULL,
+    entry_type      TEXT NOT NULL CHECK (entry_type IN ('USAGE', 'CREDIT', 'ADJUSTMENT')),
+    amount          NUMERIC(18, 6) NOT NULL,
+    balance_after   NUMERIC(18, 6) NOT NULL,
+    call_id         TEXT,
+    usage_record_id TEXT,
+    reference       TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_ledger_tenant ON tenant_balance_ledger (tenant_id, created_at);
+
+-- Transactional outbox: DB değişikliğiyle aynı transaction'da yazılan ve relay tarafından
+-- sentiric_events exchange'ine yayınlanan olaylar.
+CREATE TABLE IF NOT EXISTS cdr_outbox_events (
+    id           BIGSERIAL PRIMARY KEY,
+    event_type   TEXT NOT NULL,
+    tenant_id    TEXT NOT NULL,
+    payload      JSONB NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    last_error   TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON cdr_outbox_events (id) WHERE published_at IS NULL;
@@ -0,0 +1,10 @@
+-- Yeniden derecelendirme (rerate): eski usage satırları silinmez. Her düzeltme için
+-- orijinalin negatif tutarlı bir ters kaydı (reversal_of) ve yeni fiyatlı bir satır yazılır;
+-- orijinal satır superseded_at ile işaretlenir. SUM(calculated_cost) her zaman güncel toplamı verir.
+ALTER TABLE usage_records
+    ADD COLUMN IF NOT EXISTS reversal_of TEXT,
+    ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS rerate_batch_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_active ON usage_records (call_id, resource_type)
+    WHERE superseded_at IS NULL AND reversal_of IS NULL;
@@ -0,0 +1,34 @@
+-- Fatura satırlarının gruplanacağı tarife grubu (MOBILE, LANDLINE, INTERNATIONAL ...).
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS destination_group TEXT;
+
+-- Kapatılmış (dondurulmuş) faturalama dönemleri. Bir dönem yalnızca bir kez kapatılabilir.
+CREATE TABLE IF NOT EXISTS billing_periods (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant_id    TEXT NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end   TIMESTAMPTZ NOT NULL,
+    currency     TEXT NOT NULL DEFAULT 'USD',
+    total_amount NUMERIC(18, 6) NOT NULL DEFAULT 0,
+    closed_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, period_start)
+);
+
+-- Dönem kapatılırken üretilen fatura kalemleri. Kapatılmış dönemlere ait geç gelen
+-- usage satırları sonraki dönemde ADJUSTMENT kalemi olarak yer alır.
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id                BIGSERIAL PRIMARY KEY,
+    billing_period_id BIGINT NOT NULL REFERENCES billing_periods (id),
+    line_type         TEXT NOT NULL CHECK (line_type IN ('USAGE', 'ADJUSTMENT')),
+    service_name      TEXT NOT NULL,
+    resource_type     TEXT NOT NULL,
+    destination_group TEXT NOT NULL,
+    quantity          NUMERIC(18, 6) NOT NULL,
+    amount            NUMERIC(18, 6) NOT NULL,
+    usage_count       INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_period ON invoice_lines (billing_period_id);
+
+-- Usage satırı hangi kapatılmış döneme dahil edildiyse o dönemin id'sini taşır.
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS billing_period_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_usage_records_unbilled ON usage_records (tenant_id) WHERE billing_period_id IS NULL;
@@ -0,0 +1,46 @@
+-- Tenant bazlı zamanlanmış CDR teslim işleri. destination, hedef tipine göre bağlantı bilgilerini tutar;
+-- gizli alanlarda "${ORTAM_DEGISKENI}" kullanılabilir, değer teslim anında ortamdan okunur.
+CREATE TABLE IF NOT EXISTS cdr_delivery_jobs (
+    id                BIGSERIAL PRIMARY KEY,
+    tenant_id         TEXT NOT NULL,
+    name              TEXT NOT NULL,
+    schedule          TEXT NOT NULL,
+    window_period     TEXT NOT NULL DEFAULT 'DAILY' CHECK (window_period IN ('HOURLY', 'DAILY')),
+    timezone          TEXT NOT NULL DEFAULT 'UTC',
+    format            TEXT NOT NULL DEFAULT 'csv',
+    columns           TEXT NOT NULL DEFAULT '',
+    mask_numbers      BOOLEAN NOT NULL DEFAULT FALSE,
+    include_events    BOOLEAN NOT NULL DEFAULT FALSE,
+    compression       TEXT NOT NULL DEFAULT 'none' CHECK (compression IN ('none', 'gzip')),
+    filename_template TEXT NOT NULL DEFAULT 'cdr-{tenant}-{date}.{ext}',
+    destination_type  TEXT NOT NULL CHECK (destination_type IN ('S3', 'SFTP')),
+    destination       JSONB NOT NULL,
+    max_attempts      INTEGER NOT NULL DEFAULT 5,
+    enabled           BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at       TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, name)
+);
+
+-- Her işin her zaman penceresi için tek teslim kaydı; tekrar denemeler aynı satırı günceller.
+CREATE TABLE IF NOT EXISTS cdr_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    job_id          BIGINT NOT NULL REFERENCES cdr_delivery_jobs (id) ON DELETE CASCADE,
+    tenant_id       TEXT NOT NULL,
+    window_start    TIMESTAMPTZ NOT NULL,
+    window_end      TIMESTAMPTZ NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    object_name     TEXT,
+    row_count       INTEGER,
+    size_bytes      BIGINT,
+    sha256          TEXT,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ,
+    UNIQUE (job_id, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cdr_deliveries_pending ON cdr_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_cdr_deliveries_tenant ON cdr_deliveries (tenant_id, window_start);
@@ -0,0 +1,40 @@
+-- Tenant bazlı, yalnızca eklemeli CDR hash zinciri. Kesinleşen her çağrı ve her olay satırı bir zincir
+-- kaydı alır; satır da kendi sıra numarasını ve zincir hash'ini taşır.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS chain_hash TEXT;
+ALTER TABLE call_events ADD COLUMN IF NOT EXISTS chain_seq BIGINT;
+ALTER TABLE call_events ADD COLUMN IF NOT EXISTS chain_hash TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_call_events_chain ON call_events (call_id, chain_seq);
+
+-- Her tenant zincirinin son durumu; ekleme sırasında satır kilidiyle zinciri serileştirir.
+CREATE TABLE IF NOT EXISTS cdr_chain_heads (
+    tenant_id  TEXT PRIMARY KEY,
+    last_seq   BIGINT NOT NULL DEFAULT 0,
+    last_hash  TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS cdr_hash_chain (
+    tenant_id   TEXT NOT NULL,
+    seq         BIGINT NOT NULL,
+    record_type TEXT NOT NULL CHECK (record_type IN ('CALL', 'EVENT')),
+    call_id     TEXT NOT NULL,
+    record_hash TEXT NOT NULL,
+    prev_hash   TEXT NOT NULL,
+    chain_hash  TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, seq)
+);
+
+-- Zincir hash'lerinin Ed25519 ile imzalanmış periyodik kontrol noktaları.
+CREATE TABLE IF NOT EXISTS cdr_chain_checkpoints (
+    id         BIGSERIAL PRIMARY KEY,
+    tenant_id  TEXT NOT NULL,
+    seq        BIGINT NOT NULL,
+    chain_hash TEXT NOT NULL,
+    key_id     TEXT NOT NULL,
+    signature  TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (tenant_id, seq)
+);
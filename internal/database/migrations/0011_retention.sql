@@ -0,0 +1,55 @@
+-- Tenant bazlı saklama politikaları. tenant_id '*' platform varsayılanıdır; politikası olmayan
+-- ve varsayılan tanımlı değilse tenant verisi süresiz saklanır.
+CREATE TABLE IF NOT EXISTS cdr_retention_policies (
+    tenant_id      TEXT PRIMARY KEY,
+    retention_days INTEGER NOT NULL CHECK (retention_days > 0),
+    action         TEXT NOT NULL DEFAULT 'ARCHIVE' CHECK (action IN ('ARCHIVE', 'DELETE')),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Yasal saklama (legal hold): aralıkla kesişen satırlar ve partition'lar hiçbir koşulda silinmez.
+-- tenant_id NULL ise tüm tenant'ları kapsar; hold_to NULL ise süresizdir.
+CREATE TABLE IF NOT EXISTS cdr_legal_holds (
+    id          BIGSERIAL PRIMARY KEY,
+    tenant_id   TEXT,
+    hold_from   TIMESTAMPTZ NOT NULL DEFAULT '-infinity',
+    hold_to     TIMESTAMPTZ,
+    reason      TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    released_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_cdr_legal_holds_active ON cdr_legal_holds (tenant_id) WHERE released_at IS NULL;
+
+-- Bu servisin yönettiği aylık partition'ların kataloğu. Dönüştürülen tablonun eski verisi
+-- range_start = 'epoch' olan (gerçek alt sınırı MINVALUE) legacy partition olarak kaydedilir.
+CREATE TABLE IF NOT EXISTS cdr_partitions (
+    table_name     TEXT NOT NULL,
+    partition_name TEXT NOT NULL,
+    range_start    TIMESTAMPTZ NOT NULL,
+    range_end      TIMESTAMPTZ NOT NULL,
+    state          TEXT NOT NULL DEFAULT 'ATTACHED' CHECK (state IN ('ATTACHED', 'ARCHIVED', 'DROPPED')),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    removed_at     TIMESTAMPTZ,
+    PRIMARY KEY (table_name, partition_name)
+);
+
+CREATE TABLE IF NOT EXISTS cdr_retention_runs (
+    id                  BIGSERIAL PRIMARY KEY,
+    started_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at         TIMESTAMPTZ,
+    status              TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING', 'SUCCEEDED', 'FAILED')),
+    dry_run             BOOLEAN NOT NULL DEFAULT FALSE,
+    partitions_created  INTEGER NOT NULL DEFAULT 0,
+    partitions_archived INTEGER NOT NULL DEFAULT 0,
+    partitions_dropped  INTEGER NOT NULL DEFAULT 0,
+    rows_deleted        BIGINT NOT NULL DEFAULT 0,
+    details             JSONB NOT NULL DEFAULT '[]',
+    error               TEXT
+);
+
+-- Arşivlenen partition'lar ve satırlar burada tutulur; sorgu API'si bu şemayı okumaz.
+CREATE SCHEMA IF NOT EXISTS cdr_archive;
+
+-- Saklama süresi dolduğu için silinen/arşivlenen satırların zincir kayıtları; doğrulama bunları eksik saymaz.
+ALTER TABLE cdr_hash_chain ADD COLUMN IF NOT EXISTS pruned_at TIMESTAMPTZ;
@@ -0,0 +1,37 @@
+-- KVKK / GDPR ilgili kişi silme talepleri. Kişinin numarası veya kullanıcı ID'si açık olarak saklanmaz;
+-- subject_hash yalnızca "bu kişi için talep var mı" sorusunu yanıtlamak içindir.
+CREATE TABLE IF NOT EXISTS cdr_erasure_requests (
+    id                  BIGSERIAL PRIMARY KEY,
+    tenant_id           TEXT,
+    regulation          TEXT NOT NULL CHECK (regulation IN ('KVKK', 'GDPR')),
+    external_reference  TEXT NOT NULL,
+    subject_type        TEXT NOT NULL CHECK (subject_type IN ('PHONE', 'USER_ID')),
+    subject_hash        TEXT NOT NULL,
+    pseudonym           TEXT NOT NULL,
+    requested_by        TEXT NOT NULL DEFAULT '',
+    status              TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'COMPLETED', 'FAILED')),
+    calls_pseudonymized BIGINT NOT NULL DEFAULT 0,
+    events_scrubbed     BIGINT NOT NULL DEFAULT 0,
+    recordings_unlinked BIGINT NOT NULL DEFAULT 0,
+    webhooks_scrubbed   BIGINT NOT NULL DEFAULT 0,
+    archive_scrubbed    BIGINT NOT NULL DEFAULT 0,
+    chain_entries       BIGINT NOT NULL DEFAULT 0,
+    certificate         JSONB,
+    error               TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_cdr_erasure_requests_reference ON cdr_erasure_requests (regulation, external_reference);
+CREATE INDEX IF NOT EXISTS idx_cdr_erasure_requests_subject ON cdr_erasure_requests (subject_hash);
+
+-- Anonimleştirilen çağrı; sonradan gelen kayıt bildirimi bu çağrıya yeniden bağlanmaz.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+
+-- Anonimleştirilen satırın zincir kaydı değişmez; satırın yeni hash'i ve talebi burada tutulur ve
+-- zincire eklenen ERASURE kaydıyla mühürlenir.
+ALTER TABLE cdr_hash_chain ADD COLUMN IF NOT EXISTS erasure_id BIGINT;
+ALTER TABLE cdr_hash_chain ADD COLUMN IF NOT EXISTS erased_hash TEXT;
+ALTER TABLE cdr_hash_chain DROP CONSTRAINT IF EXISTS cdr_hash_chain_record_type_check;
+ALTER TABLE cdr_hash_chain ADD CONSTRAINT cdr_hash_chain_record_type_check
+    CHECK (record_type IN ('CALL', 'EVENT', 'ERASURE'));
@@ -0,0 +1,44 @@
+-- Alan şifrelemesi için tenant veri anahtarları. Anahtarlar ana anahtarla (dosya veya KMS) sarmalanmış
+-- olarak saklanır; master_key_id hangi ana anahtarla sarmalandığını gösterir. Tenant başına tek ACTIVE anahtar
+-- vardır; RETIRING/RETIRED anahtarlar eski değerleri açmak için tutulur.
+CREATE TABLE IF NOT EXISTS cdr_data_keys (
+    id            BIGSERIAL PRIMARY KEY,
+    tenant_id     TEXT NOT NULL,
+    wrapped_key   TEXT NOT NULL,
+    master_key_id TEXT NOT NULL,
+    state         TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (state IN ('ACTIVE', 'RETIRING', 'RETIRED')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    retired_at    TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cdr_data_keys_active ON cdr_data_keys (tenant_id) WHERE state = 'ACTIVE';
+
+-- Numaralarda eşitlik araması için tenant kör indeks anahtarı. Veri anahtarı rotasyonundan bağımsızdır.
+CREATE TABLE IF NOT EXISTS cdr_blind_index_keys (
+    tenant_id     TEXT PRIMARY KEY,
+    wrapped_key   TEXT NOT NULL,
+    master_key_id TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Rotasyon sonrası (veya şifreleme açıldığında) satırları aktif anahtarla yeniden şifreleyen arka plan işleri.
+CREATE TABLE IF NOT EXISTS cdr_rekey_jobs (
+    id                 BIGSERIAL PRIMARY KEY,
+    tenant_id          TEXT,
+    status             TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'RUNNING', 'COMPLETED', 'FAILED')),
+    last_call_id       TEXT NOT NULL DEFAULT '',
+    pass_started_at    TIMESTAMPTZ,
+    calls_reencrypted  BIGINT NOT NULL DEFAULT 0,
+    events_reencrypted BIGINT NOT NULL DEFAULT 0,
+    error              TEXT,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at         TIMESTAMPTZ,
+    finished_at        TIMESTAMPTZ
+);
+
+-- Şifreli numaraların kör indeksleri; numara araması ve silme talepleri bu kolonlarla eşleşir.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS caller_number_bidx TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS callee_number_bidx TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_calls_caller_number_bidx ON calls (tenant_id, caller_number_bidx);
+CREATE INDEX IF NOT EXISTS idx_calls_callee_number_bidx ON calls (tenant_id, callee_number_bidx);
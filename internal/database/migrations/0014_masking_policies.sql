@@ -0,0 +1,15 @@
+-- Numara maskeleme politikaları. tenant_id veya role '*' ise o boyutta varsayılandır; eşleşen politika
+-- yoksa numaraların son 4 hanesi dışı maskelenir. NONE yalnızca açık numara yetkisi olan tüketiciye uygulanır.
+CREATE TABLE IF NOT EXISTS cdr_masking_policies (
+    tenant_id  TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    mode       TEXT NOT NULL CHECK (mode IN ('KEEP_LAST', 'HASH', 'REDACT', 'NONE')),
+    keep_last  INTEGER NOT NULL DEFAULT 4 CHECK (keep_last BETWEEN 0 AND 8),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, role)
+);
+
+-- Webhook'lar ve zamanlanmış teslimler tenant'ın kendi sistemlerine gider; mevcut entegrasyonlar açık numara
+-- almaya devam eder. Tenant bazında daha sıkı politika tanımlanabilir.
+INSERT INTO cdr_masking_policies (tenant_id, role, mode) VALUES ('*', 'webhook', 'NONE'), ('*', 'delivery', 'NONE')
+ON CONFLICT (tenant_id, role) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Tenant başına günlük eşzamanlı çağrı tepesi (kanal bazlı lisanslama için). Gün UTC'dir; birden fazla servis
+-- kopyası aynı günü yazarsa en yüksek değer kalır.
+CREATE TABLE IF NOT EXISTS cdr_concurrency_daily (
+    tenant_id  TEXT NOT NULL,
+    day        DATE NOT NULL,
+    peak_calls INTEGER NOT NULL,
+    peak_at    TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, day)
+);
+
+-- Açık çağrılar açılışta ve periyodik eşitlemede bu indeksle okunur.
+CREATE INDEX IF NOT EXISTS idx_calls_open ON calls (start_time) WHERE end_time IS NULL;
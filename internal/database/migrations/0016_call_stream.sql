@@ -0,0 +1,25 @@
+-- Canlı çağrı akışının kısa ömürlü olay günlüğü. Her satır, yaşam döngüsü değişikliği anındaki calls
+-- satırının anlık görüntüsüdür; numaralar calls'taki biçimiyle (şifreliyse şifreli) kopyalanır. Tüm servis
+-- kopyaları bu tablodan okuyarak bağlı istemcilere yayın yapar; id istemcinin Last-Event-ID değeridir.
+-- Satırlar 1 saat sonra silinir.
+CREATE TABLE IF NOT EXISTS call_stream_events (
+    id               BIGSERIAL PRIMARY KEY,
+    event_type       TEXT NOT NULL,
+    tenant_id        TEXT NOT NULL,
+    call_id          TEXT NOT NULL,
+    user_id          TEXT,
+    direction        TEXT,
+    caller_number    TEXT,
+    callee_number    TEXT,
+    status           TEXT,
+    disposition      TEXT,
+    start_time       TIMESTAMPTZ,
+    answer_time      TIMESTAMPTZ,
+    end_time         TIMESTAMPTZ,
+    duration_seconds INTEGER,
+    occurred_at      TIMESTAMPTZ NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_call_stream_events_tenant ON call_stream_events (tenant_id, id);
+CREATE INDEX IF NOT EXISTS idx_call_stream_events_created ON call_stream_events (created_at);
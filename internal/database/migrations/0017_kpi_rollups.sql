@@ -0,0 +1,24 @@
+-- İletişim merkezi KPI'ları için toplanabilir sayaçlar. Oranlar (ASR, ACD, NER, terk oranı) okuma sırasında
+-- hesaplanır. Kova çağrının start_time'ına göre seçilir (UTC); direction ve user_id boş olabilir.
+CREATE TABLE IF NOT EXISTS cdr_kpi_rollups (
+    tenant_id        TEXT NOT NULL,
+    granularity      TEXT NOT NULL CHECK (granularity IN ('5m', '1h', '1d')),
+    bucket_start     TIMESTAMPTZ NOT NULL,
+    direction        TEXT NOT NULL DEFAULT '',
+    user_id          TEXT NOT NULL DEFAULT '',
+    attempts         BIGINT NOT NULL DEFAULT 0,
+    answered         BIGINT NOT NULL DEFAULT 0,
+    network_ok       BIGINT NOT NULL DEFAULT 0,
+    inbound          BIGINT NOT NULL DEFAULT 0,
+    abandoned        BIGINT NOT NULL DEFAULT 0,
+    ring_calls       BIGINT NOT NULL DEFAULT 0,
+    ring_seconds     BIGINT NOT NULL DEFAULT 0,
+    answered_seconds BIGINT NOT NULL DEFAULT 0,
+    billable_seconds BIGINT NOT NULL DEFAULT 0,
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, granularity, bucket_start, direction, user_id)
+);
+
+-- Çağrının rollup'lara eklenmiş katkısı. Çağrı yeniden işlendiğinde eski katkı çıkarılıp yenisi eklenir;
+-- tekrar işlenen, geç gelen veya düzeltilen CDR'lar sayıları bozmaz. Çağrı satırıyla birlikte silinir.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS kpi_snapshot JSONB;
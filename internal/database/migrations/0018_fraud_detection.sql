@@ -0,0 +1,49 @@
+-- Dolandırıcılık (IRSF / trafik pompalama) kural eşikleri. tenant_id '*' varsayılandır; tenant satırı varsa tüm
+-- eşikler ondan okunur. Sıfır eşik o kuralı kapatır. high_risk_prefixes virgülle ayrılmış, ülke kodlu rakam
+-- önekleridir (örn: "882,883"). Mesai dışı saatler timezone'daki yerel saattir; başlangıç bitişten büyükse gece
+-- yarısını kapsar.
+CREATE TABLE IF NOT EXISTS cdr_fraud_thresholds (
+    tenant_id                TEXT PRIMARY KEY,
+    enabled                  BOOLEAN NOT NULL DEFAULT TRUE,
+    spend_per_hour           NUMERIC(18, 6) NOT NULL DEFAULT 0 CHECK (spend_per_hour >= 0),
+    high_risk_calls_per_hour INTEGER NOT NULL DEFAULT 0 CHECK (high_risk_calls_per_hour >= 0),
+    high_risk_prefixes       TEXT NOT NULL DEFAULT '',
+    burst_calls              INTEGER NOT NULL DEFAULT 0 CHECK (burst_calls >= 0),
+    burst_window_minutes     INTEGER NOT NULL DEFAULT 10 CHECK (burst_window_minutes BETWEEN 1 AND 60),
+    short_call_seconds       INTEGER NOT NULL DEFAULT 10 CHECK (short_call_seconds >= 0),
+    off_hours_start          INTEGER NOT NULL DEFAULT 22 CHECK (off_hours_start BETWEEN 0 AND 23),
+    off_hours_end            INTEGER NOT NULL DEFAULT 6 CHECK (off_hours_end BETWEEN 0 AND 23),
+    off_hours_calls_per_hour INTEGER NOT NULL DEFAULT 0 CHECK (off_hours_calls_per_hour >= 0),
+    timezone                 TEXT NOT NULL DEFAULT 'UTC',
+    updated_at               TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Varsayılan önekler IRSF'de sık kullanılan uluslararası ağ, uydu ve yüksek tarifeli aralıklardır; operasyon
+-- ekibi listeyi güncel tutmalıdır.
+INSERT INTO cdr_fraud_thresholds (tenant_id, spend_per_hour, high_risk_calls_per_hour, high_risk_prefixes, burst_calls,
+    off_hours_calls_per_hour, timezone)
+VALUES ('*', 100, 3, '870,881,882,883,979,53,222,252,247,290,675,677,678,688', 20, 50, 'Europe/Istanbul')
+ON CONFLICT (tenant_id) DO NOTHING;
+
+-- Üretilen alarmlar. dedup_key aynı kural için aynı pencerede (ve patlama kuralında aynı hedefte) ikinci alarmı
+-- engeller; olay tekrar işlense veya birden fazla servis kopyası aynı anda değerlendirse de tek alarm yayınlanır.
+CREATE TABLE IF NOT EXISTS cdr_fraud_alerts (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant_id    TEXT NOT NULL,
+    rule         TEXT NOT NULL,
+    severity     TEXT NOT NULL,
+    dedup_key    TEXT NOT NULL,
+    call_id      TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end   TIMESTAMPTZ NOT NULL,
+    evidence     JSONB NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, rule, dedup_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_tenant ON cdr_fraud_alerts (tenant_id, created_at);
+
+-- Çağrının eşleştiği yüksek riskli önek; numaralar şifreli olabildiğinden pencere sayımı bu kolonla yapılır.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS fraud_risk_prefix TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_calls_tenant_end ON calls (tenant_id, end_time);
@@ -0,0 +1,23 @@
+-- Çağrının geldiği veya gittiği karşı SIP uç noktası (host). Olay sözleşmesinde trunk alanı olmadığından anomali
+-- izlemede trunk bu değerle ayrılır; dahili çağrılarda ve eski satırlarda NULL'dır.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS trunk TEXT;
+
+-- Anomali izleyicisi tüm tenant'ların son pencere ve taban çizgisi aralığındaki çağrılarını bitiş zamanıyla tarar.
+CREATE INDEX IF NOT EXISTS idx_calls_end_time ON calls (end_time);
+
+-- Olay akışı ve sonuç dağılımı anomalileri. Tenant, trunk ve tür başına tek OPEN kayıt olabilir; durum düzelince
+-- RESOLVED olur. Her geçiş outbox üzerinden yayınlanır.
+CREATE TABLE IF NOT EXISTS cdr_anomalies (
+    id           BIGSERIAL PRIMARY KEY,
+    tenant_id    TEXT NOT NULL,
+    trunk        TEXT NOT NULL DEFAULT '',
+    kind         TEXT NOT NULL,
+    status       TEXT NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'RESOLVED')),
+    evidence     JSONB NOT NULL,
+    detected_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cdr_anomalies_open ON cdr_anomalies (tenant_id, trunk, kind) WHERE status = 'OPEN';
+CREATE INDEX IF NOT EXISTS idx_cdr_anomalies_tenant ON cdr_anomalies (tenant_id, detected_at);
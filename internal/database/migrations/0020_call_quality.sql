@@ -0,0 +1,21 @@
+-- Medya katmanının RTP/RTCP kalite özetleri. inbound: platformun karşı uçtan aldığı ses, outbound: platformun
+-- gönderdiği ses (karşı ucun RTCP raporlarından). RTT yön bağımsızdır. Kalite olayı gelmeyen çağrılarda NULL'dır.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS codec TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS mos_inbound NUMERIC(3,2);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS mos_outbound NUMERIC(3,2);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS jitter_inbound_ms NUMERIC(10,2);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS jitter_outbound_ms NUMERIC(10,2);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS packet_loss_inbound_pct NUMERIC(5,2);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS packet_loss_outbound_pct NUMERIC(5,2);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS rtt_ms NUMERIC(10,2);
+-- En kötü yönün MOS'u eşiğin altındaysa TRUE; kalite bilinmiyorsa NULL.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS low_mos BOOLEAN;
+
+CREATE INDEX IF NOT EXISTS idx_calls_low_mos ON calls (tenant_id, start_time) WHERE low_mos;
+
+-- Kalite toplamları. Ortalamalar okuma sırasında quality_calls'a bölünerek hesaplanır; çağrı başına en kötü yön alınır.
+ALTER TABLE cdr_kpi_rollups ADD COLUMN IF NOT EXISTS quality_calls BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE cdr_kpi_rollups ADD COLUMN IF NOT EXISTS low_mos_calls BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE cdr_kpi_rollups ADD COLUMN IF NOT EXISTS mos_sum NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE cdr_kpi_rollups ADD COLUMN IF NOT EXISTS jitter_ms_sum NUMERIC NOT NULL DEFAULT 0;
+ALTER TABLE cdr_kpi_rollups ADD COLUMN IF NOT EXISTS packet_loss_pct_sum NUMERIC NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- Konuşma çıktıları: transkript bağlantısı ve dili, AI özeti, duygu skoru (-1 olumsuz, +1 olumlu), tespit edilen
+-- niyetler ve insan temsilciye devir bilgisi. Özet ve transkript kişisel veri içerebilir; silme taleplerinde temizlenir.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS transcript_uri TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS conversation_language TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS ai_summary TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS sentiment_score NUMERIC(4,3) CHECK (sentiment_score BETWEEN -1 AND 1);
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS intents TEXT[];
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_handoff BOOLEAN;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS handoff_reason TEXT;
+
+-- Kalite ekiplerinin niyet ve olumsuz duygu aramaları.
+CREATE INDEX IF NOT EXISTS idx_calls_intents ON calls USING GIN (intents);
+CREATE INDEX IF NOT EXISTS idx_calls_tenant_sentiment ON calls (tenant_id, sentiment_score) WHERE sentiment_score IS NOT NULL;
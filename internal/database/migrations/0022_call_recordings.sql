@@ -0,0 +1,36 @@
+-- Çağrı başına birden fazla kayıt (segment ve kanal). calls.recording_url geriye uyumluluk için birincil kaydı
+-- (karışık kanal, en küçük segment) gösterir. Çağrıdan önce gelen kayıt PENDING olarak bekler ve çağrı
+-- oluşunca bağlanır. Saklama süresi dolan veya silme talebine giren kayıt DELETED olur; satır, depolama
+-- katmanına gönderilen silme bildiriminin kaydı olarak kalır.
+CREATE TABLE IF NOT EXISTS call_recordings (
+    id            BIGSERIAL PRIMARY KEY,
+    call_id       TEXT NOT NULL,
+    tenant_id     TEXT,
+    segment       INT NOT NULL DEFAULT 1 CHECK (segment >= 1),
+    channel       TEXT NOT NULL DEFAULT 'mixed',
+    uri           TEXT NOT NULL,
+    public_url    TEXT,
+    format        TEXT,
+    duration_ms   BIGINT CHECK (duration_ms >= 0),
+    size_bytes    BIGINT CHECK (size_bytes >= 0),
+    checksum      TEXT,
+    storage_class TEXT NOT NULL DEFAULT 'STANDARD',
+    status        TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACTIVE', 'DELETED')),
+    -- Saklama süresinin başladığı an: bağlıysa çağrının start_time'ı, beklerken kaydın geldiği an.
+    retain_from   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    received_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attached_at   TIMESTAMPTZ,
+    deleted_at    TIMESTAMPTZ,
+    delete_reason TEXT,
+    UNIQUE (call_id, segment, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_call_recordings_retention ON call_recordings (tenant_id, retain_from) WHERE status <> 'DELETED';
+CREATE INDEX IF NOT EXISTS idx_call_recordings_pending ON call_recordings (call_id) WHERE status = 'PENDING';
+
+-- Mevcut tekil kayıtlar birinci segment olarak taşınır.
+INSERT INTO call_recordings (call_id, tenant_id, uri, status, retain_from, received_at, attached_at)
+SELECT call_id, tenant_id, recording_url, 'ACTIVE', start_time, COALESCE(updated_at, NOW()), COALESCE(updated_at, NOW())
+FROM calls
+WHERE recording_url IS NOT NULL AND recording_url <> ''
+ON CONFLICT (call_id, segment, channel) DO NOTHING;
@@ -0,0 +1,31 @@
+-- IVR yolu: ziyaret edilen düğümler, tuşlamalar ve çıkış. Adımlar olay zamanına göre sıralanır; calls'taki
+-- özet kolonlar adımlardan yeniden hesaplandığı için olayların sırası önemli değildir. PIN türü girişlerin
+-- tuşları maskelenmiş yazılır.
+CREATE TABLE IF NOT EXISTS call_ivr_steps (
+    id            BIGSERIAL PRIMARY KEY,
+    call_id       TEXT NOT NULL,
+    tenant_id     TEXT NOT NULL,
+    step_type     TEXT NOT NULL CHECK (step_type IN ('NODE', 'DTMF', 'EXIT')),
+    node_id       TEXT NOT NULL DEFAULT '',
+    node_name     TEXT,
+    node_type     TEXT,
+    digits        TEXT,
+    input_type    TEXT,
+    digits_masked BOOLEAN NOT NULL DEFAULT FALSE,
+    exit_reason   TEXT,
+    -- Çıkış olayı gelmeden biten çağrıda çıkış, çağrı bitişinden 'hangup' olarak çıkarılır.
+    inferred      BOOLEAN NOT NULL DEFAULT FALSE,
+    occurred_at   TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (call_id, step_type, node_id, occurred_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_call_ivr_steps_tenant_node ON call_ivr_steps (tenant_id, node_id, occurred_at)
+    WHERE step_type = 'NODE';
+
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS ivr_node_count INT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS ivr_last_node TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS ivr_exit_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_calls_ivr_exit ON calls (tenant_id, ivr_last_node, ivr_exit_reason)
+    WHERE ivr_exit_reason IS NOT NULL;
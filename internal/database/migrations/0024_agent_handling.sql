@@ -0,0 +1,37 @@
+-- İnsan temsilciye devredilen çağrıların kuyruk ve temsilci bilgileri. Olay zamanları ayrı ayrı tutulur; bekleme,
+-- konuşma ve toplam işlem süreleri bu zamanlardan hesaplandığı için olayların sırası önemli değildir.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS queue_name TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS queue_entered_at TIMESTAMPTZ;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_user_id TEXT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_connected_at TIMESTAMPTZ;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_disconnected_at TIMESTAMPTZ;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS after_call_work_ms BIGINT CHECK (after_call_work_ms >= 0);
+-- Kuyruğa giriş ile temsilci bağlantısı arası; bağlanmadan biten çağrıda kuyruğa giriş ile bitiş arası.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS queue_wait_ms BIGINT;
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_talk_ms BIGINT;
+-- Konuşma (bekletme dahil) + çağrı sonrası iş.
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_handle_ms BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_calls_agent ON calls (tenant_id, agent_user_id, start_time) WHERE agent_user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_calls_queue ON calls (tenant_id, queue_name, start_time) WHERE queue_name IS NOT NULL;
+
+-- Temsilci bazlı iş gücü rollup'ları. Kova, temsilcinin çağrıya bağlandığı andır; çağrı temsilciden ayrılınca
+-- katkı eklenir.
+CREATE TABLE IF NOT EXISTS cdr_agent_rollups (
+    tenant_id          TEXT NOT NULL,
+    granularity        TEXT NOT NULL CHECK (granularity IN ('5m', '1h', '1d')),
+    bucket_start       TIMESTAMPTZ NOT NULL,
+    agent_user_id      TEXT NOT NULL,
+    queue_name         TEXT NOT NULL DEFAULT '',
+    handled_calls      BIGINT NOT NULL DEFAULT 0,
+    queued_calls       BIGINT NOT NULL DEFAULT 0,
+    queue_wait_ms      BIGINT NOT NULL DEFAULT 0,
+    talk_ms            BIGINT NOT NULL DEFAULT 0,
+    after_call_work_ms BIGINT NOT NULL DEFAULT 0,
+    handle_ms          BIGINT NOT NULL DEFAULT 0,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, granularity, bucket_start, agent_user_id, queue_name)
+);
+
+-- Çağrının temsilci rollup'larına eklenmiş katkısı (bkz. kpi_snapshot).
+ALTER TABLE calls ADD COLUMN IF NOT EXISTS agent_snapshot JSONB;
@@ -0,0 +1,3 @@
+-- Webhook denemelerinde alıcının yanıt gövdesi artık saklanmıyor; yalnızca durum kodu ve durum satırı tutulur.
+-- İç ağ yanıtlarının API'den okunabilmesini önlemek için eski gövdeler de kaldırılır.
+ALTER TABLE webhook_delivery_attempts DROP COLUMN IF EXISTS response_body;
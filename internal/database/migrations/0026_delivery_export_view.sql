@@ -0,0 +1,2 @@
+-- Teslim işleri bacak bazlı CDR'lar yerine etkileşim başına konsolide yolculuk da gönderebilir.
+ALTER TABLE cdr_delivery_jobs ADD COLUMN IF NOT EXISTS export_view TEXT NOT NULL DEFAULT 'legs';
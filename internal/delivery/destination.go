@@ -0,0 +1,321 @@
+// sentiric-cdr-service/internal/delivery/destination.go
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/netguard"
+)
+
+// Destination: Teslim hedefinin bağlantı bilgileri. Tipine göre ilgili alanlar kullanılır.
+// Gizli alanlar "${CDR_DELIVERY_SECRET_<TENANT>_<AD>}" biçiminde verilebilir; değer bağlantı anında ortamdan okunur.
+// Tenant yalnızca kendi önekindeki değişkenlere başvurabilir; servisin diğer ortam değişkenleri okunamaz.
+type Destination struct {
+	// S3 uyumlu depolama (AWS S3, MinIO vb.)
+	Endpoint        string `json:"endpoint,omitempty"`
+	Region          string `json:"region,omitempty"`
+	Bucket          string `json:"bucket,omitempty"`
+	Prefix          string `json:"prefix,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	DisableTLS      bool   `json:"disable_tls,omitempty"`
+
+	// SFTP
+	Host       string `json:"host,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	PrivateKey string `json:"private_key,omitempty"`
+	// HostKey: Sunucunun authorized_keys biçimindeki açık anahtarı ("ssh-ed25519 AAAA...").
+	HostKey             string `json:"host_key,omitempty"`
+	InsecureSkipHostKey bool   `json:"insecure_skip_host_key,omitempty"`
+	Directory           string `json:"directory,omitempty"`
+}
+
+const redacted = "***"
+
+// RedactDestination: API yanıtlarında gizli alanları maskeler. Ortam değişkeni referansları olduğu gibi gösterilir.
+func RedactDestination(raw json.RawMessage) json.RawMessage {
+	var d Destination
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return json.RawMessage("{}")
+	}
+	for _, secret := range []*string{&d.SecretAccessKey, &d.Password, &d.PrivateKey} {
+		if *secret != "" && !isEnvRef(*secret) {
+			*secret = redacted
+		}
+	}
+	out, _ := json.Marshal(d)
+	return out
+}
+
+func isEnvRef(s string) bool {
+	return len(s) > 3 && s[0] == '$' && s[1] == '{' && s[len(s)-1] == '}'
+}
+
+// SecretEnvPrefix: Tenant'ın hedef gizli alanlarında başvurabileceği ortam değişkeni öneki. Tenant kimliği büyük
+// harfe çevrilir, harf ve rakam dışındaki karakterler '_' olur.
+func SecretEnvPrefix(tenantID string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, tenantID)
+	return "CDR_DELIVERY_SECRET_" + name + "_"
+}
+
+// checkSecretRef: Gizli alandaki '$' içeren değerin tenant'ın kendi önekine tam bir referans olduğunu doğrular.
+func checkSecretRef(tenantID, field, v string) error {
+	if !strings.Contains(v, "$") {
+		return nil
+	}
+	if !isEnvRef(v) {
+		return fmt.Errorf("%s yalnızca ${%s...} biçiminde ortam değişkenine başvurabilir", field, SecretEnvPrefix(tenantID))
+	}
+	name := v[2 : len(v)-1]
+	prefix := SecretEnvPrefix(tenantID)
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return fmt.Errorf("%s için ortam değişkeni %s ile başlamalı: %q", field, prefix, name)
+	}
+	return nil
+}
+
+// resolveSecret: Referansı ortamdan okur, düz değeri olduğu gibi döner. Referans doğrulaması parseDestination'da
+// yapılmış olmalıdır.
+func resolveSecret(v string) string {
+	if isEnvRef(v) {
+		return os.Getenv(v[2 : len(v)-1])
+	}
+	return v
+}
+
+func parseDestination(tenantID, destType string, raw json.RawMessage) (Destination, error) {
+	var d Destination
+	if len(raw) == 0 {
+		return d, errors.New("destination zorunludur")
+	}
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return d, fmt.Errorf("destination geçersiz JSON: %w", err)
+	}
+	for _, f := range []struct{ name, value string }{
+		{"access_key_id", d.AccessKeyID}, {"secret_access_key", d.SecretAccessKey},
+		{"password", d.Password}, {"private_key", d.PrivateKey},
+	} {
+		if err := checkSecretRef(tenantID, f.name, f.value); err != nil {
+			return d, err
+		}
+	}
+	switch destType {
+	case DestinationS3:
+		if d.Endpoint == "" || d.Bucket == "" {
+			return d, errors.New("S3 hedefi için endpoint ve bucket zorunludur")
+		}
+		if netguard.PrivateLiteral(endpointHost(d.Endpoint)) {
+			return d, fmt.Errorf("S3 endpoint iç ağ adresine yönlendirilemez: %q", d.Endpoint)
+		}
+	case DestinationSFTP:
+		if d.Host == "" || d.Username == "" {
+			return d, errors.New("SFTP hedefi için host ve username zorunludur")
+		}
+		if netguard.PrivateLiteral(d.Host) {
+			return d, fmt.Errorf("SFTP host iç ağ adresine yönlendirilemez: %q", d.Host)
+		}
+		if d.Password == "" && d.PrivateKey == "" {
+			return d, errors.New("SFTP hedefi için password veya private_key gerekir")
+		}
+		if d.HostKey == "" && !d.InsecureSkipHostKey {
+			return d, errors.New("SFTP hedefi için host_key gerekir (yalnızca test için insecure_skip_host_key)")
+		}
+	default:
+		return d, fmt.Errorf("destination_type S3 veya SFTP olmalı: %q", destType)
+	}
+	return d, nil
+}
+
+// endpointHost: S3 endpoint'inin ("host" veya "host:port") host kısmı.
+func endpointHost(endpoint string) string {
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return strings.Trim(endpoint, "[]")
+}
+
+// uploader: Hazırlanmış dosyayı hedefe yükler.
+type uploader interface {
+	upload(ctx context.Context, name string, r io.Reader, size int64, sha256Hex string) error
+}
+
+func newUploader(tenantID, destType string, raw json.RawMessage) (uploader, error) {
+	d, err := parseDestination(tenantID, destType, raw)
+	if err != nil {
+		return nil, err
+	}
+	if destType == DestinationS3 {
+		return newS3Uploader(d)
+	}
+	return &sftpUploader{dest: d, control: netguard.DialControl}, nil
+}
+
+type s3Uploader struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// newS3Uploader: İstemci yalnızca genel adreslere bağlanır; ortamdaki proxy ayarları kullanılmaz (proxy üzerinden
+// gidilirse hedef adres denetlenemez).
+func newS3Uploader(d Destination) (*s3Uploader, error) {
+	transport, err := minio.DefaultTransport(!d.DisableTLS)
+	if err != nil {
+		return nil, fmt.Errorf("S3 istemcisi oluşturulamadı: %w", err)
+	}
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second,
+		Control: netguard.DialControl}).DialContext
+	client, err := minio.New(d.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(resolveSecret(d.AccessKeyID), resolveSecret(d.SecretAccessKey), ""),
+		Secure:    !d.DisableTLS,
+		Region:    d.Region,
+		Transport: transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("S3 istemcisi oluşturulamadı: %w", err)
+	}
+	return &s3Uploader{client: client, bucket: d.Bucket, prefix: d.Prefix}, nil
+}
+
+func (u *s3Uploader) upload(ctx context.Context, name string, r io.Reader, size int64, sha256Hex string) error {
+	_, err := u.client.PutObject(ctx, u.bucket, path.Join(u.prefix, name), r, size, minio.PutObjectOptions{
+		UserMetadata: map[string]string{"sha256": sha256Hex},
+	})
+	return err
+}
+
+type sftpUploader struct {
+	dest Destination
+	// control: Bağlantı kurulmadan önce hedef adresi denetler (netguard.DialControl).
+	control func(network, address string, c syscall.RawConn) error
+}
+
+const sftpDialTimeout = 30 * time.Second
+
+// upload: Dosya önce ".part" uzantısıyla yazılır, tamamlanınca yeniden adlandırılır;
+// böylece karşı taraf yarım dosyayı hiçbir zaman nihai adıyla görmez.
+func (u *sftpUploader) upload(ctx context.Context, name string, r io.Reader, size int64, sha256Hex string) error {
+	cfg, err := u.clientConfig()
+	if err != nil {
+		return err
+	}
+	port := u.dest.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(u.dest.Host, strconv.Itoa(port))
+
+	dialer := net.Dialer{Timeout: sftpDialTimeout, Control: u.control}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SFTP sunucusuna bağlanılamadı: %w", err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SSH el sıkışması başarısız: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	defer sshClient.Close()
+
+	// Context iptalinde bağlantı kapatılarak yarım kalan transfer kesilir.
+	stop := context.AfterFunc(ctx, func() { sshClient.Close() })
+	defer stop()
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		return fmt.Errorf("SFTP oturumu açılamadı: %w", err)
+	}
+	// SSH bağlantısı önce kapatılır; aksi halde sftp istemcisi sunucunun oturumu kapatmasını bekleyerek asılı kalabilir.
+	defer func() {
+		sshClient.Close()
+		client.Close()
+	}()
+
+	dir := u.dest.Directory
+	if dir == "" {
+		dir = "."
+	}
+	if err := client.MkdirAll(dir); err != nil {
+		return fmt.Errorf("SFTP dizini oluşturulamadı: %w", err)
+	}
+	final := path.Join(dir, name)
+	tmp := final + ".part"
+
+	f, err := client.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("SFTP dosyası oluşturulamadı: %w", err)
+	}
+	if _, err := f.ReadFrom(r); err != nil {
+		f.Close()
+		return fmt.Errorf("SFTP yazma hatası: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := client.PosixRename(tmp, final); err != nil {
+		// posix-rename uzantısını desteklemeyen sunucular için standart rename (hedef yoksa çalışır).
+		_ = client.Remove(final)
+		if err := client.Rename(tmp, final); err != nil {
+			return fmt.Errorf("SFTP dosyası yeniden adlandırılamadı: %w", err)
+		}
+	}
+	return nil
+}
+
+func (u *sftpUploader) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if key := resolveSecret(u.dest.PrivateKey); key != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("SFTP private_key okunamadı: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if pw := resolveSecret(u.dest.Password); pw != "" {
+		auth = append(auth, ssh.Password(pw))
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !u.dest.InsecureSkipHostKey {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(u.dest.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("SFTP host_key okunamadı: %w", err)
+		}
+		hostKeyCallback = ssh.FixedHostKey(pub)
+	}
+
+	return &ssh.ClientConfig{
+		User:            u.dest.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sftpDialTimeout,
+	}, nil
+}
@@ -0,0 +1,239 @@
+// sentiric-cdr-service/internal/delivery/destination_test.go
+package delivery
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/netguard"
+)
+
+func TestSecretEnvPrefix(t *testing.T) {
+	cases := []struct{ tenant, want string }{
+		{"acme", "CDR_DELIVERY_SECRET_ACME_"},
+		{"Acme-Corp.1", "CDR_DELIVERY_SECRET_ACME_CORP_1_"},
+		{"ş", "CDR_DELIVERY_SECRET___"},
+	}
+	for _, c := range cases {
+		if got := SecretEnvPrefix(c.tenant); got != c.want {
+			t.Errorf("SecretEnvPrefix(%q) = %q, beklenen %q", c.tenant, got, c.want)
+		}
+	}
+}
+
+func TestParseDestinationSecretRefs(t *testing.T) {
+	cases := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"düz değer", "s3cr3t", false},
+		{"kendi öneki", "${CDR_DELIVERY_SECRET_ACME_S3_KEY}", false},
+		{"yalnızca önek", "${CDR_DELIVERY_SECRET_ACME_}", true},
+		{"başka tenant", "${CDR_DELIVERY_SECRET_OTHER_S3_KEY}", true},
+		{"servis değişkeni", "${POSTGRES_URL}", true},
+		{"parantezsiz", "$POSTGRES_URL", true},
+		{"gömülü referans", "x${CDR_DELIVERY_SECRET_ACME_KEY}", true},
+		{"dolar içeren düz değer", "pa$$word", true},
+	}
+	for _, c := range cases {
+		raw, _ := json.Marshal(Destination{Endpoint: "s3.local", Bucket: "cdr", AccessKeyID: "id", SecretAccessKey: c.secret})
+		_, err := parseDestination("acme", DestinationS3, raw)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestParseDestinationInternalAddr(t *testing.T) {
+	cases := []struct {
+		name     string
+		destType string
+		dest     Destination
+		wantErr  bool
+	}{
+		{"S3 alan adı", DestinationS3, Destination{Endpoint: "s3.example.com"}, false},
+		{"S3 genel IP ve port", DestinationS3, Destination{Endpoint: "93.184.216.34:9000"}, false},
+		{"S3 loopback", DestinationS3, Destination{Endpoint: "127.0.0.1:9000"}, true},
+		{"S3 metadata", DestinationS3, Destination{Endpoint: "169.254.169.254"}, true},
+		{"S3 özel IPv6", DestinationS3, Destination{Endpoint: "[fd00::1]:9000"}, true},
+		{"SFTP alan adı", DestinationSFTP, Destination{Host: "sftp.example.com"}, false},
+		{"SFTP özel ağ", DestinationSFTP, Destination{Host: "10.0.0.5"}, true},
+		{"SFTP özel ağ 192.168", DestinationSFTP, Destination{Host: "192.168.1.10"}, true},
+	}
+	for _, c := range cases {
+		d := c.dest
+		d.Bucket, d.AccessKeyID, d.SecretAccessKey = "cdr", "id", "key"
+		d.Username, d.Password, d.InsecureSkipHostKey = "cdr", "pw", true
+		raw, _ := json.Marshal(d)
+		_, err := parseDestination("acme", c.destType, raw)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+// cmdRecorder: SFTP sunucusuna gelen dosya komutlarını (rename vb.) kaydeder.
+type cmdRecorder struct {
+	sftp.FileCmder
+	mu   sync.Mutex
+	cmds []string
+}
+
+func (c *cmdRecorder) record(r *sftp.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cmds = append(c.cmds, r.Method+" "+r.Filepath+" "+r.Target)
+}
+
+func (c *cmdRecorder) Filecmd(r *sftp.Request) error {
+	c.record(r)
+	return c.FileCmder.Filecmd(r)
+}
+
+func (c *cmdRecorder) PosixRename(r *sftp.Request) error {
+	c.record(r)
+	return c.FileCmder.(sftp.PosixRenameFileCmder).PosixRename(r)
+}
+
+// startSFTPServer: Bellek içi dosya sistemiyle parola doğrulamalı bir SFTP sunucusu başlatır.
+func startSFTPServer(t *testing.T, password string) (Destination, sftp.Handlers, *cmdRecorder) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pw []byte) (*ssh.Permissions, error) {
+			if string(pw) != password {
+				return nil, errors.New("yanlış parola")
+			}
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(signer)
+
+	handlers := sftp.InMemHandler()
+	rec := &cmdRecorder{FileCmder: handlers.FileCmd}
+	handlers.FileCmd = rec
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, cfg, handlers)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return Destination{
+		Host:      "127.0.0.1",
+		Port:      addr.Port,
+		Username:  "cdr",
+		Password:  password,
+		HostKey:   string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+		Directory: "/cdr",
+	}, handlers, rec
+}
+
+func serveSFTP(conn net.Conn, cfg *ssh.ServerConfig, handlers sftp.Handlers) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(ssh.UnknownChannelType, "yalnızca session")
+			continue
+		}
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+			}
+		}()
+		go func() {
+			defer ch.Close()
+			_ = sftp.NewRequestServer(ch, handlers).Serve()
+		}()
+	}
+}
+
+func TestSFTPUploadRenamesPart(t *testing.T) {
+	dest, handlers, rec := startSFTPServer(t, "s3cr3t")
+	up := &sftpUploader{dest: dest}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, body := range []string{"ilk\n", "ikinci deneme\n"} {
+		if err := up.upload(ctx, "cdr.csv", strings.NewReader(body), int64(len(body)), ""); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		files, err := handlers.FileList.Filelist(sftp.NewRequest("Stat", "/cdr/cdr.csv"))
+		if err != nil {
+			t.Fatalf("nihai dosya yok: %v", err)
+		}
+		info := make([]os.FileInfo, 1)
+		if _, err := files.ListAt(info, 0); err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		if info[0].Size() != int64(len(body)) {
+			t.Errorf("nihai dosya boyutu = %d, beklenen %d", info[0].Size(), len(body))
+		}
+		if _, err := handlers.FileList.Filelist(sftp.NewRequest("Stat", "/cdr/cdr.csv.part")); err == nil {
+			t.Error(".part dosyası yeniden adlandırmadan sonra kalmamalı")
+		}
+	}
+
+	// İkinci yükleme var olan dosyanın üzerine yazar; her iki seferde de .part nihai ada taşınmalı.
+	var renames int
+	for _, cmd := range rec.cmds {
+		if strings.HasSuffix(cmd, "Rename /cdr/cdr.csv.part /cdr/cdr.csv") {
+			renames++
+		}
+	}
+	if renames != 2 {
+		t.Errorf("yeniden adlandırma komutları = %q, iki kez .part → nihai ad bekleniyor", rec.cmds)
+	}
+
+	guarded := &sftpUploader{dest: dest, control: netguard.DialControl}
+	if err := guarded.upload(ctx, "cdr3.csv", strings.NewReader("x"), 1, ""); !errors.Is(err, netguard.ErrBlockedAddr) {
+		t.Errorf("iç ağdaki SFTP sunucusuna yükleme engellenmeli, hata = %v", err)
+	}
+
+	up.dest.Password = "yanlış"
+	if err := up.upload(ctx, "cdr2.csv", strings.NewReader("x"), 1, ""); err == nil {
+		t.Error("yanlış parola ile yükleme başarısız olmalı")
+	}
+}
@@ -0,0 +1,187 @@
+// AÇIKLAMA: Bu paket, tenant bazlı zamanlanmış CDR teslim işlerini çalıştırır: zamanı gelen işler için
+// teslim penceresi açar, CDR dosyasını üretir ve S3 uyumlu depolamaya veya SFTP sunucusuna yükler.
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/export"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Pencere periyotları.
+const (
+	WindowHourly = "HOURLY"
+	WindowDaily  = "DAILY"
+)
+
+// Sıkıştırma seçenekleri.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// Hedef tipleri.
+const (
+	DestinationS3   = "S3"
+	DestinationSFTP = "SFTP"
+)
+
+const DefaultFilenameTemplate = "cdr-{tenant}-{date}.{ext}"
+
+// cronParser: Standart 5 alanlı cron ifadeleri ve "@daily" gibi kısaltmalar.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ApplyDefaults: Boş bırakılmış alanları varsayılan değerlerle doldurur.
+func ApplyDefaults(j *repository.DeliveryJob) {
+	if j.WindowPeriod == "" {
+		j.WindowPeriod = WindowDaily
+	}
+	if j.Timezone == "" {
+		j.Timezone = "UTC"
+	}
+	if j.Format == "" {
+		j.Format = export.FormatCSV
+	}
+	if j.View == "" {
+		j.View = export.ViewLegs
+	}
+	if j.Compression == "" {
+		j.Compression = CompressionNone
+	}
+	if j.FilenameTemplate == "" {
+		j.FilenameTemplate = DefaultFilenameTemplate
+	}
+	if j.MaxAttempts <= 0 {
+		j.MaxAttempts = 5
+	}
+}
+
+// Validate: İşin tüm alanlarını kaydedilmeden önce doğrular.
+func Validate(j repository.DeliveryJob) error {
+	if j.TenantID == "" || j.Name == "" {
+		return errors.New("tenant_id ve name zorunludur")
+	}
+	if _, err := cronParser.Parse(j.Schedule); err != nil {
+		return fmt.Errorf("geçersiz schedule %q: %w", j.Schedule, err)
+	}
+	if j.WindowPeriod != WindowHourly && j.WindowPeriod != WindowDaily {
+		return fmt.Errorf("window_period HOURLY veya DAILY olmalı: %q", j.WindowPeriod)
+	}
+	if _, err := time.LoadLocation(j.Timezone); err != nil {
+		return fmt.Errorf("geçersiz timezone: %q", j.Timezone)
+	}
+	if j.Compression != CompressionNone && j.Compression != CompressionGzip {
+		return fmt.Errorf("compression none veya gzip olmalı: %q", j.Compression)
+	}
+	if strings.ContainsAny(j.FilenameTemplate, `/\`) {
+		return errors.New("filename_template dizin ayırıcı içeremez; dizin hedefte prefix/directory ile verilir")
+	}
+	if !pathSafe(j.TenantID) || !pathSafe(j.Name) {
+		return errors.New("tenant_id ve name dosya adına yazıldığından dizin ayırıcı veya '..' içeremez")
+	}
+	if _, err := exportOptions(j); err != nil {
+		return err
+	}
+	_, err := parseDestination(j.TenantID, j.DestinationType, j.Destination)
+	return err
+}
+
+// FirstRun: İşin ilk çalışma anı.
+func FirstRun(j repository.DeliveryJob, now time.Time) (time.Time, error) {
+	sched, loc, err := schedule(j)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return sched.Next(now.In(loc)), nil
+}
+
+// Plan: Zamanı gelen iş için teslim penceresini hesaplar. Pencere, planlanan çalışma anından önceki son
+// tam periyottur (işin saat diliminde); böylece kaçırılan çalışmalar sırayla ve aynı pencerelerle telafi edilir.
+func Plan(j repository.DeliveryJob) (repository.DeliveryPlan, error) {
+	sched, loc, err := schedule(j)
+	if err != nil {
+		return repository.DeliveryPlan{}, err
+	}
+	if j.NextRunAt == nil {
+		return repository.DeliveryPlan{}, fmt.Errorf("iş %d için next_run_at boş", j.ID)
+	}
+	fire := j.NextRunAt.In(loc)
+
+	var start, end time.Time
+	switch j.WindowPeriod {
+	case WindowHourly:
+		end = time.Date(fire.Year(), fire.Month(), fire.Day(), fire.Hour(), 0, 0, 0, loc)
+		start = end.Add(-time.Hour)
+	default:
+		end = time.Date(fire.Year(), fire.Month(), fire.Day(), 0, 0, 0, 0, loc)
+		start = end.AddDate(0, 0, -1)
+	}
+	return repository.DeliveryPlan{WindowStart: start, WindowEnd: end, NextRunAt: sched.Next(fire)}, nil
+}
+
+func schedule(j repository.DeliveryJob) (cron.Schedule, *time.Location, error) {
+	sched, err := cronParser.Parse(j.Schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("geçersiz schedule %q: %w", j.Schedule, err)
+	}
+	loc, err := time.LoadLocation(j.Timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("geçersiz timezone: %q", j.Timezone)
+	}
+	return sched, loc, nil
+}
+
+func exportOptions(j repository.DeliveryJob) (export.Options, error) {
+	loc, err := time.LoadLocation(j.Timezone)
+	if err != nil {
+		return export.Options{}, fmt.Errorf("geçersiz timezone: %q", j.Timezone)
+	}
+	opts := export.Options{
+		Format:        j.Format,
+		View:          j.View,
+		Columns:       export.ParseColumns(j.Columns),
+		Location:      loc,
+		MaskNumbers:   j.MaskNumbers,
+		IncludeEvents: j.IncludeEvents,
+	}
+	return opts, opts.Validate()
+}
+
+// ObjectName: Dosya adı şablonunu pencereye göre doldurur.
+// Yer tutucular: {tenant}, {job}, {date} (YYYYMMDD), {datetime} (YYYYMMDDTHHMM), {format}, {ext}.
+func ObjectName(j repository.DeliveryJob, windowStart time.Time) string {
+	loc, err := time.LoadLocation(j.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := windowStart.In(loc)
+	ext := j.Format
+	if j.Compression == CompressionGzip {
+		ext += ".gz"
+	}
+	return strings.NewReplacer(
+		"{tenant}", pathSegment(j.TenantID),
+		"{job}", pathSegment(j.Name),
+		"{date}", local.Format("20060102"),
+		"{datetime}", local.Format("20060102T1504"),
+		"{format}", j.Format,
+		"{ext}", ext,
+	).Replace(j.FilenameTemplate)
+}
+
+// pathSafe: Değerin dosya adına yazıldığında hedef dizinin dışına çıkamayacağını söyler.
+func pathSafe(v string) bool {
+	return !strings.ContainsAny(v, `/\`) && !strings.Contains(v, "..")
+}
+
+// pathSegment: Doğrulamadan önce kaydedilmiş işler için şablona girecek değeri dizin ayırıcı ve '..' içermeyecek
+// biçime getirir.
+func pathSegment(v string) string {
+	return strings.ReplaceAll(strings.NewReplacer("/", "_", `\`, "_").Replace(v), "..", "__")
+}
@@ -0,0 +1,70 @@
+// sentiric-cdr-service/internal/delivery/job_test.go
+package delivery
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func testJob(tenantID, name string) repository.DeliveryJob {
+	dest, _ := json.Marshal(Destination{Endpoint: "s3.example.com", Bucket: "cdr", AccessKeyID: "id", SecretAccessKey: "key"})
+	j := repository.DeliveryJob{TenantID: tenantID, Name: name, Schedule: "@daily", DestinationType: DestinationS3,
+		Destination: dest}
+	ApplyDefaults(&j)
+	return j
+}
+
+func TestValidatePathNames(t *testing.T) {
+	cases := []struct {
+		name    string
+		tenant  string
+		job     string
+		wantErr bool
+	}{
+		{"düz adlar", "acme", "gunluk-cdr", false},
+		{"noktalı ad", "acme", "cdr.v2", false},
+		{"iş adında üst dizin", "acme", "../../etc/x", true},
+		{"iş adında dizin", "acme", "a/b", true},
+		{"iş adında ters bölü", "acme", `a\b`, true},
+		{"yalnızca üst dizin", "acme", "..", true},
+		{"tenant'ta dizin", "acme/other", "gunluk", true},
+		{"tenant'ta üst dizin", "..", "gunluk", true},
+	}
+	for _, c := range cases {
+		err := Validate(testJob(c.tenant, c.job))
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestObjectName(t *testing.T) {
+	window := time.Date(2025, 3, 9, 21, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		tenant   string
+		job      string
+		template string
+		timezone string
+		gzip     bool
+		want     string
+	}{
+		{"varsayılan şablon", "acme", "gunluk", DefaultFilenameTemplate, "UTC", false, "cdr-acme-20250309.csv"},
+		{"yerel tarih ve gzip", "acme", "gunluk", "{job}-{datetime}.{ext}", "Europe/Istanbul", true, "gunluk-20250310T0000.csv.gz"},
+		{"eski kayıtta dizin içeren iş adı", "acme", "../../etc/x", "{job}.{format}", "UTC", false, "______etc_x.csv"},
+		{"eski kayıtta dizin içeren tenant", `a\b`, "gunluk", "{tenant}-{job}.{ext}", "UTC", false, "a_b-gunluk.csv"},
+	}
+	for _, c := range cases {
+		j := testJob(c.tenant, c.job)
+		j.FilenameTemplate, j.Timezone = c.template, c.timezone
+		if c.gzip {
+			j.Compression = CompressionGzip
+		}
+		if got := ObjectName(j, window); got != c.want {
+			t.Errorf("%s: ObjectName = %q, beklenen %q", c.name, got, c.want)
+		}
+	}
+}
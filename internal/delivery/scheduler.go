@@ -0,0 +1,215 @@
+// sentiric-cdr-service/internal/delivery/scheduler.go
+package delivery
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/export"
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+const (
+	pollInterval = 30 * time.Second
+	// claimLease: Bir teslimin tek denemesi için ayrılan süre; süre dolarsa başka kopya teslimi devralabilir.
+	claimLease   = 15 * time.Minute
+	maxRetryWait = time.Hour
+)
+
+type Scheduler struct {
+	repo     *repository.DeliveryRepository
+	exporter *export.Exporter
+	masks    *masking.Engine
+	log      zerolog.Logger
+}
+
+func NewScheduler(db *sql.DB, keys *repository.Keyring, masks *masking.Engine, log zerolog.Logger) *Scheduler {
+	return &Scheduler{
+		repo:     repository.NewDeliveryRepository(db),
+		exporter: export.New(repository.NewCallRepository(db, keys, log)),
+		masks:    masks,
+		log:      log,
+	}
+}
+
+// Run: Context iptal edilene kadar zamanı gelen işleri planlar ve bekleyen teslimleri işler.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.log.Info().Msg("📦 CDR teslim zamanlayıcısı aktif")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	n, err := s.repo.ScheduleDueJobs(ctx, time.Now().UTC(), Plan)
+	if err != nil && ctx.Err() == nil {
+		s.log.Error().Err(err).Msg("Teslim işleri planlanamadı.")
+	}
+	if n > 0 {
+		s.log.Info().Int("deliveries", n).Msg("Yeni CDR teslimleri kuyruğa alındı.")
+	}
+
+	for ctx.Err() == nil {
+		d, err := s.repo.ClaimDelivery(ctx, claimLease)
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error().Err(err).Msg("Bekleyen teslim alınamadı.")
+			}
+			return
+		}
+		s.process(ctx, d)
+	}
+}
+
+func (s *Scheduler) process(ctx context.Context, d repository.Delivery) {
+	log := s.log.With().Int64("delivery_id", d.ID).Int64("job_id", d.JobID).Str("tenant_id", d.TenantID).
+		Time("window_start", d.WindowStart).Int("attempt", d.Attempts).Logger()
+
+	job, jobErr := s.repo.GetJob(ctx, d.JobID)
+	err := jobErr
+	if err == nil {
+		var res Result
+		res, err = s.Deliver(ctx, job, d.WindowStart, d.WindowEnd)
+		if err == nil {
+			if err := s.repo.MarkDelivered(ctx, d.ID, res.ObjectName, res.Rows, res.Size, res.SHA256); err != nil {
+				log.Error().Err(err).Msg("Teslim yüklendi ancak durumu kaydedilemedi; lease sonunda tekrar denenecek.")
+				return
+			}
+			log.Info().Str("object", res.ObjectName).Int("rows", res.Rows).Int64("bytes", res.Size).
+				Str("sha256", res.SHA256).Msg("📦 CDR dosyası teslim edildi.")
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return // Kapanışta yarıda kalan deneme lease sonunda tekrar alınır.
+	}
+
+	if jobErr != nil {
+		err = fmt.Errorf("teslim işi okunamadı: %w", jobErr)
+	}
+	retryAt := nextAttempt(d.Attempts, job.MaxAttempts, jobErr == nil, time.Now())
+	if markErr := s.repo.MarkAttemptFailed(ctx, d.ID, err.Error(), retryAt); markErr != nil {
+		log.Error().Err(markErr).Msg("Başarısız teslim denemesi kaydedilemedi.")
+	}
+	if retryAt == nil {
+		log.Error().Err(err).Msg("CDR teslimi kalıcı olarak başarısız oldu.")
+		return
+	}
+	log.Warn().Err(err).Time("retry_at", *retryAt).Msg("CDR teslimi başarısız, tekrar denenecek.")
+}
+
+// nextAttempt: Başarısız denemeden sonraki deneme anını döner; deneme hakkı bittiyse nil. İş okunamadıysa
+// (örn: geçici DB hatası) deneme sınırı bilinmediği için teslim kalıcı olarak başarısız sayılmaz.
+func nextAttempt(attempts, maxAttempts int, jobLoaded bool, now time.Time) *time.Time {
+	if jobLoaded && attempts >= maxAttempts {
+		return nil
+	}
+	t := now.Add(retryBackoff(attempts))
+	return &t
+}
+
+// retryBackoff: 1, 2, 4, ... dakika; en fazla bir saat.
+func retryBackoff(attempt int) time.Duration {
+	wait := time.Minute << min(attempt-1, 10)
+	return min(wait, maxRetryWait)
+}
+
+// Result: Yüklenen dosyanın adı, satır sayısı, boyutu ve SHA-256 sağlama toplamı.
+type Result struct {
+	ObjectName string
+	Rows       int
+	Size       int64
+	SHA256     string
+}
+
+// Deliver: Pencerenin CDR dosyasını geçici dosyaya üretir, sağlama toplamını hesaplar ve hedefe yükler.
+// Dosya önce diske yazılır; hem boyut/sağlama toplamı yükleme öncesi bilinir hem de DB sorgusu yükleme süresince açık kalmaz.
+func (s *Scheduler) Deliver(ctx context.Context, job repository.DeliveryJob, start, end time.Time) (Result, error) {
+	opts, err := exportOptions(job)
+	if err != nil {
+		return Result{}, err
+	}
+	// Teslim tenant'ın kendi deposuna gider; politika okunamazsa dosya maskeli üretilmek yerine teslim yeniden denenir.
+	if opts.Numbers, err = s.masks.For(ctx, job.TenantID, masking.Consumer{Role: masking.RoleDelivery, Unmasked: true}); err != nil {
+		return Result{}, fmt.Errorf("maskeleme politikası okunamadı: %w", err)
+	}
+	up, err := newUploader(job.TenantID, job.DestinationType, job.Destination)
+	if err != nil {
+		return Result{}, err
+	}
+
+	f := repository.CallFilter{TenantID: job.TenantID, From: start, To: end}
+	return deliverFile(ctx, up, ObjectName(job, start), job.Compression, func(w io.Writer) (int, error) {
+		return s.exporter.Export(ctx, f, opts, w)
+	})
+}
+
+// deliverFile: produce'un yazdığı CDR dosyasını (gerekirse sıkıştırarak) geçici dosyaya alır, boyutunu ve
+// SHA-256'sını hesaplar ve name adıyla yükler.
+func deliverFile(ctx context.Context, up uploader, name, compression string, produce func(io.Writer) (int, error)) (Result, error) {
+	tmp, err := os.CreateTemp("", "cdr-delivery-*")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	bw := bufio.NewWriterSize(io.MultiWriter(tmp, hash), 64*1024)
+	var w io.Writer = bw
+	var gz *gzip.Writer
+	if compression == CompressionGzip {
+		gz = gzip.NewWriter(bw)
+		w = gz
+	}
+
+	rows, err := produce(w)
+	if err != nil {
+		return Result{}, fmt.Errorf("CDR dosyası üretilemedi: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return Result{}, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Result{}, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Result{}, err
+	}
+
+	res := Result{ObjectName: name, Rows: rows, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}
+	if err := up.upload(ctx, res.ObjectName, tmp, size, res.SHA256); err != nil {
+		return Result{}, fmt.Errorf("yükleme başarısız: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,133 @@
+// sentiric-cdr-service/internal/delivery/scheduler_test.go
+package delivery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// memUploader: Yüklenen dosyaları bellekte tutar; fail > 0 iken denemeleri başarısız sayar.
+type memUploader struct {
+	fail  int
+	files map[string][]byte
+	sums  map[string]string
+}
+
+func (u *memUploader) upload(_ context.Context, name string, r io.Reader, size int64, sha256Hex string) error {
+	if u.fail > 0 {
+		u.fail--
+		return errors.New("bağlantı koptu")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.New("boyut uyuşmuyor")
+	}
+	if u.files == nil {
+		u.files, u.sums = map[string][]byte{}, map[string]string{}
+	}
+	u.files[name], u.sums[name] = data, sha256Hex
+	return nil
+}
+
+func TestDeliverFileChecksum(t *testing.T) {
+	const body = "call_id,tenant_id\nc1,acme\nc2,acme\n"
+	produce := func(w io.Writer) (int, error) {
+		_, err := io.WriteString(w, body)
+		return 2, err
+	}
+
+	for _, compression := range []string{CompressionNone, CompressionGzip} {
+		up := &memUploader{}
+		res, err := deliverFile(context.Background(), up, "cdr.csv", compression, produce)
+		if err != nil {
+			t.Fatalf("%s: deliverFile: %v", compression, err)
+		}
+
+		uploaded := up.files["cdr.csv"]
+		sum := sha256.Sum256(uploaded)
+		if want := hex.EncodeToString(sum[:]); res.SHA256 != want || up.sums["cdr.csv"] != want {
+			t.Errorf("%s: sha256 = %s (yüklenen %s), beklenen %s", compression, res.SHA256, up.sums["cdr.csv"], want)
+		}
+		if res.Size != int64(len(uploaded)) || res.Rows != 2 {
+			t.Errorf("%s: boyut = %d, satır = %d; beklenen %d, 2", compression, res.Size, res.Rows, len(uploaded))
+		}
+
+		content := uploaded
+		if compression == CompressionGzip {
+			zr, err := gzip.NewReader(bytes.NewReader(uploaded))
+			if err != nil {
+				t.Fatalf("gzip okunamadı: %v", err)
+			}
+			if content, err = io.ReadAll(zr); err != nil {
+				t.Fatalf("gzip okunamadı: %v", err)
+			}
+		}
+		if string(content) != body {
+			t.Errorf("%s: içerik = %q, beklenen %q", compression, content, body)
+		}
+	}
+}
+
+func TestDeliverFileRetry(t *testing.T) {
+	produce := func(w io.Writer) (int, error) {
+		_, err := io.WriteString(w, "c1\n")
+		return 1, err
+	}
+	up := &memUploader{fail: 1}
+	if _, err := deliverFile(context.Background(), up, "cdr.csv", CompressionNone, produce); err == nil {
+		t.Fatal("ilk yükleme hatası dönmeliydi")
+	}
+	if _, ok := up.files["cdr.csv"]; ok {
+		t.Fatal("başarısız denemede dosya yüklenmiş sayılmamalı")
+	}
+	res, err := deliverFile(context.Background(), up, "cdr.csv", CompressionNone, produce)
+	if err != nil {
+		t.Fatalf("tekrar deneme başarısız: %v", err)
+	}
+	if string(up.files["cdr.csv"]) != "c1\n" || res.Rows != 1 {
+		t.Errorf("tekrar denemede yüklenen = %q, satır = %d", up.files["cdr.csv"], res.Rows)
+	}
+
+	if _, err := deliverFile(context.Background(), &memUploader{}, "cdr.csv", CompressionNone, func(io.Writer) (int, error) {
+		return 0, errors.New("sorgu hatası")
+	}); err == nil {
+		t.Error("üretim hatası dönmeliydi")
+	}
+}
+
+func TestNextAttempt(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name        string
+		attempts    int
+		maxAttempts int
+		jobLoaded   bool
+		wantWait    time.Duration // 0: tekrar yok
+	}{
+		{"ilk deneme", 1, 5, true, time.Minute},
+		{"üçüncü deneme", 3, 5, true, 4 * time.Minute},
+		{"hak bitti", 5, 5, true, 0},
+		{"iş okunamadı", 1, 0, false, time.Minute},
+		{"iş okunamadı, hak aşıldı", 9, 0, false, time.Hour},
+		{"üst sınır", 20, 30, true, time.Hour},
+	}
+	for _, c := range cases {
+		got := nextAttempt(c.attempts, c.maxAttempts, c.jobLoaded, now)
+		switch {
+		case c.wantWait == 0 && got != nil:
+			t.Errorf("%s: tekrar = %v, beklenen yok", c.name, *got)
+		case c.wantWait != 0 && (got == nil || got.Sub(now) != c.wantWait):
+			t.Errorf("%s: tekrar = %v, beklenen +%v", c.name, got, c.wantWait)
+		}
+	}
+}
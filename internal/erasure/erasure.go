@@ -0,0 +1,116 @@
+// AÇIKLAMA: Bu paket, KVKK / GDPR ilgili kişi silme taleplerini yürütür: talebi kaydeder, kişiyi CDR'da takma adla
+// anonimleştirir ve imzalı silme sertifikası üretir. Faturalama tutarları ve hash zinciri korunur.
+package erasure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Request: Silme talebi. TenantID boşsa kişi tüm tenant'larda anonimleştirilir.
+type Request struct {
+	TenantID    string
+	Regulation  string
+	Reference   string
+	RequestedBy string
+	Subject     privacy.Subject
+}
+
+type Service struct {
+	repo   *repository.ErasureRepository
+	signer *chain.Signer
+	key    []byte
+	log    zerolog.Logger
+}
+
+// NewService: signer nil ise sertifikalar imzasız üretilir.
+func NewService(db *sql.DB, keys *repository.Keyring, signer *chain.Signer, pseudonymKey string, log zerolog.Logger) *Service {
+	return &Service{repo: repository.NewErasureRepository(db, keys), signer: signer, key: []byte(pseudonymKey), log: log}
+}
+
+// SubjectHash: Talep kayıtlarında kişiyi aramak için kullanılan özet.
+func (s *Service) SubjectHash(subject privacy.Subject) string {
+	return subject.Hash(s.key)
+}
+
+// Erase: Talebi kaydeder ve yürütür. Başarısız talep FAILED olarak kalır; tekrar denemek için yeni talep açılır.
+func (s *Service) Erase(ctx context.Context, req Request) (repository.ErasureRequest, error) {
+	req.Regulation = strings.ToUpper(req.Regulation)
+	if !privacy.ValidRegulation(req.Regulation) {
+		return repository.ErasureRequest{}, errors.New("mevzuat KVKK veya GDPR olmalı")
+	}
+	if req.Reference == "" {
+		return repository.ErasureRequest{}, errors.New("talep referansı zorunludur")
+	}
+	pseudonym, err := privacy.Pseudonym(s.key, req.Subject)
+	if err != nil {
+		return repository.ErasureRequest{}, err
+	}
+
+	rec, err := s.repo.CreateRequest(ctx, repository.ErasureRequest{
+		TenantID:    req.TenantID,
+		Regulation:  req.Regulation,
+		Reference:   req.Reference,
+		SubjectType: req.Subject.Type,
+		SubjectHash: req.Subject.Hash(s.key),
+		Pseudonym:   pseudonym,
+		RequestedBy: req.RequestedBy,
+	})
+	if err != nil {
+		return rec, err
+	}
+	log := s.log.With().Int64("erasure_id", rec.ID).Str("regulation", rec.Regulation).
+		Str("reference", rec.Reference).Logger()
+
+	match := repository.ErasureMatch{Pattern: req.Subject.Pattern(), Subject: req.Subject}
+	if req.Subject.Type == privacy.SubjectPhone {
+		match.Numbers = req.Subject.Variants()
+	} else {
+		match.UserID = req.Subject.Value
+	}
+
+	done, err := s.repo.Erase(ctx, rec, match, s.certify)
+	if err != nil {
+		metrics.ErasureRequests.WithLabelValues(rec.Regulation, "FAILED").Inc()
+		if failErr := s.repo.FailRequest(context.WithoutCancel(ctx), rec.ID, err); failErr != nil {
+			log.Error().Err(failErr).Msg("Silme talebi durumu güncellenemedi.")
+		}
+		return rec, err
+	}
+	metrics.ErasureRequests.WithLabelValues(rec.Regulation, done.Status).Inc()
+	log.Info().Int64("calls", done.Counts.CallsPseudonymized).Int64("events", done.Counts.EventsScrubbed).
+		Int64("recordings", done.Counts.RecordingsUnlinked).Msg("🧹 İlgili kişi anonimleştirildi.")
+	return done, nil
+}
+
+// certify: Tamamlanan talebin sertifikasını üretir ve anahtar tanımlıysa imzalar.
+func (s *Service) certify(rec repository.ErasureRequest) (json.RawMessage, error) {
+	cert := privacy.NewCertificate(privacy.Certificate{
+		RequestID:   rec.ID,
+		Regulation:  rec.Regulation,
+		Reference:   rec.Reference,
+		TenantID:    rec.TenantID,
+		SubjectType: rec.SubjectType,
+		SubjectHash: rec.SubjectHash,
+		Pseudonym:   rec.Pseudonym,
+		RequestedBy: rec.RequestedBy,
+		Counts:      rec.Counts,
+		RequestedAt: rec.CreatedAt.UTC(),
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+	})
+	if s.signer != nil {
+		cert = cert.Sign(s.signer)
+	}
+	return json.Marshal(cert)
+}
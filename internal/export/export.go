@@ -0,0 +1,349 @@
+// AÇIKLAMA: Bu paket, calls tablosundaki CDR'ları bacak bazlı veya etkileşim bazlı konsolide yolculuk olarak
+// CSV, NDJSON veya Parquet biçiminde akış halinde dışa aktarır. Satırlar veritabanından okundukça yazılır; sonuç
+// belleğe toplanmaz.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Desteklenen çıktı formatları.
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// Export görünümleri: her bacak bir satır (legs) veya her etkileşim tek satır (journey).
+const (
+	ViewLegs    = "legs"
+	ViewJourney = "journey"
+)
+
+// eventsColumn: IncludeEvents seçildiğinde kolon listesinin sonuna eklenen olay dizisi kolonu.
+const eventsColumn = "events"
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindTime
+	kindJSON
+)
+
+type column struct {
+	name string
+	kind kind
+	get  func(*repository.CallRecord) interface{}
+}
+
+// journeyColumn: view=journey kolonu. Yazıcılar yalnızca gömülü column'un ad ve tipini kullanır.
+type journeyColumn struct {
+	column
+	get func(*repository.JourneyRecord) interface{}
+}
+
+// columns: Export edilebilen kolonlar, varsayılan sırasıyla. Boş değerler nil döner ve çıktıda boş/null yazılır.
+var columns = []column{
+	{"call_id", kindString, func(c *repository.CallRecord) interface{} { return c.CallID }},
+	{"tenant_id", kindString, func(c *repository.CallRecord) interface{} { return c.TenantID }},
+	{"interaction_id", kindString, func(c *repository.CallRecord) interface{} { return c.InteractionID }},
+	{"parent_call_id", kindString, func(c *repository.CallRecord) interface{} { return optString(c.ParentCallID) }},
+	{"leg_sequence", kindInt, func(c *repository.CallRecord) interface{} { return int64(c.LegSequence) }},
+	{"leg_type", kindString, func(c *repository.CallRecord) interface{} { return c.LegType }},
+	{"direction", kindString, func(c *repository.CallRecord) interface{} { return c.Direction }},
+	{"caller_number", kindString, func(c *repository.CallRecord) interface{} { return c.CallerNumber }},
+	{"callee_number", kindString, func(c *repository.CallRecord) interface{} { return c.CalleeNumber }},
+	{"user_id", kindString, func(c *repository.CallRecord) interface{} { return optString(c.UserID) }},
+	{"status", kindString, func(c *repository.CallRecord) interface{} { return c.Status }},
+	{"disposition", kindString, func(c *repository.CallRecord) interface{} { return optString(c.Disposition) }},
+	{"hangup_source", kindString, func(c *repository.CallRecord) interface{} { return optString(c.HangupSource) }},
+	{"start_time", kindTime, func(c *repository.CallRecord) interface{} { return c.StartTime }},
+	{"answer_time", kindTime, func(c *repository.CallRecord) interface{} { return optTime(c.AnswerTime) }},
+	{"end_time", kindTime, func(c *repository.CallRecord) interface{} { return optTime(c.EndTime) }},
+	{"duration_seconds", kindInt, func(c *repository.CallRecord) interface{} { return int64(c.DurationSeconds) }},
+	{"ring_seconds", kindInt, func(c *repository.CallRecord) interface{} { return int64(c.RingSeconds) }},
+	{"talk_seconds", kindInt, func(c *repository.CallRecord) interface{} { return int64(c.TalkSeconds) }},
+	{"hold_seconds", kindInt, func(c *repository.CallRecord) interface{} { return int64(c.HoldSeconds) }},
+	{"billable_seconds", kindInt, func(c *repository.CallRecord) interface{} { return int64(c.BillableSeconds) }},
+	{"total_duration_ms", kindInt, func(c *repository.CallRecord) interface{} { return c.TotalDurationMs }},
+	{"billable_duration_ms", kindInt, func(c *repository.CallRecord) interface{} { return c.BillableDurationMs }},
+	{"post_dial_delay_ms", kindInt, func(c *repository.CallRecord) interface{} {
+		if c.PostDialDelayMs == nil {
+			return nil
+		}
+		return int64(*c.PostDialDelayMs)
+	}},
+	{"total_cost", kindFloat, func(c *repository.CallRecord) interface{} { return c.TotalCost }},
+	{"recording_url", kindString, func(c *repository.CallRecord) interface{} { return optString(c.RecordingURL) }},
+	{eventsColumn, kindJSON, func(c *repository.CallRecord) interface{} { return []byte(c.Events) }},
+}
+
+// journeyColumns: view=journey'de export edilebilen kolonlar, varsayılan sırasıyla. call_ids virgülle ayrılmış
+// bacak listesidir (bacak sırasıyla).
+var journeyColumns = []journeyColumn{
+	{column{"interaction_id", kindString, nil}, func(j *repository.JourneyRecord) interface{} { return j.InteractionID }},
+	{column{"tenant_id", kindString, nil}, func(j *repository.JourneyRecord) interface{} { return j.TenantID }},
+	{column{"caller_number", kindString, nil}, func(j *repository.JourneyRecord) interface{} { return j.CallerNumber }},
+	{column{"callee_number", kindString, nil}, func(j *repository.JourneyRecord) interface{} { return j.CalleeNumber }},
+	{column{"start_time", kindTime, nil}, func(j *repository.JourneyRecord) interface{} { return j.StartTime }},
+	{column{"end_time", kindTime, nil}, func(j *repository.JourneyRecord) interface{} { return optTime(j.EndTime) }},
+	{column{"leg_count", kindInt, nil}, func(j *repository.JourneyRecord) interface{} { return int64(j.LegCount) }},
+	{column{"total_talk_seconds", kindInt, nil}, func(j *repository.JourneyRecord) interface{} { return int64(j.TotalTalkSeconds) }},
+	{column{"total_cost", kindFloat, nil}, func(j *repository.JourneyRecord) interface{} { return j.TotalCost }},
+	{column{"final_disposition", kindString, nil}, func(j *repository.JourneyRecord) interface{} { return optString(j.FinalDisposition) }},
+	{column{"call_ids", kindString, nil}, func(j *repository.JourneyRecord) interface{} { return strings.Join(j.CallIDs, ",") }},
+}
+
+// DefaultColumns: Kolon seçilmediğinde kullanılan set (olay dizisi hariç tüm bacak kolonları).
+func DefaultColumns() []string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c.name != eventsColumn {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// DefaultJourneyColumns: view=journey'de kolon seçilmediğinde kullanılan set.
+func DefaultJourneyColumns() []string {
+	names := make([]string, len(journeyColumns))
+	for i, c := range journeyColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// Options: Export çıktısının biçimi.
+type Options struct {
+	Format string
+	// View: ViewLegs (boşsa) veya ViewJourney. Yolculuk görünümünde olaylar dahil edilemez.
+	View string
+	// Columns: Boşsa DefaultColumns kullanılır.
+	Columns []string
+	// Location: CSV ve NDJSON'daki zaman damgalarının saat dilimi. Parquet zamanları her zaman UTC'dir.
+	Location *time.Location
+	// MaskNumbers: Arayan ve aranan numaraların son 4 hanesi dışında maskelenmesi. Numbers açık numara verse de uygulanır.
+	MaskNumbers bool
+	// Numbers: Tenant ve tüketici için çözümlenmiş maskeleme politikası. Sıfır değeri tüm rakamları gizler.
+	Numbers masking.Masker
+	// IncludeEvents: Her satıra çağrının call_events kayıtlarını JSON dizisi olarak ekler.
+	IncludeEvents bool
+}
+
+// resolve: Seçenekleri doğrular ve yazılacak kolonları sırasıyla döner.
+func (o Options) resolve() ([]column, error) {
+	switch o.Format {
+	case FormatCSV, FormatNDJSON, FormatParquet:
+	default:
+		return nil, fmt.Errorf("desteklenmeyen format: %q (csv, ndjson veya parquet)", o.Format)
+	}
+
+	available, names := columns, o.Columns
+	switch o.View {
+	case "", ViewLegs:
+		if len(names) == 0 {
+			names = DefaultColumns()
+		}
+		if o.IncludeEvents && !contains(names, eventsColumn) {
+			names = append(append([]string{}, names...), eventsColumn)
+		}
+	case ViewJourney:
+		if o.IncludeEvents {
+			return nil, errors.New("journey görünümünde olaylar dahil edilemez")
+		}
+		available = make([]column, len(journeyColumns))
+		for i, jc := range journeyColumns {
+			available[i] = jc.column
+		}
+		if len(names) == 0 {
+			names = DefaultJourneyColumns()
+		}
+	default:
+		return nil, fmt.Errorf("desteklenmeyen görünüm: %q (legs veya journey)", o.View)
+	}
+
+	selected := make([]column, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		col, ok := findColumn(available, name)
+		if !ok {
+			return nil, fmt.Errorf("bilinmeyen kolon: %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("kolon birden fazla seçilmiş: %q", name)
+		}
+		if name == eventsColumn && !o.IncludeEvents {
+			return nil, fmt.Errorf("%q kolonu için olayların dahil edilmesi gerekir", eventsColumn)
+		}
+		seen[name] = true
+		selected = append(selected, col)
+	}
+	return selected, nil
+}
+
+// Validate: Seçeneklerin geçerliliğini veritabanına dokunmadan kontrol eder.
+func (o Options) Validate() error {
+	_, err := o.resolve()
+	return err
+}
+
+// ParseColumns: Virgülle ayrılmış kolon listesini ayrıştırır ("call_id,start_time").
+func ParseColumns(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ContentType: Formatın HTTP Content-Type değeri.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// Exporter: CDR'ları repository'den akış halinde okuyup seçilen formatta yazar.
+type Exporter struct {
+	repo *repository.CallRepository
+}
+
+func New(repo *repository.CallRepository) *Exporter {
+	return &Exporter{repo: repo}
+}
+
+// Export: Filtreye uyan tüm bacakları (journey görünümünde bu bacakların etkileşimlerini) w'ye yazar ve yazılan
+// satır sayısını döner. Filtrenin Limit/Offset alanları sayfalama için kullanılmaz; export tüm aralığı kapsar.
+func (e *Exporter) Export(ctx context.Context, f repository.CallFilter, opts Options, w io.Writer) (int, error) {
+	cols, err := opts.resolve()
+	if err != nil {
+		return 0, err
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	rw, err := newRowWriter(opts, cols, w)
+	if err != nil {
+		return 0, err
+	}
+
+	f.Limit, f.Offset = 0, 0
+	f.IncludeEvents = opts.IncludeEvents
+
+	count := 0
+	values := make([]interface{}, len(cols))
+	mask := opts.numberMasker()
+	if opts.View == ViewJourney {
+		getters := journeyGetters(cols)
+		err = e.repo.ForEachJourney(ctx, f, func(rec repository.JourneyRecord) error {
+			journeyValues(getters, mask, rec, values)
+			count++
+			return rw.write(values)
+		})
+		if err != nil {
+			return count, err
+		}
+		return count, rw.close()
+	}
+	err = e.repo.ForEachCall(ctx, f, func(rec repository.CallRecord) error {
+		legValues(cols, mask, rec, values)
+		count++
+		return rw.write(values)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, rw.close()
+}
+
+// numberMasker: Satırlara uygulanacak maskeleyici. MaskNumbers, politika açık numara verse de varsayılan
+// maskelemeyi zorlar.
+func (o Options) numberMasker() masking.Masker {
+	if o.MaskNumbers && o.Numbers.Unmasked() {
+		return masking.Default()
+	}
+	return o.Numbers
+}
+
+// legValues: Bacağın numaralarını maskeler ve kolon değerlerini kolon sırasıyla values'a yazar.
+func legValues(cols []column, mask masking.Masker, rec repository.CallRecord, values []interface{}) {
+	rec.CallerNumber = mask.Mask(rec.CallerNumber)
+	rec.CalleeNumber = mask.Mask(rec.CalleeNumber)
+	for i, col := range cols {
+		values[i] = col.get(&rec)
+	}
+}
+
+// journeyGetters: Seçilen kolonların yolculuk kaydından okunma fonksiyonları, kolon sırasıyla.
+func journeyGetters(cols []column) []func(*repository.JourneyRecord) interface{} {
+	getters := make([]func(*repository.JourneyRecord) interface{}, len(cols))
+	for i, col := range cols {
+		for _, jc := range journeyColumns {
+			if jc.name == col.name {
+				getters[i] = jc.get
+			}
+		}
+	}
+	return getters
+}
+
+// journeyValues: Yolculuğun numaralarını maskeler ve kolon değerlerini kolon sırasıyla values'a yazar.
+func journeyValues(getters []func(*repository.JourneyRecord) interface{}, mask masking.Masker, rec repository.JourneyRecord, values []interface{}) {
+	rec.CallerNumber = mask.Mask(rec.CallerNumber)
+	rec.CalleeNumber = mask.Mask(rec.CalleeNumber)
+	for i, get := range getters {
+		values[i] = get(&rec)
+	}
+}
+
+func findColumn(available []column, name string) (column, bool) {
+	for _, c := range available {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func optString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
@@ -0,0 +1,27 @@
+// sentiric-cdr-service/internal/export/export_test.go
+package export
+
+import "testing"
+
+func TestOptionsValidateView(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"varsayılan görünüm", Options{Format: FormatCSV}, false},
+		{"legs ve olaylar", Options{Format: FormatNDJSON, View: ViewLegs, IncludeEvents: true}, false},
+		{"journey", Options{Format: FormatParquet, View: ViewJourney}, false},
+		{"journey kolonları", Options{Format: FormatCSV, View: ViewJourney, Columns: []string{"interaction_id", "leg_count", "call_ids"}}, false},
+		{"journey ve olaylar", Options{Format: FormatCSV, View: ViewJourney, IncludeEvents: true}, true},
+		{"journey'de bacak kolonu", Options{Format: FormatCSV, View: ViewJourney, Columns: []string{"call_id"}}, true},
+		{"legs'te journey kolonu", Options{Format: FormatCSV, Columns: []string{"leg_count"}}, true},
+		{"bilinmeyen görünüm", Options{Format: FormatCSV, View: "calls"}, true},
+		{"bilinmeyen format", Options{Format: "xml", View: ViewJourney}, true},
+	}
+	for _, c := range cases {
+		if err := c.opts.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+		}
+	}
+}
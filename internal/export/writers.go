@@ -0,0 +1,188 @@
+// sentiric-cdr-service/internal/export/writers.go
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize: Parquet yazıcısının bellekte tuttuğu en fazla satır sayısı.
+const parquetRowGroupSize = 10000
+
+// rowWriter: Tek bir formatın satır yazıcısı. values, kolonlarla aynı sıradadır; nil boş değerdir.
+type rowWriter interface {
+	write(values []interface{}) error
+	close() error
+}
+
+func newRowWriter(opts Options, cols []column, w io.Writer) (rowWriter, error) {
+	switch opts.Format {
+	case FormatCSV:
+		return newCSVWriter(cols, opts.Location, w)
+	case FormatNDJSON:
+		return &ndjsonWriter{cols: cols, loc: opts.Location, w: bufio.NewWriter(w)}, nil
+	default:
+		return newParquetWriter(cols, w), nil
+	}
+}
+
+// formatTime: Metin formatlarında zaman damgaları seçilen saat diliminde RFC3339 (milisaniye) yazılır.
+func formatTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+type csvWriter struct {
+	cols   []column
+	loc    *time.Location
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(cols []column, loc *time.Location, w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{cols: cols, loc: loc, w: csv.NewWriter(w), record: make([]string, len(cols))}
+	for i, c := range cols {
+		cw.record[i] = c.name
+	}
+	return cw, cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) write(values []interface{}) error {
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			cw.record[i] = ""
+		case string:
+			cw.record[i] = v
+		case int64:
+			cw.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			cw.record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			cw.record[i] = formatTime(v, cw.loc)
+		case []byte:
+			cw.record[i] = string(v)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ndjsonWriter: Her satır, kolon sırasını koruyan tek bir JSON nesnesidir.
+type ndjsonWriter struct {
+	cols []column
+	loc  *time.Location
+	w    *bufio.Writer
+}
+
+func (nw *ndjsonWriter) write(values []interface{}) error {
+	_ = nw.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			_ = nw.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(nw.cols[i].name)
+		_, _ = nw.w.Write(key)
+		_ = nw.w.WriteByte(':')
+
+		var b []byte
+		var err error
+		switch v := v.(type) {
+		case nil:
+			b = []byte("null")
+		case time.Time:
+			b, err = json.Marshal(formatTime(v, nw.loc))
+		case []byte:
+			b = v
+		default:
+			b, err = json.Marshal(v)
+		}
+		if err != nil {
+			return err
+		}
+		_, _ = nw.w.Write(b)
+	}
+	_, err := nw.w.WriteString("}\n")
+	return err
+}
+
+func (nw *ndjsonWriter) close() error {
+	return nw.w.Flush()
+}
+
+// parquetWriter: Şema seçilen kolonlardan üretilir; tüm kolonlar opsiyoneldir.
+// Parquet şeması kolonları ada göre sıraladığı için satırlar şemanın sırasına göre kurulur.
+type parquetWriter struct {
+	w     *parquet.Writer
+	order []int // şema sırasındaki her kolonun values içindeki indeksi
+	row   parquet.Row
+}
+
+func newParquetWriter(cols []column, w io.Writer) *parquetWriter {
+	group := parquet.Group{}
+	index := make(map[string]int, len(cols))
+	for i, c := range cols {
+		index[c.name] = i
+		var node parquet.Node
+		switch c.kind {
+		case kindInt:
+			node = parquet.Int(64)
+		case kindFloat:
+			node = parquet.Leaf(parquet.DoubleType)
+		case kindTime:
+			node = parquet.Timestamp(parquet.Millisecond)
+		case kindJSON:
+			node = parquet.JSON()
+		default:
+			node = parquet.String()
+		}
+		group[c.name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema("cdr", group)
+
+	pw := &parquetWriter{
+		w:   parquet.NewWriter(w, schema, parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		row: make(parquet.Row, len(cols)),
+	}
+	for _, f := range schema.Fields() {
+		pw.order = append(pw.order, index[f.Name()])
+	}
+	return pw
+}
+
+func (pw *parquetWriter) write(values []interface{}) error {
+	for colIdx, i := range pw.order {
+		var v parquet.Value
+		switch x := values[i].(type) {
+		case nil:
+			pw.row[colIdx] = parquet.NullValue().Level(0, 0, colIdx)
+			continue
+		case string:
+			v = parquet.ByteArrayValue([]byte(x))
+		case []byte:
+			v = parquet.ByteArrayValue(x)
+		case int64:
+			v = parquet.Int64Value(x)
+		case float64:
+			v = parquet.DoubleValue(x)
+		case time.Time:
+			v = parquet.Int64Value(x.UnixMilli())
+		}
+		pw.row[colIdx] = v.Level(0, 1, colIdx)
+	}
+	_, err := pw.w.WriteRows([]parquet.Row{pw.row})
+	return err
+}
+
+func (pw *parquetWriter) close() error {
+	return pw.w.Close()
+}
@@ -0,0 +1,171 @@
+// sentiric-cdr-service/internal/export/writers_test.go
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+var (
+	testStart  = time.Date(2025, 3, 9, 21, 30, 0, 250_000_000, time.UTC)
+	testAnswer = testStart.Add(5 * time.Second)
+)
+
+// testCalls: Biri cevaplanmış, biri cevapsız (answer_time ve disposition boş) iki bacak.
+func testCalls() []repository.CallRecord {
+	return []repository.CallRecord{
+		{CallID: "c1", CallerNumber: "+905321234567", StartTime: testStart, AnswerTime: &testAnswer, TotalCost: 1.25,
+			LegSequence: 1, Disposition: "ANSWERED"},
+		{CallID: "c2", CallerNumber: "+905329876543", StartTime: testStart.Add(time.Minute), LegSequence: 2},
+	}
+}
+
+// render: Kayıtları Export'un yaptığı gibi maskeleyip seçilen formatta yazar.
+func render(t *testing.T, opts Options, calls []repository.CallRecord) []byte {
+	t.Helper()
+	cols, err := opts.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	rw, err := newRowWriter(opts, cols, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make([]interface{}, len(cols))
+	for _, rec := range calls {
+		legValues(cols, opts.numberMasker(), rec, values)
+		if err := rw.write(values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rw.close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTextWriters(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keepLast := masking.NewMasker(masking.DefaultPolicy, "acme", masking.Consumer{}, nil)
+	unmasked := masking.NewMasker(masking.Policy{Mode: masking.ModeNone}, "acme", masking.Consumer{Unmasked: true}, nil)
+	cols := []string{"call_id", "caller_number", "answer_time", "disposition", "total_cost", "leg_sequence"}
+
+	cases := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"CSV başlık, sıra ve boş değerler", Options{Format: FormatCSV, Columns: cols, Location: time.UTC, Numbers: keepLast},
+			"call_id,caller_number,answer_time,disposition,total_cost,leg_sequence\n" +
+				"c1,+********4567,2025-03-09T21:30:05.250Z,ANSWERED,1.25,1\n" +
+				"c2,+********6543,,,0,2\n"},
+		{"CSV yerel saat dilimi", Options{Format: FormatCSV, Columns: []string{"call_id", "start_time"}, Location: istanbul, Numbers: keepLast},
+			"call_id,start_time\n" +
+				"c1,2025-03-10T00:30:00.250+03:00\n" +
+				"c2,2025-03-10T00:31:00.250+03:00\n"},
+		{"CSV açık numara", Options{Format: FormatCSV, Columns: []string{"caller_number"}, Location: time.UTC, Numbers: unmasked},
+			"caller_number\n+905321234567\n+905329876543\n"},
+		{"CSV maskeleme zorlanır", Options{Format: FormatCSV, Columns: []string{"caller_number"}, Location: time.UTC, Numbers: unmasked, MaskNumbers: true},
+			"caller_number\n+********4567\n+********6543\n"},
+		{"NDJSON kolon sırası ve null", Options{Format: FormatNDJSON, Columns: cols, Location: istanbul, Numbers: keepLast},
+			`{"call_id":"c1","caller_number":"+********4567","answer_time":"2025-03-10T00:30:05.250+03:00","disposition":"ANSWERED","total_cost":1.25,"leg_sequence":1}` + "\n" +
+				`{"call_id":"c2","caller_number":"+********6543","answer_time":null,"disposition":null,"total_cost":0,"leg_sequence":2}` + "\n"},
+	}
+	for _, c := range cases {
+		if got := string(render(t, c.opts, testCalls())); got != c.want {
+			t.Errorf("%s:\n%s\nbeklenen:\n%s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNDJSONEvents(t *testing.T) {
+	calls := testCalls()[:1]
+	calls[0].Events = []byte(`[{"event_type":"call.started"}]`)
+	got := string(render(t, Options{Format: FormatNDJSON, Columns: []string{"call_id"}, Location: time.UTC, IncludeEvents: true}, calls))
+	want := `{"call_id":"c1","events":[{"event_type":"call.started"}]}` + "\n"
+	if got != want {
+		t.Errorf("olaylı NDJSON = %s, beklenen %s", got, want)
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	keepLast := masking.NewMasker(masking.DefaultPolicy, "acme", masking.Consumer{}, nil)
+	cols := []string{"call_id", "caller_number", "answer_time", "total_cost", "leg_sequence"}
+	data := render(t, Options{Format: FormatParquet, Columns: cols, Numbers: keepLast}, testCalls())
+
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("parquet dosyası açılamadı: %v", err)
+	}
+	if f.NumRows() != 2 {
+		t.Fatalf("satır sayısı = %d, beklenen 2", f.NumRows())
+	}
+	index := map[string]int{}
+	for i, field := range f.Schema().Fields() {
+		if !field.Optional() {
+			t.Errorf("%s kolonu opsiyonel olmalı", field.Name())
+		}
+		index[field.Name()] = i
+	}
+	if len(index) != len(cols) {
+		t.Fatalf("şema kolonları = %v, beklenen %v", index, cols)
+	}
+
+	rows := make([]parquet.Row, 2)
+	reader := f.RowGroups()[0].Rows()
+	defer reader.Close()
+	if n, err := reader.ReadRows(rows); n != 2 || (err != nil && !errors.Is(err, io.EOF)) {
+		t.Fatalf("ReadRows = %d, %v", n, err)
+	}
+
+	value := func(row parquet.Row, name string) parquet.Value { return row[index[name]] }
+	first, second := rows[0], rows[1]
+	if got := string(value(first, "call_id").ByteArray()); got != "c1" {
+		t.Errorf("call_id = %q, beklenen c1", got)
+	}
+	if got := string(value(first, "caller_number").ByteArray()); got != "+********4567" {
+		t.Errorf("caller_number = %q, maskelenmiş numara bekleniyor", got)
+	}
+	if got := value(first, "answer_time").Int64(); got != testAnswer.UnixMilli() {
+		t.Errorf("answer_time = %d, beklenen %d (UTC milisaniye)", got, testAnswer.UnixMilli())
+	}
+	if got := value(first, "total_cost").Double(); got != 1.25 {
+		t.Errorf("total_cost = %v, beklenen 1.25", got)
+	}
+	if got := value(second, "leg_sequence").Int64(); got != 2 {
+		t.Errorf("leg_sequence = %d, beklenen 2", got)
+	}
+	if !value(second, "answer_time").IsNull() {
+		t.Error("cevapsız bacağın answer_time değeri null olmalı")
+	}
+}
+
+func TestJourneyValues(t *testing.T) {
+	opts := Options{Format: FormatCSV, View: ViewJourney, Columns: []string{"interaction_id", "caller_number", "call_ids", "final_disposition"}}
+	cols, err := opts.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := repository.JourneyRecord{InteractionID: "i1", CallerNumber: "+905321234567", CallIDs: []string{"c1", "c2"}}
+	values := make([]interface{}, len(cols))
+	journeyValues(journeyGetters(cols), masking.Default(), rec, values)
+
+	want := []interface{}{"i1", "+********4567", "c1,c2", nil}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("%s = %v, beklenen %v", cols[i].name, values[i], want[i])
+		}
+	}
+}
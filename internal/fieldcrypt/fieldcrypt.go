@@ -0,0 +1,130 @@
+// AÇIKLAMA: Bu paket, hassas CDR alanlarının (numaralar, olay gövdeleri) zarf şifrelemesini tanımlar: tenant veri
+// anahtarıyla AES-256-GCM şifreleme, ana anahtarla (dosya veya KMS) veri anahtarı sarmalama ve eşitlik araması
+// için kör indeks. Veritabanına dokunmaz.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Prefix: Şifreli alan değerlerinin öneki. Tam biçim "enc:v1:<veri_anahtarı_id>:<base64(nonce|şifreli)>".
+// Önek taşımayan değer düz metindir (şifreleme öncesi yazılmış veya şifreleme kapalı).
+const Prefix = "enc:v1:"
+
+// KeySize: Veri ve indeks anahtarı uzunluğu (AES-256, HMAC-SHA256).
+const KeySize = 32
+
+// Şifrelenen alanlar; ek doğrulama verisine (AAD) girer, şifreli değer başka alana taşınamaz.
+const (
+	FieldCallerNumber = "caller_number"
+	FieldCalleeNumber = "callee_number"
+	FieldPayload      = "payload"
+	FieldSummary      = "ai_summary"
+)
+
+// ErrMalformed: Önekli ama çözümlenemeyen değer.
+var ErrMalformed = errors.New("şifreli alan biçimi geçersiz")
+
+// NewKey: Rastgele veri/indeks anahtarı üretir.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// IsSealed: Değerin şifreli olup olmadığını söyler.
+func IsSealed(v string) bool {
+	return strings.HasPrefix(v, Prefix)
+}
+
+// KeyID: Şifreli değeri açmak için gereken veri anahtarının ID'si.
+func KeyID(v string) (int64, error) {
+	rest, ok := strings.CutPrefix(v, Prefix)
+	if !ok {
+		return 0, ErrMalformed
+	}
+	idPart, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, ErrMalformed
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return 0, ErrMalformed
+	}
+	return id, nil
+}
+
+// aad: Şifreli değeri satıra ve alana bağlar; satırlar veya alanlar arasında kopyalanan değer açılmaz.
+func aad(field, rowID string) []byte {
+	return []byte(field + "|" + rowID)
+}
+
+// Seal: Değeri veri anahtarıyla şifreler.
+func Seal(key []byte, keyID int64, field, rowID, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), aad(field, rowID))
+	return Prefix + strconv.FormatInt(keyID, 10) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open: Seal ile şifrelenmiş değeri çözer.
+func Open(key []byte, field, rowID, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return "", ErrMalformed
+	}
+	_, body, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", ErrMalformed
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", ErrMalformed
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], aad(field, rowID))
+	if err != nil {
+		return "", fmt.Errorf("şifreli alan çözülemedi (%s): %w", field, err)
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// BlindIndex: Normalize edilmiş değerin tenant indeks anahtarıyla HMAC'i. Aynı tenant'ta aynı numara aynı indeksi
+// alır; anahtar olmadan indeksten numaraya dönülemez.
+func BlindIndex(key []byte, normalized string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
@@ -0,0 +1,193 @@
+// sentiric-cdr-service/internal/fieldcrypt/fieldcrypt_test.go
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	other := bytes.Repeat([]byte{2}, KeySize)
+	sealed, err := Seal(key, 7, "caller_number", "call-1", "+905551112233")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		key     []byte
+		field   string
+		rowID   string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"doğru anahtar", key, "caller_number", "call-1", sealed, "+905551112233", false},
+		{"yanlış anahtar", other, "caller_number", "call-1", sealed, "", true},
+		{"başka alan", key, "destination_number", "call-1", sealed, "", true},
+		{"başka satır", key, "caller_number", "call-2", sealed, "", true},
+		{"önek yok", key, "caller_number", "call-1", "+905551112233", "", true},
+		{"anahtar ID'si yok", key, "caller_number", "call-1", Prefix + "abc", "", true},
+		{"bozuk base64", key, "caller_number", "call-1", Prefix + "7:***", "", true},
+		{"kısa gövde", key, "caller_number", "call-1", Prefix + "7:AAAA", "", true},
+	}
+	for _, c := range cases {
+		got, err := Open(c.key, c.field, c.rowID, c.value)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: Open = %q, beklenen %q", c.name, got, c.want)
+		}
+	}
+
+	again, err := Seal(key, 7, "caller_number", "call-1", "+905551112233")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if again == sealed {
+		t.Error("aynı değer iki kez şifrelendiğinde farklı nonce kullanılmalı")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{Prefix + "42:AAAA", 42, false},
+		{Prefix + "0:", 0, false},
+		{"+905551112233", 0, true},
+		{Prefix + "42", 0, true},
+		{Prefix + "x:AAAA", 0, true},
+	}
+	for _, c := range cases {
+		got, err := KeyID(c.value)
+		if (err != nil) != c.wantErr {
+			t.Errorf("KeyID(%q) hata = %v, hata bekleniyor = %v", c.value, err, c.wantErr)
+			continue
+		}
+		if c.wantErr && !errors.Is(err, ErrMalformed) {
+			t.Errorf("KeyID(%q) hata = %v, beklenen ErrMalformed", c.value, err)
+		}
+		if got != c.want {
+			t.Errorf("KeyID(%q) = %d, beklenen %d", c.value, got, c.want)
+		}
+	}
+}
+
+func TestIsSealed(t *testing.T) {
+	cases := []struct {
+		value string
+		want  bool
+	}{
+		{Prefix + "1:AAAA", true},
+		{"+905551112233", false},
+		{"", false},
+		{"enc:v2:1:AAAA", false},
+	}
+	for _, c := range cases {
+		if got := IsSealed(c.value); got != c.want {
+			t.Errorf("IsSealed(%q) = %v, beklenen %v", c.value, got, c.want)
+		}
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	other := bytes.Repeat([]byte{2}, KeySize)
+	base := BlindIndex(key, "905551112233")
+
+	cases := []struct {
+		name       string
+		key        []byte
+		normalized string
+		same       bool
+	}{
+		{"aynı anahtar ve numara", key, "905551112233", true},
+		{"başka numara", key, "905551112234", false},
+		{"başka tenant anahtarı", other, "905551112233", false},
+	}
+	for _, c := range cases {
+		got := BlindIndex(c.key, c.normalized)
+		if len(got) != 32 {
+			t.Errorf("%s: indeks uzunluğu = %d, beklenen 32", c.name, len(got))
+		}
+		if (got == base) != c.same {
+			t.Errorf("%s: indeks = %s, temel indeksle aynı olması bekleniyor = %v", c.name, got, c.same)
+		}
+	}
+}
+
+func TestFileMasterKeyWrapUnwrap(t *testing.T) {
+	m := &FileMasterKey{activeID: "k2", keys: map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, KeySize),
+		"k2": bytes.Repeat([]byte{2}, KeySize),
+	}}
+	dataKey := bytes.Repeat([]byte{9}, KeySize)
+	wrapped, err := m.Wrap(context.Background(), dataKey)
+	if err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		id      string
+		wrapped string
+		wantErr bool
+	}{
+		{"etkin anahtar", "k2", wrapped, false},
+		{"eski anahtar", "k1", wrapped, true},
+		{"bilinmeyen anahtar", "k3", wrapped, true},
+		{"bozuk base64", "k2", "***", true},
+		{"kısa gövde", "k2", "AAAA", true},
+	}
+	for _, c := range cases {
+		got, err := m.Unwrap(context.Background(), c.id, c.wrapped)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+			continue
+		}
+		if !c.wantErr && !bytes.Equal(got, dataKey) {
+			t.Errorf("%s: açılan anahtar sarmalanan anahtarla aynı değil", c.name)
+		}
+	}
+}
+
+func TestLoadFileMasterKey(t *testing.T) {
+	k1 := strings.Repeat("A", 43) + "="
+	cases := []struct {
+		name    string
+		content string
+		wantID  string
+		wantErr bool
+	}{
+		{"tek anahtar", "k1 " + k1 + "\n", "k1", false},
+		{"yorum ve boş satır", "# ana anahtarlar\n\nk1 " + k1 + "\nk0 " + k1 + "\n", "k1", false},
+		{"boş dosya", "# yalnızca yorum\n", "", true},
+		{"eksik alan", "k1\n", "", true},
+		{"kısa anahtar", "k1 AAAA\n", "", true},
+		{"tekrarlanan id", "k1 " + k1 + "\nk1 " + k1 + "\n", "", true},
+	}
+	for _, c := range cases {
+		path := t.TempDir() + "/master.keys"
+		if err := os.WriteFile(path, []byte(c.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		m, err := LoadFileMasterKey(path)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: hata = %v, hata bekleniyor = %v", c.name, err, c.wantErr)
+			continue
+		}
+		if !c.wantErr && m.ID() != c.wantID {
+			t.Errorf("%s: etkin anahtar = %q, beklenen %q", c.name, m.ID(), c.wantID)
+		}
+	}
+}
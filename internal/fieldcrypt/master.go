@@ -0,0 +1,182 @@
+// sentiric-cdr-service/internal/fieldcrypt/master.go
+package fieldcrypt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// MasterKey: Tenant veri ve indeks anahtarlarını sarmalayan ana anahtar. Sarmalanmış anahtar, hangi ana anahtarla
+// sarmalandığını (ID) ile birlikte saklanır; ana anahtar değişince eski anahtarlar "keys rewrap" ile yeniden sarmalanır.
+type MasterKey interface {
+	// ID: Yeni sarmalamalarda kullanılan ana anahtarın adı.
+	ID() string
+	Wrap(ctx context.Context, key []byte) (string, error)
+	Unwrap(ctx context.Context, masterKeyID, wrapped string) ([]byte, error)
+}
+
+// wrapAAD: Ana anahtarla şifrelenen değerin veri anahtarı olduğunu bağlar.
+var wrapAAD = []byte("sentiric-cdr-data-key")
+
+// FileMasterKey: Dosyadaki AES-256 ana anahtarları. Her satır "<id> <base64 32 bayt>" biçimindedir; ilk satır
+// yeni sarmalamalarda kullanılır, sonrakiler yalnızca eski anahtarları açmak içindir (rotasyon sırasında).
+type FileMasterKey struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// LoadFileMasterKey: Ana anahtar dosyasını okur. Boş satırlar ve "#" ile başlayan satırlar atlanır.
+func LoadFileMasterKey(path string) (*FileMasterKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ana anahtar dosyası okunamadı: %w", err)
+	}
+	m := &FileMasterKey{keys: map[string][]byte{}}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("ana anahtar dosyası satır %d: \"<id> <base64 anahtar>\" bekleniyor", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(key) != KeySize {
+			return nil, fmt.Errorf("ana anahtar dosyası satır %d: anahtar base64 kodlu 32 bayt olmalı", line)
+		}
+		if _, dup := m.keys[fields[0]]; dup {
+			return nil, fmt.Errorf("ana anahtar dosyası satır %d: tekrarlanan id %q", line, fields[0])
+		}
+		if m.activeID == "" {
+			m.activeID = fields[0]
+		}
+		m.keys[fields[0]] = key
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if m.activeID == "" {
+		return nil, errors.New("ana anahtar dosyasında anahtar yok")
+	}
+	return m, nil
+}
+
+func (m *FileMasterKey) ID() string {
+	return m.activeID
+}
+
+func (m *FileMasterKey) Wrap(_ context.Context, key []byte) (string, error) {
+	gcm, err := newGCM(m.keys[m.activeID])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, key, wrapAAD)), nil
+}
+
+func (m *FileMasterKey) Unwrap(_ context.Context, masterKeyID, wrapped string) ([]byte, error) {
+	master, ok := m.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("ana anahtar dosyasında %q yok", masterKeyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	gcm, err := newGCM(master)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], wrapAAD)
+}
+
+// TransitMasterKey: Ana anahtarı servis dışında tutan, HashiCorp Vault Transit uyumlu KMS. Anahtar materyali
+// servise hiç gelmez; sarmalama ve açma KMS'e yapılan çağrılarla yapılır. Sürüm bilgisi sarmalanmış değerde taşınır.
+type TransitMasterKey struct {
+	addr    string
+	token   string
+	keyName string
+	client  *http.Client
+}
+
+func NewTransitMasterKey(addr, token, keyName string) *TransitMasterKey {
+	return &TransitMasterKey{
+		addr:    strings.TrimSuffix(addr, "/"),
+		token:   token,
+		keyName: keyName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *TransitMasterKey) ID() string {
+	return "transit:" + t.keyName
+}
+
+func (t *TransitMasterKey) Wrap(ctx context.Context, key []byte) (string, error) {
+	var out struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	err := t.call(ctx, "encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)}, &out)
+	return out.Ciphertext, err
+}
+
+func (t *TransitMasterKey) Unwrap(ctx context.Context, masterKeyID, wrapped string) ([]byte, error) {
+	if masterKeyID != t.ID() {
+		return nil, fmt.Errorf("anahtar %q ile sarmalanmış, yapılandırılan KMS anahtarı %q", masterKeyID, t.ID())
+	}
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := t.call(ctx, "decrypt", map[string]string{"ciphertext": wrapped}, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
+
+func (t *TransitMasterKey) call(ctx context.Context, op string, body map[string]string, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/v1/transit/%s/%s", t.addr, op, t.keyName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", t.token)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("KMS %s çağrısı başarısız: %w", op, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("KMS %s çağrısı HTTP %d döndü", op, resp.StatusCode)
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("KMS yanıtı okunamadı: %w", err)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,255 @@
+// AÇIKLAMA: Bu paket, kesinleşen CDR'ları uluslararası gelir paylaşımı dolandırıcılığı (IRSF) ve trafik pompalama
+// kurallarından geçirir: tenant harcama hızı, yüksek riskli öneklere çağrılar, tek hedefe kısa çağrı patlamaları ve
+// mesai dışı yoğunluk. İhlaller kanıtlarıyla kaydedilir ve outbox üzerinden fraud.alert olarak yayınlanır.
+package fraud
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// EventFraudAlert: Kural ihlalinde outbox üzerinden yayınlanan olay.
+const EventFraudAlert = "fraud.alert"
+
+// Kurallar.
+const (
+	// RuleSpendVelocity: Tenant'ın son bir saatte biten çağrılarının toplam maliyeti eşiği aştı.
+	RuleSpendVelocity = "spend_velocity"
+	// RuleHighRiskPrefix: Son bir saatte yüksek riskli öneklere giden çağrı sayısı eşiğe ulaştı.
+	RuleHighRiskPrefix = "high_risk_prefix"
+	// RuleShortCallBurst: Patlama penceresinde tek hedefe giden kısa çağrı sayısı eşiğe ulaştı.
+	RuleShortCallBurst = "short_call_burst"
+	// RuleOffHoursSpike: Son bir saatte mesai dışında başlayan çağrı sayısı eşiğe ulaştı.
+	RuleOffHoursSpike = "off_hours_spike"
+)
+
+// Alarm önem dereceleri.
+const (
+	SeverityHigh   = "HIGH"
+	SeverityMedium = "MEDIUM"
+)
+
+// Any: Tüm tenant'ları kapsayan varsayılan eşik tanımı.
+const Any = "*"
+
+// rateWindow: Harcama, yüksek riskli önek ve mesai dışı kurallarının kayan penceresi. Alarmlar pencere
+// uzunluğundaki sabit dilimlerde tekilleştirilir: aynı kural için dilim başına en fazla bir alarm.
+const rateWindow = time.Hour
+
+// Validate: Eşikleri kaydedilmeden önce doğrular.
+func Validate(t repository.FraudThresholds) error {
+	if t.TenantID == "" {
+		return fmt.Errorf("tenant zorunludur ('%s' varsayılan anlamına gelir)", Any)
+	}
+	if t.SpendPerHour < 0 || t.HighRiskCallsPerHour < 0 || t.BurstCalls < 0 || t.OffHoursCallsPerHour < 0 ||
+		t.ShortCallSeconds < 0 {
+		return errors.New("eşikler negatif olamaz")
+	}
+	if t.BurstWindowMinutes < 1 || t.BurstWindowMinutes > 60 {
+		return fmt.Errorf("burst_window_minutes 1 ile 60 arasında olmalı: %d", t.BurstWindowMinutes)
+	}
+	if t.OffHoursStart < 0 || t.OffHoursStart > 23 || t.OffHoursEnd < 0 || t.OffHoursEnd > 23 {
+		return errors.New("mesai dışı saatleri 0 ile 23 arasında olmalı")
+	}
+	if t.OffHoursStart == t.OffHoursEnd {
+		return errors.New("mesai dışı başlangıç ve bitiş saati aynı olamaz")
+	}
+	if _, err := time.LoadLocation(t.Timezone); err != nil {
+		return fmt.Errorf("geçersiz timezone: %q", t.Timezone)
+	}
+	for _, p := range Prefixes(t.HighRiskPrefixes) {
+		if _, err := strconv.ParseUint(p, 10, 64); err != nil {
+			return fmt.Errorf("yüksek riskli önek yalnızca rakam içermeli: %q", p)
+		}
+	}
+	return nil
+}
+
+// Prefixes: Virgülle ayrılmış önek listesini ayrıştırır; '+' ve "00" uluslararası ön eki atılır.
+func Prefixes(list string) []string {
+	var out []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(p), "+"), "00")
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// MatchPrefix: Numaranın eşleştiği en uzun yüksek riskli öneki döner; eşleşme yoksa boş döner.
+func MatchPrefix(prefixes []string, number string) string {
+	n := privacy.NormalizeNumber(number)
+	n = strings.TrimPrefix(n, "00")
+	best := ""
+	for _, p := range prefixes {
+		if strings.HasPrefix(n, p) && len(p) > len(best) {
+			best = p
+		}
+	}
+	return best
+}
+
+// InOffHours: Anın tenant'ın yerel saatiyle mesai dışında olup olmadığını söyler.
+func InOffHours(t repository.FraudThresholds, at time.Time) bool {
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	h := at.In(loc).Hour()
+	if t.OffHoursStart < t.OffHoursEnd {
+		return h >= t.OffHoursStart && h < t.OffHoursEnd
+	}
+	return h >= t.OffHoursStart || h < t.OffHoursEnd
+}
+
+type Detector struct {
+	repo *repository.FraudRepository
+	log  zerolog.Logger
+}
+
+func NewDetector(db *sql.DB, keys *repository.Keyring, log zerolog.Logger) *Detector {
+	return &Detector{repo: repository.NewFraudRepository(db, keys), log: log}
+}
+
+// Check: Kesinleşen çağrıyı tenant'ın eşikleriyle kurallardan geçirir. Pencereler çağrının bitiş anına göre
+// hesaplanır; geç veya tekrar işlenen çağrı aynı pencereleri görür ve kayıtlı alarmı tekrarlamaz.
+func (d *Detector) Check(ctx context.Context, callID string) error {
+	c, err := d.repo.GetCall(ctx, callID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !c.EndTime.Valid || c.Direction == "INTERNAL" {
+		return nil
+	}
+	t, err := d.repo.GetThresholds(ctx, c.TenantID)
+	if err != nil {
+		return err
+	}
+	if !t.Enabled {
+		return nil
+	}
+
+	prefix := ""
+	if c.Direction == "OUTBOUND" {
+		prefix = MatchPrefix(Prefixes(t.HighRiskPrefixes), c.CalleeNumber)
+	}
+	if err := d.repo.SetRiskPrefix(ctx, callID, prefix); err != nil {
+		return err
+	}
+
+	alerts, err := d.evaluate(ctx, c, t, prefix)
+	if err != nil {
+		return err
+	}
+	for i := range alerts {
+		a := &alerts[i]
+		created, err := d.repo.RecordAlert(ctx, a, EventFraudAlert)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+		metrics.FraudAlerts.WithLabelValues(a.Rule).Inc()
+		d.log.Warn().Int64("alert_id", a.ID).Str("tenant_id", a.TenantID).Str("rule", a.Rule).
+			Str("severity", a.Severity).Str("call_id", a.CallID).Interface("evidence", a.Evidence).
+			Msg("🚨 Dolandırıcılık alarmı üretildi")
+	}
+	return nil
+}
+
+// evaluate: Çağrının tetikleyebileceği kuralların pencerelerini okur ve eşiği aşanları alarm olarak döner.
+func (d *Detector) evaluate(ctx context.Context, c repository.FraudCall, t repository.FraudThresholds, prefix string) ([]repository.FraudAlert, error) {
+	end := c.EndTime.Time.UTC()
+	hour := repository.FraudWindowFilter{TenantID: c.TenantID, From: end.Add(-rateWindow), To: end, MaxDuration: -1}
+	var alerts []repository.FraudAlert
+	newAlert := func(rule, severity string, window time.Duration, key string, evidence map[string]interface{}) {
+		dedup := end.Truncate(window).Format(time.RFC3339)
+		if key != "" {
+			dedup += "|" + key
+		}
+		alerts = append(alerts, repository.FraudAlert{
+			TenantID: c.TenantID, Rule: rule, Severity: severity, DedupKey: dedup, CallID: c.CallID,
+			WindowStart: end.Add(-window), WindowEnd: end, Evidence: evidence,
+		})
+	}
+
+	if t.SpendPerHour > 0 {
+		w, err := d.repo.Window(ctx, hour)
+		if err != nil {
+			return nil, err
+		}
+		if w.Spend >= t.SpendPerHour {
+			newAlert(RuleSpendVelocity, SeverityHigh, rateWindow, "", map[string]interface{}{
+				"spend": w.Spend, "threshold": t.SpendPerHour, "calls": w.Calls, "call_ids": w.CallIDs,
+			})
+		}
+	}
+
+	if t.HighRiskCallsPerHour > 0 && prefix != "" {
+		f := hour
+		f.RiskOnly = true
+		w, err := d.repo.Window(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		if w.Calls >= t.HighRiskCallsPerHour {
+			newAlert(RuleHighRiskPrefix, SeverityHigh, rateWindow, "", map[string]interface{}{
+				"prefix": prefix, "calls": w.Calls, "threshold": t.HighRiskCallsPerHour, "spend": w.Spend,
+				"call_ids": w.CallIDs,
+			})
+		}
+	}
+
+	if t.BurstCalls > 0 && c.DurationSeconds <= t.ShortCallSeconds && c.DestinationKey != "" {
+		window := time.Duration(t.BurstWindowMinutes) * time.Minute
+		w, err := d.repo.Window(ctx, repository.FraudWindowFilter{
+			TenantID: c.TenantID, From: end.Add(-window), To: end,
+			DestinationKey: c.DestinationKey, MaxDuration: t.ShortCallSeconds,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if w.Calls >= t.BurstCalls {
+			// Hedef, alarm kaydında numara tutulmaması için özetle ayırt edilir.
+			sum := sha256.Sum256([]byte(c.DestinationKey))
+			newAlert(RuleShortCallBurst, SeverityMedium, window, hex.EncodeToString(sum[:8]), map[string]interface{}{
+				"calls": w.Calls, "threshold": t.BurstCalls, "max_duration_seconds": t.ShortCallSeconds,
+				"window_minutes": t.BurstWindowMinutes, "call_ids": w.CallIDs,
+			})
+		}
+	}
+
+	if t.OffHoursCallsPerHour > 0 && InOffHours(t, c.StartTime) {
+		f := hour
+		f.OffHoursTimezone, f.OffHoursStart, f.OffHoursEnd = t.Timezone, t.OffHoursStart, t.OffHoursEnd
+		w, err := d.repo.Window(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		if w.Calls >= t.OffHoursCallsPerHour {
+			newAlert(RuleOffHoursSpike, SeverityMedium, rateWindow, "", map[string]interface{}{
+				"calls": w.Calls, "threshold": t.OffHoursCallsPerHour, "timezone": t.Timezone,
+				"off_hours": fmt.Sprintf("%02d:00-%02d:00", t.OffHoursStart, t.OffHoursEnd), "call_ids": w.CallIDs,
+			})
+		}
+	}
+	return alerts, nil
+}
@@ -0,0 +1,78 @@
+// sentiric-cdr-service/internal/fraud/fraud_test.go
+package fraud
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func TestPrefixes(t *testing.T) {
+	cases := []struct {
+		list string
+		want []string
+	}{
+		{"882, +881,00252", []string{"882", "881", "252"}},
+		{" , ,", nil},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := Prefixes(c.list); !slices.Equal(got, c.want) {
+			t.Errorf("Prefixes(%q) = %q, beklenen %q", c.list, got, c.want)
+		}
+	}
+}
+
+func TestMatchPrefix(t *testing.T) {
+	prefixes := []string{"88", "882", "8821", "252"}
+	cases := []struct {
+		name   string
+		number string
+		want   string
+	}{
+		{"en uzun önek", "+88216001234", "8821"},
+		{"kısa önek", "+88301234567", "88"},
+		{"00 ön eki", "0025261234567", "252"},
+		{"ayraçlı numara", "+252 61 234 5678", "252"},
+		{"eşleşme yok", "+905321234567", ""},
+		{"yerel numara ülke koduna çevrilir", "05321234567", ""},
+		{"boş numara", "", ""},
+	}
+	for _, c := range cases {
+		if got := MatchPrefix(prefixes, c.number); got != c.want {
+			t.Errorf("%s: MatchPrefix(%q) = %q, beklenen %q", c.name, c.number, got, c.want)
+		}
+	}
+}
+
+func TestInOffHours(t *testing.T) {
+	night := repository.FraudThresholds{OffHoursStart: 22, OffHoursEnd: 6, Timezone: "UTC"}
+	day := repository.FraudThresholds{OffHoursStart: 12, OffHoursEnd: 14, Timezone: "UTC"}
+	istanbul := repository.FraudThresholds{OffHoursStart: 22, OffHoursEnd: 6, Timezone: "Europe/Istanbul"}
+	invalid := repository.FraudThresholds{OffHoursStart: 22, OffHoursEnd: 6, Timezone: "Mars/Olympus"}
+	at := func(hour int) time.Time { return time.Date(2025, 3, 10, hour, 30, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name string
+		t    repository.FraudThresholds
+		at   time.Time
+		want bool
+	}{
+		{"gece yarısını aşan aralık, gece", night, at(23), true},
+		{"gece yarısını aşan aralık, sabah", night, at(5), true},
+		{"gece yarısını aşan aralık, bitiş saati", night, at(6), false},
+		{"gece yarısını aşan aralık, gündüz", night, at(12), false},
+		{"gün içi aralık, başlangıç saati", day, at(12), true},
+		{"gün içi aralık, dışında", day, at(15), false},
+		{"yerel saat dilimi", istanbul, at(20), true},
+		{"yerel saat dilimi, sabah", istanbul, at(3), false},
+		{"geçersiz saat dilimi UTC'ye düşer", invalid, at(23), true},
+	}
+	for _, c := range cases {
+		if got := InOffHours(c.t, c.at); got != c.want {
+			t.Errorf("%s: InOffHours = %v, beklenen %v", c.name, got, c.want)
+		}
+	}
+}
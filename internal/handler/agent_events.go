@@ -0,0 +1,89 @@
+// sentiric-cdr-service/internal/handler/agent_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// İnsan temsilciye devir olayları. Olayın trace_id'si çağrının call_id'sidir; olay zamanı olayın zaman damgasıdır.
+const (
+	eventQueueEntered      = "call.queue.entered"
+	eventAgentConnected    = "call.agent.connected"
+	eventAgentDisconnected = "call.agent.disconnected"
+)
+
+var agentEventKinds = map[string]string{
+	eventQueueEntered:      repository.AgentQueueEntered,
+	eventAgentConnected:    repository.AgentConnected,
+	eventAgentDisconnected: repository.AgentDisconnected,
+}
+
+type agentPayload struct {
+	Queue           string `json:"queue"`              // call.queue.entered (zorunlu), diğerlerinde isteğe bağlı
+	AgentID         string `json:"agent_id"`           // call.agent.connected (zorunlu), kullanıcı UUID'si
+	AfterCallWorkMs *int64 `json:"after_call_work_ms"` // call.agent.disconnected, wrap-up süresi
+}
+
+func (p agentPayload) validate(eventType string) error {
+	switch eventType {
+	case eventQueueEntered:
+		if p.Queue == "" {
+			return errors.New("queue zorunludur")
+		}
+	case eventAgentConnected:
+		if p.AgentID == "" {
+			return errors.New("agent_id zorunludur")
+		}
+	}
+	if p.AgentID != "" {
+		if _, err := uuid.Parse(p.AgentID); err != nil {
+			return errors.New("agent_id geçerli bir UUID olmalı")
+		}
+	}
+	if p.AfterCallWorkMs != nil && *p.AfterCallWorkMs < 0 {
+		return errors.New("after_call_work_ms negatif olamaz")
+	}
+	return nil
+}
+
+// processAgentEvent: Kuyruğa giriş, temsilci bağlantısı ve ayrılma olaylarını çağrıya işler; temsilci
+// ayrıldığında temsilci rollup'ları güncellenir.
+func (h *EventHandler) processAgentEvent(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+
+	var p agentPayload
+	err := json.Unmarshal([]byte(event.PayloadJson), &p)
+	if err == nil {
+		err = p.validate(event.EventType)
+	}
+	if err != nil {
+		l.Warn().Err(err).Msg("Temsilci olayı payload'ı okunamadı, işlenmedi.")
+		h.eventsFailed.WithLabelValues(event.EventType, "payload_error").Inc()
+		return queue.Ack
+	}
+
+	err = h.repo.ApplyAgentEvent(context.Background(), event.TraceId, agentEventKinds[event.EventType], repository.AgentEvent{
+		Queue:           p.Queue,
+		AgentUserID:     p.AgentID,
+		At:              event.Timestamp.AsTime(),
+		AfterCallWorkMs: p.AfterCallWorkMs,
+	})
+	if errors.Is(err, repository.ErrCallNotFound) {
+		l.Warn().Msg("Çağrı kaydı DB'de yok, CallStarted gecikmiş olabilir. Retry ediliyor.")
+		return queue.NackRetry
+	}
+	if err != nil {
+		l.Error().Err(err).Msg("Temsilci bilgisi DB'ye yazılamadı")
+		return queue.NackRetry
+	}
+	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
+	return queue.Ack
+}
@@ -0,0 +1,100 @@
+// sentiric-cdr-service/internal/handler/call_timing.go
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// durationBreakdown: Kontakt merkezi panellerinin ihtiyaç duyduğu süre kırılımı (saniye).
+// Toplam ve faturalanabilir süre ayrıca milisaniye hassasiyetinde tutulur.
+type durationBreakdown struct {
+	ring       int // Çalma başlangıcından (yoksa çağrı başlangıcından) cevaba veya bitişe kadar
+	total      int // Çağrı başlangıcından bitişe kadar
+	billable   int // Faturalanan süre (cevaplanmadıysa 0)
+	hold       int // Beklemede geçen toplam süre
+	talk       int // billable - hold
+	totalMs    int64
+	billableMs int64
+}
+
+func computeDurations(t repository.CallTimeline, endTime time.Time, disposition string, durationMs int64) durationBreakdown {
+	var b durationBreakdown
+
+	if t.StartTime.Valid {
+		b.totalMs = max(0, endTime.Sub(t.StartTime.Time).Milliseconds())
+		b.total = int(b.totalMs / 1000)
+
+		ringFrom := t.StartTime.Time
+		if t.RingingTime.Valid {
+			ringFrom = t.RingingTime.Time
+		}
+		ringTo := endTime
+		if t.AnswerTime.Valid {
+			ringTo = t.AnswerTime.Time
+		}
+		b.ring = secondsBetween(ringFrom, ringTo)
+	}
+
+	if disposition == "ANSWERED" {
+		b.billableMs = durationMs
+		b.billable = int(durationMs / 1000)
+	}
+
+	// Kapatılmadan biten bekleme aralığı çağrı sonunda kapanmış sayılır.
+	b.hold = t.HoldSeconds
+	if t.HoldStartedAt.Valid {
+		b.hold += secondsBetween(t.HoldStartedAt.Time, endTime)
+	}
+	if b.hold > b.billable {
+		b.hold = b.billable
+	}
+	b.talk = b.billable - b.hold
+
+	return b
+}
+
+func secondsBetween(from, to time.Time) int {
+	d := int(to.Sub(from).Seconds())
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// processTimingEvent: call.ringing, call.hold ve call.resumed olaylarını çağrı kaydına işler.
+func (h *EventHandler) processTimingEvent(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+	ctx := context.Background()
+	ts := event.Timestamp.AsTime()
+
+	var err error
+	switch event.EventType {
+	case "call.ringing":
+		err = h.repo.SetRingingTime(ctx, event.TraceId, ts)
+	case "call.hold":
+		err = h.repo.StartHold(ctx, event.TraceId, ts)
+	case "call.resumed":
+		err = h.repo.EndHold(ctx, event.TraceId, ts)
+	}
+
+	if errors.Is(err, repository.ErrCallNotFound) {
+		l.Warn().Msg("Çağrı kaydı DB'de yok, CallStarted gecikmiş olabilir. Retry ediliyor.")
+		return queue.NackRetry
+	}
+	if errors.Is(err, repository.ErrHoldNotStarted) {
+		l.Warn().Msg("call.resumed, call.hold olayından önce geldi. Retry ediliyor.")
+		return queue.NackRetry
+	}
+	if err != nil {
+		l.Error().Err(err).Msg("Zamanlama olayı DB'ye yazılamadı")
+		return queue.NackRetry
+	}
+	h.live.Publish(event.EventType, event.TraceId, ts)
+	return queue.Ack
+}
@@ -0,0 +1,101 @@
+// sentiric-cdr-service/internal/handler/conversation_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// Konuşma çıktısı olayları. Olayın trace_id'si çağrının call_id'sidir.
+const (
+	eventTranscriptReady   = "call.transcript.ready"
+	eventSummaryReady      = "call.summary.ready"
+	eventSentimentAnalyzed = "call.sentiment.analyzed"
+)
+
+// maxSummaryLength, maxIntents: Tek olayda kabul edilen en uzun özet (karakter) ve en fazla niyet.
+const (
+	maxSummaryLength = 8000
+	maxIntents       = 20
+)
+
+type conversationPayload struct {
+	TranscriptURI  string   `json:"transcript_uri"`  // call.transcript.ready
+	Summary        string   `json:"summary"`         // call.summary.ready
+	Language       string   `json:"language"`        // transkript ve özet
+	SentimentScore *float64 `json:"sentiment_score"` // call.sentiment.analyzed, -1 ile +1 arası
+	Intents        []string `json:"intents"`         // call.sentiment.analyzed
+	AgentHandoff   bool     `json:"agent_handoff"`   // call.sentiment.analyzed
+	HandoffReason  string   `json:"handoff_reason"`  // call.sentiment.analyzed
+}
+
+func (p conversationPayload) validate(eventType string) error {
+	switch eventType {
+	case eventTranscriptReady:
+		if p.TranscriptURI == "" {
+			return errors.New("transcript_uri zorunludur")
+		}
+	case eventSummaryReady:
+		if p.Summary == "" {
+			return errors.New("summary zorunludur")
+		}
+		if len([]rune(p.Summary)) > maxSummaryLength {
+			return errors.New("summary çok uzun")
+		}
+	case eventSentimentAnalyzed:
+		if p.SentimentScore == nil || *p.SentimentScore < -1 || *p.SentimentScore > 1 {
+			return errors.New("sentiment_score -1 ile 1 arasında olmalı")
+		}
+		if len(p.Intents) > maxIntents {
+			return errors.New("intents çok fazla")
+		}
+	}
+	return nil
+}
+
+// processConversation: Transkript, özet ve duygu analizi olaylarını çağrı kaydına işler.
+func (h *EventHandler) processConversation(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+	ctx := context.Background()
+
+	var p conversationPayload
+	err := json.Unmarshal([]byte(event.PayloadJson), &p)
+	if err == nil {
+		err = p.validate(event.EventType)
+	}
+	if err != nil {
+		l.Warn().Err(err).Msg("Konuşma olayı payload'ı okunamadı, işlenmedi.")
+		h.eventsFailed.WithLabelValues(event.EventType, "payload_error").Inc()
+		return queue.Ack
+	}
+
+	switch event.EventType {
+	case eventTranscriptReady:
+		err = h.repo.SetTranscript(ctx, event.TraceId, p.TranscriptURI, p.Language)
+	case eventSummaryReady:
+		err = h.repo.SetSummary(ctx, event.TraceId, p.Summary, p.Language)
+	case eventSentimentAnalyzed:
+		err = h.repo.SetSentiment(ctx, event.TraceId, repository.SentimentAnalysis{
+			Score:         *p.SentimentScore,
+			Intents:       p.Intents,
+			AgentHandoff:  p.AgentHandoff,
+			HandoffReason: p.HandoffReason,
+		})
+	}
+
+	if errors.Is(err, repository.ErrCallNotFound) {
+		l.Warn().Msg("Çağrı kaydı DB'de yok, CallStarted gecikmiş olabilir. Retry ediliyor.")
+		return queue.NackRetry
+	}
+	if err != nil {
+		l.Error().Err(err).Msg("Konuşma çıktısı DB'ye yazılamadı")
+		return queue.NackRetry
+	}
+	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
+	return queue.Ack
+}
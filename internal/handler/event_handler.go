@@ -5,29 +5,52 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+	"github.com/sentiric/sentiric-cdr-service/internal/concurrency"
+	"github.com/sentiric/sentiric-cdr-service/internal/fraud"
 	"github.com/sentiric/sentiric-cdr-service/internal/logger"
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/outbox"
 	"github.com/sentiric/sentiric-cdr-service/internal/queue"
 	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	"github.com/sentiric/sentiric-cdr-service/internal/stream"
 	"github.com/sentiric/sentiric-cdr-service/internal/utils"
+	"github.com/sentiric/sentiric-cdr-service/internal/webhook"
 	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
 )
 
 type EventHandler struct {
 	repo            *repository.CallRepository
+	recordings      *repository.RecordingRepository
+	webhooks        *repository.WebhookRepository
+	kpis            *repository.KPIRepository
+	fraud           *fraud.Detector
+	masks           *masking.Engine
+	concurrency     *concurrency.Tracker
+	live            *stream.Publisher
 	log             zerolog.Logger
 	eventsProcessed *prometheus.CounterVec
 	eventsFailed    *prometheus.CounterVec
 }
 
-func NewEventHandler(db *sql.DB, log zerolog.Logger, processed, failed *prometheus.CounterVec) *EventHandler {
+func NewEventHandler(db *sql.DB, keys *repository.Keyring, masks *masking.Engine, tracker *concurrency.Tracker, live *stream.Publisher, log zerolog.Logger, processed, failed *prometheus.CounterVec) *EventHandler {
 	return &EventHandler{
-		repo:            repository.NewCallRepository(db, log),
+		repo:            repository.NewCallRepository(db, keys, log),
+		recordings:      repository.NewRecordingRepository(db),
+		webhooks:        repository.NewWebhookRepository(db),
+		kpis:            repository.NewKPIRepository(db),
+		fraud:           fraud.NewDetector(db, keys, log),
+		masks:           masks,
+		concurrency:     tracker,
+		live:            live,
 		log:             log,
 		eventsProcessed: processed,
 		eventsFailed:    failed,
@@ -52,7 +75,7 @@ func (h *EventHandler) HandleEvent(body []byte) queue.HandlerResult {
 
 	var recordingEvent eventv1.CallRecordingAvailableEvent
 	if err := proto.Unmarshal(body, &recordingEvent); err == nil && recordingEvent.EventType == "call.recording.available" {
-		return h.processRecordingAvailable(recordingEvent.CallId, recordingEvent.RecordingUri)
+		return h.processRecordingAvailable(recordingFromProto(&recordingEvent))
 	}
 
 	var genericEvent eventv1.GenericEvent
@@ -69,7 +92,13 @@ func (h *EventHandler) HandleEvent(body []byte) queue.HandlerResult {
 		}
 		if uri, ok := jsonEvent["uri"].(string); ok {
 			if callId, ok := jsonEvent["callId"].(string); ok {
-				return h.processRecordingAvailable(callId, uri)
+				rec, err := recordingFromJSON(body)
+				if err != nil {
+					h.log.Warn().Err(err).Str("call_id", callId).Str("uri", uri).Msg("Kayıt olayı payload'ı okunamadı, işlenmedi.")
+					h.eventsFailed.WithLabelValues(eventRecordingAvailable, "payload_error").Inc()
+					return queue.Ack
+				}
+				return h.processRecordingAvailable(rec)
 			}
 		}
 	}
@@ -103,6 +132,17 @@ func (h *EventHandler) processCallStarted(body []byte, event *eventv1.CallStarte
 	callerNum := utils.ParseSipUri(event.FromUri)
 	calleeNum := utils.ParseSipUri(event.ToUri)
 	direction := utils.DetermineDirection(callerNum, calleeNum)
+	// Contract'ta trunk alanı yok; karşı SIP uç noktasının host'u trunk kabul edilir.
+	trunk := ""
+	switch direction {
+	case "INBOUND":
+		trunk = utils.ParseSipHost(event.FromUri)
+	case "OUTBOUND":
+		trunk = utils.ParseSipHost(event.ToUri)
+	}
+
+	lm := h.logMasker(tenantID)
+	l = l.With().Str("caller", lm.Mask(callerNum)).Str("callee", lm.Mask(calleeNum)).Logger()
 
 	data := repository.CallStartData{
 		CallID:       event.CallId,
@@ -110,6 +150,8 @@ func (h *EventHandler) processCallStarted(body []byte, event *eventv1.CallStarte
 		CallerNumber: callerNum,
 		CalleeNumber: calleeNum,
 		Direction:    direction,
+		DestGroup:    utils.DestinationGroup(direction, calleeNum),
+		Trunk:        trunk,
 		StartTime:    event.Timestamp.AsTime(),
 		UserID:       userID,
 		ContactID:    contactID,
@@ -119,8 +161,14 @@ func (h *EventHandler) processCallStarted(body []byte, event *eventv1.CallStarte
 		l.Error().Err(err).Msg("DB Write Error (CallStarted)")
 		return queue.NackRetry
 	}
+	if err := h.attachPendingRecordings(context.Background(), event.CallId, event.Timestamp.AsTime()); err != nil {
+		l.Error().Err(err).Msg("Bekleyen ses kayıtları çağrıya bağlanamadı")
+		return queue.NackRetry
+	}
+	h.concurrency.Started(tenantID, event.CallId)
+	h.live.Publish(event.EventType, event.CallId, event.Timestamp.AsTime())
 
-	_ = h.repo.LogEvent(context.Background(), event.CallId, event.EventType, event.Timestamp.AsTime(), "{}")
+	_ = h.repo.LogEvent(context.Background(), tenantID, event.CallId, event.EventType, event.Timestamp.AsTime(), "{}")
 
 	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
 	return queue.Ack
@@ -129,7 +177,7 @@ func (h *EventHandler) processCallStarted(body []byte, event *eventv1.CallStarte
 func (h *EventHandler) processCallEnded(body []byte, event *eventv1.CallEndedEvent) queue.HandlerResult {
 	l := h.log.With().Str("call_id", event.CallId).Logger()
 
-	startTime, answerTime, tenantID, err := h.repo.GetCallDates(context.Background(), event.CallId)
+	timeline, err := h.repo.GetCallTimeline(context.Background(), event.CallId)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			l.Warn().Msg("Çağrı kaydı DB'de yok, CallStarted gecikmiş olabilir. Retry ediliyor.")
@@ -138,20 +186,24 @@ func (h *EventHandler) processCallEnded(body []byte, event *eventv1.CallEndedEve
 		l.Error().Err(err).Msg("Çağrı kaydı okunamadı.")
 		return queue.NackRetry
 	}
+	tenantID := timeline.TenantID
+	startTime, answerTime := timeline.StartTime, timeline.AnswerTime
 
 	endTime := event.Timestamp.AsTime()
-	duration := 0
+	var durationMs int64
 	disposition := "NO_ANSWER"
 
+	// Süreler milisaniye hassasiyetinde tutulur; yuvarlama yalnızca derecelendirmede yapılır.
 	if answerTime.Valid {
-		duration = int(endTime.Sub(answerTime.Time).Seconds())
+		durationMs = endTime.Sub(answerTime.Time).Milliseconds()
 		disposition = "ANSWERED"
 	} else if startTime.Valid {
-		duration = int(endTime.Sub(startTime.Time).Seconds())
+		durationMs = endTime.Sub(startTime.Time).Milliseconds()
 	}
-	if duration < 0 {
-		duration = 0
+	if durationMs < 0 {
+		durationMs = 0
 	}
+	duration := int(durationMs / 1000)
 
 	if !answerTime.Valid && duration > 0 && event.Reason == "normal_clearing" {
 		disposition = "ANSWERED"
@@ -170,8 +222,10 @@ func (h *EventHandler) processCallEnded(body []byte, event *eventv1.CallEndedEve
 		disposition = "ANSWERED" // Biz kapattıysak mutlaka cevaplanmıştır
 	}
 
-	if disposition == "ANSWERED" && duration > 0 {
-		if err := h.calculateAndRecordUsage(context.Background(), event.CallId, tenantID, duration); err != nil {
+	breakdown := computeDurations(timeline, endTime, disposition, durationMs)
+
+	if disposition == "ANSWERED" && breakdown.billableMs > 0 {
+		if err := h.calculateAndRecordUsage(context.Background(), event.CallId, tenantID, breakdown.billableMs, endTime); err != nil {
 			return queue.NackRetry
 		}
 	}
@@ -183,23 +237,71 @@ func (h *EventHandler) processCallEnded(body []byte, event *eventv1.CallEndedEve
 		Disposition:     disposition,
 		HangupSource:    hangupSource,
 		SipCode:         0,
+		RingSeconds:     breakdown.ring,
+		TotalSeconds:    breakdown.total,
+		BillableSeconds: breakdown.billable,
+		HoldSeconds:     breakdown.hold,
+		TalkSeconds:     breakdown.talk,
+		TotalMs:         breakdown.totalMs,
+		BillableMs:      breakdown.billableMs,
 	}
 
-	if err := h.repo.UpdateCallEnd(context.Background(), updateData); err != nil {
+	// Mühürlenmiş çağrıda bitiş alanları değişmez; tekrar işleme kalan adımları (webhook, KPI) tamamlamak için sürer.
+	if err := h.repo.UpdateCallEnd(context.Background(), updateData); errors.Is(err, repository.ErrCallSealed) {
+		l.Warn().Msg("Çağrı hash zincirine mühürlenmiş; tekrar gelen call.ended bitiş alanlarını değiştirmedi.")
+	} else if err != nil {
 		l.Error().Err(err).Msg("DB Write Error (CallEnded)")
 		return queue.NackRetry
 	}
+	h.concurrency.Ended(tenantID, event.CallId)
+
+	// IVR'dan çıkış olayı gelmeden biten çağrı IVR'da kapatılmış (hangup) sayılır.
+	if err := h.repo.InferIVRHangup(context.Background(), event.CallId, endTime); err != nil {
+		l.Error().Err(err).Msg("IVR çıkışı yazılamadı.")
+		return queue.NackRetry
+	}
+	// Kuyrukta temsilciye bağlanmadan biten çağrının bekleme süresi bitişe göre tamamlanır.
+	if err := h.repo.RefreshAgentHandling(context.Background(), event.CallId); err != nil {
+		l.Error().Err(err).Msg("Kuyruk bekleme süresi yazılamadı.")
+		return queue.NackRetry
+	}
+
+	// Çağrıdan önce gelmiş AI usage satırları da toplam maliyete yansısın.
+	_ = h.repo.RecomputeCallCost(context.Background(), event.CallId)
+
+	// Kesinleşen CDR tenant hash zincirine eklenir; maliyet ve kayıt URL'si sonradan değişebildiği için özete girmez.
+	if err := h.repo.SealCall(context.Background(), event.CallId); err != nil {
+		l.Error().Err(err).Msg("CDR hash zincirine eklenemedi.")
+		return queue.NackRetry
+	}
+
+	if err := h.enqueueCallWebhook(context.Background(), webhook.EventCallCompleted, tenantID, event.CallId, endTime); err != nil {
+		l.Error().Err(err).Msg("Webhook teslimi kuyruğa alınamadı.")
+		return queue.NackRetry
+	}
+
+	// KPI rollup'ları çağrının katkısıyla güncellenir; olay tekrar işlenirse katkı iki kez eklenmez.
+	if err := h.kpis.RefreshCall(context.Background(), event.CallId); err != nil {
+		l.Error().Err(err).Msg("KPI rollup'ları güncellenemedi.")
+		return queue.NackRetry
+	}
+	// Dolandırıcılık kuralları çağrının bitiş anındaki pencerelerle değerlendirilir; tekrar işlenen olay alarmı tekrarlamaz.
+	if err := h.fraud.Check(context.Background(), event.CallId); err != nil {
+		l.Error().Err(err).Msg("Dolandırıcılık kuralları değerlendirilemedi.")
+		return queue.NackRetry
+	}
+	h.live.Publish(event.EventType, event.CallId, endTime)
 
 	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
 	return queue.Ack
 }
 
-func (h *EventHandler) calculateAndRecordUsage(ctx context.Context, callID, tenantID string, duration int) error {
-	if duration <= 0 {
+func (h *EventHandler) calculateAndRecordUsage(ctx context.Context, callID, tenantID string, billableMs int64, ratedAt time.Time) error {
+	if billableMs <= 0 {
 		return nil
 	}
 
-	exists, err := h.repo.CheckUsageExists(ctx, callID, "telephony_minute")
+	exists, err := h.repo.CheckUsageExists(ctx, callID, billing.ResourceTelephonyMinute)
 	if err != nil {
 		return err
 	}
@@ -207,44 +309,99 @@ func (h *EventHandler) calculateAndRecordUsage(ctx context.Context, callID, tena
 		return nil
 	}
 
-	costPerUnit := 0.005
-	minutes := float64(duration) / 60.0
+	policy, err := h.repo.GetRoundingPolicy(ctx, tenantID)
+	if err != nil {
+		h.log.Error().Err(err).Str("tenant_id", tenantID).Msg("Yuvarlama politikası okunamadı!")
+		return err
+	}
+	ratedSeconds := policy.BillableSeconds(billableMs)
+
+	costPerUnit, rateVersion, err := h.repo.GetUnitPrice(ctx, tenantID, billing.ResourceTelephonyMinute, ratedAt)
+	if err != nil {
+		h.log.Error().Err(err).Str("tenant_id", tenantID).Msg("Birim fiyat okunamadı!")
+		return err
+	}
+	minutes := float64(ratedSeconds) / 60.0
 	totalCost := minutes * costPerUnit
 
-	if err := h.repo.CreateUsageRecord(ctx, tenantID, callID, "telephony-core", "telephony_minute", minutes, totalCost); err != nil {
+	usage := repository.UsageRecord{
+		TenantID:       tenantID,
+		CallID:         callID,
+		ServiceName:    "telephony-core",
+		ResourceType:   billing.ResourceTelephonyMinute,
+		Quantity:       minutes,
+		UnitPrice:      costPerUnit,
+		Cost:           totalCost,
+		RateVersion:    rateVersion,
+		RawDurationMs:  billableMs,
+		RatedSeconds:   ratedSeconds,
+		RoundingPolicy: policy.String(),
+	}
+	if err := h.repo.CreateUsageRecord(ctx, usage); err != nil {
 		h.log.Error().Err(err).Msg("Usage record oluşturulamadı!")
 		return err
 	}
 
-	_ = h.repo.UpdateCost(ctx, callID, totalCost)
+	_ = h.repo.RecomputeCallCost(ctx, callID)
 
 	h.log.Info().Str("call_id", callID).Float64("cost", totalCost).Msg("💰 Fatura kaydı oluşturuldu.")
 	return nil
 }
 
-func (h *EventHandler) processRecordingAvailable(callId string, uri string) queue.HandlerResult {
-	if err := h.repo.UpdateRecording(context.Background(), callId, uri); err != nil {
-		h.log.Error().Err(err).Msg("Recording Update Error")
-		return queue.NackRetry
+func (h *EventHandler) handleGenericEvent(event *eventv1.GenericEvent, rawBody []byte) queue.HandlerResult {
+	if outbox.IsOwnEvent(event.EventType) {
+		return queue.Ack
 	}
-	h.log.Info().Str("uri", uri).Msg("🎙️ Ses kaydı DB'ye işlendi.")
-	h.eventsProcessed.WithLabelValues("call.recording.available").Inc()
-	return queue.Ack
-}
 
-func (h *EventHandler) handleGenericEvent(event *eventv1.GenericEvent, rawBody []byte) queue.HandlerResult {
-	if event.EventType == "call.answered" {
+	switch event.EventType {
+	case "call.answered":
 		if err := h.repo.SetAnswerTime(context.Background(), event.TraceId, event.Timestamp.AsTime()); err != nil {
 			return queue.NackRetry
 		}
+		h.live.Publish(event.EventType, event.TraceId, event.Timestamp.AsTime())
+	case "call.ringing", "call.hold", "call.resumed":
+		if result := h.processTimingEvent(event); result != queue.Ack {
+			return result
+		}
+	case "call.transferred", "call.bridged", "call.forked", "call.conference.joined":
+		if result := h.processLegLink(event); result != queue.Ack {
+			return result
+		}
+	case "stt.usage.recorded", "tts.usage.recorded", "llm.usage.recorded":
+		if result := h.processAIUsage(event); result != queue.Ack {
+			return result
+		}
+	case eventMediaQualitySummary:
+		if result := h.processMediaQuality(event); result != queue.Ack {
+			return result
+		}
+	case eventTranscriptReady, eventSummaryReady, eventSentimentAnalyzed:
+		if result := h.processConversation(event); result != queue.Ack {
+			return result
+		}
+	case eventIVRNodeEntered, eventDTMFReceived, eventIVRExited:
+		if result := h.processIVR(event); result != queue.Ack {
+			return result
+		}
+	case eventQueueEntered, eventAgentConnected, eventAgentDisconnected:
+		if result := h.processAgentEvent(event); result != queue.Ack {
+			return result
+		}
 	}
 
 	payloadStr := "{}"
 	if len(event.PayloadJson) > 0 {
 		payloadStr = event.PayloadJson
 	}
+	switch event.EventType {
+	case eventSummaryReady:
+		// Özet metni call_events'e kopyalanmaz; silme talebi yalnızca calls.ai_summary'yi temizler.
+		payloadStr = "{}"
+	case eventDTMFReceived:
+		payloadStr = dtmfEventPayload(payloadStr)
+	}
 
-	err := h.repo.LogEvent(context.Background(), event.TraceId, event.EventType, event.Timestamp.AsTime(), payloadStr)
+	err := h.repo.LogEvent(context.Background(), event.TenantId, event.TraceId, event.EventType, event.Timestamp.AsTime(), payloadStr)
 
 	if err != nil {
 		h.log.Error().Err(err).Msg("LogEvent DB'ye yazılamadı")
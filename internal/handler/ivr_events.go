@@ -0,0 +1,134 @@
+// sentiric-cdr-service/internal/handler/ivr_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// IVR olayları. Olayın trace_id'si çağrının call_id'sidir; adım zamanı olayın zaman damgasıdır.
+const (
+	eventIVRNodeEntered = "call.ivr.node.entered"
+	eventDTMFReceived   = "call.dtmf.received"
+	eventIVRExited      = "call.ivr.exited"
+)
+
+// maxDTMFDigits: Tek olayda kabul edilen en fazla tuş.
+const maxDTMFDigits = 64
+
+// secretInputTypes: Tuşları maskelenerek saklanan giriş türleri. Olay secure=true bildirirse tür ne olursa olsun
+// maskelenir.
+var secretInputTypes = map[string]bool{
+	"pin": true, "password": true, "passcode": true, "card": true, "cvv": true, "account": true, "secure": true,
+}
+
+type ivrPayload struct {
+	NodeID     string `json:"node_id"`     // tüm olaylar; çıkışta isteğe bağlı
+	NodeName   string `json:"node_name"`   // call.ivr.node.entered
+	NodeType   string `json:"node_type"`   // call.ivr.node.entered (menu, input, play, transfer...)
+	Digits     string `json:"digits"`      // call.dtmf.received
+	InputType  string `json:"input_type"`  // call.dtmf.received (menu, pin, account...)
+	Secure     bool   `json:"secure"`      // call.dtmf.received
+	ExitReason string `json:"exit_reason"` // call.ivr.exited
+}
+
+func (p ivrPayload) validate(eventType string) error {
+	switch eventType {
+	case eventIVRNodeEntered:
+		if p.NodeID == "" {
+			return errors.New("node_id zorunludur")
+		}
+	case eventDTMFReceived:
+		if p.Digits == "" || len(p.Digits) > maxDTMFDigits {
+			return fmt.Errorf("digits 1 ile %d karakter arasında olmalı", maxDTMFDigits)
+		}
+		if strings.Trim(strings.ToUpper(p.Digits), "0123456789*#ABCD") != "" {
+			return errors.New("digits yalnızca 0-9, *, # ve A-D içerebilir")
+		}
+	case eventIVRExited:
+		if !slices.Contains(repository.IVRExitReasons, p.ExitReason) {
+			return fmt.Errorf("exit_reason şunlardan biri olmalı: %s", strings.Join(repository.IVRExitReasons, ", "))
+		}
+	}
+	return nil
+}
+
+// parseIVRPayload: Gövdeyi okur ve serbest metin alanlarını normalize eder; maskeleme ve doğrulama normalize
+// edilmiş değerlere bakar (" PIN" ile "pin" aynı türdür).
+func parseIVRPayload(raw string) (ivrPayload, error) {
+	var p ivrPayload
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return p, err
+	}
+	p.InputType = strings.ToLower(strings.TrimSpace(p.InputType))
+	p.ExitReason = strings.ToLower(strings.TrimSpace(p.ExitReason))
+	return p, nil
+}
+
+// masked: PIN türü girişin tuşlarını yıldızlar; tuş sayısı korunur.
+func (p ivrPayload) masked() ivrPayload {
+	if p.Secure || secretInputTypes[p.InputType] {
+		p.Digits = strings.Repeat("*", len(p.Digits))
+		p.Secure = true
+	}
+	return p
+}
+
+// dtmfEventPayload: call_events'e yazılacak tuşlama gövdesi; PIN türü girişler maskelenir.
+func dtmfEventPayload(raw string) string {
+	p, err := parseIVRPayload(raw)
+	if err != nil {
+		return "{}"
+	}
+	b, err := json.Marshal(p.masked())
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// processIVR: IVR düğüm, tuşlama ve çıkış olaylarını çağrının IVR yoluna ekler.
+func (h *EventHandler) processIVR(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+
+	p, err := parseIVRPayload(event.PayloadJson)
+	if err == nil {
+		err = p.validate(event.EventType)
+	}
+	if err != nil {
+		l.Warn().Err(err).Msg("IVR olayı payload'ı okunamadı, işlenmedi.")
+		h.eventsFailed.WithLabelValues(event.EventType, "payload_error").Inc()
+		return queue.Ack
+	}
+
+	step := repository.IVRStep{NodeID: p.NodeID, OccurredAt: event.Timestamp.AsTime()}
+	switch event.EventType {
+	case eventIVRNodeEntered:
+		step.Type, step.NodeName, step.NodeType = repository.IVRStepNode, p.NodeName, p.NodeType
+	case eventDTMFReceived:
+		p = p.masked()
+		step.Type, step.Digits, step.InputType, step.DigitsMasked = repository.IVRStepDTMF, p.Digits, p.InputType, p.Secure
+	case eventIVRExited:
+		step.Type, step.ExitReason = repository.IVRStepExit, p.ExitReason
+	}
+
+	err = h.repo.AddIVRStep(context.Background(), event.TraceId, step)
+	if errors.Is(err, repository.ErrCallNotFound) {
+		l.Warn().Msg("Çağrı kaydı DB'de yok, CallStarted gecikmiş olabilir. Retry ediliyor.")
+		return queue.NackRetry
+	}
+	if err != nil {
+		l.Error().Err(err).Msg("IVR adımı DB'ye yazılamadı")
+		return queue.NackRetry
+	}
+	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
+	return queue.Ack
+}
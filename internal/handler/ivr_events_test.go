@@ -0,0 +1,42 @@
+// sentiric-cdr-service/internal/handler/ivr_events_test.go
+package handler
+
+import "testing"
+
+func TestIVRPayloadMasked(t *testing.T) {
+	cases := []struct {
+		name       string
+		p          ivrPayload
+		wantDigits string
+		wantSecure bool
+	}{
+		{"menü", ivrPayload{Digits: "1", InputType: "menu"}, "1", false},
+		{"pin", ivrPayload{Digits: "1234", InputType: "pin"}, "****", true},
+		{"kart", ivrPayload{Digits: "4111111111111111", InputType: "card"}, "****************", true},
+		{"secure bayrağı", ivrPayload{Digits: "99#", InputType: "menu", Secure: true}, "***", true},
+		{"tür yok", ivrPayload{Digits: "42"}, "42", false},
+	}
+	for _, c := range cases {
+		got := c.p.masked()
+		if got.Digits != c.wantDigits || got.Secure != c.wantSecure {
+			t.Errorf("%s: masked = (%q, %v), beklenen (%q, %v)", c.name, got.Digits, got.Secure, c.wantDigits, c.wantSecure)
+		}
+	}
+}
+
+func TestDTMFEventPayload(t *testing.T) {
+	cases := []struct {
+		raw, want string
+	}{
+		{`{"digits":"1234","input_type":"pin"}`, `{"node_id":"","node_name":"","node_type":"","digits":"****","input_type":"pin","secure":true,"exit_reason":""}`},
+		{`{"digits":"1234","input_type":"PIN "}`, `{"node_id":"","node_name":"","node_type":"","digits":"****","input_type":"pin","secure":true,"exit_reason":""}`},
+		{`{"digits":"1234","input_type":" Account"}`, `{"node_id":"","node_name":"","node_type":"","digits":"****","input_type":"account","secure":true,"exit_reason":""}`},
+		{`{"digits":"2","input_type":"menu"}`, `{"node_id":"","node_name":"","node_type":"","digits":"2","input_type":"menu","secure":false,"exit_reason":""}`},
+		{`bozuk`, `{}`},
+	}
+	for _, c := range cases {
+		if got := dtmfEventPayload(c.raw); got != c.want {
+			t.Errorf("dtmfEventPayload(%s) = %s, beklenen %s", c.raw, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// sentiric-cdr-service/internal/handler/leg_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// legLinkPayload: B2BUA ve workflow'un bacak ilişkisi bildiren GenericEvent payload'ları.
+// Olayın trace_id'si her zaman olayı yayınlayan bacağın call_id'sidir.
+type legLinkPayload struct {
+	TargetCallID string `json:"target_call_id"` // call.transferred: transferin yeni bacağı
+	PeerCallID   string `json:"peer_call_id"`   // call.bridged: köprülenen karşı bacak
+	ForkedCallID string `json:"forked_call_id"` // call.forked: paralel aranan bacak
+	HostCallID   string `json:"host_call_id"`   // call.conference.joined: konferansı açan bacak
+}
+
+func (h *EventHandler) processLegLink(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+
+	var payload legLinkPayload
+	if err := json.Unmarshal([]byte(event.PayloadJson), &payload); err != nil {
+		l.Warn().Err(err).Msg("Bacak ilişkisi payload'ı okunamadı, ham olay olarak saklanacak.")
+		return queue.Ack
+	}
+
+	parent, child, legType := event.TraceId, "", ""
+	switch event.EventType {
+	case "call.transferred":
+		child, legType = payload.TargetCallID, repository.LegTypeTransfer
+	case "call.bridged":
+		child, legType = payload.PeerCallID, repository.LegTypeBridge
+	case "call.forked":
+		child, legType = payload.ForkedCallID, repository.LegTypeFork
+	case "call.conference.joined":
+		parent, child, legType = payload.HostCallID, event.TraceId, repository.LegTypeConference
+	}
+
+	if parent == "" || child == "" {
+		l.Debug().Msg("Bacak ilişkisi için karşı call_id yok, atlanıyor.")
+		return queue.Ack
+	}
+
+	err := h.repo.LinkLegs(context.Background(), event.TenantId, parent, child, legType)
+	if errors.Is(err, repository.ErrCrossTenantLegs) {
+		l.Warn().Str("child_call_id", child).Str("leg_type", legType).Msg("Farklı tenant'lara ait bacaklar bağlanmadı.")
+		return queue.Ack
+	}
+	if err != nil {
+		l.Error().Err(err).Str("child_call_id", child).Msg("Bacak ilişkisi DB'ye yazılamadı")
+		return queue.NackRetry
+	}
+
+	l.Debug().Str("child_call_id", child).Str("leg_type", legType).Msg("Bacak ilişkisi kaydedildi.")
+	return queue.Ack
+}
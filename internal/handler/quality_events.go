@@ -0,0 +1,89 @@
+// sentiric-cdr-service/internal/handler/quality_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// eventMediaQualitySummary: Medya katmanının bir yön için yayınladığı RTP/RTCP kalite özeti.
+// Olayın trace_id'si çağrının call_id'sidir; her yön için ayrı olay gelir.
+const eventMediaQualitySummary = "media.quality.summary"
+
+type mediaQualityPayload struct {
+	Direction     string   `json:"direction"` // inbound (platformun aldığı) veya outbound (platformun gönderdiği)
+	Codec         string   `json:"codec"`
+	MOS           *float64 `json:"mos"`
+	JitterMs      float64  `json:"jitter_ms"`
+	PacketLossPct float64  `json:"packet_loss_pct"`
+	RTTMs         *float64 `json:"rtt_ms"` // RTCP yoksa gelmeyebilir
+}
+
+func (p mediaQualityPayload) quality() (repository.MediaQuality, error) {
+	q := repository.MediaQuality{
+		Direction:     strings.ToLower(p.Direction),
+		Codec:         p.Codec,
+		JitterMs:      p.JitterMs,
+		PacketLossPct: p.PacketLossPct,
+		RTTMs:         p.RTTMs,
+	}
+	if q.Direction != repository.MediaInbound && q.Direction != repository.MediaOutbound {
+		return q, fmt.Errorf("direction inbound veya outbound olmalı: %q", p.Direction)
+	}
+	if p.MOS == nil || *p.MOS < 1 || *p.MOS > 5 {
+		return q, errors.New("mos 1 ile 5 arasında olmalı")
+	}
+	q.MOS = *p.MOS
+	if p.JitterMs < 0 || (p.RTTMs != nil && *p.RTTMs < 0) {
+		return q, errors.New("jitter_ms ve rtt_ms negatif olamaz")
+	}
+	if p.PacketLossPct < 0 || p.PacketLossPct > 100 {
+		return q, errors.New("packet_loss_pct 0 ile 100 arasında olmalı")
+	}
+	return q, nil
+}
+
+// processMediaQuality: Yönün kalite özetini çağrıya yazar. Çağrı bitmişse KPI katkısı kaliteyle yenilenir.
+func (h *EventHandler) processMediaQuality(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+	ctx := context.Background()
+
+	var payload mediaQualityPayload
+	err := json.Unmarshal([]byte(event.PayloadJson), &payload)
+	var q repository.MediaQuality
+	if err == nil {
+		q, err = payload.quality()
+	}
+	if err != nil {
+		l.Warn().Err(err).Msg("Kalite özeti okunamadı, işlenmedi.")
+		h.eventsFailed.WithLabelValues(event.EventType, "payload_error").Inc()
+		return queue.Ack
+	}
+
+	err = h.repo.SetMediaQuality(ctx, event.TraceId, q)
+	if errors.Is(err, repository.ErrCallNotFound) {
+		l.Warn().Msg("Çağrı kaydı DB'de yok, CallStarted gecikmiş olabilir. Retry ediliyor.")
+		return queue.NackRetry
+	}
+	if err != nil {
+		l.Error().Err(err).Msg("Kalite özeti DB'ye yazılamadı")
+		return queue.NackRetry
+	}
+	if err := h.kpis.RefreshCall(ctx, event.TraceId); err != nil {
+		l.Error().Err(err).Msg("KPI rollup'ları güncellenemedi")
+		return queue.NackRetry
+	}
+
+	if q.MOS < repository.LowMOSThreshold {
+		l.Warn().Str("direction", q.Direction).Float64("mos", q.MOS).Msg("📉 Düşük ses kalitesi")
+	}
+	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
+	return queue.Ack
+}
@@ -0,0 +1,133 @@
+// sentiric-cdr-service/internal/handler/recording_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/recording"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+const eventRecordingAvailable = "call.recording.available"
+
+// recordingPayload: Kayıt olayının JSON biçimi. Contract'taki olay yalnızca uri ve public_url taşır; segment,
+// kanal ve dosya meta verisi JSON olayla gelir.
+type recordingPayload struct {
+	CallID       string `json:"callId"`
+	URI          string `json:"uri"`
+	PublicURL    string `json:"publicUrl"`
+	Segment      int    `json:"segment"`
+	Channel      string `json:"channel"`
+	Format       string `json:"format"`
+	DurationMs   *int64 `json:"durationMs"`
+	SizeBytes    *int64 `json:"sizeBytes"`
+	Checksum     string `json:"checksum"`
+	StorageClass string `json:"storageClass"`
+}
+
+// recordingFromProto: Contract olayını tek segmentli karışık kanal kaydına çevirir.
+func recordingFromProto(event *eventv1.CallRecordingAvailableEvent) *repository.Recording {
+	return newRecording(recordingPayload{CallID: event.CallId, URI: event.RecordingUri, PublicURL: event.PublicUrl})
+}
+
+// recordingFromJSON: JSON kayıt olayını doğrular ve kayda çevirir.
+func recordingFromJSON(body []byte) (*repository.Recording, error) {
+	var p recordingPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
+	}
+	if p.Segment < 0 {
+		return nil, errors.New("segment 1 veya daha büyük olmalı")
+	}
+	if (p.DurationMs != nil && *p.DurationMs < 0) || (p.SizeBytes != nil && *p.SizeBytes < 0) {
+		return nil, errors.New("durationMs ve sizeBytes negatif olamaz")
+	}
+	checksum, err := recording.NormalizeChecksum(p.Checksum)
+	if err != nil {
+		return nil, err
+	}
+	p.Checksum = checksum
+	return newRecording(p), nil
+}
+
+// newRecording: Bildirilmeyen alanlara varsayılanları uygular.
+func newRecording(p recordingPayload) *repository.Recording {
+	rec := &repository.Recording{
+		CallID:       p.CallID,
+		Segment:      p.Segment,
+		Channel:      strings.ToLower(strings.TrimSpace(p.Channel)),
+		URI:          p.URI,
+		PublicURL:    p.PublicURL,
+		Format:       strings.ToLower(strings.TrimSpace(p.Format)),
+		DurationMs:   p.DurationMs,
+		SizeBytes:    p.SizeBytes,
+		Checksum:     p.Checksum,
+		StorageClass: strings.ToUpper(strings.TrimSpace(p.StorageClass)),
+	}
+	if rec.Segment == 0 {
+		rec.Segment = 1
+	}
+	if rec.Channel == "" {
+		rec.Channel = recording.ChannelMixed
+	}
+	if rec.Format == "" {
+		rec.Format = recording.FormatFromURI(p.URI)
+	}
+	if rec.StorageClass == "" {
+		rec.StorageClass = recording.StorageStandard
+	}
+	return rec
+}
+
+// processRecordingAvailable: Kaydı çağrıya bağlar. Çağrı henüz yoksa kayıt bekletilir ve CallStarted geldiğinde
+// bağlanır; olay retry edilmez.
+func (h *EventHandler) processRecordingAvailable(rec *repository.Recording) queue.HandlerResult {
+	l := h.log.With().Str("call_id", rec.CallID).Int("segment", rec.Segment).Str("channel", rec.Channel).Logger()
+	ctx := context.Background()
+
+	outcome, err := h.recordings.AddRecording(ctx, rec)
+	if err != nil {
+		l.Error().Err(err).Msg("Recording Update Error")
+		return queue.NackRetry
+	}
+	switch outcome {
+	case repository.RecordingPending:
+		l.Info().Msg("🎙️ Çağrı kaydı henüz yok, ses kaydı beklemeye alındı.")
+		h.eventsProcessed.WithLabelValues(eventRecordingAvailable).Inc()
+		return queue.Ack
+	case repository.RecordingDiscarded:
+		l.Warn().Msg("Çağrı silinmiş veya kayıt daha önce silinmiş; ses kaydı bağlanmadı.")
+		h.eventsFailed.WithLabelValues(eventRecordingAvailable, "discarded").Inc()
+		return queue.Ack
+	}
+	l.Info().Str("uri", rec.URI).Msg("🎙️ Ses kaydı DB'ye işlendi.")
+
+	if err := h.enqueueRecordingWebhook(ctx, *rec, time.Now().UTC()); err != nil {
+		l.Error().Err(err).Msg("Webhook teslimi kuyruğa alınamadı.")
+		return queue.NackRetry
+	}
+	h.eventsProcessed.WithLabelValues(eventRecordingAvailable).Inc()
+	return queue.Ack
+}
+
+// attachPendingRecordings: Çağrıdan önce gelen kayıtları yeni oluşan çağrıya bağlar; her bağlanan kayıt için
+// webhook kuyruğa yazılır.
+func (h *EventHandler) attachPendingRecordings(ctx context.Context, callID string, at time.Time) error {
+	attached, err := h.recordings.AttachPending(ctx, callID)
+	if err != nil || len(attached) == 0 {
+		return err
+	}
+	h.log.Info().Str("call_id", callID).Int("recordings", len(attached)).Msg("🎙️ Bekleyen ses kayıtları çağrıya bağlandı.")
+	for _, rec := range attached {
+		if err := h.enqueueRecordingWebhook(ctx, rec, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// sentiric-cdr-service/internal/handler/recording_events_test.go
+package handler
+
+import (
+	"testing"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func TestRecordingEventID(t *testing.T) {
+	base := repository.Recording{ID: 10, CallID: "c1", Segment: 1, Channel: "mixed", URI: "s3://rec/c1-1.wav",
+		Checksum: "sha256:aa"}
+	base2 := base
+	base2.ReceivedAt = base.ReceivedAt.AddDate(0, 0, 1)
+	otherSegment := base
+	otherSegment.ID, otherSegment.Segment, otherSegment.URI = 11, 2, "s3://rec/c1-2.wav"
+	otherChannel := base
+	otherChannel.ID, otherChannel.Channel = 12, "caller"
+	replacedURI := base
+	replacedURI.URI = "s3://rec/c1-1-v2.wav"
+	replacedChecksum := base
+	replacedChecksum.Checksum = "sha256:bb"
+
+	cases := []struct {
+		name string
+		rec  repository.Recording
+		same bool
+	}{
+		{"aynı olayın tekrarı", base2, true},
+		{"ikinci segment", otherSegment, false},
+		{"başka kanal", otherChannel, false},
+		{"aynı segmentte yeni URI", replacedURI, false},
+		{"aynı segmentte yeni checksum", replacedChecksum, false},
+	}
+	want := recordingEventID(base)
+	for _, c := range cases {
+		if got := recordingEventID(c.rec); (got == want) != c.same {
+			t.Errorf("%s: event_id = %s, temel %s ile aynı olması bekleniyor = %v", c.name, got, want, c.same)
+		}
+	}
+}
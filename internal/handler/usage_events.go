@@ -0,0 +1,118 @@
+// sentiric-cdr-service/internal/handler/usage_events.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// aiUsagePayload: STT, TTS ve LLM servislerinin yayınladığı usage olaylarının ortak payload'ı.
+// Olayın trace_id'si çağrının call_id'sidir; bir çağrı için çok sayıda olay gelebilir.
+type aiUsagePayload struct {
+	UsageID          string `json:"usage_id"` // Tekrar gelen olayı ayırt etmek için (yoksa türetilir)
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	AudioDurationMs  int64  `json:"audio_duration_ms"` // stt.usage.recorded
+	Characters       int64  `json:"characters"`        // tts.usage.recorded
+	PromptTokens     int64  `json:"prompt_tokens"`     // llm.usage.recorded
+	CompletionTokens int64  `json:"completion_tokens"` // llm.usage.recorded
+}
+
+type aiUsageLine struct {
+	service  string
+	resource string
+	quantity float64
+}
+
+func (p aiUsagePayload) lines(eventType string) []aiUsageLine {
+	switch eventType {
+	case "stt.usage.recorded":
+		return []aiUsageLine{{"stt-service", billing.ResourceSTTSecond, float64(p.AudioDurationMs) / 1000.0}}
+	case "tts.usage.recorded":
+		return []aiUsageLine{{"tts-service", billing.ResourceTTSCharacter, float64(p.Characters)}}
+	case "llm.usage.recorded":
+		return []aiUsageLine{
+			{"llm-service", billing.ResourceLLMPromptToken, float64(p.PromptTokens)},
+			{"llm-service", billing.ResourceLLMCompletionToken, float64(p.CompletionTokens)},
+		}
+	}
+	return nil
+}
+
+// processAIUsage: AI kaynak tüketimini kendi birim fiyatıyla usage_records'a yazar ve çağrı maliyetine yansıtır.
+func (h *EventHandler) processAIUsage(event *eventv1.GenericEvent) queue.HandlerResult {
+	l := h.log.With().Str("call_id", event.TraceId).Str("event_type", event.EventType).Logger()
+	ctx := context.Background()
+
+	var payload aiUsagePayload
+	if err := json.Unmarshal([]byte(event.PayloadJson), &payload); err != nil {
+		l.Warn().Err(err).Msg("Usage payload'ı okunamadı, faturalanamadı.")
+		h.eventsFailed.WithLabelValues(event.EventType, "payload_error").Inc()
+		return queue.Ack
+	}
+
+	// Olay tenant taşımıyorsa kullanım çağrının tenant'ına yazılır.
+	tenantID := event.TenantId
+	if tenantID == "" {
+		callTenant, err := h.repo.GetCallTenant(ctx, event.TraceId)
+		if errors.Is(err, repository.ErrCallNotFound) || (err == nil && callTenant == "") {
+			l.Warn().Msg("Usage olayında tenant yok ve çağrı kaydı henüz hazır değil. Retry ediliyor.")
+			return queue.NackRetry
+		}
+		if err != nil {
+			l.Error().Err(err).Msg("Çağrının tenant'ı okunamadı")
+			return queue.NackRetry
+		}
+		tenantID = callTenant
+	}
+	usageID := payload.UsageID
+	if usageID == "" {
+		usageID = fmt.Sprintf("%s:%s:%d", event.EventType, event.TraceId, event.Timestamp.AsTime().UnixNano())
+	}
+	metadata, _ := json.Marshal(map[string]string{"provider": payload.Provider, "model": payload.Model})
+	ts := event.Timestamp.AsTime()
+
+	for _, line := range payload.lines(event.EventType) {
+		if line.quantity <= 0 {
+			continue
+		}
+
+		unitPrice, rateVersion, err := h.repo.GetUnitPrice(ctx, tenantID, line.resource, ts)
+		if err != nil {
+			l.Error().Err(err).Msg("Birim fiyat okunamadı!")
+			return queue.NackRetry
+		}
+
+		usage := repository.UsageRecord{
+			TenantID:      tenantID,
+			CallID:        event.TraceId,
+			ServiceName:   line.service,
+			ResourceType:  line.resource,
+			Quantity:      line.quantity,
+			UnitPrice:     unitPrice,
+			Cost:          line.quantity * unitPrice,
+			RateVersion:   rateVersion,
+			SourceEventID: usageID + ":" + line.resource,
+			Metadata:      string(metadata),
+		}
+		if err := h.repo.CreateUsageRecord(ctx, usage); err != nil {
+			l.Error().Err(err).Msg("AI usage record oluşturulamadı!")
+			return queue.NackRetry
+		}
+	}
+
+	if err := h.repo.RecomputeCallCost(ctx, event.TraceId); err != nil {
+		l.Error().Err(err).Msg("Çağrı maliyeti güncellenemedi")
+		return queue.NackRetry
+	}
+
+	h.eventsProcessed.WithLabelValues(event.EventType).Inc()
+	return queue.Ack
+}
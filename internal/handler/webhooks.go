@@ -0,0 +1,89 @@
+// sentiric-cdr-service/internal/handler/webhooks.go
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	"github.com/sentiric/sentiric-cdr-service/internal/webhook"
+)
+
+// enqueueCallWebhook: Çağrının güncel CDR'ını tenant'ın webhook aboneliklerine teslim edilmek üzere kuyruğa yazar.
+// event_id olay tipi ve call_id'den türetildiği için olay tekrar işlenirse ikinci teslim oluşmaz.
+func (h *EventHandler) enqueueCallWebhook(ctx context.Context, eventType, tenantID, callID string, occurredAt time.Time) error {
+	call, err := h.webhookCall(ctx, tenantID, callID)
+	if err != nil {
+		return err
+	}
+	return h.enqueueWebhook(ctx, eventType, eventType+":"+callID, tenantID, callID, occurredAt, call)
+}
+
+// recordingWebhook: call.recording.available gövdesi; çağrının CDR'ı ve bildirilen kayıt.
+type recordingWebhook struct {
+	repository.CallRecord
+	Recording repository.Recording `json:"recording"`
+}
+
+// enqueueRecordingWebhook: Çağrıya bağlanan kaydı CDR ile birlikte webhook aboneliklerine kuyruğa yazar. Her kayıt
+// (segment ve kanal) ve aynı kaydın yerine geçen her yeni dosya ayrı teslim alır; aynı olayın tekrarı almaz.
+func (h *EventHandler) enqueueRecordingWebhook(ctx context.Context, rec repository.Recording, occurredAt time.Time) error {
+	call, err := h.webhookCall(ctx, rec.TenantID, rec.CallID)
+	if err != nil {
+		return err
+	}
+	return h.enqueueWebhook(ctx, webhook.EventCallRecordingAvailable, recordingEventID(rec), rec.TenantID, rec.CallID,
+		occurredAt, recordingWebhook{CallRecord: call, Recording: rec})
+}
+
+// recordingEventID: Kayıt webhook'unun event_id'si. Kayıt ID'si segment ve kanalı, URI ve checksum özeti ise aynı
+// segmentin yerine geçen dosyayı ayırır.
+func recordingEventID(rec repository.Recording) string {
+	sum := sha256.Sum256([]byte(rec.URI + "|" + rec.Checksum))
+	return fmt.Sprintf("%s:%s:%d:%s", webhook.EventCallRecordingAvailable, rec.CallID, rec.ID, hex.EncodeToString(sum[:8]))
+}
+
+// webhookCall: Çağrının CDR'ını webhook tüketicisinin politikasıyla maskelenmiş olarak okur. Gövde kuyruğa
+// maskelenmiş yazılır; teslim ve replay kuyruktaki gövdeyi olduğu gibi gönderir.
+func (h *EventHandler) webhookCall(ctx context.Context, tenantID, callID string) (repository.CallRecord, error) {
+	calls, err := h.repo.ListCalls(ctx, repository.CallFilter{TenantID: tenantID, CallID: callID, Limit: 1})
+	if err != nil {
+		return repository.CallRecord{}, err
+	}
+	if len(calls) == 0 {
+		return repository.CallRecord{}, repository.ErrCallNotFound
+	}
+	m, err := h.masks.For(ctx, tenantID, masking.Consumer{Role: masking.RoleWebhook, Unmasked: true})
+	if err != nil {
+		return repository.CallRecord{}, err
+	}
+	call := calls[0]
+	call.CallerNumber = m.Mask(call.CallerNumber)
+	call.CalleeNumber = m.Mask(call.CalleeNumber)
+	return call, nil
+}
+
+func (h *EventHandler) enqueueWebhook(ctx context.Context, eventType, eventID, tenantID, callID string, occurredAt time.Time, data interface{}) error {
+	n, err := webhook.Enqueue(ctx, h.webhooks, tenantID, eventType, eventID, occurredAt, data)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		h.log.Debug().Str("call_id", callID).Str("event_type", eventType).Int("subscriptions", n).Msg("Webhook teslimleri kuyruğa alındı.")
+	}
+	return nil
+}
+
+// logMasker: Log alanları için maskeleyici. Log tüketicisi hiçbir zaman açık numara görmez; politika okunamazsa
+// olay işleme durdurulmaz, varsayılan maskeleme kullanılır.
+func (h *EventHandler) logMasker(tenantID string) masking.Masker {
+	m, err := h.masks.For(context.Background(), tenantID, masking.Consumer{Role: masking.RoleLog})
+	if err != nil {
+		h.log.Warn().Err(err).Msg("Maskeleme politikası okunamadı, varsayılan maskeleme kullanılıyor.")
+	}
+	return m
+}
@@ -0,0 +1,75 @@
+// AÇIKLAMA: Bu paket, CDR hash zincirinin imzalı kontrol noktalarını üretir ve zinciri satırlarla
+// karşılaştırarak değiştirme, silme ve yer değiştirmeyi tespit eder.
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// checkpointInterval: Büyüyen her tenant zinciri için en fazla bu sıklıkta kontrol noktası üretilir.
+const checkpointInterval = time.Hour
+
+type Checkpointer struct {
+	repo   *repository.ChainRepository
+	signer *chain.Signer
+	log    zerolog.Logger
+}
+
+func NewCheckpointer(db *sql.DB, keys *repository.Keyring, signer *chain.Signer, log zerolog.Logger) *Checkpointer {
+	return &Checkpointer{repo: repository.NewChainRepository(db, keys), signer: signer, log: log}
+}
+
+// Run: Context iptal edilene kadar periyodik olarak kontrol noktası üretir.
+func (c *Checkpointer) Run(ctx context.Context) {
+	c.log.Info().Str("key_id", c.signer.KeyID).Str("public_key", c.signer.PublicKey()).
+		Msg("🔏 Hash zinciri kontrol noktası üreticisi aktif")
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := c.CheckpointNow(ctx); err != nil {
+			if ctx.Err() == nil {
+				c.log.Error().Err(err).Msg("Hash zinciri kontrol noktası üretilemedi.")
+			}
+		} else if n > 0 {
+			c.log.Info().Int("tenants", n).Msg("Hash zinciri kontrol noktaları imzalandı.")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckpointNow: Son kontrol noktasından sonra büyümüş her tenant zincirinin başını imzalar.
+func (c *Checkpointer) CheckpointNow(ctx context.Context) (int, error) {
+	heads, err := c.repo.ListUncheckpointedHeads(ctx)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, h := range heads {
+		cp := c.signer.Sign(chain.Checkpoint{
+			TenantID:  h.TenantID,
+			Seq:       h.LastSeq,
+			ChainHash: h.LastHash,
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+		})
+		ok, err := c.repo.CreateCheckpoint(ctx, cp)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
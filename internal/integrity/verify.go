@@ -0,0 +1,203 @@
+// sentiric-cdr-service/internal/integrity/verify.go
+package integrity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"fmt"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Tespit edilen tutarsızlık tipleri.
+const (
+	IssueSeqGap              = "SEQ_GAP"              // Zincir kaydı silinmiş veya sıra numarası değiştirilmiş
+	IssuePrevHashMismatch    = "PREV_HASH_MISMATCH"   // Kayıt önceki kayda bağlı değil (araya ekleme/yer değiştirme)
+	IssueChainHashMismatch   = "CHAIN_HASH_MISMATCH"  // Zincir kaydının kendisi değiştirilmiş
+	IssueRecordMissing       = "RECORD_MISSING"       // Zincirdeki satır tablodan silinmiş
+	IssueRecordModified      = "RECORD_MODIFIED"      // Satırın içeriği mühürlendikten sonra değişmiş
+	IssueRowLinkMismatch     = "ROW_LINK_MISMATCH"    // Satırın taşıdığı sıra/hash zincirle uyuşmuyor
+	IssueHeadMismatch        = "HEAD_MISMATCH"        // Zincir başı son kayıtla uyuşmuyor (sondan silme)
+	IssueCheckpointMismatch  = "CHECKPOINT_MISMATCH"  // İmzalı kontrol noktası zincirle uyuşmuyor
+	IssueCheckpointSignature = "CHECKPOINT_SIGNATURE" // Kontrol noktası imzası geçersiz
+	IssueUnchained           = "UNCHAINED_RECORDS"    // Zincir başladıktan sonra zincir dışı kalmış satırlar
+	IssueErasureUnsealed     = "ERASURE_UNSEALED"     // Anonimleştirme zincire ERASURE kaydıyla eklenmemiş
+)
+
+// maxIssues: Tenant başına raporlanan en fazla tutarsızlık; toplu bozulmada raporun şişmesini önler.
+const maxIssues = 1000
+
+type Issue struct {
+	Kind   string `json:"kind"`
+	Seq    int64  `json:"seq,omitempty"`
+	CallID string `json:"call_id,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// TenantReport: Bir tenant zincirinin doğrulama sonucu.
+type TenantReport struct {
+	TenantID           string  `json:"tenant_id"`
+	Entries            int64   `json:"entries"`
+	Pruned             int64   `json:"pruned"`
+	Erased             int64   `json:"erased"`
+	LastSeq            int64   `json:"last_seq"`
+	CheckpointsChecked int     `json:"checkpoints_checked"`
+	SignaturesChecked  bool    `json:"signatures_checked"`
+	Issues             []Issue `json:"issues"`
+	IssueCount         int     `json:"issue_count"`
+}
+
+func (t *TenantReport) add(i Issue) {
+	t.IssueCount++
+	if len(t.Issues) < maxIssues {
+		t.Issues = append(t.Issues, i)
+	}
+}
+
+// Verifier: Zinciri baştan yürüyerek her bağlantıyı, işaret edilen satırı ve kontrol noktalarını doğrular.
+type Verifier struct {
+	repo *repository.ChainRepository
+	// PublicKey: Kontrol noktası imzalarını doğrulamak için; nil ise imzalar kontrol edilmez.
+	PublicKey ed25519.PublicKey
+	// External: Zincirin dışında (örn: outbox ile arşivlenmiş) saklanan kontrol noktaları. Veritabanındaki
+	// kontrol noktaları silinse veya zincir baştan yeniden hesaplansa bile bunlar tutarsızlığı ortaya çıkarır.
+	External []chain.Checkpoint
+}
+
+func NewVerifier(db *sql.DB, keys *repository.Keyring) *Verifier {
+	return &Verifier{repo: repository.NewChainRepository(db, keys)}
+}
+
+// Verify: tenantID boşsa zinciri olan tüm tenant'ları doğrular.
+func (v *Verifier) Verify(ctx context.Context, tenantID string) ([]TenantReport, error) {
+	heads, err := v.repo.ListHeads(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenantID != "" && len(heads) == 0 {
+		return nil, fmt.Errorf("tenant için hash zinciri yok: %s", tenantID)
+	}
+
+	reports := make([]TenantReport, 0, len(heads))
+	for _, h := range heads {
+		rep, err := v.verifyTenant(ctx, h)
+		if err != nil {
+			return reports, fmt.Errorf("%s: %w", h.TenantID, err)
+		}
+		reports = append(reports, rep)
+	}
+	return reports, nil
+}
+
+func (v *Verifier) verifyTenant(ctx context.Context, head repository.ChainHead) (TenantReport, error) {
+	rep := TenantReport{TenantID: head.TenantID, SignaturesChecked: v.PublicKey != nil, Issues: []Issue{}}
+
+	stored, err := v.repo.ListCheckpoints(ctx, head.TenantID, 0)
+	if err != nil {
+		return rep, err
+	}
+	checkpoints := map[int64][]chain.Checkpoint{}
+	for _, cp := range append(stored, v.External...) {
+		if cp.TenantID != head.TenantID {
+			continue
+		}
+		rep.CheckpointsChecked++
+		if v.PublicKey != nil && !chain.VerifySignature(v.PublicKey, cp) {
+			rep.add(Issue{Kind: IssueCheckpointSignature, Seq: cp.Seq, Detail: "key_id=" + cp.KeyID})
+		}
+		checkpoints[cp.Seq] = append(checkpoints[cp.Seq], cp)
+	}
+
+	// erasures: Anonimleştirilen satırların beklenen ERASURE kaydı özetleri; sealed: zincirde görülen ERASURE kayıtları.
+	erasures, sealed := map[string]int64{}, map[string]bool{}
+	expected, prev := int64(1), chain.GenesisHash
+	err = v.repo.ForEachEntry(ctx, head.TenantID, func(en repository.ChainEntry) error {
+		rep.Entries++
+		if en.Seq != expected {
+			rep.add(Issue{Kind: IssueSeqGap, Seq: en.Seq, Detail: fmt.Sprintf("beklenen sıra %d", expected)})
+		}
+		if en.PrevHash != prev {
+			rep.add(Issue{Kind: IssuePrevHashMismatch, Seq: en.Seq, CallID: en.CallID})
+		}
+		if chain.Link(en.PrevHash, en.Seq, en.RecordType, en.CallID, en.RecordHash) != en.ChainHash {
+			rep.add(Issue{Kind: IssueChainHashMismatch, Seq: en.Seq, CallID: en.CallID})
+		}
+		if en.RecordType == chain.RecordErasure {
+			sealed[en.RecordHash] = true
+		} else {
+			v.checkRow(&rep, en)
+		}
+		if en.ErasureID != 0 {
+			erasures[chain.ErasureHash(en.ErasureID, en.Seq, en.ErasedHash)] = en.Seq
+		}
+		for _, cp := range checkpoints[en.Seq] {
+			if cp.ChainHash != en.ChainHash {
+				rep.add(Issue{Kind: IssueCheckpointMismatch, Seq: en.Seq, Detail: "kontrol noktası hash'i zincirle uyuşmuyor"})
+			}
+		}
+		delete(checkpoints, en.Seq)
+		expected, prev = en.Seq+1, en.ChainHash
+		rep.LastSeq = en.Seq
+		return nil
+	})
+	if err != nil {
+		return rep, err
+	}
+
+	if head.LastSeq != rep.LastSeq || head.LastHash != prev {
+		rep.add(Issue{Kind: IssueHeadMismatch, Seq: head.LastSeq,
+			Detail: fmt.Sprintf("zincir başı %d, son kayıt %d", head.LastSeq, rep.LastSeq)})
+	}
+	for hash, seq := range erasures {
+		if !sealed[hash] {
+			rep.add(Issue{Kind: IssueErasureUnsealed, Seq: seq, Detail: "anonimleştirilmiş hash zincirde mühürlü değil"})
+		}
+	}
+	// Zincirde karşılığı kalmamış kontrol noktası, imzalandıktan sonra kayıtların silindiğini gösterir.
+	for seq := range checkpoints {
+		rep.add(Issue{Kind: IssueCheckpointMismatch, Seq: seq, Detail: "kontrol noktasının sıra numarası zincirde yok"})
+	}
+
+	calls, events, err := v.repo.CountUnchained(ctx, head.TenantID)
+	if err != nil {
+		return rep, err
+	}
+	if calls+events > 0 {
+		rep.add(Issue{Kind: IssueUnchained, Detail: fmt.Sprintf("%d çağrı, %d olay", calls, events)})
+	}
+	return rep, nil
+}
+
+// checkRow: Zincir kaydının işaret ettiği satırın varlığını, içeriğini ve taşıdığı zincir bilgisini doğrular.
+func (v *Verifier) checkRow(rep *TenantReport, en repository.ChainEntry) {
+	// Saklama süresi dolan satırın zincir kaydı korunur; bağlantılar doğrulanır ama satır aranmaz.
+	if en.Pruned {
+		rep.Pruned++
+		return
+	}
+	var recordHash string
+	switch {
+	case en.Call != nil:
+		recordHash = en.Call.Hash()
+	case en.Event != nil:
+		recordHash = en.Event.Hash()
+	default:
+		rep.add(Issue{Kind: IssueRecordMissing, Seq: en.Seq, CallID: en.CallID, Detail: en.RecordType})
+		return
+	}
+	// Silme talebiyle anonimleştirilen satır, zincire ERASURE kaydıyla eklenmiş yeni hash'ine göre doğrulanır.
+	expectedHash := en.RecordHash
+	if en.ErasureID != 0 {
+		rep.Erased++
+		expectedHash = en.ErasedHash
+	}
+	if recordHash != expectedHash {
+		rep.add(Issue{Kind: IssueRecordModified, Seq: en.Seq, CallID: en.CallID, Detail: en.RecordType})
+	}
+	if en.RowSeq.Int64 != en.Seq || en.RowHash.String != en.ChainHash {
+		rep.add(Issue{Kind: IssueRowLinkMismatch, Seq: en.Seq, CallID: en.CallID,
+			Detail: fmt.Sprintf("satırdaki sıra %d", en.RowSeq.Int64)})
+	}
+}
@@ -0,0 +1,75 @@
+// sentiric-cdr-service/internal/integrity/verify_test.go
+package integrity
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func TestCheckRow(t *testing.T) {
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	call := &chain.CallRecord{CallID: "c1", TenantID: "acme", CallerNumber: "+905551112233", StartTime: &start, Disposition: "ANSWERED"}
+	erased := *call
+	erased.CallerNumber = "anon-1"
+
+	entry := func(modify func(*repository.ChainEntry)) repository.ChainEntry {
+		en := repository.ChainEntry{
+			Seq: 7, RecordType: chain.RecordCall, CallID: "c1", RecordHash: call.Hash(), ChainHash: "h7", Call: call,
+			RowSeq: sql.NullInt64{Int64: 7, Valid: true}, RowHash: sql.NullString{String: "h7", Valid: true},
+		}
+		if modify != nil {
+			modify(&en)
+		}
+		return en
+	}
+
+	cases := []struct {
+		name       string
+		en         repository.ChainEntry
+		wantIssues []string
+		wantPruned int64
+		wantErased int64
+	}{
+		{"sağlam satır", entry(nil), nil, 0, 0},
+		{"saklama ile silinmiş", entry(func(en *repository.ChainEntry) { en.Call, en.Pruned = nil, true }), nil, 1, 0},
+		{"satır silinmiş", entry(func(en *repository.ChainEntry) { en.Call = nil }), []string{IssueRecordMissing}, 0, 0},
+		{"satır değişmiş", entry(func(en *repository.ChainEntry) { en.Call = &erased }), []string{IssueRecordModified}, 0, 0},
+		{"anonimleştirilmiş", entry(func(en *repository.ChainEntry) {
+			en.Call, en.ErasureID, en.ErasedHash = &erased, 3, erased.Hash()
+		}), nil, 0, 1},
+		{"anonimleştirme sonrası değişmiş", entry(func(en *repository.ChainEntry) {
+			en.ErasureID, en.ErasedHash = 3, erased.Hash()
+		}), []string{IssueRecordModified}, 0, 1},
+		{"satırdaki sıra farklı", entry(func(en *repository.ChainEntry) { en.RowSeq.Int64 = 8 }), []string{IssueRowLinkMismatch}, 0, 0},
+		{"satırdaki hash farklı", entry(func(en *repository.ChainEntry) { en.RowHash.String = "x" }), []string{IssueRowLinkMismatch}, 0, 0},
+	}
+	for _, c := range cases {
+		rep := TenantReport{}
+		(&Verifier{}).checkRow(&rep, c.en)
+		var kinds []string
+		for _, i := range rep.Issues {
+			kinds = append(kinds, i.Kind)
+		}
+		if !slices.Equal(kinds, c.wantIssues) {
+			t.Errorf("%s: tutarsızlıklar = %v, beklenen %v", c.name, kinds, c.wantIssues)
+		}
+		if rep.Pruned != c.wantPruned || rep.Erased != c.wantErased {
+			t.Errorf("%s: pruned = %d, erased = %d; beklenen %d, %d", c.name, rep.Pruned, rep.Erased, c.wantPruned, c.wantErased)
+		}
+	}
+}
+
+func TestTenantReportIssueLimit(t *testing.T) {
+	rep := TenantReport{}
+	for i := 0; i < maxIssues+5; i++ {
+		rep.add(Issue{Kind: IssueSeqGap})
+	}
+	if len(rep.Issues) != maxIssues || rep.IssueCount != maxIssues+5 {
+		t.Errorf("raporlanan = %d, sayılan = %d", len(rep.Issues), rep.IssueCount)
+	}
+}
@@ -0,0 +1,80 @@
+// AÇIKLAMA: Bu paket, aylık faturalama dönemlerinin tanımını ve fatura verisinin
+// JSON/CSV çıktılarını içerir. Dönem kapatma mantığı repository.InvoiceRepository'dedir.
+package invoice
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// periodLayout: Dönemler takvim ayıdır ve UTC ile ifade edilir (örn: "2025-01").
+const periodLayout = "2006-01"
+
+// ParsePeriod: "YYYY-MM" biçimindeki dönemi [başlangıç, bitiş) aralığına çevirir.
+func ParsePeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse(periodLayout, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("dönem YYYY-MM biçiminde olmalı: %q", period)
+	}
+	start = start.UTC()
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// FormatPeriod: Dönem başlangıcını "YYYY-MM" biçimine çevirir.
+func FormatPeriod(start time.Time) string {
+	return start.UTC().Format(periodLayout)
+}
+
+// WriteJSON: Faturayı girintili JSON olarak yazar.
+func WriteJSON(w io.Writer, inv repository.Invoice) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inv)
+}
+
+var csvHeader = []string{
+	"tenant_id", "period", "status", "line_type", "service_name", "resource_type",
+	"destination_group", "quantity", "amount", "usage_count", "currency",
+}
+
+// WriteCSV: Her fatura kalemini bir satır olarak yazar; muhasebe yazılımlarına aktarım içindir.
+func WriteCSV(w io.Writer, inv repository.Invoice) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	period := FormatPeriod(inv.PeriodStart)
+	for _, l := range inv.Lines {
+		err := cw.Write([]string{
+			inv.TenantID, period, inv.Status, l.LineType, l.ServiceName, l.ResourceType,
+			l.DestinationGroup,
+			strconv.FormatFloat(l.Quantity, 'f', 6, 64),
+			strconv.FormatFloat(l.Amount, 'f', 6, 64),
+			strconv.Itoa(l.UsageCount),
+			inv.Currency,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Write: format "json" veya "csv" olabilir.
+func Write(w io.Writer, format string, inv repository.Invoice) error {
+	switch format {
+	case "", "json":
+		return WriteJSON(w, inv)
+	case "csv":
+		return WriteCSV(w, inv)
+	default:
+		return fmt.Errorf("desteklenmeyen format: %q (json veya csv)", format)
+	}
+}
@@ -0,0 +1,267 @@
+// AÇIKLAMA: Bu paket, telefon numaralarının loglarda, export'larda, webhook'larda ve API yanıtlarında tenant ve
+// tüketici rolüne göre maskelenmesini tanımlar. Politika yoksa numaralar maskelenir; açık numara yalnızca
+// yetkisi olan tüketiciye verilir.
+package masking
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+)
+
+// Maskeleme biçimleri.
+const (
+	// ModeKeepLast: Son KeepLast hane dışındaki rakamlar '*' olur ("+905321234567" -> "+********4567").
+	ModeKeepLast = "KEEP_LAST"
+	// ModeHash: Numara tenant'a özgü HMAC özetiyle değiştirilir; aynı numara aynı özeti alır, numaraya dönülemez.
+	ModeHash = "HASH"
+	// ModeRedact: Numara tamamen kaldırılır.
+	ModeRedact = "REDACT"
+	// ModeNone: Maskeleme yok; yalnızca PermissionUnmasked yetkisi olan tüketiciye uygulanır.
+	ModeNone = "NONE"
+)
+
+// Servis içi tüketiciler. API ve CLI tüketicilerinin rolü serbesttir (örn: "support", "analyst").
+const (
+	RoleLog      = "log"
+	RoleWebhook  = "webhook"
+	RoleDelivery = "delivery"
+	// RoleAPI: Rol başlığı taşımayan API isteği.
+	RoleAPI = "api"
+)
+
+// Any: Politikada tüm tenant'ları veya tüm rolleri kapsayan değer.
+const Any = "*"
+
+// PermissionUnmasked: Açık numara görmek için gereken yetki.
+const PermissionUnmasked = "cdr.numbers.unmasked"
+
+// Redacted: ModeRedact çıktısı.
+const Redacted = "[gizli]"
+
+const hashPrefix = "h:"
+
+// hashContext: Anahtar tanımlı değilse özet bu sabitle alınır; numara uzayı küçük olduğundan kaba kuvvete açıktır.
+const hashContext = "sentiric-cdr-masking/v1"
+
+// Policy: Tenant ve rol için maskeleme kuralı. TenantID veya Role Any ise o boyutta varsayılandır.
+type Policy struct {
+	TenantID  string    `json:"tenant_id"`
+	Role      string    `json:"role"`
+	Mode      string    `json:"mode"`
+	KeepLast  int       `json:"keep_last,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultPolicy: Eşleşen politika yoksa veya açık numara yetkisi yoksa uygulanır.
+var DefaultPolicy = Policy{TenantID: Any, Role: Any, Mode: ModeKeepLast, KeepLast: 4}
+
+// Validate: Politika alanlarını doğrular.
+func (p Policy) Validate() error {
+	if p.TenantID == "" || p.Role == "" {
+		return fmt.Errorf("tenant ve rol zorunludur ('%s' tümü anlamına gelir)", Any)
+	}
+	switch p.Mode {
+	case ModeKeepLast:
+		if p.KeepLast < 0 || p.KeepLast > 8 {
+			return fmt.Errorf("keep_last 0 ile 8 arasında olmalı: %d", p.KeepLast)
+		}
+	case ModeHash, ModeRedact, ModeNone:
+	default:
+		return fmt.Errorf("geçersiz maskeleme biçimi: %q (KEEP_LAST, HASH, REDACT veya NONE)", p.Mode)
+	}
+	return nil
+}
+
+// Consumer: Maskelenmiş çıktıyı alan taraf. Unmasked, tüketicinin PermissionUnmasked yetkisini taşıdığını söyler.
+type Consumer struct {
+	Role     string
+	Unmasked bool
+}
+
+// Resolve: Tenant ve rol için en özel politikayı seçer: (tenant, rol), (tenant, *), (*, rol), (*, *).
+func Resolve(policies []Policy, tenantID, role string) Policy {
+	best, rank := DefaultPolicy, 0
+	for _, p := range policies {
+		r := 0
+		switch {
+		case p.TenantID == tenantID && p.Role == role:
+			r = 4
+		case p.TenantID == tenantID && p.Role == Any:
+			r = 3
+		case p.TenantID == Any && p.Role == role:
+			r = 2
+		case p.TenantID == Any && p.Role == Any:
+			r = 1
+		}
+		if r > rank {
+			best, rank = p, r
+		}
+	}
+	return best
+}
+
+// Masker: Tek tenant ve tüketici için çözümlenmiş maskeleme.
+type Masker struct {
+	policy   Policy
+	tenantID string
+	key      []byte
+}
+
+// NewMasker: Politikayı tüketicinin yetkisine göre uygular; yetkisiz tüketici için NONE varsayılana düşer.
+func NewMasker(p Policy, tenantID string, c Consumer, key []byte) Masker {
+	if p.Mode == ModeNone && !c.Unmasked {
+		p = DefaultPolicy
+	}
+	return Masker{policy: p, tenantID: tenantID, key: key}
+}
+
+// Default: Politika okunamadığında kullanılan maskeleyici.
+func Default() Masker {
+	return Masker{policy: DefaultPolicy}
+}
+
+// Mode: Uygulanan maskeleme biçimi.
+func (m Masker) Mode() string {
+	return m.policy.Mode
+}
+
+// Unmasked: Numaraların açık verildiğini söyler.
+func (m Masker) Unmasked() bool {
+	return m.policy.Mode == ModeNone
+}
+
+// Mask: Numarayı politikaya göre maskeler. Boş değer boş kalır.
+func (m Masker) Mask(number string) string {
+	if number == "" {
+		return ""
+	}
+	switch m.policy.Mode {
+	case ModeNone:
+		return number
+	case ModeHash:
+		key := m.key
+		if len(key) == 0 {
+			key = []byte(hashContext)
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(m.tenantID + "|" + privacy.NormalizeNumber(number)))
+		return hashPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+	case ModeRedact:
+		return Redacted
+	}
+	return keepLast(number, m.policy.KeepLast)
+}
+
+// keepLast: Son n rakam dışındaki rakamları '*' yapar; '+' ve ayraçlar korunur.
+func keepLast(number string, n int) string {
+	r := []rune(number)
+	kept := 0
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i] < '0' || r[i] > '9' {
+			if r[i] != '+' && kept >= n {
+				r[i] = '*'
+			}
+			continue
+		}
+		if kept < n {
+			kept++
+			continue
+		}
+		r[i] = '*'
+	}
+	return string(r)
+}
+
+// numberFields: JSON gövdelerinde numara taşıyan alanlar.
+var numberFields = map[string]bool{"caller_number": true, "callee_number": true}
+
+// MaskJSON: JSON belgesindeki caller_number/callee_number alanlarını (iç içe nesneler dahil) maskeler.
+// Belge çözümlenemezse olduğu gibi döner.
+func (m Masker) MaskJSON(raw json.RawMessage) json.RawMessage {
+	if m.Unmasked() || len(raw) == 0 {
+		return raw
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return raw
+	}
+	out, err := json.Marshal(m.maskValue(doc))
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+func (m Masker) maskValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if s, ok := child.(string); ok && numberFields[k] {
+				t[k] = m.Mask(s)
+				continue
+			}
+			t[k] = m.maskValue(child)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = m.maskValue(child)
+		}
+	}
+	return v
+}
+
+// PolicySource: Politikaların okunduğu yer (veritabanı).
+type PolicySource interface {
+	ListPolicies(ctx context.Context) ([]Policy, error)
+}
+
+// policyTTL: Politikaların bellekte tutulma süresi; CLI ile yapılan değişiklik en geç bu süre sonra uygulanır.
+const policyTTL = time.Minute
+
+// Engine: Politikaları önbellekte tutar ve tüketiciye göre maskeleyici üretir.
+type Engine struct {
+	source PolicySource
+	key    []byte
+
+	mu       sync.Mutex
+	policies []Policy
+	loadedAt time.Time
+}
+
+func NewEngine(source PolicySource, key []byte) *Engine {
+	return &Engine{source: source, key: key}
+}
+
+// For: Tenant ve tüketici için maskeleyici döner. Politikalar okunamazsa hata ile birlikte Default döner;
+// çağıran hatayı loglayıp maskeleyiciyi kullanabilir.
+func (e *Engine) For(ctx context.Context, tenantID string, c Consumer) (Masker, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.policies == nil || time.Since(e.loadedAt) >= policyTTL {
+		policies, err := e.source.ListPolicies(ctx)
+		if err != nil {
+			return Default(), err
+		}
+		e.policies, e.loadedAt = policies, time.Now()
+	}
+	return NewMasker(Resolve(e.policies, tenantID, c.Role), tenantID, c, e.key), nil
+}
+
+// ParsePermissions: Virgülle ayrılmış yetki listesinde açık numara yetkisini arar.
+func ParsePermissions(header string) bool {
+	for _, p := range strings.Split(header, ",") {
+		if strings.TrimSpace(p) == PermissionUnmasked {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// sentiric-cdr-service/internal/masking/masking_test.go
+package masking
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestKeepLast(t *testing.T) {
+	cases := []struct {
+		name   string
+		number string
+		n      int
+		want   string
+	}{
+		{"uluslararası numara", "+905321234567", 4, "+********4567"},
+		{"hiç hane bırakma", "+905321234567", 0, "+************"},
+		{"numaradan uzun n", "1234", 8, "1234"},
+		{"ayraç korunan kısımda", "0532-123-45-67", 4, "*********45-67"},
+		{"boş değer", "", 4, ""},
+	}
+	for _, c := range cases {
+		if got := keepLast(c.number, c.n); got != c.want {
+			t.Errorf("%s: keepLast(%q, %d) = %q, beklenen %q", c.name, c.number, c.n, got, c.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	policies := []Policy{
+		{TenantID: Any, Role: Any, Mode: ModeRedact},
+		{TenantID: Any, Role: "support", Mode: ModeHash},
+		{TenantID: "acme", Role: Any, Mode: ModeKeepLast, KeepLast: 2},
+		{TenantID: "acme", Role: "billing", Mode: ModeNone},
+	}
+	cases := []struct {
+		name     string
+		policies []Policy
+		tenant   string
+		role     string
+		want     string
+	}{
+		{"tenant ve rol", policies, "acme", "billing", ModeNone},
+		{"tenant varsayılanı", policies, "acme", "support", ModeKeepLast},
+		{"rol varsayılanı", policies, "other", "support", ModeHash},
+		{"genel varsayılan", policies, "other", "analyst", ModeRedact},
+		{"politika yok", nil, "acme", "billing", DefaultPolicy.Mode},
+		{"başka tenant'ın politikası", policies[2:], "other", "billing", DefaultPolicy.Mode},
+	}
+	for _, c := range cases {
+		if got := Resolve(c.policies, c.tenant, c.role); got.Mode != c.want {
+			t.Errorf("%s: Resolve biçimi = %s, beklenen %s", c.name, got.Mode, c.want)
+		}
+	}
+}
+
+func TestMaskJSON(t *testing.T) {
+	keep := NewMasker(Policy{Mode: ModeKeepLast, KeepLast: 4}, "acme", Consumer{Role: RoleWebhook}, nil)
+	redact := NewMasker(Policy{Mode: ModeRedact}, "acme", Consumer{Role: RoleWebhook}, nil)
+	none := NewMasker(Policy{Mode: ModeNone}, "acme", Consumer{Role: "billing", Unmasked: true}, nil)
+	noneDenied := NewMasker(Policy{Mode: ModeNone}, "acme", Consumer{Role: "billing"}, nil)
+
+	cases := []struct {
+		name   string
+		masker Masker
+		raw    string
+		want   string
+	}{
+		{"düz alanlar", keep, `{"call_id":"c1","caller_number":"+905321234567"}`, `{"call_id":"c1","caller_number":"+********4567"}`},
+		{"iç içe nesne ve dizi", redact, `{"calls":[{"callee_number":"5551234"}]}`, `{"calls":[{"callee_number":"[gizli]"}]}`},
+		{"numara olmayan alan", redact, `{"caller_id":"+905321234567"}`, `{"caller_id":"+905321234567"}`},
+		{"metin olmayan numara alanı", redact, `{"caller_number":null}`, `{"caller_number":null}`},
+		{"açık numara yetkisi", none, `{"caller_number":"+905321234567"}`, `{"caller_number":"+905321234567"}`},
+		{"yetkisiz NONE", noneDenied, `{"caller_number":"+905321234567"}`, `{"caller_number":"+********4567"}`},
+		{"geçersiz JSON", redact, `{"caller_number":`, `{"caller_number":`},
+		{"boş gövde", redact, ``, ``},
+	}
+	for _, c := range cases {
+		if got := string(c.masker.MaskJSON(json.RawMessage(c.raw))); got != c.want {
+			t.Errorf("%s: MaskJSON = %s, beklenen %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestMaskHash(t *testing.T) {
+	key := []byte("masking-key")
+	acme := NewMasker(Policy{Mode: ModeHash}, "acme", Consumer{}, key)
+	other := NewMasker(Policy{Mode: ModeHash}, "other", Consumer{}, key)
+
+	got := acme.Mask("+90 532 123 45 67")
+	if !strings.HasPrefix(got, hashPrefix) || len(got) != len(hashPrefix)+16 {
+		t.Fatalf("özet biçimi = %q", got)
+	}
+	if acme.Mask("+905321234567") != got {
+		t.Error("aynı numaranın farklı yazımları aynı özeti almalı")
+	}
+	if other.Mask("+905321234567") == got {
+		t.Error("farklı tenant'larda aynı numara farklı özet almalı")
+	}
+}
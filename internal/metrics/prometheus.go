@@ -29,6 +29,131 @@ var (
 		},
 		[]string{"event_type", "reason"},
 	)
+
+	// RetentionRuns, saklama çalışmalarını sonuçlarına göre sayar.
+	RetentionRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_retention_runs_total",
+			Help: "Sonuçlanan toplam saklama (retention) çalışması sayısı.",
+		},
+		[]string{"status"},
+	)
+	// RetentionRows, saklama süresi dolduğu için silinen veya arşivlenen satırları sayar.
+	RetentionRows = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_retention_rows_total",
+			Help: "Saklama süresi dolduğu için silinen veya arşivlenen toplam satır sayısı.",
+		},
+		[]string{"table", "action"},
+	)
+	// RetentionPartitions, oluşturulan, arşivlenen, silinen veya yasal saklama nedeniyle atlanan partition'ları sayar.
+	RetentionPartitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_retention_partitions_total",
+			Help: "Saklama çalışmalarında işlem gören toplam partition sayısı.",
+		},
+		[]string{"table", "action"},
+	)
+	// RetentionLastSuccess, son başarılı saklama çalışmasının zamanını tutar.
+	RetentionLastSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sentiric_cdr_retention_last_success_timestamp_seconds",
+			Help: "Son başarılı saklama çalışmasının Unix zamanı.",
+		},
+	)
+	// ErasureRequests, KVKK / GDPR silme taleplerini mevzuata ve sonuca göre sayar.
+	ErasureRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_erasure_requests_total",
+			Help: "İşlenen toplam KVKK / GDPR silme talebi sayısı.",
+		},
+		[]string{"regulation", "status"},
+	)
+	// RekeyJobs, yeniden şifreleme işlerini sonuca göre sayar.
+	RekeyJobs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_rekey_jobs_total",
+			Help: "Biten toplam yeniden şifreleme işi sayısı.",
+		},
+		[]string{"status"},
+	)
+	// RekeyRows, aktif veri anahtarıyla yeniden şifrelenen satırları sayar.
+	RekeyRows = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_rekey_rows_total",
+			Help: "Yeniden şifrelenen toplam çağrı ve olay satırı sayısı.",
+		},
+		[]string{"table"},
+	)
+	// ConcurrentCalls, tenant'ın şu anda açık çağrı bacağı sayısını tutar.
+	ConcurrentCalls = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiric_cdr_concurrent_calls",
+			Help: "Tenant başına şu anda açık çağrı bacağı sayısı.",
+		},
+		[]string{"tenant_id"},
+	)
+	// ConcurrentCallsPeak, tenant'ın bugünkü (UTC) en yüksek eşzamanlı çağrı sayısını tutar.
+	ConcurrentCallsPeak = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiric_cdr_concurrent_calls_peak",
+			Help: "Tenant başına bugünkü (UTC) en yüksek eşzamanlı çağrı bacağı sayısı.",
+		},
+		[]string{"tenant_id"},
+	)
+	// StreamDropped, canlı akışa yazılamadan düşürülen değişiklikleri sayar.
+	StreamDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_stream_dropped_total",
+			Help: "Canlı çağrı akışına yazılamadan düşürülen toplam değişiklik sayısı.",
+		},
+		[]string{"reason"},
+	)
+	// StreamSubscribers, bu kopyaya bağlı canlı akış abonelerinin sayısını tutar.
+	StreamSubscribers = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "sentiric_cdr_stream_subscribers",
+			Help: "Bağlı canlı çağrı akışı abonesi sayısı.",
+		},
+	)
+	// StreamSlowClients, olaylara yetişemediği için bağlantısı kesilen aboneleri sayar.
+	StreamSlowClients = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_stream_slow_clients_total",
+			Help: "Olaylara yetişemediği için bağlantısı kesilen toplam canlı akış abonesi sayısı.",
+		},
+	)
+	// StreamLateEvents, okunan id'lerden sonra commit edildiği için boşluk taramasıyla geç dağıtılan olayları sayar.
+	StreamLateEvents = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_stream_late_events_total",
+			Help: "Sırası geçtikten sonra görünür olup boşluk taramasıyla dağıtılan toplam canlı akış olayı sayısı.",
+		},
+	)
+	// FraudAlerts, kurala göre üretilen dolandırıcılık alarmlarını sayar.
+	FraudAlerts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_fraud_alerts_total",
+			Help: "Kurala göre üretilen toplam dolandırıcılık alarmı sayısı.",
+		},
+		[]string{"rule"},
+	)
+	// AnomaliesOpen, tenant ve trunk başına açık anomalileri 1 olarak gösterir.
+	AnomaliesOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sentiric_cdr_anomalies_open",
+			Help: "Tenant, trunk ve tür başına açık anomali (1 = açık).",
+		},
+		[]string{"tenant_id", "trunk", "kind"},
+	)
+	// AnomalyAlerts, türe göre açılan anomalileri sayar.
+	AnomalyAlerts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sentiric_cdr_anomaly_alerts_total",
+			Help: "Türe göre açılan toplam anomali sayısı.",
+		},
+		[]string{"kind"},
+	)
 )
 
 // StartServer, metrikleri sunmak için bir HTTP sunucusu başlatır.
@@ -0,0 +1,45 @@
+// AÇIKLAMA: Bu paket, tenant'ların tanımladığı dış hedeflere (webhook, S3, SFTP) yapılan bağlantıların iç ağa
+// (özel, loopback, link-local, metadata vb. adresler) ulaşmasını engeller.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+)
+
+// ErrBlockedAddr: Bağlanılmak istenen adres iç ağda (özel, loopback, link-local vb.).
+var ErrBlockedAddr = errors.New("hedef adres iç ağa çözümleniyor")
+
+// cgnat: Taşıyıcı NAT aralığı (RFC 6598); netip.Addr.IsPrivate kapsamaz.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicAddr: Adresin dış hedef olarak kullanılabilecek genel bir adres olup olmadığını söyler.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!ip.IsUnspecified() && !cgnat.Contains(ip)
+}
+
+// PrivateLiteral: Host bir IP adresi olarak verilmişse ve genel değilse true döner. Alan adları burada
+// denetlenmez; bağlantı anında DialControl ile denetlenir.
+func PrivateLiteral(host string) bool {
+	ip, err := netip.ParseAddr(host)
+	return err == nil && !PublicAddr(ip)
+}
+
+// DialControl: net.Dialer.Control olarak kullanılır. DNS çözümlemesinden sonra, bağlantı kurulmadan hemen önce
+// gerçek hedef adresi denetler; böylece alan adı sonradan iç adrese çözümlense de (DNS rebinding) bağlantı iç ağa
+// ulaşmaz.
+func DialControl(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddr, ap.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// sentiric-cdr-service/internal/netguard/netguard_test.go
+package netguard
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestDialControl(t *testing.T) {
+	cases := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:443", true},
+		{"10.1.2.3:443", true},
+		{"172.16.0.1:443", true},
+		{"192.168.0.1:443", true},
+		{"100.64.0.1:443", true},
+		{"169.254.169.254:80", true},
+		{"0.0.0.0:443", true},
+		{"[::1]:443", true},
+		{"[fe80::1]:443", true},
+		{"[fd00::1]:443", true},
+		{"[::ffff:127.0.0.1]:443", true},
+	}
+	for _, c := range cases {
+		err := DialControl("tcp", c.addr, nil)
+		if got := errors.Is(err, ErrBlockedAddr); got != c.blocked {
+			t.Errorf("DialControl(%q) = %v, engellenmesi bekleniyor = %v", c.addr, err, c.blocked)
+		}
+	}
+}
+
+func TestPublicAddrInvalid(t *testing.T) {
+	if PublicAddr(netip.Addr{}) {
+		t.Error("geçersiz adres genel sayılmamalı")
+	}
+}
+
+func TestPrivateLiteral(t *testing.T) {
+	cases := []struct {
+		host string
+		want bool
+	}{
+		{"hooks.example.com", false},
+		{"93.184.216.34", false},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"fd00::1", true},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := PrivateLiteral(c.host); got != c.want {
+			t.Errorf("PrivateLiteral(%q) = %v, beklenen %v", c.host, got, c.want)
+		}
+	}
+}
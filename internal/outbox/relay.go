@@ -0,0 +1,102 @@
+// AÇIKLAMA: Bu paket, cdr_outbox_events tablosuna transaction içinde yazılan olayları
+// sentiric_events exchange'ine GenericEvent olarak yayınlayan relay'i içerir.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/anomaly"
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/fraud"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+	"github.com/sentiric/sentiric-cdr-service/internal/queue"
+	"github.com/sentiric/sentiric-cdr-service/internal/recording"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+	eventv1 "github.com/sentiric/sentiric-contracts/gen/go/sentiric/event/v1"
+)
+
+// ownEventTypes: Exchange'e "#" ile bağlı olduğumuz için kendi yayınladığımız olaylar bize geri döner.
+var ownEventTypes = map[string]bool{
+	anomaly.EventAnomalyDetected:        true,
+	anomaly.EventAnomalyResolved:        true,
+	billing.EventTenantBalanceLow:       true,
+	billing.EventTenantBalanceExhausted: true,
+	billing.EventTenantBalanceRestored:  true,
+	chain.EventCheckpoint:               true,
+	fraud.EventFraudAlert:               true,
+	privacy.EventSubjectErased:          true,
+	recording.EventDeleted:              true,
+	recording.EventRetention:            true,
+}
+
+// IsOwnEvent: Olayın bu servis tarafından yayınlanıp yayınlanmadığını söyler.
+func IsOwnEvent(eventType string) bool {
+	return ownEventTypes[eventType]
+}
+
+const (
+	pollInterval = time.Second
+	batchSize    = 100
+)
+
+type Relay struct {
+	repo      *repository.OutboxRepository
+	publisher *queue.Publisher
+	log       zerolog.Logger
+}
+
+func NewRelay(repo *repository.OutboxRepository, publisher *queue.Publisher, log zerolog.Logger) *Relay {
+	return &Relay{repo: repo, publisher: publisher, log: log}
+}
+
+// Run: Context iptal edilene kadar outbox'ı periyodik olarak boşaltır.
+func (r *Relay) Run(ctx context.Context) {
+	r.log.Info().Msg("📤 Outbox relay aktif")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := r.repo.PublishPending(ctx, batchSize, func(e repository.OutboxEvent) error {
+					return r.publish(ctx, e)
+				})
+				if err != nil && ctx.Err() == nil {
+					r.log.Error().Err(err).Msg("Outbox olayları yayınlanamadı, sonraki turda tekrar denenecek.")
+				}
+				if err != nil || n < batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, e repository.OutboxEvent) error {
+	body, err := proto.Marshal(&eventv1.GenericEvent{
+		EventType: e.EventType,
+		TraceId:   fmt.Sprintf("cdr-outbox-%d", e.ID), // Tekrar yayında tüketiciler aynı olayı tanıyabilsin
+
+		Timestamp:   timestamppb.New(e.CreatedAt),
+		TenantId:    e.TenantID,
+		PayloadJson: e.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.publisher.Publish(ctx, e.EventType, body); err != nil {
+		return err
+	}
+	r.log.Debug().Int64("outbox_id", e.ID).Str("event_type", e.EventType).Msg("Outbox olayı yayınlandı.")
+	return nil
+}
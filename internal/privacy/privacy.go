@@ -0,0 +1,247 @@
+// AÇIKLAMA: Bu paket, KVKK / GDPR silme taleplerinin ilgili kişi tanımını, takma ad (pseudonym) üretimini ve
+// silme sertifikasının biçimini tanımlar. Veritabanına dokunmaz.
+package privacy
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+)
+
+// EventSubjectErased: Silme tamamlandığında outbox üzerinden yayınlanan olay; medya servisi kayıt dosyalarını siler.
+const EventSubjectErased = "cdr.subject.erased"
+
+// Talebin dayandığı mevzuat.
+const (
+	RegulationKVKK = "KVKK"
+	RegulationGDPR = "GDPR"
+)
+
+// İlgili kişinin tanımlanma biçimi.
+const (
+	SubjectPhone  = "PHONE"
+	SubjectUserID = "USER_ID"
+)
+
+// pseudonymPrefix: Anonimleştirilmiş değerlerin CDR'da kolayca ayırt edilmesi için önek.
+const pseudonymPrefix = "anon-"
+
+// hashContext: Anahtar tanımlı değilken kişi özetinde kullanılan sabit bağlam.
+const hashContext = "sentiric-cdr-erasure/v1"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ValidRegulation: Mevzuat kodunun desteklenip desteklenmediğini söyler.
+func ValidRegulation(r string) bool {
+	return r == RegulationKVKK || r == RegulationGDPR
+}
+
+// Subject: Normalize edilmiş ilgili kişi. Telefon numaraları ülke kodlu rakamlar olarak tutulur (905551234567).
+type Subject struct {
+	Type  string
+	Value string
+}
+
+// NewSubject: Ham numarayı veya kullanıcı ID'sini normalize eder.
+func NewSubject(typ, value string) (Subject, error) {
+	value = strings.TrimSpace(value)
+	switch typ {
+	case SubjectPhone:
+		if len(digits(value)) < 3 {
+			return Subject{}, fmt.Errorf("geçersiz telefon numarası: %q", value)
+		}
+		return Subject{Type: typ, Value: NormalizeNumber(value)}, nil
+	case SubjectUserID:
+		value = strings.ToLower(value)
+		if !uuidPattern.MatchString(value) {
+			return Subject{}, fmt.Errorf("kullanıcı ID'si UUID olmalı: %q", value)
+		}
+		return Subject{Type: typ, Value: value}, nil
+	}
+	return Subject{}, fmt.Errorf("bilinmeyen kişi tipi: %q", typ)
+}
+
+// NormalizeNumber: Numarayı ülke kodlu rakamlara çevirir (0555 123 45 67 -> 905551234567). Kör indeks ve silme
+// talepleri aynı biçimi kullanır. Numara olmayan değerler ("anonymous", takma adlar) küçük harfle aynen döner.
+func NormalizeNumber(value string) string {
+	value = strings.TrimSpace(value)
+	for _, r := range value {
+		if !strings.ContainsRune("0123456789+ -().", r) {
+			return strings.ToLower(value)
+		}
+	}
+	d := digits(value)
+	switch {
+	case len(d) == 11 && d[0] == '0':
+		d = "90" + d[1:]
+	case len(d) == 10 && d[0] != '0':
+		d = "90" + d
+	}
+	return d
+}
+
+func digits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Variants: CDR'da kişinin saklanmış olabileceği yazımlar. Numaralar arayan/aranan alanlarına SIP URI'den
+// ayıklanarak yazıldığından ulusal (05..., 5...) ve uluslararası (+90..., 90...) biçimlerin hepsi aranır.
+func (s Subject) Variants() []string {
+	if s.Type != SubjectPhone {
+		return []string{s.Value}
+	}
+	set := map[string]bool{s.Value: true, "+" + s.Value: true}
+	if national, ok := strings.CutPrefix(s.Value, "90"); ok && len(s.Value) == 12 {
+		set[national] = true
+		set["0"+national] = true
+	}
+	out := make([]string, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	// Uzun yazımlar önce: "+905..." içinde "905..." eşleşip "+" işaretini geride bırakmasın.
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) > len(out[j])
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// Pattern: Olay ve webhook gövdelerinde kişiyi bulan PostgreSQL düzenli ifadesi. Numara yazımları başka bir
+// numaranın parçası olarak eşleşmesin diye rakamlarla sınırlandırılır.
+func (s Subject) Pattern() string {
+	variants := s.Variants()
+	for i, v := range variants {
+		variants[i] = regexp.QuoteMeta(v)
+	}
+	alt := "(" + strings.Join(variants, "|") + ")"
+	if s.Type == SubjectPhone {
+		return "(?<![0-9])" + alt + "(?![0-9])"
+	}
+	return "(?<![0-9a-fA-F-])" + alt + "(?![0-9a-fA-F-])"
+}
+
+// Replace: Pattern'in uygulama tarafı karşılığı; şifreli olduğu için veritabanında taranamayan gövdelerde kullanılır.
+// Go düzenli ifadelerinde geriye bakış olmadığından sınır karakterleri yakalanıp korunur. Bir ayraçla bitişik iki
+// eşleşmeden ikincisi ilk geçişte atlandığı için ikinci geçiş yapılır.
+func (s Subject) Replace(text, pseudonym string) string {
+	variants := s.Variants()
+	for i, v := range variants {
+		variants[i] = regexp.QuoteMeta(v)
+	}
+	boundary, flags := "0-9", ""
+	if s.Type != SubjectPhone {
+		boundary, flags = "0-9a-fA-F-", "(?i)"
+	}
+	re := regexp.MustCompile(fmt.Sprintf("%s(^|[^%s])(%s)([^%s]|$)", flags, boundary, strings.Join(variants, "|"), boundary))
+	repl := "${1}" + strings.ReplaceAll(pseudonym, "$", "$$") + "${3}"
+	return re.ReplaceAllString(re.ReplaceAllString(text, repl), repl)
+}
+
+// Hash: Kişinin talep kaydında tutulan özeti; açık değer saklanmadan "bu kişi için talep var mı" sorgulanabilir.
+// Anahtar tanımlıysa HMAC kullanılır; numara uzayı küçük olduğundan anahtarsız özet kaba kuvvete açıktır.
+func (s Subject) Hash(key []byte) string {
+	if len(key) == 0 {
+		key = []byte(hashContext)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s.Type + "|" + s.Value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Pseudonym: Kişinin CDR'daki yerine yazılan takma ad. Anahtar tanımlıysa aynı kişi her talepte aynı takma adı
+// alır (kayıtlar arası ilişki korunur); değilse her talep için rastgele üretilir.
+func Pseudonym(key []byte, s Subject) (string, error) {
+	if len(key) == 0 {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return pseudonymPrefix + hex.EncodeToString(b), nil
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pseudonym|" + s.Type + "|" + s.Value))
+	return pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:16], nil
+}
+
+// Counts: Silme talebinin etkilediği kayıt sayıları.
+type Counts struct {
+	CallsPseudonymized int64 `json:"calls_pseudonymized"`
+	EventsScrubbed     int64 `json:"events_scrubbed"`
+	RecordingsUnlinked int64 `json:"recordings_unlinked"`
+	WebhooksScrubbed   int64 `json:"webhook_payloads_scrubbed"`
+	ArchiveScrubbed    int64 `json:"archive_rows_scrubbed"`
+	ChainEntries       int64 `json:"chain_entries"`
+}
+
+// certificateVersion: İmzalanan belge biçimi; alanlar değişirse artırılmalıdır.
+const certificateVersion = "sentiric-cdr-erasure-certificate/v1"
+
+// Certificate: Talebin ne zaman, hangi dayanakla ve hangi kapsamda yerine getirildiğinin kaydı.
+// Kişinin açık değerini içermez.
+type Certificate struct {
+	Version     string    `json:"version"`
+	RequestID   int64     `json:"request_id"`
+	Regulation  string    `json:"regulation"`
+	Reference   string    `json:"external_reference"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	SubjectType string    `json:"subject_type"`
+	SubjectHash string    `json:"subject_hash"`
+	Pseudonym   string    `json:"pseudonym"`
+	RequestedBy string    `json:"requested_by"`
+	Counts      Counts    `json:"counts"`
+	RequestedAt time.Time `json:"requested_at"`
+	IssuedAt    time.Time `json:"issued_at"`
+	KeyID       string    `json:"key_id,omitempty"`
+	Signature   string    `json:"signature,omitempty"`
+}
+
+// NewCertificate: Sertifika sürümünü doldurur.
+func NewCertificate(c Certificate) Certificate {
+	c.Version = certificateVersion
+	return c
+}
+
+// message: İmzalanan içerik; imza alanı hariç sertifikanın JSON gösterimi.
+func (c Certificate) message() []byte {
+	c.Signature = ""
+	b, _ := json.Marshal(c)
+	return b
+}
+
+// Sign: Sertifikayı hash zinciri anahtarıyla imzalar.
+func (c Certificate) Sign(s *chain.Signer) Certificate {
+	c.KeyID = s.KeyID
+	c.Signature = s.SignMessage(c.message())
+	return c
+}
+
+// VerifyCertificate: Sertifika imzasını açık anahtarla doğrular.
+func VerifyCertificate(pub ed25519.PublicKey, c Certificate) error {
+	if c.Signature == "" {
+		return errors.New("sertifika imzasız")
+	}
+	if !chain.VerifyMessage(pub, c.message(), c.Signature) {
+		return errors.New("sertifika imzası geçersiz")
+	}
+	return nil
+}
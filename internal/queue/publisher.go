@@ -0,0 +1,59 @@
+// sentiric-cdr-service/internal/queue/publisher.go
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// Publisher: sentiric_events exchange'ine publish confirm ile mesaj yayınlar.
+type Publisher struct {
+	ch *amqp091.Channel
+}
+
+func NewPublisher(conn *amqp091.Connection) (*Publisher, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(exchangeName, "topic", true, false, false, false, nil); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return &Publisher{ch: ch}, nil
+}
+
+// Publish: Mesajı routingKey ile yayınlar ve broker'ın diske yazdığına dair onayı bekler.
+func (p *Publisher) Publish(ctx context.Context, routingKey string, body []byte) error {
+	confirm, err := p.ch.PublishWithDeferredConfirmWithContext(ctx, exchangeName, routingKey, false, false, amqp091.Publishing{
+		ContentType:  "application/protobuf",
+		Body:         body,
+		DeliveryMode: amqp091.Persistent,
+		Timestamp:    time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	acked, err := confirm.WaitContext(waitCtx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("broker mesajı nack etti")
+	}
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return p.ch.Close()
+}
@@ -0,0 +1,73 @@
+// AÇIKLAMA: Bu paket, çağrı kayıtlarının (segment ve kanal bazlı) yaşam döngüsü sabitlerini ve kayıt meta
+// verisinin doğrulamasını tanımlar. Depolama katmanı saklama ve silme kararlarını outbox olaylarıyla alır.
+package recording
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Depolama katmanına yayınlanan olaylar.
+const (
+	// EventRetention: Saklama süresi dolan kayıt arşiv depolama sınıfına taşınmalı (ARCHIVE politikası).
+	EventRetention = "cdr.recording.retention"
+	// EventDeleted: Kayıt dosyası silinmeli (DELETE politikası veya silme talebi).
+	EventDeleted = "cdr.recording.deleted"
+)
+
+// Kayıt durumları.
+const (
+	StatusPending = "PENDING"
+	StatusActive  = "ACTIVE"
+	StatusDeleted = "DELETED"
+)
+
+// Silme nedenleri.
+const (
+	ReasonRetention = "retention"
+	ReasonErasure   = "erasure"
+)
+
+// Depolama sınıfları.
+const (
+	StorageStandard = "STANDARD"
+	StorageArchive  = "ARCHIVE"
+)
+
+// ChannelMixed: Tüm tarafların tek dosyada olduğu kayıt; kanal bildirilmezse varsayılır.
+const ChannelMixed = "mixed"
+
+// checksumLengths: Desteklenen özet algoritmaları ve onaltılık uzunlukları.
+var checksumLengths = map[string]int{"md5": 32, "sha1": 40, "sha256": 64}
+
+// NormalizeChecksum: Özeti "algoritma:onaltılık" biçimine getirir. Ön eksiz 64 haneli değer sha256 sayılır.
+func NormalizeChecksum(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return "", nil
+	}
+	algo, sum, ok := strings.Cut(s, ":")
+	if !ok {
+		algo, sum = "sha256", s
+	}
+	n, known := checksumLengths[algo]
+	if !known {
+		return "", fmt.Errorf("desteklenmeyen checksum algoritması: %q", algo)
+	}
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != n {
+		return "", fmt.Errorf("geçersiz %s checksum", algo)
+	}
+	return algo + ":" + sum, nil
+}
+
+// FormatFromURI: Format bildirilmediğinde URI'nin dosya uzantısını (küçük harf) döner.
+func FormatFromURI(uri string) string {
+	p := uri
+	if u, err := url.Parse(uri); err == nil && u.Path != "" {
+		p = u.Path
+	}
+	return strings.TrimPrefix(strings.ToLower(path.Ext(p)), ".")
+}
@@ -0,0 +1,119 @@
+// AÇIKLAMA: Bu paket, veri anahtarı rotasyonundan (veya şifrelemenin sonradan açılmasından) sonra çağrı
+// numaralarını ve olay gövdelerini arka planda aktif anahtarla yeniden şifreleyen çalışanı içerir.
+package rekey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+const (
+	// pollInterval: Bekleyen iş kontrol sıklığı.
+	pollInterval = 30 * time.Second
+	// batchSize: Tek adımda yeniden şifrelenen en fazla çağrı sayısı; kısa transaction'lar canlı yazımı bekletmez.
+	batchSize = 200
+)
+
+type Worker struct {
+	repo *repository.RekeyRepository
+	log  zerolog.Logger
+}
+
+func NewWorker(db *sql.DB, keys *repository.Keyring, log zerolog.Logger) *Worker {
+	return &Worker{repo: repository.NewRekeyRepository(db, keys), log: log}
+}
+
+// Run: Context iptal edilene kadar bekleyen yeniden şifreleme işlerini yürütür. Aynı anda tek kopya çalışır.
+func (w *Worker) Run(ctx context.Context) {
+	w.log.Info().Msg("🔐 Yeniden şifreleme çalışanı aktif")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.RunOnce(ctx); err != nil && !errors.Is(err, repository.ErrRekeyBusy) && ctx.Err() == nil {
+			w.log.Error().Err(err).Msg("Yeniden şifreleme işi yürütülemedi.")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce: Bekleyen işleri sırayla ilerletir. Rotasyon üzerinden ActiveKeyTTL geçmeden tamamlanan tur işi
+// bitirmez; iş açık kalır ve süre dolunca tur baştan yapılır.
+func (w *Worker) RunOnce(ctx context.Context) error {
+	unlock, err := w.repo.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for {
+		job, ok, err := w.repo.NextJob(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		done, err := w.process(ctx, &job)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			metrics.RekeyJobs.WithLabelValues("FAILED").Inc()
+			if failErr := w.repo.FailJob(context.WithoutCancel(ctx), job.ID, err); failErr != nil {
+				w.log.Error().Err(failErr).Int64("job_id", job.ID).Msg("Yeniden şifreleme işi durumu güncellenemedi.")
+			}
+			return err
+		}
+		if !done {
+			return nil
+		}
+	}
+}
+
+// process: İşi bitirebildiyse true döner; bekleme süresi dolmadıysa false.
+func (w *Worker) process(ctx context.Context, job *repository.RekeyJob) (bool, error) {
+	log := w.log.With().Int64("job_id", job.ID).Str("tenant_id", job.TenantID).Logger()
+	settled := job.CreatedAt.Add(repository.ActiveKeyTTL)
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		calls, events := job.CallsReencrypted, job.EventsReencrypted
+		passDone, err := w.repo.ReencryptBatch(ctx, job, batchSize)
+		if err != nil {
+			return false, err
+		}
+		metrics.RekeyRows.WithLabelValues("calls").Add(float64(job.CallsReencrypted - calls))
+		metrics.RekeyRows.WithLabelValues("events").Add(float64(job.EventsReencrypted - events))
+		if !passDone {
+			continue
+		}
+
+		switch {
+		case job.PassStartedAt != nil && !job.PassStartedAt.Before(settled):
+			if err := w.repo.FinishJob(ctx, *job); err != nil {
+				return false, err
+			}
+			metrics.RekeyJobs.WithLabelValues("COMPLETED").Inc()
+			log.Info().Int64("calls", job.CallsReencrypted).Int64("events", job.EventsReencrypted).
+				Msg("🔐 Yeniden şifreleme işi tamamlandı.")
+			return true, nil
+		case time.Now().Before(settled):
+			return false, nil
+		default:
+			log.Info().Msg("Rotasyon sonrası bekleme süresi doldu; yeniden şifreleme turu baştan yapılıyor.")
+			if err := w.repo.RestartPass(ctx, job); err != nil {
+				return false, err
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// sentiric-cdr-service/internal/repository/agent.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AgentEvent: Kuyruk ve temsilci olaylarından çağrıya işlenen alanlar. Boş alanlar kayıtlı değeri korur.
+type AgentEvent struct {
+	Queue           string
+	AgentUserID     string
+	At              time.Time
+	AfterCallWorkMs *int64
+}
+
+// Kuyruk ve temsilci olay türleri.
+const (
+	AgentQueueEntered = "queue_entered"
+	AgentConnected    = "connected"
+	AgentDisconnected = "disconnected"
+)
+
+// agentContribution: Tek çağrının temsilci rollup'larına katkısı; calls.agent_snapshot'ta saklanır.
+type agentContribution struct {
+	TenantID        string    `json:"tenant_id"`
+	ConnectedAt     time.Time `json:"connected_at"`
+	AgentUserID     string    `json:"agent_user_id"`
+	Queue           string    `json:"queue"`
+	Queued          int64     `json:"queued"`
+	QueueWaitMs     int64     `json:"queue_wait_ms"`
+	TalkMs          int64     `json:"talk_ms"`
+	AfterCallWorkMs int64     `json:"after_call_work_ms"`
+	HandleMs        int64     `json:"handle_ms"`
+}
+
+func (c agentContribution) equal(o agentContribution) bool {
+	if !c.ConnectedAt.Equal(o.ConnectedAt) {
+		return false
+	}
+	c.ConnectedAt = o.ConnectedAt
+	return c == o
+}
+
+// AgentKPIBucket: Bir temsilcinin kovadaki işlem sayıları ve ortalamaları.
+type AgentKPIBucket struct {
+	BucketStart  time.Time `json:"bucket_start"`
+	AgentUserID  string    `json:"agent_user_id"`
+	Queue        string    `json:"queue,omitempty"`
+	HandledCalls int64     `json:"handled_calls"`
+	// AvgQueueWait: Kuyruktan gelen çağrıların ortalama bekleme süresi (saniye).
+	AvgQueueWait float64 `json:"avg_queue_wait_seconds"`
+	AvgTalk      float64 `json:"avg_talk_seconds"`
+	// AvgAfterCallWork: Çağrı sonrası işin (wrap-up) ortalaması (saniye).
+	AvgAfterCallWork float64 `json:"avg_after_call_work_seconds"`
+	// AHT: Ortalama işlem süresi; konuşma + çağrı sonrası iş (saniye).
+	AHT           float64 `json:"aht_seconds"`
+	HandleMinutes float64 `json:"handle_minutes"`
+}
+
+// AgentKPIFilter: Temsilci rollup sorgusu. GroupBy boş (temsilci) veya "queue" (temsilci ve kuyruk) olabilir.
+type AgentKPIFilter struct {
+	TenantID    string
+	Granularity string
+	From, To    time.Time
+	AgentUserID string
+	Queue       string
+	GroupBy     string
+}
+
+// msBetween: İki zaman arasındaki milisaniye; negatif süre sıfır sayılır.
+func msBetween(from, to string) string {
+	return fmt.Sprintf("GREATEST(0, (EXTRACT(EPOCH FROM (%s - %s)) * 1000)::bigint)", to, from)
+}
+
+// ApplyAgentEvent: Kuyruk/temsilci olayını çağrıya yazar, süreleri yeniden hesaplar ve temsilci rollup'larını
+// günceller.
+func (r *CallRepository) ApplyAgentEvent(ctx context.Context, callID, kind string, e AgentEvent) error {
+	var set string
+	args := []interface{}{callID, e.At}
+	switch kind {
+	case AgentQueueEntered:
+		set = "queue_entered_at = $2, queue_name = $3"
+		args = append(args, e.Queue)
+	case AgentConnected:
+		set = "agent_connected_at = $2, queue_name = COALESCE(NULLIF($3, ''), queue_name), agent_user_id = $4"
+		args = append(args, e.Queue, e.AgentUserID)
+	case AgentDisconnected:
+		set = `agent_disconnected_at = $2, queue_name = COALESCE(NULLIF($3, ''), queue_name),
+			agent_user_id = COALESCE(agent_user_id, NULLIF($4, '')), after_call_work_ms = COALESCE($5::bigint, after_call_work_ms)`
+		args = append(args, e.Queue, e.AgentUserID, e.AfterCallWorkMs)
+	default:
+		return fmt.Errorf("bilinmeyen temsilci olayı: %q", kind)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Aynı olayın tekrarı aynı değerleri yazar.
+	res, err := tx.ExecContext(ctx, "UPDATE calls SET "+set+", updated_at = NOW() WHERE call_id = $1", args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCallNotFound
+	}
+	if err := refreshAgentHandling(ctx, tx, callID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RefreshAgentHandling: Çağrı bittiğinde kuyrukta bağlanmadan bekleyen çağrının bekleme süresini tamamlar.
+func (r *CallRepository) RefreshAgentHandling(ctx context.Context, callID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := refreshAgentHandling(ctx, tx, callID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// refreshAgentHandling: Bekleme, konuşma ve işlem sürelerini olay zamanlarından hesaplar; temsilci ayrıldıysa
+// çağrının rollup katkısını yeniler. Kayıtlı katkı çıkarılıp güncel katkı eklenir (bkz. refreshCallKPI).
+func refreshAgentHandling(ctx context.Context, tx *sql.Tx, callID string) error {
+	talk := "CASE WHEN agent_connected_at IS NOT NULL AND agent_disconnected_at IS NOT NULL THEN " +
+		msBetween("agent_connected_at", "agent_disconnected_at") + " END"
+	var (
+		c                    agentContribution
+		connected            sql.NullTime
+		wait, talkMs, handle sql.NullInt64
+		afterCallWork        sql.NullInt64
+		snapshot             []byte
+	)
+	err := tx.QueryRowContext(ctx, `
+		UPDATE calls SET
+			queue_wait_ms = CASE
+				WHEN queue_entered_at IS NULL THEN NULL
+				WHEN agent_connected_at IS NOT NULL THEN `+msBetween("queue_entered_at", "agent_connected_at")+`
+				WHEN end_time IS NOT NULL THEN `+msBetween("queue_entered_at", "end_time")+`
+			END,
+			agent_talk_ms = `+talk+`,
+			agent_handle_ms = `+talk+` + COALESCE(after_call_work_ms, 0)
+		WHERE call_id = $1 AND (queue_entered_at IS NOT NULL OR agent_connected_at IS NOT NULL
+			OR agent_disconnected_at IS NOT NULL OR agent_snapshot IS NOT NULL)
+		RETURNING tenant_id, COALESCE(agent_user_id, ''), COALESCE(queue_name, ''), agent_connected_at, queue_wait_ms,
+			agent_talk_ms, after_call_work_ms, agent_handle_ms, agent_snapshot`, callID).Scan(
+		&c.TenantID, &c.AgentUserID, &c.Queue, &connected, &wait, &talkMs, &afterCallWork, &handle, &snapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var next *agentContribution
+	if c.AgentUserID != "" && talkMs.Valid {
+		c.ConnectedAt = connected.Time.UTC()
+		c.TalkMs, c.AfterCallWorkMs, c.HandleMs = talkMs.Int64, afterCallWork.Int64, handle.Int64
+		if wait.Valid {
+			c.Queued, c.QueueWaitMs = 1, wait.Int64
+		}
+		next = &c
+	}
+
+	var prev *agentContribution
+	if len(snapshot) > 0 {
+		prev = &agentContribution{}
+		if err := json.Unmarshal(snapshot, prev); err != nil {
+			return fmt.Errorf("agent_snapshot çözülemedi: %w", err)
+		}
+	}
+	if prev == nil && next == nil || prev != nil && next != nil && prev.equal(*next) {
+		return nil
+	}
+
+	if prev != nil {
+		if err := applyAgentRollup(ctx, tx, *prev, -1); err != nil {
+			return err
+		}
+	}
+	var value interface{}
+	if next != nil {
+		if err := applyAgentRollup(ctx, tx, *next, 1); err != nil {
+			return err
+		}
+		b, err := json.Marshal(next)
+		if err != nil {
+			return err
+		}
+		value = string(b)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE calls SET agent_snapshot = $2 WHERE call_id = $1", callID, value)
+	return err
+}
+
+// applyAgentRollup: Katkıyı sign (+1/-1) ile her çözünürlükteki temsilci kovasına ekler. Boşalan kova silinir.
+func applyAgentRollup(ctx context.Context, tx *sql.Tx, c agentContribution, sign int64) error {
+	for _, gr := range Granularities {
+		g, bucket := gr.Name, c.ConnectedAt.Truncate(gr.Bucket)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO cdr_agent_rollups AS a (tenant_id, granularity, bucket_start, agent_user_id, queue_name,
+				handled_calls, queued_calls, queue_wait_ms, talk_ms, after_call_work_ms, handle_ms)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (tenant_id, granularity, bucket_start, agent_user_id, queue_name) DO UPDATE SET
+				handled_calls = a.handled_calls + EXCLUDED.handled_calls,
+				queued_calls = a.queued_calls + EXCLUDED.queued_calls,
+				queue_wait_ms = a.queue_wait_ms + EXCLUDED.queue_wait_ms, talk_ms = a.talk_ms + EXCLUDED.talk_ms,
+				after_call_work_ms = a.after_call_work_ms + EXCLUDED.after_call_work_ms,
+				handle_ms = a.handle_ms + EXCLUDED.handle_ms, updated_at = NOW()`,
+			c.TenantID, g, bucket, c.AgentUserID, c.Queue, sign, sign*c.Queued, sign*c.QueueWaitMs, sign*c.TalkMs,
+			sign*c.AfterCallWorkMs, sign*c.HandleMs)
+		if err != nil {
+			return err
+		}
+		if sign < 0 {
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM cdr_agent_rollups
+				WHERE tenant_id = $1 AND granularity = $2 AND bucket_start = $3 AND agent_user_id = $4 AND queue_name = $5
+				  AND handled_calls = 0`, c.TenantID, g, bucket, c.AgentUserID, c.Queue)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListAgentKPIs: Filtredeki temsilci kovalarını, seçilen boyuta göre gruplanmış olarak döner.
+func (r *KPIRepository) ListAgentKPIs(ctx context.Context, f AgentKPIFilter) ([]AgentKPIBucket, error) {
+	dims := "agent_user_id, ''"
+	if f.GroupBy == "queue" {
+		dims = "agent_user_id, queue_name"
+	}
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT bucket_start, %s, SUM(handled_calls), SUM(queued_calls), SUM(queue_wait_ms), SUM(talk_ms),
+			SUM(after_call_work_ms), SUM(handle_ms)
+		FROM cdr_agent_rollups
+		WHERE tenant_id = $1 AND granularity = $2 AND bucket_start >= $3 AND bucket_start < $4
+		  AND ($5 = '' OR agent_user_id = $5) AND ($6 = '' OR queue_name = $6)
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`, dims), f.TenantID, f.Granularity, f.From, f.To, f.AgentUserID, f.Queue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []AgentKPIBucket{}
+	for rows.Next() {
+		var b AgentKPIBucket
+		var queued, waitMs, talkMs, acwMs, handleMs int64
+		if err := rows.Scan(&b.BucketStart, &b.AgentUserID, &b.Queue, &b.HandledCalls, &queued, &waitMs, &talkMs,
+			&acwMs, &handleMs); err != nil {
+			return nil, err
+		}
+		b.AvgQueueWait = ratio(waitMs, queued) / 1000
+		b.AvgTalk = ratio(talkMs, b.HandledCalls) / 1000
+		b.AvgAfterCallWork = ratio(acwMs, b.HandledCalls) / 1000
+		b.AHT = ratio(handleMs, b.HandledCalls) / 1000
+		b.HandleMinutes = float64(handleMs) / 60000
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,254 @@
+// sentiric-cdr-service/internal/repository/anomaly.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Anomali durumları.
+const (
+	AnomalyOpen     = "OPEN"
+	AnomalyResolved = "RESOLVED"
+)
+
+// anomalyLockKey: Aynı anda tek bir anomali değerlendirmesine izin veren advisory lock anahtarı.
+const anomalyLockKey = 72054
+
+// ErrAnomalyBusy: Başka bir kopya anomali değerlendirmesini yürütüyor.
+var ErrAnomalyBusy = errors.New("başka bir anomali değerlendirmesi sürüyor")
+
+// FlowStats: Tenant ve trunk için son penceredeki ve önceki taban çizgisi aralığındaki çağrı sayıları.
+// Started penceredeki başlayan, diğer sayılar biten çağrılardır.
+type FlowStats struct {
+	TenantID string
+	Trunk    string
+
+	Started      int
+	Ended        int
+	FailedBusy   int
+	NoAnswer     int
+	ZeroDuration int
+
+	BaseEnded        int
+	BaseFailedBusy   int
+	BaseNoAnswer     int
+	BaseZeroDuration int
+
+	LastEndedAt *time.Time
+}
+
+// Anomaly: Tenant ve trunk için tespit edilen anomali.
+type Anomaly struct {
+	ID         int64                  `json:"id"`
+	TenantID   string                 `json:"tenant_id"`
+	Trunk      string                 `json:"trunk"`
+	Kind       string                 `json:"kind"`
+	Status     string                 `json:"status"`
+	Evidence   map[string]interface{} `json:"evidence"`
+	DetectedAt time.Time              `json:"detected_at"`
+	LastSeenAt time.Time              `json:"last_seen_at"`
+	ResolvedAt *time.Time             `json:"resolved_at,omitempty"`
+}
+
+// AnomalyFilter: Anomali sorgusu. Boş alanlar süzmez.
+type AnomalyFilter struct {
+	TenantID string
+	Status   string
+	Limit    int
+}
+
+const anomalyColumns = `id, tenant_id, trunk, kind, status, evidence::text, detected_at, last_seen_at, resolved_at`
+
+type AnomalyRepository struct {
+	db *sql.DB
+}
+
+func NewAnomalyRepository(db *sql.DB) *AnomalyRepository {
+	return &AnomalyRepository{db: db}
+}
+
+// Lock: Değerlendirme için oturum düzeyinde advisory lock alır. Kilit başka kopyadaysa ErrAnomalyBusy döner.
+func (r *AnomalyRepository) Lock(ctx context.Context) (func(), error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", anomalyLockKey).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, ErrAnomalyBusy
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", anomalyLockKey)
+		conn.Close()
+	}, nil
+}
+
+// FlowStats: (split, now] penceresini ve (since, split] taban çizgisini tenant ve trunk bazında sayar. Dahili
+// çağrılar sayılmaz. Sıfır süre, başlangıçtan bitişe geçen sürenin sıfır olmasıdır.
+func (r *AnomalyRepository) FlowStats(ctx context.Context, since, split, now time.Time) ([]FlowStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, COALESCE(trunk, ''),
+			COUNT(*) FILTER (WHERE start_time > $2 AND start_time <= $3),
+			COUNT(*) FILTER (WHERE end_time > $2 AND end_time <= $3),
+			COUNT(*) FILTER (WHERE end_time > $2 AND end_time <= $3 AND disposition IN ('FAILED', 'BUSY')),
+			COUNT(*) FILTER (WHERE end_time > $2 AND end_time <= $3 AND disposition = 'NO_ANSWER'),
+			COUNT(*) FILTER (WHERE end_time > $2 AND end_time <= $3 AND COALESCE(total_duration_ms, duration_seconds * 1000) = 0),
+			COUNT(*) FILTER (WHERE end_time > $1 AND end_time <= $2),
+			COUNT(*) FILTER (WHERE end_time > $1 AND end_time <= $2 AND disposition IN ('FAILED', 'BUSY')),
+			COUNT(*) FILTER (WHERE end_time > $1 AND end_time <= $2 AND disposition = 'NO_ANSWER'),
+			COUNT(*) FILTER (WHERE end_time > $1 AND end_time <= $2 AND COALESCE(total_duration_ms, duration_seconds * 1000) = 0),
+			MAX(end_time) FILTER (WHERE end_time <= $3)
+		FROM calls
+		WHERE ((end_time > $1 AND end_time <= $3) OR (start_time > $2 AND start_time <= $3))
+		  AND COALESCE(direction, '') <> 'INTERNAL'
+		GROUP BY 1, 2`, since, split, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FlowStats
+	for rows.Next() {
+		var s FlowStats
+		var last sql.NullTime
+		if err := rows.Scan(&s.TenantID, &s.Trunk, &s.Started, &s.Ended, &s.FailedBusy, &s.NoAnswer, &s.ZeroDuration,
+			&s.BaseEnded, &s.BaseFailedBusy, &s.BaseNoAnswer, &s.BaseZeroDuration, &last); err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			t := last.Time
+			s.LastEndedAt = &t
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func scanAnomaly(row rowScanner) (Anomaly, error) {
+	var a Anomaly
+	var evidence string
+	var resolved sql.NullTime
+	if err := row.Scan(&a.ID, &a.TenantID, &a.Trunk, &a.Kind, &a.Status, &evidence, &a.DetectedAt, &a.LastSeenAt,
+		&resolved); err != nil {
+		return a, err
+	}
+	if resolved.Valid {
+		t := resolved.Time
+		a.ResolvedAt = &t
+	}
+	return a, json.Unmarshal([]byte(evidence), &a.Evidence)
+}
+
+// ListAnomalies: Anomaliler, en yeni önce.
+func (r *AnomalyRepository) ListAnomalies(ctx context.Context, f AnomalyFilter) ([]Anomaly, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+anomalyColumns+`
+		FROM cdr_anomalies
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT NULLIF($3, 0)`, f.TenantID, f.Status, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Anomaly{}
+	for rows.Next() {
+		a, err := scanAnomaly(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// OpenAnomaly: Anomaliyi OPEN olarak kaydeder ve aynı transaction'da outbox'a yazar. Aynı tenant, trunk ve tür
+// için açık kayıt varsa yalnızca kanıt ve last_seen_at güncellenir; olay yayınlanmaz ve false döner.
+func (r *AnomalyRepository) OpenAnomaly(ctx context.Context, a *Anomaly, eventType string) (bool, error) {
+	evidence, err := json.Marshal(a.Evidence)
+	if err != nil {
+		return false, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO cdr_anomalies (tenant_id, trunk, kind, evidence) VALUES ($1, $2, $3, $4::jsonb)
+		ON CONFLICT (tenant_id, trunk, kind) WHERE status = 'OPEN' DO NOTHING
+		RETURNING id, status, detected_at, last_seen_at`, a.TenantID, a.Trunk, a.Kind, string(evidence)).
+		Scan(&a.ID, &a.Status, &a.DetectedAt, &a.LastSeenAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE cdr_anomalies SET evidence = $4::jsonb, last_seen_at = NOW()
+			WHERE tenant_id = $1 AND trunk = $2 AND kind = $3 AND status = 'OPEN'`,
+			a.TenantID, a.Trunk, a.Kind, string(evidence))
+		if err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := enqueueOutboxEvent(ctx, tx, eventType, a.TenantID, anomalyPayload(*a)); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ResolveAnomaly: Açık anomaliyi kapatır ve aynı transaction'da outbox'a yazar. Kayıt zaten kapalıysa false döner.
+func (r *AnomalyRepository) ResolveAnomaly(ctx context.Context, id int64, evidence map[string]interface{}, eventType string) (bool, error) {
+	body, err := json.Marshal(evidence)
+	if err != nil {
+		return false, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	a, err := scanAnomaly(tx.QueryRowContext(ctx, `
+		UPDATE cdr_anomalies SET status = 'RESOLVED', resolved_at = NOW(), evidence = $2::jsonb
+		WHERE id = $1 AND status = 'OPEN'
+		RETURNING `+anomalyColumns, id, string(body)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := enqueueOutboxEvent(ctx, tx, eventType, a.TenantID, anomalyPayload(a)); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func anomalyPayload(a Anomaly) map[string]interface{} {
+	payload := map[string]interface{}{
+		"anomaly_id":  a.ID,
+		"tenant_id":   a.TenantID,
+		"trunk":       a.Trunk,
+		"kind":        a.Kind,
+		"status":      a.Status,
+		"detected_at": a.DetectedAt,
+		"evidence":    a.Evidence,
+	}
+	if a.ResolvedAt != nil {
+		payload["resolved_at"] = *a.ResolvedAt
+	}
+	return payload
+}
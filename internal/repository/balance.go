@@ -0,0 +1,171 @@
+// sentiric-cdr-service/internal/repository/balance.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+)
+
+// Bakiye defteri kayıt tipleri.
+const (
+	LedgerUsage      = "USAGE"
+	LedgerCredit     = "CREDIT"
+	LedgerAdjustment = "ADJUSTMENT"
+)
+
+// ErrNoPrepaidBalance: Tenant için ön ödemeli bakiye tanımı yok.
+var ErrNoPrepaidBalance = errors.New("tenant için ön ödemeli bakiye tanımı yok")
+
+// LedgerEntry: Defterin tek bir hareketi.
+type LedgerEntry struct {
+	TenantID      string
+	EntryType     string
+	Amount        float64 // Kullanımda negatif, yüklemede pozitif
+	CallID        interface{}
+	UsageRecordID interface{}
+	Reference     interface{}
+}
+
+// applyLedgerEntry: Bakiyeyi günceller, deftere yazar ve eşik geçişinde outbox'a olay ekler.
+// Ön ödemeli olmayan tenant'lar için ErrNoPrepaidBalance döner; çağıran taraf bunu yok sayabilir.
+func applyLedgerEntry(ctx context.Context, tx *sql.Tx, e LedgerEntry) (float64, error) {
+	var balance, threshold float64
+	var previousState, currency string
+	err := tx.QueryRowContext(ctx, `
+		SELECT state FROM tenant_balances WHERE tenant_id = $1 AND is_prepaid FOR UPDATE`, e.TenantID).
+		Scan(&previousState)
+	if err == sql.ErrNoRows {
+		return 0, ErrNoPrepaidBalance
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE tenant_balances SET balance = balance + $1, updated_at = NOW()
+		WHERE tenant_id = $2
+		RETURNING balance::float8, low_balance_threshold::float8, currency`, e.Amount, e.TenantID).
+		Scan(&balance, &threshold, &currency)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tenant_balance_ledger (tenant_id, entry_type, amount, balance_after, call_id, usage_record_id, reference)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		e.TenantID, e.EntryType, e.Amount, balance, e.CallID, e.UsageRecordID, e.Reference)
+	if err != nil {
+		return 0, err
+	}
+
+	state := billing.BalanceState(balance, threshold)
+	if state == previousState {
+		return balance, nil
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE tenant_balances SET state = $1 WHERE tenant_id = $2", state, e.TenantID); err != nil {
+		return 0, err
+	}
+	payload := map[string]interface{}{
+		"tenant_id":      e.TenantID,
+		"balance":        balance,
+		"threshold":      threshold,
+		"currency":       currency,
+		"previous_state": previousState,
+		"state":          state,
+	}
+	if e.CallID != nil {
+		payload["call_id"] = e.CallID
+	}
+	return balance, enqueueOutboxEvent(ctx, tx, billing.BalanceStateEvent(state), e.TenantID, payload)
+}
+
+// BalanceRepository: Bakiye yüklemeleri ve defter sorguları.
+type BalanceRepository struct {
+	db *sql.DB
+}
+
+func NewBalanceRepository(db *sql.DB) *BalanceRepository {
+	return &BalanceRepository{db: db}
+}
+
+// Credit: Bakiyeye yükleme (veya negatif düzeltme) yapar ve yeni bakiyeyi döner.
+func (r *BalanceRepository) Credit(ctx context.Context, tenantID, entryType string, amount float64, reference string) (float64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	balance, err := applyLedgerEntry(ctx, tx, LedgerEntry{
+		TenantID:  tenantID,
+		EntryType: entryType,
+		Amount:    amount,
+		Reference: reference,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return balance, tx.Commit()
+}
+
+// TenantBalance: Bakiye özeti.
+type TenantBalance struct {
+	TenantID     string    `json:"tenant_id"`
+	Balance      float64   `json:"balance"`
+	Currency     string    `json:"currency"`
+	LowThreshold float64   `json:"low_balance_threshold"`
+	State        string    `json:"state"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (r *BalanceRepository) Get(ctx context.Context, tenantID string) (TenantBalance, error) {
+	var b TenantBalance
+	err := r.db.QueryRowContext(ctx, `
+		SELECT tenant_id, balance::float8, currency, low_balance_threshold::float8, state, updated_at
+		FROM tenant_balances WHERE tenant_id = $1 AND is_prepaid`, tenantID).
+		Scan(&b.TenantID, &b.Balance, &b.Currency, &b.LowThreshold, &b.State, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return b, ErrNoPrepaidBalance
+	}
+	return b, err
+}
+
+// LedgerLine: Defter sorgusunun tek satırı.
+type LedgerLine struct {
+	ID            int64     `json:"id"`
+	EntryType     string    `json:"entry_type"`
+	Amount        float64   `json:"amount"`
+	BalanceAfter  float64   `json:"balance_after"`
+	CallID        string    `json:"call_id,omitempty"`
+	UsageRecordID string    `json:"usage_record_id,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (r *BalanceRepository) ListLedger(ctx context.Context, tenantID string, from, to time.Time, limit int) ([]LedgerLine, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, entry_type, amount::float8, balance_after::float8,
+			COALESCE(call_id, ''), COALESCE(usage_record_id, ''), COALESCE(reference, ''), created_at
+		FROM tenant_balance_ledger
+		WHERE tenant_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY id DESC
+		LIMIT $4`, tenantID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []LedgerLine{}
+	for rows.Next() {
+		var l LedgerLine
+		if err := rows.Scan(&l.ID, &l.EntryType, &l.Amount, &l.BalanceAfter, &l.CallID, &l.UsageRecordID, &l.Reference, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
@@ -0,0 +1,202 @@
+// sentiric-cdr-service/internal/repository/call_legs.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Leg tipleri: Bir etkileşim içindeki çağrı bacağının nasıl oluştuğunu belirtir.
+const (
+	LegTypePrimary    = "PRIMARY"
+	LegTypeTransfer   = "TRANSFER"
+	LegTypeBridge     = "BRIDGE"
+	LegTypeFork       = "FORK"
+	LegTypeConference = "CONFERENCE"
+)
+
+// ErrCrossTenantLegs: Bağlanmak istenen bacaklar (veya etkileşimleri) farklı tenant'lara ait; bağlantı yapılmaz.
+var ErrCrossTenantLegs = errors.New("bacaklar farklı tenant'lara ait")
+
+// errLegsMoved: Kilit alınırken bacaklardan biri başka etkileşime taşındı; bağlama baştan denenir.
+var errLegsMoved = errors.New("bacak kilit beklenirken başka etkileşime taşındı")
+
+// LinkLegs: childCallID bacağını parentCallID'nin etkileşimine bağlar.
+// Olaylar sırasız gelebileceği için call_legs satırları calls kaydından bağımsız oluşturulur.
+// Çocuk bacak daha önce kendi etkileşimini başlatmışsa, o grup parent'ın etkileşimine taşınır.
+// Çocuk parent'ın atası ise (örn. köprünün karşı bacağından gelen ters yönlü olay) bağlantı döngü oluşturacağı
+// için yok sayılır; böylece etkileşimin kök bacağı hiçbir zaman başka bir bacağın çocuğu yapılmaz.
+// İki etkileşimin kayıtlı çağrıları ve olayın tenant'ı (boş değilse) aynı tenant'a ait olmalıdır; aksi halde
+// ErrCrossTenantLegs döner.
+func (r *CallRepository) LinkLegs(ctx context.Context, tenantID, parentCallID, childCallID, legType string) error {
+	if parentCallID == "" || childCallID == "" || parentCallID == childCallID {
+		return nil
+	}
+	for attempt := 1; ; attempt++ {
+		err := r.linkLegs(ctx, tenantID, parentCallID, childCallID, legType)
+		if !errors.Is(err, errLegsMoved) || attempt == 3 {
+			return err
+		}
+	}
+}
+
+func (r *CallRepository) linkLegs(ctx context.Context, tenantID, parentCallID, childCallID, legType string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 1. Parent bacak yoksa kendi etkileşiminin ilk bacağı olarak aç.
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO call_legs (call_id, interaction_id, leg_sequence, leg_type)
+		VALUES ($1, $1, 1, 'PRIMARY')
+		ON CONFLICT (call_id) DO NOTHING`, parentCallID)
+	if err != nil {
+		return err
+	}
+
+	interactionID, childInteraction, err := legInteractions(ctx, tx, parentCallID, childCallID)
+	if err != nil {
+		return err
+	}
+
+	// 2. Her iki etkileşim de sabit sırayla kilitlenir: aynı etkileşime eşzamanlı bağlanan bacakların sıra numarası
+	// çakışmaz, çocuğun grubu taşınırken ona bağlanan olmaz ve ters sırada bağlanan iki olay kilitlenmez.
+	locks := []string{interactionID}
+	if childInteraction.Valid && childInteraction.String != interactionID {
+		locks = append(locks, childInteraction.String)
+		slices.Sort(locks)
+	}
+	for _, id := range locks {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", id); err != nil {
+			return err
+		}
+	}
+	lockedInteraction, lockedChild, err := legInteractions(ctx, tx, parentCallID, childCallID)
+	if err != nil {
+		return err
+	}
+	if lockedInteraction != interactionID || lockedChild != childInteraction {
+		return errLegsMoved
+	}
+
+	var childParent sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT parent_call_id FROM call_legs WHERE call_id = $1 FOR UPDATE", childCallID).
+		Scan(&childParent)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if childParent.Valid && childParent.String == parentCallID {
+		// Tekrar gelen (duplicate) olay.
+		return tx.Commit()
+	}
+
+	if err := checkLegTenants(ctx, tx, tenantID, parentCallID, childCallID, locks); err != nil {
+		return err
+	}
+
+	// 3. Çocuk, parent'ın atasıysa (etkileşimin kökü dahil) bağlantı döngü kurar.
+	if childInteraction.Valid && childInteraction.String == interactionID {
+		var ancestor bool
+		err = tx.QueryRowContext(ctx, `
+			WITH RECURSIVE up AS (
+				SELECT call_id, parent_call_id FROM call_legs WHERE call_id = $1
+				UNION
+				SELECT l.call_id, l.parent_call_id FROM call_legs l JOIN up ON l.call_id = up.parent_call_id
+			)
+			SELECT EXISTS(SELECT 1 FROM up WHERE call_id = $2) OR $2 = $3`,
+			parentCallID, childCallID, interactionID).Scan(&ancestor)
+		if err != nil {
+			return err
+		}
+		if ancestor {
+			r.log.Warn().Str("parent_call_id", parentCallID).Str("child_call_id", childCallID).Str("leg_type", legType).
+				Msg("Bacak bağlantısı döngü oluşturacağı için yok sayıldı.")
+			return tx.Commit()
+		}
+	}
+
+	var maxSeq int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(leg_sequence), 0) FROM call_legs WHERE interaction_id = $1", interactionID).Scan(&maxSeq); err != nil {
+		return err
+	}
+
+	var transferredFrom interface{}
+	if legType == LegTypeTransfer {
+		transferredFrom = parentCallID
+	}
+
+	if childInteraction.Valid {
+		if childInteraction.String != interactionID {
+			// 4a. Çocuğun mevcut grubunu sıra numaralarını kaydırarak birleştir.
+			_, err = tx.ExecContext(ctx, `
+				UPDATE call_legs SET
+					interaction_id = $1,
+					leg_sequence = leg_sequence + $2,
+					updated_at = NOW()
+				WHERE interaction_id = $3`, interactionID, maxSeq, childInteraction.String)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE call_legs SET
+				parent_call_id = $1,
+				leg_type = $2,
+				transferred_from_call_id = COALESCE($3, transferred_from_call_id),
+				updated_at = NOW()
+			WHERE call_id = $4`, parentCallID, legType, transferredFrom, childCallID)
+	} else {
+		// 4b. Yeni bacak etkileşimin sonuna eklenir.
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO call_legs (call_id, interaction_id, parent_call_id, leg_sequence, leg_type, transferred_from_call_id)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			childCallID, interactionID, parentCallID, maxSeq+1, legType, transferredFrom)
+	}
+	if err != nil {
+		return err
+	}
+
+	if legType == LegTypeTransfer {
+		_, err = tx.ExecContext(ctx, "UPDATE call_legs SET transferred_to_call_id = $1, updated_at = NOW() WHERE call_id = $2", childCallID, parentCallID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// legInteractions: Parent bacağın ve (varsa) çocuk bacağın bulunduğu etkileşimler.
+func legInteractions(ctx context.Context, tx *sql.Tx, parentCallID, childCallID string) (string, sql.NullString, error) {
+	var parent string
+	var child sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT (SELECT interaction_id FROM call_legs WHERE call_id = $1),
+			(SELECT interaction_id FROM call_legs WHERE call_id = $2)`, parentCallID, childCallID).Scan(&parent, &child)
+	return parent, child, err
+}
+
+// checkLegTenants: Bağlantının birleştireceği etkileşimlerin kayıtlı çağrılarının tek bir tenant'a ait olduğunu
+// doğrular. Çağrı kaydı henüz oluşmamış bacaklar denetlenemez; bu durumda okuma tarafı tenant ile sınırlıdır.
+func checkLegTenants(ctx context.Context, tx *sql.Tx, tenantID, parentCallID, childCallID string, interactions []string) error {
+	var tenants int
+	var tenant sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT NULLIF(tenant_id, '')), MIN(NULLIF(tenant_id, ''))
+		FROM calls
+		WHERE call_id IN ($1, $2)
+		   OR call_id IN (SELECT call_id FROM call_legs WHERE interaction_id = ANY(string_to_array($3, ',')))`,
+		parentCallID, childCallID, strings.Join(interactions, ",")).Scan(&tenants, &tenant)
+	if err != nil {
+		return err
+	}
+	if tenants > 1 || (tenants == 1 && tenantID != "" && tenant.String != tenantID) {
+		return ErrCrossTenantLegs
+	}
+	return nil
+}
@@ -0,0 +1,568 @@
+// sentiric-cdr-service/internal/repository/call_query.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+)
+
+// CallFilter: Sorgu ve export yollarının ortak filtre modeli.
+type CallFilter struct {
+	TenantID      string
+	From          time.Time
+	To            time.Time
+	Direction     string
+	UserID        string
+	InteractionID string
+	CallID        string
+	Limit         int
+	Offset        int
+	// IncludeEvents: Her bacağın call_events satırlarını zaman sırasıyla CallRecord.Events'e ekler.
+	IncludeEvents bool
+	// Number: Arayan veya aranan numarası eşit olan bacaklar; şifreli satırlar kör indeksle eşleşir.
+	Number string
+	// LowMOS: Yalnızca en kötü yönünün MOS'u LowMOSThreshold altında kalan bacaklar.
+	LowMOS bool
+	// Intent: Tespit edilen niyetleri arasında bu niyet bulunan bacaklar (küçük harf).
+	Intent string
+	// Sentiment: Duygu skoru SentimentNegative, SentimentNeutral veya SentimentPositive bandında olan bacaklar.
+	Sentiment string
+	// AgentHandoff: Verilirse yalnızca insan temsilciye devredilen (true) veya devredilmeyen (false) bacaklar.
+	AgentHandoff *bool
+	// IVRLastNode, IVRExitReason: IVR'dan bu düğümde ve/veya bu nedenle çıkan bacaklar.
+	IVRLastNode   string
+	IVRExitReason string
+	// AgentUserID, Queue: Bu temsilcinin karşıladığı ve/veya bu kuyruğa giren bacaklar.
+	AgentUserID string
+	Queue       string
+}
+
+// CallRecord: Bacak (leg) bazlı CDR görünümü.
+type CallRecord struct {
+	CallID                string     `json:"call_id"`
+	TenantID              string     `json:"tenant_id"`
+	Direction             string     `json:"direction"`
+	CallerNumber          string     `json:"caller_number"`
+	CalleeNumber          string     `json:"callee_number"`
+	UserID                string     `json:"user_id,omitempty"`
+	Status                string     `json:"status"`
+	Disposition           string     `json:"disposition,omitempty"`
+	HangupSource          string     `json:"hangup_source,omitempty"`
+	StartTime             time.Time  `json:"start_time"`
+	AnswerTime            *time.Time `json:"answer_time,omitempty"`
+	EndTime               *time.Time `json:"end_time,omitempty"`
+	DurationSeconds       int        `json:"duration_seconds"`
+	RingSeconds           int        `json:"ring_seconds"`
+	TotalSeconds          int        `json:"total_duration_seconds"`
+	BillableSeconds       int        `json:"billable_seconds"`
+	HoldSeconds           int        `json:"hold_seconds"`
+	TalkSeconds           int        `json:"talk_seconds"`
+	PostDialDelayMs       *int       `json:"post_dial_delay_ms,omitempty"`
+	TotalDurationMs       int64      `json:"total_duration_ms"`
+	BillableDurationMs    int64      `json:"billable_duration_ms"`
+	TotalCost             float64    `json:"total_cost"`
+	RecordingURL          string     `json:"recording_url,omitempty"`
+	InteractionID         string     `json:"interaction_id"`
+	ParentCallID          string     `json:"parent_call_id,omitempty"`
+	LegSequence           int        `json:"leg_sequence"`
+	LegType               string     `json:"leg_type"`
+	TransferredFromCallID string     `json:"transferred_from_call_id,omitempty"`
+	TransferredToCallID   string     `json:"transferred_to_call_id,omitempty"`
+	// Quality: Medya kalite özeti; kalite olayı gelmemişse boştur.
+	Quality *CallQuality `json:"quality,omitempty"`
+	// Conversation: Transkript, özet ve duygu analizi; hiçbiri gelmemişse boştur.
+	Conversation *CallConversation `json:"conversation,omitempty"`
+	// IVR: IVR yolunun özeti; çağrı IVR'a girmemişse boştur. Adımlar GET /v1/calls/{call_id}/ivr-path ile okunur.
+	IVR *CallIVR `json:"ivr,omitempty"`
+	// Agent: Kuyruk ve temsilci bilgisi; çağrı kuyruğa girmemiş ve temsilciye bağlanmamışsa boştur.
+	Agent *CallAgent `json:"agent,omitempty"`
+	// Events: Yalnızca CallFilter.IncludeEvents ile doldurulur; call_events satırlarının JSON dizisidir.
+	Events json.RawMessage `json:"events,omitempty"`
+}
+
+// CallQuality: Yön bazlı RTP/RTCP kalite özeti. Özeti gelmeyen yönün alanları boştur.
+type CallQuality struct {
+	Codec                 string   `json:"codec,omitempty"`
+	MOSInbound            *float64 `json:"mos_inbound,omitempty"`
+	MOSOutbound           *float64 `json:"mos_outbound,omitempty"`
+	JitterInboundMs       *float64 `json:"jitter_inbound_ms,omitempty"`
+	JitterOutboundMs      *float64 `json:"jitter_outbound_ms,omitempty"`
+	PacketLossInboundPct  *float64 `json:"packet_loss_inbound_pct,omitempty"`
+	PacketLossOutboundPct *float64 `json:"packet_loss_outbound_pct,omitempty"`
+	RTTMs                 *float64 `json:"rtt_ms,omitempty"`
+	LowMOS                bool     `json:"low_mos"`
+}
+
+// CallConversation: Çağrının konuşma çıktıları.
+type CallConversation struct {
+	TranscriptURI  string   `json:"transcript_uri,omitempty"`
+	Language       string   `json:"language,omitempty"`
+	Summary        string   `json:"summary,omitempty"`
+	SentimentScore *float64 `json:"sentiment_score,omitempty"`
+	Intents        []string `json:"intents,omitempty"`
+	AgentHandoff   *bool    `json:"agent_handoff,omitempty"`
+	HandoffReason  string   `json:"handoff_reason,omitempty"`
+}
+
+// CallIVR: Çağrının IVR özeti. ExitReason çıkış olayı veya çağrı bitişi gelene kadar boştur.
+type CallIVR struct {
+	NodeCount  int    `json:"node_count"`
+	LastNode   string `json:"last_node,omitempty"`
+	ExitReason string `json:"exit_reason,omitempty"`
+	DropOff    bool   `json:"drop_off"`
+}
+
+// CallAgent: Temsilciye devredilen çağrının kuyruk bekleme ve temsilci işlem süreleri. QueueAbandoned, kuyruğa
+// girip temsilciye bağlanmadan biten çağrıları işaretler.
+type CallAgent struct {
+	Queue           string     `json:"queue,omitempty"`
+	QueueEnteredAt  *time.Time `json:"queue_entered_at,omitempty"`
+	QueueWaitMs     *int64     `json:"queue_wait_ms,omitempty"`
+	QueueAbandoned  bool       `json:"queue_abandoned"`
+	AgentUserID     string     `json:"agent_user_id,omitempty"`
+	ConnectedAt     *time.Time `json:"connected_at,omitempty"`
+	DisconnectedAt  *time.Time `json:"disconnected_at,omitempty"`
+	TalkMs          *int64     `json:"talk_ms,omitempty"`
+	AfterCallWorkMs *int64     `json:"after_call_work_ms,omitempty"`
+	HandleMs        *int64     `json:"handle_ms,omitempty"`
+}
+
+// JourneyRecord: Bir etkileşimin tüm bacaklarının tek kayıtta birleştirilmiş hali.
+type JourneyRecord struct {
+	InteractionID    string     `json:"interaction_id"`
+	TenantID         string     `json:"tenant_id"`
+	CallerNumber     string     `json:"caller_number"`
+	CalleeNumber     string     `json:"callee_number"`
+	StartTime        time.Time  `json:"start_time"`
+	EndTime          *time.Time `json:"end_time,omitempty"`
+	LegCount         int        `json:"leg_count"`
+	TotalTalkSeconds int        `json:"total_talk_seconds"`
+	TotalCost        float64    `json:"total_cost"`
+	FinalDisposition string     `json:"final_disposition,omitempty"`
+	CallIDs          []string   `json:"call_ids"`
+}
+
+// legColumns, legFrom: calls ile call_legs birleşimi. Bacak kaydı olmayan çağrı kendi etkileşimidir.
+const legColumns = `
+	SELECT
+		c.call_id, c.tenant_id, COALESCE(c.direction, ''), COALESCE(c.caller_number, ''), COALESCE(c.callee_number, ''),
+		COALESCE(c.user_id::text, ''), COALESCE(c.status, ''), COALESCE(c.disposition, ''), COALESCE(c.hangup_source, ''),
+		c.start_time, c.answer_time, c.end_time, COALESCE(c.duration_seconds, 0),
+		COALESCE(c.ring_seconds, 0), COALESCE(c.total_duration_seconds, 0), COALESCE(c.billable_seconds, 0),
+		COALESCE(c.hold_seconds, 0), COALESCE(c.talk_seconds, 0), c.post_dial_delay_ms,
+		COALESCE(c.total_duration_ms, 0), COALESCE(c.billable_duration_ms, 0),
+		COALESCE(c.total_cost, 0), COALESCE(c.recording_url, ''),
+		COALESCE(l.interaction_id, c.call_id), COALESCE(l.parent_call_id, ''), COALESCE(l.leg_sequence, 1),
+		COALESCE(l.leg_type, 'PRIMARY'), COALESCE(l.transferred_from_call_id, ''), COALESCE(l.transferred_to_call_id, ''),
+		COALESCE(c.codec, ''), c.mos_inbound::float8, c.mos_outbound::float8, c.jitter_inbound_ms::float8,
+		c.jitter_outbound_ms::float8, c.packet_loss_inbound_pct::float8, c.packet_loss_outbound_pct::float8,
+		c.rtt_ms::float8, c.low_mos,
+		COALESCE(c.transcript_uri, ''), COALESCE(c.conversation_language, ''), COALESCE(c.ai_summary, ''),
+		c.sentiment_score::float8, COALESCE(array_to_string(c.intents, ','), ''), c.agent_handoff,
+		COALESCE(c.handoff_reason, ''),
+		c.ivr_node_count, COALESCE(c.ivr_last_node, ''), COALESCE(c.ivr_exit_reason, ''),
+		COALESCE(c.queue_name, ''), c.queue_entered_at, c.queue_wait_ms, COALESCE(c.agent_user_id, ''),
+		c.agent_connected_at, c.agent_disconnected_at, c.agent_talk_ms, c.after_call_work_ms, c.agent_handle_ms`
+
+const legFrom = `
+	FROM calls c
+	LEFT JOIN call_legs l ON l.call_id = c.call_id`
+
+// legEventsColumn, legEventsJoin: IncludeEvents için eklenen olay dizisi. LATERAL alt sorgu her bacak için tek satır döner.
+const legEventsColumn = `, ev.events`
+
+const legEventsJoin = `
+	LEFT JOIN LATERAL (
+		SELECT json_agg(json_build_object(
+			'event_type', e.event_type, 'event_timestamp', e.event_timestamp, 'payload', e.payload
+		) ORDER BY e.event_timestamp) AS events
+		FROM call_events e WHERE e.call_id = c.call_id
+	) ev ON true`
+
+func (r *CallRepository) buildCallWhere(ctx context.Context, f CallFilter) (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, val interface{}) {
+		args = append(args, val)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	add("c.tenant_id = $%d", f.TenantID)
+	if !f.From.IsZero() {
+		add("c.start_time >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("c.start_time < $%d", f.To)
+	}
+	if f.Direction != "" {
+		add("c.direction = $%d", f.Direction)
+	}
+	if f.UserID != "" {
+		add("c.user_id::text = $%d", f.UserID)
+	}
+	if f.InteractionID != "" {
+		add("COALESCE(l.interaction_id, c.call_id) = $%d", f.InteractionID)
+	}
+	if f.CallID != "" {
+		add("c.call_id = $%d", f.CallID)
+	}
+	if f.LowMOS {
+		conds = append(conds, "c.low_mos")
+	}
+	if f.Intent != "" {
+		add("c.intents @> ARRAY[$%d::text]", strings.ToLower(f.Intent))
+	}
+	switch f.Sentiment {
+	case SentimentNegative:
+		add("c.sentiment_score < $%d::numeric", NegativeSentimentBelow)
+	case SentimentPositive:
+		add("c.sentiment_score > $%d::numeric", PositiveSentimentAbove)
+	case SentimentNeutral:
+		args = append(args, NegativeSentimentBelow, PositiveSentimentAbove)
+		conds = append(conds, fmt.Sprintf("c.sentiment_score BETWEEN $%d::numeric AND $%d::numeric", len(args)-1, len(args)))
+	}
+	if f.AgentHandoff != nil {
+		add("COALESCE(c.agent_handoff, false) = $%d::boolean", *f.AgentHandoff)
+	}
+	if f.IVRLastNode != "" {
+		add("c.ivr_last_node = $%d", f.IVRLastNode)
+	}
+	if f.IVRExitReason != "" {
+		add("c.ivr_exit_reason = $%d", f.IVRExitReason)
+	}
+	if f.AgentUserID != "" {
+		add("c.agent_user_id = $%d", f.AgentUserID)
+	}
+	if f.Queue != "" {
+		add("c.queue_name = $%d", f.Queue)
+	}
+	if f.Number != "" {
+		// Şifreleme öncesi yazılmış (düz metin) satırlar numaranın yazım biçimleriyle, şifreli satırlar
+		// kör indeksle bulunur.
+		variants := []string{f.Number}
+		if s, err := privacy.NewSubject(privacy.SubjectPhone, f.Number); err == nil {
+			variants = s.Variants()
+		}
+		index, err := r.keys.numberIndex(ctx, f.TenantID, f.Number)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, strings.Join(variants, ","), index)
+		conds = append(conds, fmt.Sprintf(
+			"(c.caller_number = ANY(string_to_array($%[1]d, ',')) OR c.callee_number = ANY(string_to_array($%[1]d, ','))"+
+				" OR c.caller_number_bidx = $%[2]d OR c.callee_number_bidx = $%[2]d)", len(args)-1, len(args)))
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+func appendPaging(query string, args []interface{}, f CallFilter) (string, []interface{}) {
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if f.Offset > 0 {
+		args = append(args, f.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return query, args
+}
+
+// ForEachCall: Filtreye uyan bacakları satır satır okur; sonuç belleğe toplanmaz.
+func (r *CallRepository) ForEachCall(ctx context.Context, f CallFilter, fn func(CallRecord) error) error {
+	where, args, err := r.buildCallWhere(ctx, f)
+	if err != nil {
+		return err
+	}
+	selectSQL := legColumns + legFrom
+	if f.IncludeEvents {
+		selectSQL = legColumns + legEventsColumn + legFrom + legEventsJoin
+	}
+	query, args := appendPaging(selectSQL+where+" ORDER BY c.start_time, c.call_id", args, f)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec CallRecord
+		var answerTime, endTime sql.NullTime
+		var pdd sql.NullInt32
+		var events []byte
+		var q callQualityRow
+		var conv callConversationRow
+		var ivr callIVRRow
+		var agent callAgentRow
+		dest := []interface{}{
+			&rec.CallID, &rec.TenantID, &rec.Direction, &rec.CallerNumber, &rec.CalleeNumber,
+			&rec.UserID, &rec.Status, &rec.Disposition, &rec.HangupSource,
+			&rec.StartTime, &answerTime, &endTime, &rec.DurationSeconds,
+			&rec.RingSeconds, &rec.TotalSeconds, &rec.BillableSeconds,
+			&rec.HoldSeconds, &rec.TalkSeconds, &pdd,
+			&rec.TotalDurationMs, &rec.BillableDurationMs,
+			&rec.TotalCost, &rec.RecordingURL,
+			&rec.InteractionID, &rec.ParentCallID, &rec.LegSequence,
+			&rec.LegType, &rec.TransferredFromCallID, &rec.TransferredToCallID,
+			&q.codec, &q.mosIn, &q.mosOut, &q.jitterIn, &q.jitterOut, &q.lossIn, &q.lossOut, &q.rtt, &q.lowMOS,
+			&conv.transcript, &conv.language, &conv.summary, &conv.sentiment, &conv.intents, &conv.handoff,
+			&conv.handoffReason,
+			&ivr.nodeCount, &ivr.lastNode, &ivr.exitReason,
+			&agent.queue, &agent.entered, &agent.wait, &agent.agentID, &agent.connected, &agent.disconnected,
+			&agent.talk, &agent.afterCallWork, &agent.handle,
+		}
+		if f.IncludeEvents {
+			dest = append(dest, &events)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		rec.AnswerTime = nullTimePtr(answerTime)
+		rec.EndTime = nullTimePtr(endTime)
+		rec.Quality = q.quality()
+		if conv.summary, err = r.keys.openField(ctx, fieldcrypt.FieldSummary, rec.CallID, conv.summary); err != nil {
+			return err
+		}
+		rec.Conversation = conv.conversation()
+		rec.IVR = ivr.ivr()
+		rec.Agent = agent.agent(endTime.Valid)
+		if pdd.Valid {
+			v := int(pdd.Int32)
+			rec.PostDialDelayMs = &v
+		}
+		if rec.CallerNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCallerNumber, rec.CallID, rec.CallerNumber); err != nil {
+			return err
+		}
+		if rec.CalleeNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCalleeNumber, rec.CallID, rec.CalleeNumber); err != nil {
+			return err
+		}
+		if f.IncludeEvents {
+			rec.Events = json.RawMessage("[]")
+			if len(events) > 0 {
+				if rec.Events, err = r.keys.openEvents(ctx, rec.CallID, events); err != nil {
+					return err
+				}
+			}
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListCalls: ForEachCall'un sayfalı API yanıtları için toplayan hali.
+func (r *CallRepository) ListCalls(ctx context.Context, f CallFilter) ([]CallRecord, error) {
+	records := []CallRecord{}
+	err := r.ForEachCall(ctx, f, func(rec CallRecord) error {
+		records = append(records, rec)
+		return nil
+	})
+	return records, err
+}
+
+// ForEachJourney: Filtreye uyan bacakların etkileşimlerini tüm bacaklarıyla birlikte konsolide eder.
+// Konuşma süresi yalnızca cevaplanan bacakların beklemede geçmeyen süresinin toplamıdır.
+// Etkileşime yalnızca filtredeki tenant'ın bacakları girer ($1, buildCallWhere'in ilk koşuludur); başka tenant'a
+// bağlanmış bir bacağın numaraları ve maliyeti bu tenant'ın etkileşimine karışmaz.
+func (r *CallRepository) ForEachJourney(ctx context.Context, f CallFilter, fn func(JourneyRecord) error) error {
+	where, args, err := r.buildCallWhere(ctx, f)
+	if err != nil {
+		return err
+	}
+	query := `
+		WITH scoped AS (
+			SELECT DISTINCT COALESCE(l.interaction_id, c.call_id) AS interaction_id
+			FROM calls c LEFT JOIN call_legs l ON l.call_id = c.call_id` + where + `
+		), legs AS (
+			SELECT c.*, COALESCE(l.interaction_id, c.call_id) AS interaction_id, COALESCE(l.leg_sequence, 1) AS leg_sequence
+			FROM calls c LEFT JOIN call_legs l ON l.call_id = c.call_id
+			WHERE COALESCE(l.interaction_id, c.call_id) IN (SELECT interaction_id FROM scoped) AND c.tenant_id = $1
+		)
+		SELECT
+			interaction_id,
+			MIN(tenant_id),
+			COALESCE((array_agg(caller_number ORDER BY leg_sequence, start_time))[1], ''),
+			COALESCE((array_agg(callee_number ORDER BY leg_sequence DESC, start_time DESC))[1], ''),
+			MIN(start_time),
+			MAX(end_time),
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN disposition = 'ANSWERED' THEN COALESCE(talk_seconds, duration_seconds) ELSE 0 END), 0),
+			COALESCE(SUM(total_cost), 0),
+			COALESCE((array_agg(disposition ORDER BY leg_sequence DESC, start_time DESC))[1], ''),
+			array_to_string(array_agg(call_id ORDER BY leg_sequence, start_time), ','),
+			(array_agg(call_id ORDER BY leg_sequence, start_time))[1],
+			(array_agg(call_id ORDER BY leg_sequence DESC, start_time DESC))[1]
+		FROM legs
+		GROUP BY interaction_id
+		ORDER BY MIN(start_time), interaction_id`
+	query, args = appendPaging(query, args, f)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec JourneyRecord
+		var endTime sql.NullTime
+		var callIDs, firstCallID, lastCallID string
+		err := rows.Scan(
+			&rec.InteractionID, &rec.TenantID, &rec.CallerNumber, &rec.CalleeNumber,
+			&rec.StartTime, &endTime, &rec.LegCount, &rec.TotalTalkSeconds, &rec.TotalCost,
+			&rec.FinalDisposition, &callIDs, &firstCallID, &lastCallID,
+		)
+		if err != nil {
+			return err
+		}
+		if rec.CallerNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCallerNumber, firstCallID, rec.CallerNumber); err != nil {
+			return err
+		}
+		if rec.CalleeNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCalleeNumber, lastCallID, rec.CalleeNumber); err != nil {
+			return err
+		}
+		rec.EndTime = nullTimePtr(endTime)
+		rec.CallIDs = strings.Split(callIDs, ",")
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListJourneys: ForEachJourney'in sayfalı API yanıtları için toplayan hali.
+func (r *CallRepository) ListJourneys(ctx context.Context, f CallFilter) ([]JourneyRecord, error) {
+	records := []JourneyRecord{}
+	err := r.ForEachJourney(ctx, f, func(rec JourneyRecord) error {
+		records = append(records, rec)
+		return nil
+	})
+	return records, err
+}
+
+// callQualityRow: legColumns'daki kalite kolonlarının taranmış hali.
+type callQualityRow struct {
+	codec                                                    string
+	mosIn, mosOut, jitterIn, jitterOut, lossIn, lossOut, rtt sql.NullFloat64
+	lowMOS                                                   sql.NullBool
+}
+
+func (q callQualityRow) quality() *CallQuality {
+	if !q.mosIn.Valid && !q.mosOut.Valid {
+		return nil
+	}
+	return &CallQuality{
+		Codec:                 q.codec,
+		MOSInbound:            nullFloatPtr(q.mosIn),
+		MOSOutbound:           nullFloatPtr(q.mosOut),
+		JitterInboundMs:       nullFloatPtr(q.jitterIn),
+		JitterOutboundMs:      nullFloatPtr(q.jitterOut),
+		PacketLossInboundPct:  nullFloatPtr(q.lossIn),
+		PacketLossOutboundPct: nullFloatPtr(q.lossOut),
+		RTTMs:                 nullFloatPtr(q.rtt),
+		LowMOS:                q.lowMOS.Bool,
+	}
+}
+
+// callConversationRow: legColumns'daki konuşma kolonlarının taranmış hali.
+type callConversationRow struct {
+	transcript, language, summary, intents, handoffReason string
+	sentiment                                             sql.NullFloat64
+	handoff                                               sql.NullBool
+}
+
+func (c callConversationRow) conversation() *CallConversation {
+	if c.transcript == "" && c.summary == "" && !c.sentiment.Valid && c.intents == "" && !c.handoff.Valid {
+		return nil
+	}
+	conv := &CallConversation{
+		TranscriptURI:  c.transcript,
+		Language:       c.language,
+		Summary:        c.summary,
+		SentimentScore: nullFloatPtr(c.sentiment),
+		HandoffReason:  c.handoffReason,
+	}
+	if c.intents != "" {
+		conv.Intents = strings.Split(c.intents, ",")
+	}
+	if c.handoff.Valid {
+		v := c.handoff.Bool
+		conv.AgentHandoff = &v
+	}
+	return conv
+}
+
+// callIVRRow: legColumns'daki IVR özet kolonlarının taranmış hali.
+type callIVRRow struct {
+	nodeCount            sql.NullInt32
+	lastNode, exitReason string
+}
+
+func (v callIVRRow) ivr() *CallIVR {
+	if !v.nodeCount.Valid || v.nodeCount.Int32 == 0 {
+		return nil
+	}
+	return &CallIVR{
+		NodeCount:  int(v.nodeCount.Int32),
+		LastNode:   v.lastNode,
+		ExitReason: v.exitReason,
+		DropOff:    IsIVRDropOff(v.exitReason),
+	}
+}
+
+// callAgentRow: legColumns'daki kuyruk ve temsilci kolonlarının taranmış hali.
+type callAgentRow struct {
+	queue, agentID                    string
+	entered, connected, disconnected  sql.NullTime
+	wait, talk, afterCallWork, handle sql.NullInt64
+}
+
+func (a callAgentRow) agent(ended bool) *CallAgent {
+	if !a.entered.Valid && !a.connected.Valid && a.agentID == "" {
+		return nil
+	}
+	return &CallAgent{
+		Queue:           a.queue,
+		QueueEnteredAt:  nullTimePtr(a.entered),
+		QueueWaitMs:     nullInt64Ptr(a.wait),
+		QueueAbandoned:  a.entered.Valid && !a.connected.Valid && ended,
+		AgentUserID:     a.agentID,
+		ConnectedAt:     nullTimePtr(a.connected),
+		DisconnectedAt:  nullTimePtr(a.disconnected),
+		TalkMs:          nullInt64Ptr(a.talk),
+		AfterCallWorkMs: nullInt64Ptr(a.afterCallWork),
+		HandleMs:        nullInt64Ptr(a.handle),
+	}
+}
+
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	v := n.Int64
+	return &v
+}
+
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
@@ -4,18 +4,32 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
 )
 
+// ErrCallNotFound: Güncellenmek istenen çağrı henüz calls tablosunda yok (call.started gecikmiş olabilir).
+var ErrCallNotFound = errors.New("çağrı kaydı bulunamadı")
+
+// ErrCallSealed: Çağrı hash zincirine mühürlenmiş; zincir özetine giren alanları artık değiştirilemez.
+var ErrCallSealed = errors.New("çağrı hash zincirine mühürlenmiş")
+
+// ErrHoldNotStarted: call.resumed, çağrının ilk call.hold olayından önce geldi (olaylar sırasız teslim edilmiş olabilir).
+var ErrHoldNotStarted = errors.New("açık bekleme aralığı yok")
+
 type CallRepository struct {
-	db  *sql.DB
-	log zerolog.Logger
+	db   *sql.DB
+	keys *Keyring
+	log  zerolog.Logger
 }
 
-func NewCallRepository(db *sql.DB, log zerolog.Logger) *CallRepository {
-	return &CallRepository{db: db, log: log}
+// NewCallRepository: keys nil ise numaralar, özet ve olay gövdeleri düz metin yazılır.
+func NewCallRepository(db *sql.DB, keys *Keyring, log zerolog.Logger) *CallRepository {
+	return &CallRepository{db: db, keys: keys, log: log}
 }
 
 type CallStartData struct {
@@ -24,41 +38,86 @@ type CallStartData struct {
 	CallerNumber string
 	CalleeNumber string
 	Direction    string
+	DestGroup    string
+	Trunk        string // karşı SIP uç noktasının host'u; dahili çağrıda boş
 	StartTime    time.Time
 	UserID       interface{} // uuid or nil
 	ContactID    interface{} // int or nil
 }
 
 func (r *CallRepository) UpsertCallStart(ctx context.Context, data CallStartData) error {
-	// [KRİTİK DÜZELTME]: DO UPDATE kısmından 'recording_url' çıkarıldı.
+	// [KRİTİK DÜZELTME]: Güncelleme kısmında 'recording_url' yok.
 	// Artık başlangıç event'i asla kayıt URL'ini ezemez.
-	query := `
-		INSERT INTO calls (
-			call_id, tenant_id, caller_number, callee_number, direction, 
-			start_time, status, user_id, contact_id
-		) 
-		VALUES ($1, $2, $3, $4, $5, $6, 'STARTED', $7, $8)
-		ON CONFLICT (call_id) DO UPDATE SET 
-			tenant_id = COALESCE(calls.tenant_id, EXCLUDED.tenant_id),
-			caller_number = COALESCE(calls.caller_number, EXCLUDED.caller_number),
-			callee_number = COALESCE(calls.callee_number, EXCLUDED.callee_number),
-			user_id = COALESCE(calls.user_id, EXCLUDED.user_id),
-			updated_at = NOW()`
-
-	_, err := r.db.ExecContext(ctx, query,
-		data.CallID, data.TenantID, data.CallerNumber, data.CalleeNumber, data.Direction,
-		data.StartTime, data.UserID, data.ContactID,
-	)
-	return err
+	// calls tablosu start_time ile partition'lanmış olabilir; bu durumda call_id tek başına unique değildir
+	// ve ON CONFLICT (call_id) kullanılamaz. Aynı çağrının eşzamanlı olayları advisory lock ile sıraya girer.
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('calls:' || $1))", data.CallID); err != nil {
+		return err
+	}
+
+	// Numaralar satırın kalıcı tenant'ının anahtarıyla şifrelenir; kayıt varsa tenant'ı korunur.
+	var existingTenant sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT tenant_id FROM calls WHERE call_id = $1", data.CallID).Scan(&existingTenant)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	tenantID := data.TenantID
+	if existingTenant.String != "" {
+		tenantID = existingTenant.String
+	}
+	n, err := r.keys.sealNumbers(ctx, tenantID, data.CallID, data.CallerNumber, data.CalleeNumber)
+	if err != nil {
+		return err
+	}
+
+	// Mühürlenmiş çağrının zincir özetine giren alanları (tenant, numaralar) geç gelen başlangıç olayıyla doldurulmaz.
+	res, err := tx.ExecContext(ctx, `
+		UPDATE calls SET 
+			tenant_id = CASE WHEN chain_seq IS NULL THEN COALESCE(tenant_id, $2) ELSE tenant_id END,
+			caller_number_bidx = CASE WHEN caller_number IS NULL AND chain_seq IS NULL THEN $7 ELSE caller_number_bidx END,
+			callee_number_bidx = CASE WHEN callee_number IS NULL AND chain_seq IS NULL THEN $8 ELSE callee_number_bidx END,
+			caller_number = CASE WHEN chain_seq IS NULL THEN COALESCE(caller_number, $3) ELSE caller_number END,
+			callee_number = CASE WHEN chain_seq IS NULL THEN COALESCE(callee_number, $4) ELSE callee_number END,
+			user_id = COALESCE(user_id, $5),
+			destination_group = COALESCE(destination_group, $6),
+			trunk = COALESCE(trunk, NULLIF($9, '')),
+			updated_at = NOW()
+		WHERE call_id = $1`,
+		data.CallID, data.TenantID, n.caller, n.callee, data.UserID, data.DestGroup, n.callerIndex, n.calleeIndex, data.Trunk)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO calls (
+				call_id, tenant_id, caller_number, callee_number, direction, 
+				start_time, status, user_id, contact_id, destination_group,
+				caller_number_bidx, callee_number_bidx, trunk
+			) 
+			VALUES ($1, $2, $3, $4, $5, $6, 'STARTED', $7, $8, $9, $10, $11, NULLIF($12, ''))`,
+			data.CallID, data.TenantID, n.caller, n.callee, data.Direction,
+			data.StartTime, data.UserID, data.ContactID, data.DestGroup, n.callerIndex, n.calleeIndex, data.Trunk,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
+// SetAnswerTime: Cevaplanma anını yazar. Mühürlenmiş çağrıya geç gelen call.answered etkisizdir.
 func (r *CallRepository) SetAnswerTime(ctx context.Context, callID string, answerTime time.Time) error {
 	query := `
 		UPDATE calls SET 
 			answer_time = $1, 
 			status = 'ANSWERED', 
 			updated_at = NOW() 
-		WHERE call_id = $2`
+		WHERE call_id = $2 AND chain_seq IS NULL`
 	_, err := r.db.ExecContext(ctx, query, answerTime, callID)
 	return err
 }
@@ -70,8 +129,17 @@ type CallEndData struct {
 	Disposition     string
 	HangupSource    string
 	SipCode         int32
+	RingSeconds     int
+	TotalSeconds    int
+	BillableSeconds int
+	HoldSeconds     int
+	TalkSeconds     int
+	TotalMs         int64
+	BillableMs      int64
 }
 
+// UpdateCallEnd: Bitiş alanlarını yazar. Çağrı mühürlenmişse hiçbir alan değiştirilmez ve ErrCallSealed döner;
+// tekrar gelen call.ended zincire girmiş bitiş değerlerini değiştiremez.
 func (r *CallRepository) UpdateCallEnd(ctx context.Context, data CallEndData) error {
 	// [KRİTİK DÜZELTME]: Sadece bitişle ilgili alanlar güncelleniyor.
 	// recording_url ve total_cost BURADA GÜNCELLENMEZ.
@@ -83,64 +151,303 @@ func (r *CallRepository) UpdateCallEnd(ctx context.Context, data CallEndData) er
 			disposition = $3,
 			hangup_source = $4,
 			sip_hangup_cause = $5,
+			ring_seconds = $6,
+			total_duration_seconds = $7,
+			billable_seconds = $8,
+			hold_seconds = $9,
+			hold_started_at = NULL,
+			talk_seconds = $10,
+			total_duration_ms = $11,
+			billable_duration_ms = $12,
 			updated_at = NOW() 
-		WHERE call_id = $6`
+		WHERE call_id = $13 AND chain_seq IS NULL`
 
-	_, err := r.db.ExecContext(ctx, query,
+	res, err := r.db.ExecContext(ctx, query,
 		data.EndTime, data.DurationSeconds, data.Disposition,
-		data.HangupSource, data.SipCode, data.CallID,
+		data.HangupSource, data.SipCode,
+		data.RingSeconds, data.TotalSeconds, data.BillableSeconds, data.HoldSeconds, data.TalkSeconds,
+		data.TotalMs, data.BillableMs,
+		data.CallID,
 	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	var sealed bool
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM calls WHERE call_id = $1 AND chain_seq IS NOT NULL)", data.CallID).
+		Scan(&sealed)
+	if err == nil && sealed {
+		return ErrCallSealed
+	}
 	return err
 }
 
-func (r *CallRepository) UpdateCost(ctx context.Context, callID string, cost float64) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE calls SET total_cost = $1 WHERE call_id = $2", cost, callID)
+// RecomputeCallCost: total_cost'u çağrının tüm usage satırlarının (telefon + AI) toplamı olarak yeniden yazar.
+func (r *CallRepository) RecomputeCallCost(ctx context.Context, callID string) error {
+	query := `
+		UPDATE calls SET
+			total_cost = (SELECT COALESCE(SUM(calculated_cost), 0) FROM usage_records WHERE call_id = $1)
+		WHERE call_id = $1`
+	_, err := r.db.ExecContext(ctx, query, callID)
 	return err
 }
 
-func (r *CallRepository) GetCallDates(ctx context.Context, callID string) (startTime, answerTime sql.NullTime, tenantID string, err error) {
-	err = r.db.QueryRowContext(ctx, "SELECT start_time, answer_time, tenant_id FROM calls WHERE call_id = $1", callID).
-		Scan(&startTime, &answerTime, &tenantID)
-	return
+// CallTimeline: call.ended işlenirken süre kırılımını hesaplamak için gereken zaman damgaları.
+type CallTimeline struct {
+	TenantID      string
+	StartTime     sql.NullTime
+	AnswerTime    sql.NullTime
+	RingingTime   sql.NullTime
+	HoldStartedAt sql.NullTime
+	HoldSeconds   int
+}
+
+func (r *CallRepository) GetCallTimeline(ctx context.Context, callID string) (CallTimeline, error) {
+	var t CallTimeline
+	err := r.db.QueryRowContext(ctx, `
+		SELECT tenant_id, start_time, answer_time, ringing_time, hold_started_at, hold_seconds
+		FROM calls WHERE call_id = $1`, callID).
+		Scan(&t.TenantID, &t.StartTime, &t.AnswerTime, &t.RingingTime, &t.HoldStartedAt, &t.HoldSeconds)
+	return t, err
+}
+
+// GetCallTenant: Çağrının tenant'ını döner. Çağrı kaydı yoksa ErrCallNotFound döner; tenant henüz yazılmamışsa boştur.
+func (r *CallRepository) GetCallTenant(ctx context.Context, callID string) (string, error) {
+	var tenantID sql.NullString
+	err := r.db.QueryRowContext(ctx, "SELECT tenant_id FROM calls WHERE call_id = $1", callID).Scan(&tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrCallNotFound
+	}
+	return tenantID.String, err
+}
+
+// SetRingingTime: İlk 180/183 anını ve başlangıca göre post-dial delay'i kaydeder. Tekrar gelen olay ilk değeri ezmez.
+func (r *CallRepository) SetRingingTime(ctx context.Context, callID string, ringingTime time.Time) error {
+	query := `
+		UPDATE calls SET
+			ringing_time = COALESCE(ringing_time, $1),
+			post_dial_delay_ms = COALESCE(post_dial_delay_ms,
+				GREATEST(0, (EXTRACT(EPOCH FROM ($1::timestamptz - start_time)) * 1000)::int)),
+			updated_at = NOW()
+		WHERE call_id = $2`
+	return r.execExpectingCall(ctx, callID, query, ringingTime, callID)
+}
+
+// StartHold: Açık bir bekleme aralığı başlatır. Zaten beklemedeyse ilk an korunur.
+func (r *CallRepository) StartHold(ctx context.Context, callID string, ts time.Time) error {
+	query := `
+		UPDATE calls SET
+			hold_count = hold_count + CASE WHEN hold_started_at IS NULL THEN 1 ELSE 0 END,
+			hold_started_at = COALESCE(hold_started_at, $1),
+			updated_at = NOW()
+		WHERE call_id = $2 AND end_time IS NULL`
+	return r.execExpectingCall(ctx, callID, query, ts, callID)
+}
+
+// EndHold: Açık bekleme aralığını kapatıp süresini hold_seconds'a ekler. Çağrı kaydı yoksa ErrCallNotFound,
+// bitmemiş çağrıda henüz hiç bekleme başlamamışsa ErrHoldNotStarted döner. Önceki bir aralık zaten kapatılmışsa
+// (tekrar teslim) etkisizdir.
+func (r *CallRepository) EndHold(ctx context.Context, callID string, ts time.Time) error {
+	query := `
+		UPDATE calls SET
+			hold_seconds = hold_seconds + GREATEST(0, EXTRACT(EPOCH FROM ($1::timestamptz - hold_started_at)))::int,
+			hold_started_at = NULL,
+			updated_at = NOW()
+		WHERE call_id = $2 AND hold_started_at IS NOT NULL`
+	if err := r.execExpectingCall(ctx, callID, query, ts, callID); err != nil {
+		return err
+	}
+
+	var waiting bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT hold_started_at IS NULL AND hold_count = 0 AND end_time IS NULL
+		FROM calls WHERE call_id = $1`, callID).Scan(&waiting)
+	if err != nil {
+		return err
+	}
+	if waiting {
+		return ErrHoldNotStarted
+	}
+	return nil
+}
+
+// execExpectingCall: Hiç satır etkilenmediyse bunun koşuldan mı yoksa eksik çağrı kaydından mı kaynaklandığını ayırt eder.
+func (r *CallRepository) execExpectingCall(ctx context.Context, callID, query string, args ...interface{}) error {
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM calls WHERE call_id = $1)", callID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrCallNotFound
+		}
+	}
+	return nil
 }
 
+// CheckUsageExists: Yalnızca geçerli (ters kaydı yapılmamış) usage satırlarını dikkate alır.
 func (r *CallRepository) CheckUsageExists(ctx context.Context, callID, resourceType string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM usage_records WHERE call_id = $1 AND resource_type = $2)", callID, resourceType).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM usage_records
+			WHERE call_id = $1 AND resource_type = $2 AND superseded_at IS NULL AND reversal_of IS NULL
+		)`, callID, resourceType).Scan(&exists)
 	return exists, err
 }
 
-func (r *CallRepository) CreateUsageRecord(ctx context.Context, tenantID, callID, service, resource string, qty, cost float64) error {
-	query := `INSERT INTO usage_records (tenant_id, call_id, service_name, resource_type, quantity, calculated_cost) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.ExecContext(ctx, query, tenantID, callID, service, resource, qty, cost)
-	return err
+// UsageRecord: usage_records tablosuna yazılan tek bir faturalanabilir kullanım satırı.
+type UsageRecord struct {
+	TenantID       string
+	CallID         string
+	ServiceName    string
+	ResourceType   string
+	Quantity       float64
+	UnitPrice      float64
+	Cost           float64
+	RateVersion    string
+	RawDurationMs  interface{} // int64 or nil
+	RatedSeconds   interface{} // int64 or nil
+	RoundingPolicy interface{} // string or nil
+	SourceEventID  interface{} // string or nil
+	Metadata       interface{} // JSON string or nil
+	ReversalOf     interface{} // Ters kaydı yapılan usage satırının id'si or nil
+	RerateBatchID  interface{} // string or nil
+}
+
+// CreateUsageRecord: Usage satırını ve (ön ödemeli tenant'larda) bakiye hareketini tek transaction'da yazar.
+// Aynı source_event_id ile tekrar gelen kullanım sessizce yok sayılır.
+func (r *CallRepository) CreateUsageRecord(ctx context.Context, u UsageRecord) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := insertUsage(ctx, tx, u); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertUsage: Usage satırını yazar ve tutarı ön ödemeli bakiyeye yansıtır.
+// Satır tekrar (duplicate) ise sql.ErrNoRows döner.
+func insertUsage(ctx context.Context, tx *sql.Tx, u UsageRecord) (string, error) {
+	// usage_records partition'lanmışsa source_event_id unique indeksi created_at'i de içerir ve tekrar gelen
+	// olayı yakalamaz; tekrar kontrolü bu yüzden advisory lock altında açıkça yapılır.
+	if u.SourceEventID != nil {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('usage:' || $1))", u.SourceEventID); err != nil {
+			return "", err
+		}
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM usage_records WHERE source_event_id = $1)", u.SourceEventID).Scan(&exists)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", sql.ErrNoRows
+		}
+	}
+
+	query := `
+		INSERT INTO usage_records (
+			tenant_id, call_id, service_name, resource_type, quantity, calculated_cost,
+			unit_price, rate_version, raw_duration_ms, rated_seconds, rounding_policy,
+			source_event_id, metadata, reversal_of, rerate_batch_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13::jsonb, $14, $15)
+		ON CONFLICT DO NOTHING
+		RETURNING id::text`
+	var usageID string
+	err := tx.QueryRowContext(ctx, query,
+		u.TenantID, u.CallID, u.ServiceName, u.ResourceType, u.Quantity, u.Cost,
+		u.UnitPrice, u.RateVersion, u.RawDurationMs, u.RatedSeconds, u.RoundingPolicy,
+		u.SourceEventID, u.Metadata, u.ReversalOf, u.RerateBatchID,
+	).Scan(&usageID)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Cost != 0 {
+		entryType := LedgerUsage
+		if u.ReversalOf != nil {
+			entryType = LedgerAdjustment
+		}
+		_, err := applyLedgerEntry(ctx, tx, LedgerEntry{
+			TenantID:      u.TenantID,
+			EntryType:     entryType,
+			Amount:        -u.Cost,
+			CallID:        u.CallID,
+			UsageRecordID: usageID,
+			Reference:     u.RerateBatchID,
+		})
+		if err != nil && !errors.Is(err, ErrNoPrepaidBalance) {
+			return "", err
+		}
+	}
+	return usageID, nil
 }
 
-func (r *CallRepository) LogEvent(ctx context.Context, callID, eventType string, ts time.Time, payloadJsonString string) error {
+// LogEvent: Olayı call_events'e yazar ve tenant zincirine ekler. Tenant, çağrı kaydı varsa ondan alınır;
+// yoksa olayın taşıdığı tenant kullanılır. İkisi de bilinmiyorsa olay zincire alınmadan yazılır.
+func (r *CallRepository) LogEvent(ctx context.Context, tenantID, callID, eventType string, ts time.Time, payloadJsonString string) error {
 	var exists bool
 	_ = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM call_events WHERE call_id = $1 AND event_type = $2 AND event_timestamp = $3)", callID, eventType, ts).Scan(&exists)
 	if exists {
 		return nil
 	}
 
-	query := `INSERT INTO call_events (call_id, event_type, event_timestamp, payload) VALUES ($1, $2, $3, $4::jsonb)`
-	_, err := r.db.ExecContext(ctx, query, callID, eventType, ts, payloadJsonString)
-	return err
-}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var callTenant sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT tenant_id FROM calls WHERE call_id = $1", callID).Scan(&callTenant)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if callTenant.String != "" {
+		tenantID = callTenant.String
+	}
 
-func (r *CallRepository) UpdateRecording(ctx context.Context, callID, uri string) error {
-	// [DEBUG]: RowsAffected kontrolü eklendi.
-	query := `UPDATE calls SET recording_url = $1, updated_at = NOW() WHERE call_id = $2`
-	res, err := r.db.ExecContext(ctx, query, uri, callID)
+	// Zincir özeti gövdenin jsonb biçimindeki düz metni üzerinden alınır; şifreli yazılsa da doğrulama
+	// çözülmüş gövdeyle aynı özeti üretir.
+	rec := chain.EventRecord{CallID: callID, EventType: eventType}
+	if err := tx.QueryRowContext(ctx, "SELECT $1::jsonb::text", payloadJsonString).Scan(&rec.Payload); err != nil {
+		return err
+	}
+	stored, err := r.keys.sealPayload(ctx, tenantID, callID, rec.Payload)
 	if err != nil {
 		return err
 	}
+	query := `INSERT INTO call_events (call_id, event_type, event_timestamp, payload) VALUES ($1, $2, $3, $4::jsonb)
+		RETURNING event_timestamp`
+	if err := tx.QueryRowContext(ctx, query, callID, eventType, ts, stored).Scan(&rec.EventTimestamp); err != nil {
+		return err
+	}
 
-	rows, _ := res.RowsAffected()
-	if rows == 0 {
-		r.log.Warn().Str("call_id", callID).Msg("⚠️ UpdateRecording: Kayıt güncellenemedi çünkü Call ID bulunamadı.")
-	} else {
-		r.log.Info().Str("call_id", callID).Msg("✅ UpdateRecording: Veritabanı güncellendi.")
+	if tenantID != "" {
+		seq, chainHash, err := appendChain(ctx, tx, tenantID, chain.RecordEvent, callID, rec.Hash())
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE call_events SET chain_seq = $1, chain_hash = $2
+			WHERE call_id = $3 AND event_type = $4 AND event_timestamp = $5 AND chain_seq IS NULL`,
+			seq, chainHash, callID, eventType, rec.EventTimestamp)
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+	return tx.Commit()
 }
@@ -0,0 +1,303 @@
+// sentiric-cdr-service/internal/repository/chain.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+)
+
+// chainCallColumns: Zincir özetine giren çağrı alanları; mühürleme ve doğrulama aynı listeyi okur.
+const chainCallColumns = `
+	c.call_id, c.tenant_id, COALESCE(c.caller_number, ''), COALESCE(c.callee_number, ''), COALESCE(c.direction, ''),
+	c.start_time, c.answer_time, c.end_time, COALESCE(c.total_duration_ms, 0), COALESCE(c.billable_duration_ms, 0),
+	COALESCE(c.disposition, ''), COALESCE(c.hangup_source, '')`
+
+// unchainedGrace: Henüz işlenmekte olan satırların doğrulamada zincir dışı sayılmaması için bekleme süresi.
+const unchainedGrace = "10 minutes"
+
+// appendChain: Kaydı tenant zincirinin sonuna ekler. Zincir başı satır kilidiyle tutulur; aynı tenant'ın
+// eklemeleri transaction bitene kadar sıraya girer.
+func appendChain(ctx context.Context, tx *sql.Tx, tenantID, recordType, callID, recordHash string) (int64, string, error) {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO cdr_chain_heads (tenant_id, last_hash) VALUES ($1, $2) ON CONFLICT (tenant_id) DO NOTHING",
+		tenantID, chain.GenesisHash)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var lastSeq int64
+	var prevHash string
+	err = tx.QueryRowContext(ctx,
+		"SELECT last_seq, last_hash FROM cdr_chain_heads WHERE tenant_id = $1 FOR UPDATE", tenantID).
+		Scan(&lastSeq, &prevHash)
+	if err != nil {
+		return 0, "", err
+	}
+
+	seq := lastSeq + 1
+	chainHash := chain.Link(prevHash, seq, recordType, callID, recordHash)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cdr_hash_chain (tenant_id, seq, record_type, call_id, record_hash, prev_hash, chain_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		tenantID, seq, recordType, callID, recordHash, prevHash, chainHash)
+	if err != nil {
+		return 0, "", err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE cdr_chain_heads SET last_seq = $1, last_hash = $2, updated_at = NOW() WHERE tenant_id = $3",
+		seq, chainHash, tenantID)
+	return seq, chainHash, err
+}
+
+// SealCall: Kesinleşen CDR'ı tenant zincirine ekler. Zaten mühürlenmiş çağrı (tekrar gelen call.ended) atlanır.
+func (r *CallRepository) SealCall(ctx context.Context, callID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var rec chain.CallRecord
+	var sealed sql.NullInt64
+	err = tx.QueryRowContext(ctx, "SELECT"+chainCallColumns+", c.chain_seq FROM calls c WHERE c.call_id = $1 FOR UPDATE", callID).
+		Scan(&rec.CallID, &rec.TenantID, &rec.CallerNumber, &rec.CalleeNumber, &rec.Direction,
+			&rec.StartTime, &rec.AnswerTime, &rec.EndTime, &rec.TotalDurationMs, &rec.BillableDurationMs,
+			&rec.Disposition, &rec.HangupSource, &sealed)
+	if err != nil {
+		return err
+	}
+	if sealed.Valid {
+		return nil
+	}
+	if err := r.keys.openCallRecord(ctx, &rec); err != nil {
+		return err
+	}
+
+	seq, chainHash, err := appendChain(ctx, tx, rec.TenantID, chain.RecordCall, callID, rec.Hash())
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE calls SET chain_seq = $1, chain_hash = $2 WHERE call_id = $3", seq, chainHash, callID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ChainEntry: Zincir kaydı ve işaret ettiği satırın doğrulama anındaki hali. Satır silinmişse Call/Event nil olur.
+type ChainEntry struct {
+	Seq        int64
+	RecordType string
+	CallID     string
+	RecordHash string
+	PrevHash   string
+	ChainHash  string
+
+	Call  *chain.CallRecord
+	Event *chain.EventRecord
+	// RowSeq, RowHash: Satırın üzerinde taşıdığı zincir bilgisi.
+	RowSeq  sql.NullInt64
+	RowHash sql.NullString
+	// Pruned: Satır saklama süresi dolduğu için silindi veya arşivlendi.
+	Pruned bool
+	// ErasureID, ErasedHash: Satır silme talebiyle anonimleştirildiyse son talep ve satırın yeni hash'i.
+	ErasureID  int64
+	ErasedHash string
+}
+
+// ChainHead: Tenant zincirinin son durumu.
+type ChainHead struct {
+	TenantID string
+	LastSeq  int64
+	LastHash string
+}
+
+type ChainRepository struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+func NewChainRepository(db *sql.DB, keys *Keyring) *ChainRepository {
+	return &ChainRepository{db: db, keys: keys}
+}
+
+// ListHeads: Zinciri olan tenant'lar; tenantID boş değilse yalnızca o tenant.
+func (r *ChainRepository) ListHeads(ctx context.Context, tenantID string) ([]ChainHead, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, last_seq, last_hash FROM cdr_chain_heads
+		WHERE $1 = '' OR tenant_id = $1
+		ORDER BY tenant_id`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heads []ChainHead
+	for rows.Next() {
+		var h ChainHead
+		if err := rows.Scan(&h.TenantID, &h.LastSeq, &h.LastHash); err != nil {
+			return nil, err
+		}
+		heads = append(heads, h)
+	}
+	return heads, rows.Err()
+}
+
+// ForEachEntry: Tenant zincirini sıra numarasına göre, işaret edilen satırlarla birlikte akıtır.
+// Olay satırı, zincirdeki sıra numarası üzerinden bulunur; numarası değiştirilmiş satır silinmiş görünür.
+func (r *ChainRepository) ForEachEntry(ctx context.Context, tenantID string, fn func(ChainEntry) error) error {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.seq, h.record_type, h.call_id, h.record_hash, h.prev_hash, h.chain_hash, h.pruned_at IS NOT NULL,
+			COALESCE(h.erasure_id, 0), COALESCE(h.erased_hash, ''),
+			c.call_id IS NOT NULL,`+chainCallColumns+`, c.chain_seq, c.chain_hash,
+			e.call_id IS NOT NULL, COALESCE(e.event_type, ''), e.event_timestamp, COALESCE(e.payload::text, ''),
+			e.chain_seq, e.chain_hash
+		FROM cdr_hash_chain h
+		LEFT JOIN calls c ON h.record_type = 'CALL' AND c.call_id = h.call_id
+		LEFT JOIN call_events e ON h.record_type = 'EVENT' AND e.call_id = h.call_id AND e.chain_seq = h.seq
+		WHERE h.tenant_id = $1
+		ORDER BY h.seq`, tenantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var en ChainEntry
+		var call chain.CallRecord
+		var callID, callTenant sql.NullString
+		var hasCall, hasEvent bool
+		var ev chain.EventRecord
+		var evTime *time.Time
+		var callSeq, evSeq sql.NullInt64
+		var callHash, evHash sql.NullString
+		err := rows.Scan(&en.Seq, &en.RecordType, &en.CallID, &en.RecordHash, &en.PrevHash, &en.ChainHash, &en.Pruned,
+			&en.ErasureID, &en.ErasedHash,
+			&hasCall, &callID, &callTenant, &call.CallerNumber, &call.CalleeNumber, &call.Direction,
+			&call.StartTime, &call.AnswerTime, &call.EndTime, &call.TotalDurationMs, &call.BillableDurationMs,
+			&call.Disposition, &call.HangupSource, &callSeq, &callHash,
+			&hasEvent, &ev.EventType, &evTime, &ev.Payload, &evSeq, &evHash)
+		if err != nil {
+			return err
+		}
+		switch {
+		case hasCall:
+			call.CallID, call.TenantID = callID.String, callTenant.String
+			if err := r.keys.openCallRecord(ctx, &call); err != nil {
+				return err
+			}
+			en.Call, en.RowSeq, en.RowHash = &call, callSeq, callHash
+		case hasEvent:
+			ev.CallID = en.CallID
+			if evTime != nil {
+				ev.EventTimestamp = *evTime
+			}
+			if ev.Payload, err = r.keys.openPayload(ctx, ev.CallID, ev.Payload); err != nil {
+				return err
+			}
+			en.Event, en.RowSeq, en.RowHash = &ev, evSeq, evHash
+		}
+		if err := fn(en); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CountUnchained: Tenant zinciri başladıktan sonra kesinleşmiş ama zincirde olmayan çağrı ve olay sayıları.
+// Zincir dışı eklenen (sahte) satırları ortaya çıkarır; işlenmekte olan satırlar için bekleme payı bırakılır.
+func (r *ChainRepository) CountUnchained(ctx context.Context, tenantID string) (calls, events int64, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		WITH start AS (SELECT MIN(created_at) AS at FROM cdr_hash_chain WHERE tenant_id = $1)
+		SELECT
+			(SELECT COUNT(*) FROM calls c, start
+			 WHERE c.tenant_id = $1 AND c.chain_seq IS NULL AND c.status = 'COMPLETED'
+			   AND c.end_time >= start.at AND c.end_time < NOW() - INTERVAL '`+unchainedGrace+`'),
+			(SELECT COUNT(*) FROM call_events e JOIN calls c ON c.call_id = e.call_id, start
+			 WHERE c.tenant_id = $1 AND e.chain_seq IS NULL
+			   AND e.event_timestamp >= start.at AND e.event_timestamp < NOW() - INTERVAL '`+unchainedGrace+`')`,
+		tenantID).Scan(&calls, &events)
+	return calls, events, err
+}
+
+// ListCheckpoints: Tenant'ın en yeni limit kontrol noktası, eskiden yeniye. limit 0 ise tümü.
+func (r *ChainRepository) ListCheckpoints(ctx context.Context, tenantID string, limit int) ([]chain.Checkpoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, seq, chain_hash, key_id, signature, created_at
+		FROM cdr_chain_checkpoints
+		WHERE tenant_id = $1
+		ORDER BY seq DESC
+		LIMIT NULLIF($2, 0)`, tenantID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []chain.Checkpoint
+	for rows.Next() {
+		var c chain.Checkpoint
+		if err := rows.Scan(&c.TenantID, &c.Seq, &c.ChainHash, &c.KeyID, &c.Signature, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Sorgu en yenileri seçer; çıktı eskiden yeniye sıralanır.
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// ListUncheckpointedHeads: Son kontrol noktasından sonra büyümüş tenant zincirleri.
+func (r *ChainRepository) ListUncheckpointedHeads(ctx context.Context) ([]ChainHead, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.tenant_id, h.last_seq, h.last_hash
+		FROM cdr_chain_heads h
+		WHERE h.last_seq > COALESCE((SELECT MAX(seq) FROM cdr_chain_checkpoints cp WHERE cp.tenant_id = h.tenant_id), 0)
+		ORDER BY h.tenant_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heads []ChainHead
+	for rows.Next() {
+		var h ChainHead
+		if err := rows.Scan(&h.TenantID, &h.LastSeq, &h.LastHash); err != nil {
+			return nil, err
+		}
+		heads = append(heads, h)
+	}
+	return heads, rows.Err()
+}
+
+// CreateCheckpoint: İmzalı kontrol noktasını kaydeder ve dış arşive gitmesi için aynı transaction'da outbox'a yazar.
+// Aynı sıra numarasına ikinci kontrol noktası (başka kopya) sessizce atlanır.
+func (r *ChainRepository) CreateCheckpoint(ctx context.Context, cp chain.Checkpoint) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO cdr_chain_checkpoints (tenant_id, seq, chain_hash, key_id, signature, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, seq) DO NOTHING`,
+		cp.TenantID, cp.Seq, cp.ChainHash, cp.KeyID, cp.Signature, cp.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if err := enqueueOutboxEvent(ctx, tx, chain.EventCheckpoint, cp.TenantID, cp); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,96 @@
+// sentiric-cdr-service/internal/repository/concurrency.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// OpenCall: Bitiş zamanı yazılmamış çağrı bacağı.
+type OpenCall struct {
+	TenantID string
+	CallID   string
+}
+
+// DailyPeak: Tenant'ın bir gündeki (UTC) en yüksek eşzamanlı çağrı sayısı.
+type DailyPeak struct {
+	TenantID  string    `json:"tenant_id"`
+	Day       time.Time `json:"day"`
+	PeakCalls int       `json:"peak_calls"`
+	PeakAt    time.Time `json:"peak_at"`
+}
+
+type ConcurrencyRepository struct {
+	db *sql.DB
+}
+
+func NewConcurrencyRepository(db *sql.DB) *ConcurrencyRepository {
+	return &ConcurrencyRepository{db: db}
+}
+
+// ListOpenCalls: since'ten sonra başlamış ve henüz bitmemiş çağrılar. Daha eski açık çağrılar call.ended
+// olayı kaybolmuş sayılır.
+func (r *ConcurrencyRepository) ListOpenCalls(ctx context.Context, since time.Time) ([]OpenCall, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, call_id FROM calls
+		WHERE end_time IS NULL AND start_time >= $1`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OpenCall
+	for rows.Next() {
+		var c OpenCall
+		if err := rows.Scan(&c.TenantID, &c.CallID); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// RecordDailyPeaks: Günlük tepeleri yazar; kayıtlı değerden düşük tepeler yok sayılır.
+func (r *ConcurrencyRepository) RecordDailyPeaks(ctx context.Context, peaks []DailyPeak) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range peaks {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO cdr_concurrency_daily (tenant_id, day, peak_calls, peak_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (tenant_id, day) DO UPDATE SET
+				peak_calls = EXCLUDED.peak_calls, peak_at = EXCLUDED.peak_at, updated_at = NOW()
+			WHERE cdr_concurrency_daily.peak_calls < EXCLUDED.peak_calls`,
+			p.TenantID, p.Day.Format(time.DateOnly), p.PeakCalls, p.PeakAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListDailyPeaks: Tenant'ın [from, to) aralığındaki günlük tepeleri, gün sırasıyla.
+func (r *ConcurrencyRepository) ListDailyPeaks(ctx context.Context, tenantID string, from, to time.Time) ([]DailyPeak, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, day, peak_calls, peak_at FROM cdr_concurrency_daily
+		WHERE tenant_id = $1 AND day >= $2 AND day < $3
+		ORDER BY day`, tenantID, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DailyPeak{}
+	for rows.Next() {
+		var p DailyPeak
+		if err := rows.Scan(&p.TenantID, &p.Day, &p.PeakCalls, &p.PeakAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
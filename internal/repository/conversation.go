@@ -0,0 +1,90 @@
+// sentiric-cdr-service/internal/repository/conversation.go
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+)
+
+// Duygu bantları. Skor -1 (olumsuz) ile +1 (olumlu) arasındadır.
+const (
+	SentimentNegative = "negative"
+	SentimentNeutral  = "neutral"
+	SentimentPositive = "positive"
+)
+
+// NegativeSentimentBelow, PositiveSentimentAbove: Bantların sınırları; arası nötrdür.
+const (
+	NegativeSentimentBelow = -0.25
+	PositiveSentimentAbove = 0.25
+)
+
+// SentimentAnalysis: Konuşma analizinin çağrıya işlenen sonucu. Boş niyet listesi kayıtlı niyetleri korur.
+type SentimentAnalysis struct {
+	Score         float64
+	Intents       []string
+	AgentHandoff  bool
+	HandoffReason string
+}
+
+// SetTranscript: Transkript bağlantısını ve dilini çağrıya yazar. Silme talebi işlenmiş çağrıya yazılmaz.
+func (r *CallRepository) SetTranscript(ctx context.Context, callID, uri, language string) error {
+	query := `
+		UPDATE calls SET
+			transcript_uri = $2,
+			conversation_language = COALESCE(NULLIF($3, ''), conversation_language),
+			updated_at = NOW()
+		WHERE call_id = $1 AND erased_at IS NULL`
+	return r.execExpectingCall(ctx, callID, query, callID, uri, language)
+}
+
+// SetSummary: AI özetini çağrıya yazar. Özet ad ve numara içerebildiği için tenant anahtarıyla şifrelenir.
+// Silme talebi işlenmiş çağrıya yazılmaz.
+func (r *CallRepository) SetSummary(ctx context.Context, callID, summary, language string) error {
+	tenantID, err := r.GetCallTenant(ctx, callID)
+	if err != nil {
+		return err
+	}
+	if summary, err = r.keys.sealField(ctx, tenantID, fieldcrypt.FieldSummary, callID, summary); err != nil {
+		return err
+	}
+	query := `
+		UPDATE calls SET
+			ai_summary = $2,
+			conversation_language = COALESCE(NULLIF($3, ''), conversation_language),
+			updated_at = NOW()
+		WHERE call_id = $1 AND erased_at IS NULL`
+	return r.execExpectingCall(ctx, callID, query, callID, summary, language)
+}
+
+// SetSentiment: Duygu skorunu, niyetleri ve temsilciye devir bilgisini çağrıya yazar. Niyetler küçük harfe
+// çevrilip tekilleştirilir.
+func (r *CallRepository) SetSentiment(ctx context.Context, callID string, s SentimentAnalysis) error {
+	query := `
+		UPDATE calls SET
+			sentiment_score = $2::numeric,
+			intents = COALESCE(string_to_array(NULLIF($3, ''), ','), intents),
+			agent_handoff = $4::boolean,
+			handoff_reason = NULLIF($5, ''),
+			updated_at = NOW()
+		WHERE call_id = $1`
+	return r.execExpectingCall(ctx, callID, query, callID, s.Score, strings.Join(NormalizeIntents(s.Intents), ","),
+		s.AgentHandoff, s.HandoffReason)
+}
+
+// NormalizeIntents: Niyetleri küçük harfe çevirir, boşlukları kırpar ve tekilleştirir. Virgül niyet içinde
+// kullanılamaz; alt çizgiye çevrilir.
+func NormalizeIntents(intents []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, in := range intents {
+		in = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(in)), ",", "_")
+		if in != "" && !seen[in] {
+			seen[in] = true
+			out = append(out, in)
+		}
+	}
+	return out
+}
@@ -0,0 +1,286 @@
+// sentiric-cdr-service/internal/repository/delivery.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Teslim durumları.
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryFailed    = "FAILED"
+)
+
+var (
+	ErrDeliveryJobNotFound = errors.New("teslim işi bulunamadı")
+	ErrDeliveryNotFound    = errors.New("teslim kaydı bulunamadı")
+	ErrDeliveryJobExists   = errors.New("tenant'ın aynı adlı bir teslim işi zaten var")
+)
+
+// DeliveryJob: Tenant'ın zamanlanmış CDR teslim işi.
+type DeliveryJob struct {
+	ID               int64           `json:"id"`
+	TenantID         string          `json:"tenant_id"`
+	Name             string          `json:"name"`
+	Schedule         string          `json:"schedule"`
+	WindowPeriod     string          `json:"window_period"`
+	Timezone         string          `json:"timezone"`
+	Format           string          `json:"format"`
+	View             string          `json:"view"`
+	Columns          string          `json:"columns"`
+	MaskNumbers      bool            `json:"mask_numbers"`
+	IncludeEvents    bool            `json:"include_events"`
+	Compression      string          `json:"compression"`
+	FilenameTemplate string          `json:"filename_template"`
+	DestinationType  string          `json:"destination_type"`
+	Destination      json.RawMessage `json:"destination"`
+	MaxAttempts      int             `json:"max_attempts"`
+	Enabled          bool            `json:"enabled"`
+	NextRunAt        *time.Time      `json:"next_run_at,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// Delivery: Bir işin tek bir zaman penceresi için teslim kaydı.
+type Delivery struct {
+	ID            int64      `json:"id"`
+	JobID         int64      `json:"job_id"`
+	TenantID      string     `json:"tenant_id"`
+	WindowStart   time.Time  `json:"window_start"`
+	WindowEnd     time.Time  `json:"window_end"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	ObjectName    string     `json:"object_name,omitempty"`
+	RowCount      int        `json:"row_count"`
+	SizeBytes     int64      `json:"size_bytes"`
+	SHA256        string     `json:"sha256,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// DeliveryPlan: Zamanı gelen bir iş için açılacak teslim penceresi ve işin bir sonraki çalışma anı.
+type DeliveryPlan struct {
+	WindowStart time.Time
+	WindowEnd   time.Time
+	NextRunAt   time.Time
+}
+
+type DeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewDeliveryRepository(db *sql.DB) *DeliveryRepository {
+	return &DeliveryRepository{db: db}
+}
+
+const deliveryJobColumns = `
+	id, tenant_id, name, schedule, window_period, timezone, format, export_view, columns, mask_numbers, include_events,
+	compression, filename_template, destination_type, destination, max_attempts, enabled, next_run_at, created_at`
+
+func scanDeliveryJob(row interface{ Scan(...interface{}) error }) (DeliveryJob, error) {
+	var j DeliveryJob
+	var dest []byte
+	var nextRun sql.NullTime
+	err := row.Scan(&j.ID, &j.TenantID, &j.Name, &j.Schedule, &j.WindowPeriod, &j.Timezone, &j.Format, &j.View, &j.Columns,
+		&j.MaskNumbers, &j.IncludeEvents, &j.Compression, &j.FilenameTemplate, &j.DestinationType, &dest,
+		&j.MaxAttempts, &j.Enabled, &nextRun, &j.CreatedAt)
+	j.Destination = dest
+	j.NextRunAt = nullTimePtr(nextRun)
+	return j, err
+}
+
+// CreateJob: Yeni teslim işini kaydeder. NextRunAt çağıran tarafından zamanlamadan hesaplanmış olmalıdır.
+func (r *DeliveryRepository) CreateJob(ctx context.Context, j DeliveryJob) (DeliveryJob, error) {
+	row := r.db.QueryRowContext(ctx, `
+		INSERT INTO cdr_delivery_jobs (tenant_id, name, schedule, window_period, timezone, format, columns, mask_numbers,
+			include_events, compression, filename_template, destination_type, destination, max_attempts, enabled, next_run_at,
+			export_view)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13::jsonb, $14, $15, $16, $17)
+		ON CONFLICT (tenant_id, name) DO NOTHING
+		RETURNING`+deliveryJobColumns,
+		j.TenantID, j.Name, j.Schedule, j.WindowPeriod, j.Timezone, j.Format, j.Columns, j.MaskNumbers,
+		j.IncludeEvents, j.Compression, j.FilenameTemplate, j.DestinationType, string(j.Destination), j.MaxAttempts,
+		j.Enabled, j.NextRunAt, j.View)
+	created, err := scanDeliveryJob(row)
+	if err == sql.ErrNoRows {
+		return created, ErrDeliveryJobExists
+	}
+	return created, err
+}
+
+// GetJob: İşi id ile okur.
+func (r *DeliveryRepository) GetJob(ctx context.Context, id int64) (DeliveryJob, error) {
+	j, err := scanDeliveryJob(r.db.QueryRowContext(ctx, `SELECT`+deliveryJobColumns+` FROM cdr_delivery_jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return j, ErrDeliveryJobNotFound
+	}
+	return j, err
+}
+
+// ListJobs: Tenant'ın teslim işlerini döner.
+func (r *DeliveryRepository) ListJobs(ctx context.Context, tenantID string) ([]DeliveryJob, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT`+deliveryJobColumns+` FROM cdr_delivery_jobs WHERE tenant_id = $1 ORDER BY id`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []DeliveryJob{}
+	for rows.Next() {
+		j, err := scanDeliveryJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// ScheduleDueJobs: Zamanı gelen her iş için plan fonksiyonunun döndüğü pencereyi teslim kuyruğuna ekler
+// ve işin bir sonraki çalışma anını ilerletir. Aynı pencere ikinci kez eklenmez. Satırlar SKIP LOCKED ile
+// kilitlendiği için birden fazla servis kopyası aynı işi iki kez planlamaz. Plan hatası işi atlar.
+func (r *DeliveryRepository) ScheduleDueJobs(ctx context.Context, now time.Time, plan func(DeliveryJob) (DeliveryPlan, error)) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT`+deliveryJobColumns+` FROM cdr_delivery_jobs
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at
+		FOR UPDATE SKIP LOCKED`, now)
+	if err != nil {
+		return 0, err
+	}
+	var due []DeliveryJob
+	for rows.Next() {
+		j, err := scanDeliveryJob(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, j)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var planErr error
+	scheduled := 0
+	for _, j := range due {
+		p, err := plan(j)
+		if err != nil {
+			planErr = errors.Join(planErr, err)
+			continue
+		}
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO cdr_deliveries (job_id, tenant_id, window_start, window_end)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (job_id, window_start) DO NOTHING`, j.ID, j.TenantID, p.WindowStart, p.WindowEnd)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			scheduled++
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE cdr_delivery_jobs SET next_run_at = $2 WHERE id = $1`, j.ID, p.NextRunAt); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return scheduled, planErr
+}
+
+const deliveryColumns = `
+	id, job_id, tenant_id, window_start, window_end, status, attempts, next_attempt_at,
+	COALESCE(object_name, ''), COALESCE(row_count, 0), COALESCE(size_bytes, 0), COALESCE(sha256, ''),
+	COALESCE(last_error, ''), created_at, delivered_at`
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (Delivery, error) {
+	var d Delivery
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.JobID, &d.TenantID, &d.WindowStart, &d.WindowEnd, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.ObjectName, &d.RowCount, &d.SizeBytes, &d.SHA256, &d.LastError, &d.CreatedAt, &deliveredAt)
+	d.DeliveredAt = nullTimePtr(deliveredAt)
+	return d, err
+}
+
+// ClaimDelivery: Denemesi gelen en eski teslimi alır, deneme sayısını artırır ve lease süresi boyunca
+// başka kopyaların almaması için bir sonraki deneme anını ileri atar. Bekleyen teslim yoksa sql.ErrNoRows döner.
+func (r *DeliveryRepository) ClaimDelivery(ctx context.Context, lease time.Duration) (Delivery, error) {
+	return scanDelivery(r.db.QueryRowContext(ctx, `
+		UPDATE cdr_deliveries SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $1)
+		WHERE id = (
+			SELECT id FROM cdr_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING`+deliveryColumns, lease.Seconds()))
+}
+
+// MarkDelivered: Başarılı teslimin dosya bilgilerini ve sağlama toplamını kaydeder.
+func (r *DeliveryRepository) MarkDelivered(ctx context.Context, id int64, objectName string, rowCount int, size int64, sha256 string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE cdr_deliveries
+		SET status = 'DELIVERED', object_name = $2, row_count = $3, size_bytes = $4, sha256 = $5,
+			last_error = NULL, delivered_at = NOW()
+		WHERE id = $1`, id, objectName, rowCount, size, sha256)
+	return err
+}
+
+// MarkAttemptFailed: Başarısız denemeyi kaydeder. retryAt nil ise teslim kalıcı olarak FAILED olur.
+func (r *DeliveryRepository) MarkAttemptFailed(ctx context.Context, id int64, errMsg string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := r.db.ExecContext(ctx, `UPDATE cdr_deliveries SET status = 'FAILED', last_error = $2 WHERE id = $1`, id, errMsg)
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE cdr_deliveries SET last_error = $2, next_attempt_at = $3 WHERE id = $1`, id, errMsg, *retryAt)
+	return err
+}
+
+// RetryDelivery: Kalıcı olarak başarısız olmuş teslimi deneme sayacını sıfırlayarak yeniden kuyruğa alır.
+func (r *DeliveryRepository) RetryDelivery(ctx context.Context, tenantID string, id int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE cdr_deliveries SET status = 'PENDING', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND tenant_id = $2 AND status = 'FAILED'`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// ListDeliveries: Tenant'ın teslim kayıtlarını en yeni pencereden başlayarak döner.
+func (r *DeliveryRepository) ListDeliveries(ctx context.Context, tenantID string, limit int) ([]Delivery, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT`+deliveryColumns+` FROM cdr_deliveries
+		WHERE tenant_id = $1 ORDER BY window_start DESC, id DESC LIMIT $2`, tenantID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,672 @@
+// sentiric-cdr-service/internal/repository/erasure.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+)
+
+// ErrErasureNotFound: Silme talebi kaydı yok.
+var ErrErasureNotFound = errors.New("silme talebi bulunamadı")
+
+// ErasureRequest: KVKK / GDPR silme talebi ve sonucu. Kişinin açık değeri saklanmaz.
+type ErasureRequest struct {
+	ID          int64           `json:"id"`
+	TenantID    string          `json:"tenant_id,omitempty"`
+	Regulation  string          `json:"regulation"`
+	Reference   string          `json:"external_reference"`
+	SubjectType string          `json:"subject_type"`
+	SubjectHash string          `json:"subject_hash"`
+	Pseudonym   string          `json:"pseudonym"`
+	RequestedBy string          `json:"requested_by"`
+	Status      string          `json:"status"`
+	Counts      privacy.Counts  `json:"counts"`
+	Certificate json.RawMessage `json:"certificate,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// ErasureMatch: Kişinin CDR'da aranma biçimi. Numbers arayan/aranan alanlarıyla birebir, Pattern ise olay ve
+// webhook gövdelerinde düzenli ifade olarak eşleştirilir. Şifreli numaralar kör indeksle, şifreli gövdeler
+// Subject ile uygulama tarafında eşleştirilir.
+type ErasureMatch struct {
+	Numbers []string
+	UserID  string
+	Pattern string
+	Subject privacy.Subject
+}
+
+// ErasureFilter: Talep listeleme kriterleri; boş alanlar filtrelenmez.
+type ErasureFilter struct {
+	TenantID    string
+	Regulation  string
+	Reference   string
+	SubjectHash string
+	Limit       int
+}
+
+// erasedTarget: Anonimleştirilen ve zincirde mühürlü satır.
+type erasedTarget struct {
+	tenantID   string
+	seq        int64
+	callID     string
+	erasedHash string
+}
+
+// erasedNotice: Tenant başına yayınlanan cdr.subject.erased olayının gövdesi.
+type erasedNotice struct {
+	ErasureID     int64    `json:"erasure_id"`
+	TenantID      string   `json:"tenant_id"`
+	Regulation    string   `json:"regulation"`
+	Reference     string   `json:"external_reference"`
+	Pseudonym     string   `json:"pseudonym"`
+	CallIDs       []string `json:"call_ids"`
+	RecordingURLs []string `json:"recording_urls"`
+	// TranscriptURIs: Depolama katmanının silmesi gereken transkript dosyaları.
+	TranscriptURIs []string `json:"transcript_uris"`
+}
+
+const erasureColumns = `
+	id, COALESCE(tenant_id, ''), regulation, external_reference, subject_type, subject_hash, pseudonym, requested_by,
+	status, calls_pseudonymized, events_scrubbed, recordings_unlinked, webhooks_scrubbed, archive_scrubbed,
+	chain_entries, COALESCE(certificate::text, ''), COALESCE(error, ''), created_at, completed_at`
+
+type ErasureRepository struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+func NewErasureRepository(db *sql.DB, keys *Keyring) *ErasureRepository {
+	return &ErasureRepository{db: db, keys: keys}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanErasure(row rowScanner) (ErasureRequest, error) {
+	var e ErasureRequest
+	var cert string
+	err := row.Scan(&e.ID, &e.TenantID, &e.Regulation, &e.Reference, &e.SubjectType, &e.SubjectHash, &e.Pseudonym,
+		&e.RequestedBy, &e.Status, &e.Counts.CallsPseudonymized, &e.Counts.EventsScrubbed, &e.Counts.RecordingsUnlinked,
+		&e.Counts.WebhooksScrubbed, &e.Counts.ArchiveScrubbed, &e.Counts.ChainEntries, &cert, &e.Error,
+		&e.CreatedAt, &e.CompletedAt)
+	if cert != "" {
+		e.Certificate = json.RawMessage(cert)
+	}
+	return e, err
+}
+
+// CreateRequest: Talebi işlem başlamadan PENDING olarak kaydeder; başarısız talepler de izlenebilir kalır.
+func (r *ErasureRepository) CreateRequest(ctx context.Context, e ErasureRequest) (ErasureRequest, error) {
+	row := r.db.QueryRowContext(ctx, `
+		INSERT INTO cdr_erasure_requests (tenant_id, regulation, external_reference, subject_type, subject_hash, pseudonym, requested_by)
+		VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7)
+		RETURNING`+erasureColumns,
+		e.TenantID, e.Regulation, e.Reference, e.SubjectType, e.SubjectHash, e.Pseudonym, e.RequestedBy)
+	return scanErasure(row)
+}
+
+// FailRequest: Geri alınan silme işleminin hatasını talebe yazar.
+func (r *ErasureRepository) FailRequest(ctx context.Context, id int64, cause error) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE cdr_erasure_requests SET status = 'FAILED', error = $1, completed_at = NOW() WHERE id = $2",
+		cause.Error(), id)
+	return err
+}
+
+func (r *ErasureRepository) GetRequest(ctx context.Context, id int64) (ErasureRequest, error) {
+	e, err := scanErasure(r.db.QueryRowContext(ctx, "SELECT"+erasureColumns+" FROM cdr_erasure_requests WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, ErrErasureNotFound
+	}
+	return e, err
+}
+
+// ListRequests: Talepleri en yeniden eskiye listeler.
+func (r *ErasureRepository) ListRequests(ctx context.Context, f ErasureFilter) ([]ErasureRequest, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT`+erasureColumns+`
+		FROM cdr_erasure_requests
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR regulation = $2)
+		  AND ($3 = '' OR external_reference = $3) AND ($4 = '' OR subject_hash = $4)
+		ORDER BY id DESC
+		LIMIT NULLIF($5, 0)`,
+		f.TenantID, f.Regulation, f.Reference, f.SubjectHash, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ErasureRequest{}
+	for rows.Next() {
+		e, err := scanErasure(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Erase: Kişiyi tek transaction içinde anonimleştirir: çağrılardaki numara ve kullanıcı ID'si takma adla
+// değiştirilir, kayıt bağlantısı kaldırılır, olay/webhook/arşiv gövdeleri temizlenir. Faturalama satırlarına
+// (usage_records, maliyetler) dokunulmaz. Zincirde mühürlü satırların yeni hash'i ERASURE kayıtlarıyla zincire
+// eklenir. certify, sayılar kesinleştikten sonra sertifikayı üretir; sertifika da aynı transaction'da yazılır.
+func (r *ErasureRepository) Erase(ctx context.Context, e ErasureRequest, m ErasureMatch,
+	certify func(ErasureRequest) (json.RawMessage, error)) (ErasureRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return e, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	numbers := strings.Join(m.Numbers, ",")
+	indexes, err := r.erasureIndexes(ctx, tx, e.TenantID, m.Numbers)
+	if err != nil {
+		return e, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT call_id, tenant_id, COALESCE(recording_url, ''), COALESCE(transcript_uri, '') FROM calls
+		WHERE ($1 = '' OR tenant_id = $1)
+		  AND (caller_number = ANY(string_to_array($2, ',')) OR callee_number = ANY(string_to_array($2, ','))
+		       OR caller_number_bidx = ANY(string_to_array($4, ',')) OR callee_number_bidx = ANY(string_to_array($4, ','))
+		       OR ($3 <> '' AND user_id::text = $3))
+		ORDER BY call_id
+		FOR UPDATE`, e.TenantID, numbers, m.UserID, indexes)
+	if err != nil {
+		return e, err
+	}
+	var callIDs []string
+	notices := map[string]*erasedNotice{}
+	for rows.Next() {
+		var callID, tenantID, recording, transcript string
+		if err := rows.Scan(&callID, &tenantID, &recording, &transcript); err != nil {
+			rows.Close()
+			return e, err
+		}
+		callIDs = append(callIDs, callID)
+		n := notices[tenantID]
+		if n == nil {
+			n = &erasedNotice{ErasureID: e.ID, TenantID: tenantID, Regulation: e.Regulation, Reference: e.Reference,
+				Pseudonym: e.Pseudonym, CallIDs: []string{}, RecordingURLs: []string{}, TranscriptURIs: []string{}}
+			notices[tenantID] = n
+		}
+		n.CallIDs = append(n.CallIDs, callID)
+		if recording != "" {
+			n.RecordingURLs = append(n.RecordingURLs, recording)
+			e.Counts.RecordingsUnlinked++
+		}
+		if transcript != "" {
+			n.TranscriptURIs = append(n.TranscriptURIs, transcript)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return e, err
+	}
+	ids := strings.Join(callIDs, ",")
+
+	// Takma ad düz metin yazılır ve kör indeks silinir; numara aramasında kişi artık bulunmaz.
+	res, err := tx.ExecContext(ctx, `
+		UPDATE calls SET
+			caller_number = CASE WHEN caller_number = ANY(string_to_array($2, ',')) OR caller_number_bidx = ANY(string_to_array($5, ','))
+				THEN $3 ELSE caller_number END,
+			callee_number = CASE WHEN callee_number = ANY(string_to_array($2, ',')) OR callee_number_bidx = ANY(string_to_array($5, ','))
+				THEN $3 ELSE callee_number END,
+			caller_number_bidx = CASE WHEN caller_number = ANY(string_to_array($2, ',')) OR caller_number_bidx = ANY(string_to_array($5, ','))
+				THEN NULL ELSE caller_number_bidx END,
+			callee_number_bidx = CASE WHEN callee_number = ANY(string_to_array($2, ',')) OR callee_number_bidx = ANY(string_to_array($5, ','))
+				THEN NULL ELSE callee_number_bidx END,
+			user_id = CASE WHEN user_id::text = $4 THEN NULL ELSE user_id END,
+			recording_url = NULL, transcript_uri = NULL, ai_summary = NULL, erased_at = NOW(), updated_at = NOW()
+		WHERE call_id = ANY(string_to_array($1, ','))`, ids, numbers, e.Pseudonym, m.UserID, indexes)
+	if err != nil {
+		return e, err
+	}
+	e.Counts.CallsPseudonymized, _ = res.RowsAffected()
+
+	// Çağrıların tüm kayıt segmentleri silinir; recording_url'de olmayanlar bildirime eklenir. IVR'da girilen
+	// maskesiz tuşlar da temizlenir.
+	if len(callIDs) > 0 {
+		if err := eraseIVRDigits(ctx, tx, ids); err != nil {
+			return e, err
+		}
+		uris, err := eraseRecordings(ctx, tx, ids)
+		if err != nil {
+			return e, err
+		}
+		for t, list := range uris {
+			n := notices[t]
+			if n == nil {
+				continue
+			}
+			for _, uri := range list {
+				if !slices.Contains(n.RecordingURLs, uri) {
+					n.RecordingURLs = append(n.RecordingURLs, uri)
+					e.Counts.RecordingsUnlinked++
+				}
+			}
+		}
+	}
+
+	// Kullanıcı ID'si kaldırılan çağrıların KPI katkısı kullanıcısız kovaya taşınır.
+	if m.UserID != "" {
+		for _, id := range callIDs {
+			if err := refreshCallKPI(ctx, tx, id); err != nil {
+				return e, err
+			}
+		}
+	}
+
+	var targets []erasedTarget
+	if len(callIDs) > 0 {
+		if targets, err = r.sealedCallTargets(ctx, tx, ids); err != nil {
+			return e, err
+		}
+	}
+
+	// Kişi, arayan/aranan olmadığı çağrıların olaylarında da (örn: transfer hedefi) geçebilir; tenant'ın tüm
+	// olayları taranır.
+	rows, err = tx.QueryContext(ctx, `
+		UPDATE call_events ev SET payload = regexp_replace(ev.payload::text, $2, $3, 'gi')::jsonb
+		FROM calls c
+		WHERE c.call_id = ev.call_id AND ($1 = '' OR c.tenant_id = $1) AND ev.payload::text ~* $2
+		RETURNING c.tenant_id, ev.call_id, ev.chain_seq, ev.event_type, ev.event_timestamp, ev.payload::text`,
+		e.TenantID, m.Pattern, e.Pseudonym)
+	if err != nil {
+		return e, err
+	}
+	for rows.Next() {
+		var tenantID string
+		var seq sql.NullInt64
+		var ev chain.EventRecord
+		var ts *time.Time
+		if err := rows.Scan(&tenantID, &ev.CallID, &seq, &ev.EventType, &ts, &ev.Payload); err != nil {
+			rows.Close()
+			return e, err
+		}
+		if ts != nil {
+			ev.EventTimestamp = *ts
+		}
+		e.Counts.EventsScrubbed++
+		if seq.Valid {
+			targets = append(targets, erasedTarget{tenantID: tenantID, seq: seq.Int64, callID: ev.CallID, erasedHash: ev.Hash()})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return e, err
+	}
+
+	// Şifreli gövdeler veritabanında taranamaz; eşleşen çağrıların ve aynı etkileşimdeki bacakların olayları
+	// çözülüp uygulama tarafında temizlenir.
+	if r.keys.enabled() && len(callIDs) > 0 {
+		scope, err := interactionCallIDs(ctx, tx, ids)
+		if err != nil {
+			return e, err
+		}
+		scrubbed, err := r.scrubSealedEvents(ctx, tx, "call_events", scope, m.Subject, e.Pseudonym)
+		if err != nil {
+			return e, err
+		}
+		for _, ev := range scrubbed {
+			e.Counts.EventsScrubbed++
+			if ev.seq.Valid {
+				targets = append(targets, erasedTarget{tenantID: ev.tenantID, seq: ev.seq.Int64, callID: ev.rec.CallID,
+					erasedHash: ev.rec.Hash()})
+			}
+		}
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET payload = regexp_replace(payload::text, $2, $3, 'gi')::jsonb
+		WHERE ($1 = '' OR tenant_id = $1) AND payload::text ~* $2`,
+		e.TenantID, m.Pattern, e.Pseudonym)
+	if err != nil {
+		return e, err
+	}
+	e.Counts.WebhooksScrubbed, _ = res.RowsAffected()
+
+	if e.Counts.ArchiveScrubbed, err = r.scrubArchive(ctx, tx, e, m, numbers, indexes); err != nil {
+		return e, err
+	}
+
+	// Zincir başları tenant sırasıyla kilitlenir; eşzamanlı silme talepleri birbirini kilitlemez.
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].tenantID != targets[j].tenantID {
+			return targets[i].tenantID < targets[j].tenantID
+		}
+		return targets[i].seq < targets[j].seq
+	})
+	for _, t := range targets {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE cdr_hash_chain SET erasure_id = $1, erased_hash = $2 WHERE tenant_id = $3 AND seq = $4",
+			e.ID, t.erasedHash, t.tenantID, t.seq); err != nil {
+			return e, err
+		}
+		if _, _, err := appendChain(ctx, tx, t.tenantID, chain.RecordErasure, t.callID,
+			chain.ErasureHash(e.ID, t.seq, t.erasedHash)); err != nil {
+			return e, err
+		}
+		e.Counts.ChainEntries++
+	}
+
+	tenants := make([]string, 0, len(notices))
+	for t := range notices {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+	for _, t := range tenants {
+		if err := enqueueOutboxEvent(ctx, tx, privacy.EventSubjectErased, t, notices[t]); err != nil {
+			return e, err
+		}
+	}
+
+	now := time.Now().UTC()
+	e.Status, e.CompletedAt = "COMPLETED", &now
+	if e.Certificate, err = certify(e); err != nil {
+		return e, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE cdr_erasure_requests SET
+			status = $1, calls_pseudonymized = $2, events_scrubbed = $3, recordings_unlinked = $4,
+			webhooks_scrubbed = $5, archive_scrubbed = $6, chain_entries = $7, certificate = $8::jsonb, completed_at = $9
+		WHERE id = $10`,
+		e.Status, e.Counts.CallsPseudonymized, e.Counts.EventsScrubbed, e.Counts.RecordingsUnlinked,
+		e.Counts.WebhooksScrubbed, e.Counts.ArchiveScrubbed, e.Counts.ChainEntries, string(e.Certificate), now, e.ID)
+	if err != nil {
+		return e, err
+	}
+	return e, tx.Commit()
+}
+
+// sealedCallTargets: Anonimleştirilmiş ve zincirde mühürlü çağrıların yeni hash'leri.
+func (r *ErasureRepository) sealedCallTargets(ctx context.Context, tx *sql.Tx, ids string) ([]erasedTarget, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT"+chainCallColumns+`, c.chain_seq
+		FROM calls c WHERE c.call_id = ANY(string_to_array($1, ',')) AND c.chain_seq IS NOT NULL`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []erasedTarget
+	for rows.Next() {
+		var rec chain.CallRecord
+		var seq int64
+		err := rows.Scan(&rec.CallID, &rec.TenantID, &rec.CallerNumber, &rec.CalleeNumber, &rec.Direction,
+			&rec.StartTime, &rec.AnswerTime, &rec.EndTime, &rec.TotalDurationMs, &rec.BillableDurationMs,
+			&rec.Disposition, &rec.HangupSource, &seq)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.keys.openCallRecord(ctx, &rec); err != nil {
+			return nil, err
+		}
+		out = append(out, erasedTarget{tenantID: rec.TenantID, seq: seq, callID: rec.CallID, erasedHash: rec.Hash()})
+	}
+	return out, rows.Err()
+}
+
+// scrubArchive: Saklama süresi dolup cdr_archive şemasına taşınmış çağrı ve olay satırlarını da temizler.
+// Arşivdeki satırların zincir kaydı budanmış olduğundan zincire ERASURE kaydı eklenmez.
+func (r *ErasureRepository) scrubArchive(ctx context.Context, tx *sql.Tx, e ErasureRequest, m ErasureMatch, numbers, indexes string) (int64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c.relname,
+			COUNT(*) FILTER (WHERE a.attname IN ('call_id', 'tenant_id', 'caller_number', 'callee_number', 'user_id', 'recording_url')) = 6,
+			COUNT(*) FILTER (WHERE a.attname IN ('call_id', 'payload')) = 2,
+			COUNT(*) FILTER (WHERE a.attname IN ('caller_number_bidx', 'callee_number_bidx')) = 2,
+			COUNT(*) FILTER (WHERE a.attname IN ('transcript_uri', 'ai_summary')) = 2
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		WHERE n.nspname = 'cdr_archive' AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		GROUP BY c.relname
+		ORDER BY c.relname`)
+	if err != nil {
+		return 0, err
+	}
+	var callTables, eventTables []string
+	indexed, conversational := map[string]bool{}, map[string]bool{}
+	for rows.Next() {
+		var name string
+		var isCalls, isEvents, hasIndex, hasConversation bool
+		if err := rows.Scan(&name, &isCalls, &isEvents, &hasIndex, &hasConversation); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		switch {
+		case isCalls:
+			callTables = append(callTables, name)
+			indexed[name] = hasIndex
+			conversational[name] = hasConversation
+		case isEvents:
+			eventTables = append(eventTables, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	var archivedCalls []string
+	for _, t := range callTables {
+		// Kör indeks kolonları olmayan (şifreleme öncesi arşivlenmiş) tablolar yalnızca düz metinle eşleşir.
+		callerMatch := "caller_number = ANY(string_to_array($2, ','))"
+		calleeMatch := "callee_number = ANY(string_to_array($2, ','))"
+		extraSet := ""
+		args := []interface{}{e.TenantID, numbers, e.Pseudonym, m.UserID}
+		if indexed[t] {
+			args = append(args, indexes)
+			callerMatch = "(" + callerMatch + " OR caller_number_bidx = ANY(string_to_array($5, ',')))"
+			calleeMatch = "(" + calleeMatch + " OR callee_number_bidx = ANY(string_to_array($5, ',')))"
+			extraSet = fmt.Sprintf(`
+				caller_number_bidx = CASE WHEN %s THEN NULL ELSE caller_number_bidx END,
+				callee_number_bidx = CASE WHEN %s THEN NULL ELSE callee_number_bidx END,`, callerMatch, calleeMatch)
+		}
+		// Konuşma kolonları olmayan tablolar bu kolonlar eklenmeden önce arşivlenmiştir.
+		if conversational[t] {
+			extraSet += `
+				transcript_uri = NULL, ai_summary = NULL,`
+		}
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+			UPDATE cdr_archive.%[1]s SET%[4]s
+				caller_number = CASE WHEN %[2]s THEN $3 ELSE caller_number END,
+				callee_number = CASE WHEN %[3]s THEN $3 ELSE callee_number END,
+				user_id = CASE WHEN user_id::text = $4 THEN NULL ELSE user_id END,
+				recording_url = NULL
+			WHERE ($1 = '' OR tenant_id = $1)
+			  AND (%[2]s OR %[3]s OR ($4 <> '' AND user_id::text = $4))
+			RETURNING call_id`, quoteIdent(t), callerMatch, calleeMatch, extraSet), args...)
+		if err != nil {
+			return total, err
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return total, err
+			}
+			archivedCalls = append(archivedCalls, id)
+			total++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, err
+		}
+	}
+
+	// Arşivdeki olayların tenant'ı yoktur; tenant sınırlıysa yalnızca eşleşen çağrıların olayları, değilse tümü taranır.
+	ids := strings.Join(archivedCalls, ",")
+	for _, t := range eventTables {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			UPDATE cdr_archive.%s SET payload = regexp_replace(payload::text, $2, $3, 'gi')::jsonb
+			WHERE payload::text ~* $2
+			  AND ($1 = '' OR call_id = ANY(string_to_array($4, ','))
+			       OR call_id IN (SELECT call_id FROM calls WHERE tenant_id = $1))`, quoteIdent(t)),
+			e.TenantID, m.Pattern, e.Pseudonym, ids)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+		if r.keys.enabled() && len(archivedCalls) > 0 {
+			scrubbed, err := r.scrubSealedEvents(ctx, tx, "cdr_archive."+quoteIdent(t), archivedCalls, m.Subject, e.Pseudonym)
+			if err != nil {
+				return total, err
+			}
+			total += int64(len(scrubbed))
+		}
+	}
+	return total, nil
+}
+
+// erasureIndexes: Numaranın kör indeksleri. İndeks tenant anahtarına bağlı olduğundan tenant sınırı yoksa
+// indeks anahtarı olan tüm tenant'lar için hesaplanır.
+func (r *ErasureRepository) erasureIndexes(ctx context.Context, tx *sql.Tx, tenantID string, numbers []string) (string, error) {
+	if !r.keys.enabled() || len(numbers) == 0 {
+		return "", nil
+	}
+	tenants := []string{tenantID}
+	if tenantID == "" {
+		rows, err := tx.QueryContext(ctx, "SELECT tenant_id FROM cdr_blind_index_keys ORDER BY tenant_id")
+		if err != nil {
+			return "", err
+		}
+		tenants = nil
+		for rows.Next() {
+			var t string
+			if err := rows.Scan(&t); err != nil {
+				rows.Close()
+				return "", err
+			}
+			tenants = append(tenants, t)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+	}
+	var out []string
+	for _, t := range tenants {
+		index, err := r.keys.numberIndex(ctx, t, numbers[0])
+		if err != nil {
+			return "", err
+		}
+		out = append(out, index)
+	}
+	return strings.Join(out, ","), nil
+}
+
+// interactionCallIDs: Çağrılar ve aynı etkileşimdeki diğer bacaklar.
+func interactionCallIDs(ctx context.Context, tx *sql.Tx, ids string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT call_id FROM unnest(string_to_array($1, ',')) AS call_id
+		UNION
+		SELECT l2.call_id FROM call_legs l1 JOIN call_legs l2 ON l2.interaction_id = l1.interaction_id
+		WHERE l1.call_id = ANY(string_to_array($1, ','))
+		ORDER BY 1`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// scrubbedEvent: Uygulama tarafında temizlenen şifreli olay; rec düz metin gövdeyle yeni zincir özetini verir.
+type scrubbedEvent struct {
+	tenantID string
+	seq      sql.NullInt64
+	rec      chain.EventRecord
+}
+
+// scrubSealedEvents: Tablodaki şifreli olay gövdelerini çözer, kişiyi takma adla değiştirir ve değişen gövdeyi
+// aynı tenant'ın aktif anahtarıyla yeniden şifreler. Tenant, gövdenin şifrelendiği veri anahtarından bulunur.
+func (r *ErasureRepository) scrubSealedEvents(ctx context.Context, tx *sql.Tx, table string, callIDs []string, subject privacy.Subject,
+	pseudonym string) ([]scrubbedEvent, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT call_id, event_type, event_timestamp, payload::text, chain_seq FROM %s
+		WHERE call_id = ANY(string_to_array($1, ',')) AND jsonb_typeof(payload) = 'string'
+		  AND payload #>> '{}' LIKE $2
+		FOR UPDATE`, table), strings.Join(callIDs, ","), fieldcrypt.Prefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	type sealedEvent struct {
+		callID, eventType, payload string
+		ts                         *time.Time
+		seq                        sql.NullInt64
+	}
+	var events []sealedEvent
+	for rows.Next() {
+		var ev sealedEvent
+		if err := rows.Scan(&ev.callID, &ev.eventType, &ev.ts, &ev.payload, &ev.seq); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if ev.ts != nil {
+			events = append(events, ev)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []scrubbedEvent
+	for _, ev := range events {
+		plain, err := r.keys.openPayload(ctx, ev.callID, ev.payload)
+		if err != nil {
+			return out, err
+		}
+		replaced := subject.Replace(plain, pseudonym)
+		if replaced == plain {
+			continue
+		}
+		rec := chain.EventRecord{CallID: ev.callID, EventType: ev.eventType, EventTimestamp: *ev.ts}
+		if err := tx.QueryRowContext(ctx, "SELECT $1::jsonb::text", replaced).Scan(&rec.Payload); err != nil {
+			return out, err
+		}
+		keyID, err := fieldcrypt.KeyID(sealedPayloadValue(ev.payload))
+		if err != nil {
+			return out, err
+		}
+		tenantID, err := r.keys.keyTenant(ctx, keyID)
+		if err != nil {
+			return out, err
+		}
+		sealed, err := r.keys.sealPayload(ctx, tenantID, ev.callID, rec.Payload)
+		if err != nil {
+			return out, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			UPDATE %s SET payload = $1::jsonb
+			WHERE call_id = $2 AND event_type = $3 AND event_timestamp = $4 AND payload = $5::jsonb`, table),
+			sealed, ev.callID, ev.eventType, *ev.ts, ev.payload); err != nil {
+			return out, err
+		}
+		out = append(out, scrubbedEvent{tenantID: tenantID, seq: ev.seq, rec: rec})
+	}
+	return out, nil
+}
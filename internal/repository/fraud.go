@@ -0,0 +1,295 @@
+// sentiric-cdr-service/internal/repository/fraud.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+)
+
+// ErrFraudThresholdsNotFound: Silinmek istenen eşik tanımı yok.
+var ErrFraudThresholdsNotFound = errors.New("dolandırıcılık eşik tanımı bulunamadı")
+
+// FraudThresholds: Tenant'ın dolandırıcılık kuralı eşikleri. TenantID "*" ise varsayılandır. Sıfır eşik kuralı kapatır.
+type FraudThresholds struct {
+	TenantID             string    `json:"tenant_id"`
+	Enabled              bool      `json:"enabled"`
+	SpendPerHour         float64   `json:"spend_per_hour"`
+	HighRiskCallsPerHour int       `json:"high_risk_calls_per_hour"`
+	HighRiskPrefixes     string    `json:"high_risk_prefixes"`
+	BurstCalls           int       `json:"burst_calls"`
+	BurstWindowMinutes   int       `json:"burst_window_minutes"`
+	ShortCallSeconds     int       `json:"short_call_seconds"`
+	OffHoursStart        int       `json:"off_hours_start"`
+	OffHoursEnd          int       `json:"off_hours_end"`
+	OffHoursCallsPerHour int       `json:"off_hours_calls_per_hour"`
+	Timezone             string    `json:"timezone"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// FraudCall: Kurallardan geçirilecek çağrının alanları. DestinationKey, aranan numaranın şifreli satırlarda da
+// eşitlikle karşılaştırılabilen anahtarıdır (kör indeks veya düz numara).
+type FraudCall struct {
+	CallID          string
+	TenantID        string
+	Direction       string
+	CalleeNumber    string
+	DestinationKey  string
+	StartTime       time.Time
+	EndTime         sql.NullTime
+	DurationSeconds int
+}
+
+// FraudWindowFilter: Tenant'ın bir zaman penceresinde biten çağrılarını süzer. Boş/negatif alanlar süzmez.
+type FraudWindowFilter struct {
+	TenantID string
+	From, To time.Time
+	// RiskOnly: Yalnızca yüksek riskli öneke eşleşmiş çağrılar.
+	RiskOnly bool
+	// DestinationKey: Yalnızca bu hedefe giden çağrılar.
+	DestinationKey string
+	// MaxDuration: Yalnızca süresi bu kadar saniye veya daha kısa çağrılar; negatifse süzmez.
+	MaxDuration int
+	// OffHoursTimezone boş değilse yalnızca bu saat diliminde OffHoursStart-OffHoursEnd arasında başlamış çağrılar.
+	OffHoursTimezone string
+	OffHoursStart    int
+	OffHoursEnd      int
+}
+
+// FraudWindow: Penceredeki çağrı sayısı, toplam maliyet ve kanıt olarak en son biten çağrılar.
+type FraudWindow struct {
+	Calls   int
+	Spend   float64
+	CallIDs []string
+}
+
+// FraudAlert: Kural ihlali. Evidence kurala özgü gözlenen değerleri ve ilgili çağrı ID'lerini taşır; numara içermez.
+type FraudAlert struct {
+	ID          int64                  `json:"id"`
+	TenantID    string                 `json:"tenant_id"`
+	Rule        string                 `json:"rule"`
+	Severity    string                 `json:"severity"`
+	DedupKey    string                 `json:"-"`
+	CallID      string                 `json:"call_id"`
+	WindowStart time.Time              `json:"window_start"`
+	WindowEnd   time.Time              `json:"window_end"`
+	Evidence    map[string]interface{} `json:"evidence"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// FraudAlertFilter: Alarm sorgusu.
+type FraudAlertFilter struct {
+	TenantID string
+	Rule     string
+	From, To time.Time
+	Limit    int
+}
+
+const fraudThresholdColumns = `
+	tenant_id, enabled, spend_per_hour::float8, high_risk_calls_per_hour, high_risk_prefixes, burst_calls,
+	burst_window_minutes, short_call_seconds, off_hours_start, off_hours_end, off_hours_calls_per_hour, timezone, updated_at`
+
+type FraudRepository struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+func NewFraudRepository(db *sql.DB, keys *Keyring) *FraudRepository {
+	return &FraudRepository{db: db, keys: keys}
+}
+
+func scanFraudThresholds(row rowScanner) (FraudThresholds, error) {
+	var t FraudThresholds
+	err := row.Scan(&t.TenantID, &t.Enabled, &t.SpendPerHour, &t.HighRiskCallsPerHour, &t.HighRiskPrefixes, &t.BurstCalls,
+		&t.BurstWindowMinutes, &t.ShortCallSeconds, &t.OffHoursStart, &t.OffHoursEnd, &t.OffHoursCallsPerHour,
+		&t.Timezone, &t.UpdatedAt)
+	return t, err
+}
+
+// GetThresholds: Tenant'ın eşikleri; tenant tanımı yoksa "*" varsayılanı. Hiçbiri yoksa kurallar kapalı döner.
+func (r *FraudRepository) GetThresholds(ctx context.Context, tenantID string) (FraudThresholds, error) {
+	t, err := scanFraudThresholds(r.db.QueryRowContext(ctx, `
+		SELECT`+fraudThresholdColumns+`
+		FROM cdr_fraud_thresholds WHERE tenant_id IN ($1, '*')
+		ORDER BY tenant_id = '*'
+		LIMIT 1`, tenantID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return FraudThresholds{TenantID: tenantID}, nil
+	}
+	return t, err
+}
+
+// ListThresholds: Tüm eşik tanımları.
+func (r *FraudRepository) ListThresholds(ctx context.Context) ([]FraudThresholds, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT`+fraudThresholdColumns+` FROM cdr_fraud_thresholds ORDER BY tenant_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []FraudThresholds{}
+	for rows.Next() {
+		t, err := scanFraudThresholds(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// SetThresholds: Tenant'ın eşiklerini ekler veya günceller.
+func (r *FraudRepository) SetThresholds(ctx context.Context, t FraudThresholds) (FraudThresholds, error) {
+	return scanFraudThresholds(r.db.QueryRowContext(ctx, `
+		INSERT INTO cdr_fraud_thresholds (tenant_id, enabled, spend_per_hour, high_risk_calls_per_hour, high_risk_prefixes,
+			burst_calls, burst_window_minutes, short_call_seconds, off_hours_start, off_hours_end, off_hours_calls_per_hour,
+			timezone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled, spend_per_hour = EXCLUDED.spend_per_hour,
+			high_risk_calls_per_hour = EXCLUDED.high_risk_calls_per_hour, high_risk_prefixes = EXCLUDED.high_risk_prefixes,
+			burst_calls = EXCLUDED.burst_calls, burst_window_minutes = EXCLUDED.burst_window_minutes,
+			short_call_seconds = EXCLUDED.short_call_seconds, off_hours_start = EXCLUDED.off_hours_start,
+			off_hours_end = EXCLUDED.off_hours_end, off_hours_calls_per_hour = EXCLUDED.off_hours_calls_per_hour,
+			timezone = EXCLUDED.timezone, updated_at = NOW()
+		RETURNING`+fraudThresholdColumns,
+		t.TenantID, t.Enabled, t.SpendPerHour, t.HighRiskCallsPerHour, t.HighRiskPrefixes, t.BurstCalls,
+		t.BurstWindowMinutes, t.ShortCallSeconds, t.OffHoursStart, t.OffHoursEnd, t.OffHoursCallsPerHour, t.Timezone))
+}
+
+// DeleteThresholds: Tenant tanımını kaldırır; tenant "*" varsayılanına düşer.
+func (r *FraudRepository) DeleteThresholds(ctx context.Context, tenantID string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM cdr_fraud_thresholds WHERE tenant_id = $1", tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFraudThresholdsNotFound
+	}
+	return nil
+}
+
+// GetCall: Çağrının kurallar için gereken alanlarını okur; aranan numara çözülmüş döner.
+func (r *FraudRepository) GetCall(ctx context.Context, callID string) (FraudCall, error) {
+	c := FraudCall{CallID: callID}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT tenant_id, COALESCE(direction, ''), COALESCE(callee_number, ''),
+			COALESCE(callee_number_bidx, callee_number, ''), start_time, end_time, COALESCE(duration_seconds, 0)
+		FROM calls WHERE call_id = $1
+		ORDER BY start_time DESC LIMIT 1`, callID).
+		Scan(&c.TenantID, &c.Direction, &c.CalleeNumber, &c.DestinationKey, &c.StartTime, &c.EndTime, &c.DurationSeconds)
+	if err != nil {
+		return c, err
+	}
+	c.CalleeNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCalleeNumber, callID, c.CalleeNumber)
+	return c, err
+}
+
+// SetRiskPrefix: Çağrının eşleştiği yüksek riskli öneki yazar; boş önek eşleşmeyi kaldırır.
+func (r *FraudRepository) SetRiskPrefix(ctx context.Context, callID, prefix string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE calls SET fraud_risk_prefix = NULLIF($2, '')
+		WHERE call_id = $1 AND fraud_risk_prefix IS DISTINCT FROM NULLIF($2, '')`, callID, prefix)
+	return err
+}
+
+// Window: Süzgece uyan, (From, To] aralığında biten dahili olmayan çağrıları sayar. CallIDs en son biten 10 çağrıdır.
+func (r *FraudRepository) Window(ctx context.Context, f FraudWindowFilter) (FraudWindow, error) {
+	var w FraudWindow
+	var ids string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(total_cost), 0)::float8,
+			COALESCE(array_to_string((array_agg(call_id ORDER BY end_time DESC))[1:10], ','), '')
+		FROM calls, LATERAL (SELECT EXTRACT(HOUR FROM start_time AT TIME ZONE NULLIF($7, ''))::int AS local_hour) h
+		WHERE tenant_id = $1 AND end_time > $2 AND end_time <= $3 AND COALESCE(direction, '') <> 'INTERNAL'
+		  AND (NOT $4::boolean OR fraud_risk_prefix IS NOT NULL)
+		  AND ($5 = '' OR COALESCE(callee_number_bidx, callee_number) = $5)
+		  AND ($6::int < 0 OR COALESCE(duration_seconds, 0) <= $6::int)
+		  AND ($7 = '' OR CASE WHEN $8::int < $9::int
+				THEN h.local_hour >= $8::int AND h.local_hour < $9::int
+				ELSE h.local_hour >= $8::int OR h.local_hour < $9::int END)`,
+		f.TenantID, f.From, f.To, f.RiskOnly, f.DestinationKey, f.MaxDuration,
+		f.OffHoursTimezone, f.OffHoursStart, f.OffHoursEnd).Scan(&w.Calls, &w.Spend, &ids)
+	if err != nil {
+		return w, err
+	}
+	if ids != "" {
+		w.CallIDs = strings.Split(ids, ",")
+	}
+	return w, nil
+}
+
+// RecordAlert: Alarmı kaydeder ve aynı transaction'da yayınlanmak üzere outbox'a yazar. Aynı kural, pencere ve
+// dedup anahtarıyla kayıtlı alarm varsa hiçbir şey yapmaz ve false döner.
+func (r *FraudRepository) RecordAlert(ctx context.Context, a *FraudAlert, eventType string) (bool, error) {
+	evidence, err := json.Marshal(a.Evidence)
+	if err != nil {
+		return false, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO cdr_fraud_alerts (tenant_id, rule, severity, dedup_key, call_id, window_start, window_end, evidence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb)
+		ON CONFLICT (tenant_id, rule, dedup_key) DO NOTHING
+		RETURNING id, created_at`,
+		a.TenantID, a.Rule, a.Severity, a.DedupKey, a.CallID, a.WindowStart, a.WindowEnd, string(evidence)).
+		Scan(&a.ID, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := enqueueOutboxEvent(ctx, tx, eventType, a.TenantID, map[string]interface{}{
+		"alert_id":     a.ID,
+		"tenant_id":    a.TenantID,
+		"rule":         a.Rule,
+		"severity":     a.Severity,
+		"call_id":      a.CallID,
+		"window_start": a.WindowStart,
+		"window_end":   a.WindowEnd,
+		"evidence":     a.Evidence,
+	}); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ListAlerts: Tenant'ın alarmları, en yeni önce.
+func (r *FraudRepository) ListAlerts(ctx context.Context, f FraudAlertFilter) ([]FraudAlert, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, tenant_id, rule, severity, call_id, window_start, window_end, evidence::text, created_at
+		FROM cdr_fraud_alerts
+		WHERE tenant_id = $1 AND ($2 = '' OR rule = $2) AND created_at >= $3 AND created_at < $4
+		ORDER BY id DESC
+		LIMIT NULLIF($5, 0)`, f.TenantID, f.Rule, f.From, f.To, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []FraudAlert{}
+	for rows.Next() {
+		var a FraudAlert
+		var evidence string
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.Rule, &a.Severity, &a.CallID, &a.WindowStart, &a.WindowEnd,
+			&evidence, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(evidence), &a.Evidence); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,262 @@
+// sentiric-cdr-service/internal/repository/invoice.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Fatura kalemi tipleri.
+const (
+	InvoiceLineUsage      = "USAGE"
+	InvoiceLineAdjustment = "ADJUSTMENT"
+)
+
+var (
+	// ErrPeriodClosed: Dönem daha önce kapatılmış; kapatılmış dönem değiştirilemez.
+	ErrPeriodClosed = errors.New("faturalama dönemi zaten kapatılmış")
+	// ErrPeriodNotFound: Dönem henüz kapatılmamış.
+	ErrPeriodNotFound = errors.New("faturalama dönemi bulunamadı")
+	// ErrEarlierPeriodOpen: Daha önceki, hiç kapatılmamış bir döneme ait faturalanmamış usage var; o dönem önce kapatılmalıdır.
+	ErrEarlierPeriodOpen = errors.New("önceki bir faturalama dönemi kapatılmamış")
+)
+
+// InvoiceLine: Servis, kaynak ve tarife grubuna göre toplanmış fatura kalemi.
+type InvoiceLine struct {
+	LineType         string  `json:"line_type"`
+	ServiceName      string  `json:"service_name"`
+	ResourceType     string  `json:"resource_type"`
+	DestinationGroup string  `json:"destination_group"`
+	Quantity         float64 `json:"quantity"`
+	Amount           float64 `json:"amount"`
+	UsageCount       int     `json:"usage_count"`
+}
+
+// Invoice: Bir tenant'ın bir dönemine ait fatura verisi. Kapatılmamış dönem DRAFT olarak önizlenir.
+type Invoice struct {
+	TenantID    string        `json:"tenant_id"`
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Status      string        `json:"status"`
+	ClosedAt    *time.Time    `json:"closed_at,omitempty"`
+	Currency    string        `json:"currency"`
+	Total       float64       `json:"total"`
+	Lines       []InvoiceLine `json:"lines"`
+}
+
+// unbilledUsage: Henüz bir döneme dahil edilmemiş ve dönem sonundan önce gerçekleşmiş usage satırları.
+// Satırın zamanı çağrının başlangıcıdır; çağrı kaydı olmayan satırlarda usage'ın yazıldığı an kullanılır.
+// Dönem başlangıcından önceki satırlar (kapatılmış döneme geç gelenler) düzeltme kalemi olur.
+const unbilledUsage = `
+	FROM usage_records u
+	LEFT JOIN calls c ON c.call_id = u.call_id
+	WHERE u.tenant_id = $1 AND u.billing_period_id IS NULL
+	  AND COALESCE(c.start_time, u.created_at) < $3`
+
+// usageTimeExpr: Usage satırının faturalamadaki zamanı (bkz. unbilledUsage).
+const usageTimeExpr = `COALESCE(c.start_time, u.created_at)`
+
+const lineTypeExpr = `CASE WHEN COALESCE(c.start_time, u.created_at) >= $2 THEN 'USAGE' ELSE 'ADJUSTMENT' END`
+
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// PreviewInvoice: Dönem şu an kapatılsaydı oluşacak kalemleri hesaplar; hiçbir şey yazmaz.
+func (r *InvoiceRepository) PreviewInvoice(ctx context.Context, tenantID string, start, end time.Time) (Invoice, error) {
+	inv := Invoice{TenantID: tenantID, PeriodStart: start, PeriodEnd: end, Status: "DRAFT", Currency: "USD"}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+lineTypeExpr+`, u.service_name, u.resource_type, COALESCE(c.destination_group, 'UNKNOWN'),
+			SUM(u.quantity)::float8, SUM(u.calculated_cost)::float8, COUNT(*)`+unbilledUsage+`
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1 DESC, 2, 3, 4`, tenantID, start, end)
+	if err != nil {
+		return inv, err
+	}
+	defer rows.Close()
+
+	inv.Lines, inv.Total, err = scanInvoiceLines(rows)
+	return inv, err
+}
+
+// ClosePeriod: Dönemi kapatır, kalemleri yazar ve dahil edilen usage satırlarını döneme bağlar.
+// Tüm işlem tek transaction'dır; kapanıştan sonra gelen satırlar bir sonraki dönemde düzeltme olur.
+func (r *InvoiceRepository) ClosePeriod(ctx context.Context, tenantID string, start, end time.Time) (Invoice, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Invoice{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('invoice:' || $1))", tenantID); err != nil {
+		return Invoice{}, err
+	}
+
+	// Kapatılmış bir döneme geç gelen usage düzeltme kalemi olur; ancak hiç kapatılmamış önceki bir dönemin
+	// usage'ı bu döneme alınırsa o dönem bir daha faturalanamaz.
+	var openSince sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT MIN(`+usageTimeExpr+`)
+		FROM usage_records u
+		LEFT JOIN calls c ON c.call_id = u.call_id
+		WHERE u.tenant_id = $1 AND u.billing_period_id IS NULL
+		  AND `+usageTimeExpr+` < $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM billing_periods p
+			WHERE p.tenant_id = $1 AND `+usageTimeExpr+` >= p.period_start AND `+usageTimeExpr+` < p.period_end
+		  )`, tenantID, start).Scan(&openSince)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if openSince.Valid {
+		return Invoice{}, fmt.Errorf("%w: %s tarihli usage faturalanmamış", ErrEarlierPeriodOpen, openSince.Time.UTC().Format(time.RFC3339))
+	}
+
+	var periodID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO billing_periods (tenant_id, period_start, period_end)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id, period_start) DO NOTHING
+		RETURNING id`, tenantID, start, end).Scan(&periodID)
+	if err == sql.ErrNoRows {
+		return Invoice{}, ErrPeriodClosed
+	}
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	// Önce satırlar döneme bağlanır, kalemler yalnızca bağlanan satırlardan üretilir;
+	// böylece kapanış sırasında yazılan bir usage satırı ya iki tarafta da vardır ya hiçbirinde.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE usage_records u SET billing_period_id = $3
+		WHERE u.tenant_id = $1 AND u.billing_period_id IS NULL
+		  AND COALESCE((SELECT c.start_time FROM calls c WHERE c.call_id = u.call_id), u.created_at) < $2`,
+		tenantID, end, periodID)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO invoice_lines (billing_period_id, line_type, service_name, resource_type, destination_group, quantity, amount, usage_count)
+		SELECT $1, `+lineTypeExpr+`, u.service_name, u.resource_type, COALESCE(c.destination_group, 'UNKNOWN'),
+			SUM(u.quantity), SUM(u.calculated_cost), COUNT(*)
+		FROM usage_records u
+		LEFT JOIN calls c ON c.call_id = u.call_id
+		WHERE u.billing_period_id = $1
+		GROUP BY 2, 3, 4, 5`, periodID, start)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE billing_periods SET total_amount = (
+			SELECT COALESCE(SUM(amount), 0) FROM invoice_lines WHERE billing_period_id = $1
+		) WHERE id = $1`, periodID)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Invoice{}, err
+	}
+	return r.GetInvoice(ctx, tenantID, start)
+}
+
+// GetInvoice: Kapatılmış dönemin dondurulmuş fatura verisini okur.
+func (r *InvoiceRepository) GetInvoice(ctx context.Context, tenantID string, start time.Time) (Invoice, error) {
+	inv := Invoice{TenantID: tenantID, Status: "CLOSED"}
+	var periodID int64
+	var closedAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, period_start, period_end, currency, total_amount::float8, closed_at
+		FROM billing_periods WHERE tenant_id = $1 AND period_start = $2`, tenantID, start).
+		Scan(&periodID, &inv.PeriodStart, &inv.PeriodEnd, &inv.Currency, &inv.Total, &closedAt)
+	if err == sql.ErrNoRows {
+		return inv, ErrPeriodNotFound
+	}
+	if err != nil {
+		return inv, err
+	}
+	inv.ClosedAt = &closedAt
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT line_type, service_name, resource_type, destination_group, quantity::float8, amount::float8, usage_count
+		FROM invoice_lines WHERE billing_period_id = $1
+		ORDER BY line_type DESC, service_name, resource_type, destination_group`, periodID)
+	if err != nil {
+		return inv, err
+	}
+	defer rows.Close()
+
+	inv.Lines, _, err = scanInvoiceLines(rows)
+	return inv, err
+}
+
+// ListInvoices: Tenant'ın kapatılmış dönemlerini kalemleri olmadan, en yeniden eskiye döner.
+func (r *InvoiceRepository) ListInvoices(ctx context.Context, tenantID string) ([]Invoice, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT period_start, period_end, currency, total_amount::float8, closed_at
+		FROM billing_periods WHERE tenant_id = $1
+		ORDER BY period_start DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []Invoice{}
+	for rows.Next() {
+		inv := Invoice{TenantID: tenantID, Status: "CLOSED"}
+		var closedAt time.Time
+		if err := rows.Scan(&inv.PeriodStart, &inv.PeriodEnd, &inv.Currency, &inv.Total, &closedAt); err != nil {
+			return nil, err
+		}
+		inv.ClosedAt = &closedAt
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+// ListUnbilledTenants: Dönem sonundan önce faturalanmamış usage satırı olan tenant'ları döner.
+func (r *InvoiceRepository) ListUnbilledTenants(ctx context.Context, end time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT u.tenant_id
+		FROM usage_records u
+		LEFT JOIN calls c ON c.call_id = u.call_id
+		WHERE u.billing_period_id IS NULL AND `+usageTimeExpr+` < $1
+		ORDER BY u.tenant_id`, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+func scanInvoiceLines(rows *sql.Rows) ([]InvoiceLine, float64, error) {
+	lines := []InvoiceLine{}
+	total := 0.0
+	for rows.Next() {
+		var l InvoiceLine
+		if err := rows.Scan(&l.LineType, &l.ServiceName, &l.ResourceType, &l.DestinationGroup, &l.Quantity, &l.Amount, &l.UsageCount); err != nil {
+			return nil, 0, err
+		}
+		total += l.Amount
+		lines = append(lines, l)
+	}
+	return lines, total, rows.Err()
+}
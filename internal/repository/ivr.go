@@ -0,0 +1,245 @@
+// sentiric-cdr-service/internal/repository/ivr.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// IVR adım türleri.
+const (
+	IVRStepNode = "NODE"
+	IVRStepDTMF = "DTMF"
+	IVRStepExit = "EXIT"
+)
+
+// IVR çıkış nedenleri. Completed ve transfer dışındaki çıkışlar terk (drop-off) sayılır.
+const (
+	IVRExitCompleted    = "completed"
+	IVRExitTransfer     = "transfer"
+	IVRExitHangup       = "hangup"
+	IVRExitTimeout      = "timeout"
+	IVRExitInvalidInput = "invalid_input"
+	IVRExitError        = "error"
+)
+
+// IVRExitReasons: Kabul edilen çıkış nedenleri.
+var IVRExitReasons = []string{IVRExitCompleted, IVRExitTransfer, IVRExitHangup, IVRExitTimeout, IVRExitInvalidInput, IVRExitError}
+
+// IsIVRDropOff: Çıkışın arayanın IVR'ı amacına ulaşmadan terk ettiği anlamına gelip gelmediğini söyler.
+func IsIVRDropOff(reason string) bool {
+	return reason != "" && reason != IVRExitCompleted && reason != IVRExitTransfer
+}
+
+// IVRStep: Çağrının IVR yolundaki tek adım. Maskelenen girişlerde Digits yalnızca yıldızlardan oluşur.
+type IVRStep struct {
+	Type         string    `json:"type"`
+	NodeID       string    `json:"node_id,omitempty"`
+	NodeName     string    `json:"node_name,omitempty"`
+	NodeType     string    `json:"node_type,omitempty"`
+	Digits       string    `json:"digits,omitempty"`
+	InputType    string    `json:"input_type,omitempty"`
+	DigitsMasked bool      `json:"digits_masked,omitempty"`
+	ExitReason   string    `json:"exit_reason,omitempty"`
+	Inferred     bool      `json:"inferred,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// IVRNodeStats: Bir IVR düğümünün zaman aralığındaki giriş ve çıkış sayıları.
+type IVRNodeStats struct {
+	NodeID   string `json:"node_id"`
+	NodeName string `json:"node_name,omitempty"`
+	// Calls: Düğüme en az bir kez giren çağrılar.
+	Calls int64 `json:"calls"`
+	// Exits: IVR'dan bu düğümdeyken çıkan çağrılar, nedene göre.
+	Exits       map[string]int64 `json:"exits"`
+	DropOffs    int64            `json:"drop_offs"`
+	DropOffRate float64          `json:"drop_off_rate"`
+}
+
+// lockCallIVR: Aynı çağrının adımlarını sıraya sokar; eşzamanlı iki adımın özeti birbirini ezmez.
+func lockCallIVR(ctx context.Context, tx *sql.Tx, callID string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('call_ivr:' || $1))", callID)
+	return err
+}
+
+// AddIVRStep: Adımı çağrının IVR yoluna ekler ve calls'taki IVR özetini adımlardan yeniden hesaplar. Aynı adım
+// tekrar gelirse eklenmez. Silme talebi işlenmiş çağrıya tuşlar yazılmaz.
+func (r *CallRepository) AddIVRStep(ctx context.Context, callID string, s IVRStep) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockCallIVR(ctx, tx, callID); err != nil {
+		return err
+	}
+	var found bool
+	err = tx.QueryRowContext(ctx, `
+		WITH c AS (
+			SELECT call_id, tenant_id, erased_at FROM calls WHERE call_id = $1 ORDER BY start_time DESC LIMIT 1
+		), ins AS (
+			INSERT INTO call_ivr_steps (call_id, tenant_id, step_type, node_id, node_name, node_type, digits, input_type,
+				digits_masked, exit_reason, occurred_at)
+			SELECT c.call_id, c.tenant_id, $2, $3, NULLIF($4, ''), NULLIF($5, ''),
+				CASE WHEN c.erased_at IS NULL THEN NULLIF($6, '') END, NULLIF($7, ''), $8::boolean, NULLIF($9, ''), $10
+			FROM c
+			ON CONFLICT (call_id, step_type, node_id, occurred_at) DO NOTHING
+		)
+		SELECT EXISTS(SELECT 1 FROM c)`,
+		callID, s.Type, s.NodeID, s.NodeName, s.NodeType, s.Digits, s.InputType, s.DigitsMasked, s.ExitReason,
+		s.OccurredAt).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrCallNotFound
+	}
+	if err := refreshIVRSummary(ctx, tx, callID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InferIVRHangup: IVR'a girip çıkış olayı gelmeden biten çağrıya bitiş anında çıkarımsal 'hangup' çıkışı ekler.
+// Sonradan gelen gerçek çıkış olayı çıkarımsal olanın önüne geçer.
+func (r *CallRepository) InferIVRHangup(ctx context.Context, callID string, endTime time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockCallIVR(ctx, tx, callID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO call_ivr_steps (call_id, tenant_id, step_type, exit_reason, inferred, occurred_at)
+		SELECT call_id, tenant_id, 'EXIT', $2, TRUE, $3 FROM calls
+		WHERE call_id = $1
+		  AND EXISTS (SELECT 1 FROM call_ivr_steps WHERE call_id = $1 AND step_type = 'NODE')
+		  AND NOT EXISTS (SELECT 1 FROM call_ivr_steps WHERE call_id = $1 AND step_type = 'EXIT')
+		ORDER BY start_time DESC LIMIT 1
+		ON CONFLICT (call_id, step_type, node_id, occurred_at) DO NOTHING`, callID, IVRExitHangup, endTime)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if err := refreshIVRSummary(ctx, tx, callID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// refreshIVRSummary: Düğüm sayısını, son düğümü ve çıkış nedenini adımlardan hesaplar; olay sırasından bağımsızdır.
+// Son düğüm, çıkış olayı düğüm bildiriyorsa o düğüm, bildirmiyorsa en son girilen düğümdür.
+func refreshIVRSummary(ctx context.Context, tx execer, callID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE calls SET
+			ivr_node_count = (SELECT COUNT(*) FROM call_ivr_steps WHERE call_id = $1 AND step_type = 'NODE'),
+			ivr_last_node = COALESCE(
+				(SELECT NULLIF(node_id, '') FROM call_ivr_steps WHERE call_id = $1 AND step_type = 'EXIT'
+				 ORDER BY inferred, occurred_at DESC, id DESC LIMIT 1),
+				(SELECT node_id FROM call_ivr_steps WHERE call_id = $1 AND step_type = 'NODE'
+				 ORDER BY occurred_at DESC, id DESC LIMIT 1)),
+			ivr_exit_reason = (SELECT exit_reason FROM call_ivr_steps WHERE call_id = $1 AND step_type = 'EXIT'
+				ORDER BY inferred, occurred_at DESC, id DESC LIMIT 1),
+			updated_at = NOW()
+		WHERE call_id = $1`, callID)
+	return err
+}
+
+// eraseIVRDigits: Silme talebine giren çağrıların maskelenmemiş tuşlarını temizler; yol ve çıkış korunur.
+func eraseIVRDigits(ctx context.Context, tx execer, callIDs string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE call_ivr_steps SET digits = NULL
+		WHERE call_id = ANY(string_to_array($1, ',')) AND digits IS NOT NULL AND NOT digits_masked`, callIDs)
+	return err
+}
+
+// ListIVRPath: Tenant'ın çağrısının IVR adımları, olay zamanı sırasıyla.
+func (r *CallRepository) ListIVRPath(ctx context.Context, tenantID, callID string) ([]IVRStep, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT step_type, node_id, COALESCE(node_name, ''), COALESCE(node_type, ''), COALESCE(digits, ''),
+			COALESCE(input_type, ''), digits_masked, COALESCE(exit_reason, ''), inferred, occurred_at
+		FROM call_ivr_steps
+		WHERE tenant_id = $1 AND call_id = $2
+		ORDER BY occurred_at, id`, tenantID, callID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []IVRStep{}
+	for rows.Next() {
+		var s IVRStep
+		if err := rows.Scan(&s.Type, &s.NodeID, &s.NodeName, &s.NodeType, &s.Digits, &s.InputType, &s.DigitsMasked,
+			&s.ExitReason, &s.Inferred, &s.OccurredAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// IVRDropOff: Zaman aralığında başlayan çağrılar için düğüm bazlı giriş ve çıkışlar. Düğümler terk sayısına göre
+// azalan sıradadır.
+func (r *CallRepository) IVRDropOff(ctx context.Context, tenantID string, from, to time.Time) ([]IVRNodeStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH scope AS (
+			SELECT call_id, ivr_last_node, ivr_exit_reason FROM calls
+			WHERE tenant_id = $1 AND start_time >= $2 AND start_time < $3 AND ivr_node_count > 0
+		), visits AS (
+			SELECT s.node_id, COUNT(DISTINCT s.call_id) AS calls,
+				(array_agg(s.node_name ORDER BY s.occurred_at DESC) FILTER (WHERE s.node_name IS NOT NULL))[1] AS node_name
+			FROM call_ivr_steps s JOIN scope c ON c.call_id = s.call_id
+			WHERE s.tenant_id = $1 AND s.step_type = 'NODE'
+			GROUP BY s.node_id
+		), exits AS (
+			SELECT ivr_last_node AS node_id, ivr_exit_reason AS reason, COUNT(*) AS n FROM scope
+			WHERE ivr_exit_reason IS NOT NULL
+			GROUP BY 1, 2
+		)
+		SELECT v.node_id, COALESCE(v.node_name, ''), v.calls, COALESCE(e.reason, ''), COALESCE(e.n, 0)
+		FROM visits v LEFT JOIN exits e ON e.node_id = v.node_id
+		ORDER BY v.node_id`, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []IVRNodeStats{}
+	for rows.Next() {
+		var node, name, reason string
+		var calls, n int64
+		if err := rows.Scan(&node, &name, &calls, &reason, &n); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 || out[len(out)-1].NodeID != node {
+			out = append(out, IVRNodeStats{NodeID: node, NodeName: name, Calls: calls, Exits: map[string]int64{}})
+		}
+		st := &out[len(out)-1]
+		if reason == "" {
+			continue
+		}
+		st.Exits[reason] = n
+		if IsIVRDropOff(reason) {
+			st.DropOffs += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range out {
+		if out[i].Calls > 0 {
+			out[i].DropOffRate = float64(out[i].DropOffs) / float64(out[i].Calls)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DropOffs > out[j].DropOffs })
+	return out, nil
+}
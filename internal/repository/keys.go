@@ -0,0 +1,474 @@
+// sentiric-cdr-service/internal/repository/keys.go
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+	"github.com/sentiric/sentiric-cdr-service/internal/privacy"
+)
+
+// Veri anahtarı durumları. RETIRING anahtarla yeni değer şifrelenmez ama satırlar yeniden şifrelenene kadar açılır.
+const (
+	KeyActive   = "ACTIVE"
+	KeyRetiring = "RETIRING"
+	KeyRetired  = "RETIRED"
+)
+
+// Keyring: Tenant veri ve kör indeks anahtarlarını oluşturur, ana anahtarla açar ve bellekte tutar.
+// Repository'lere kurucularıyla verilir; nil anahtarlık alan şifrelemesinin kapalı olduğu anlamına gelir ve
+// alanlar düz metin yazılır.
+type Keyring struct {
+	db     *sql.DB
+	master fieldcrypt.MasterKey
+
+	mu     sync.Mutex
+	keys   map[int64][]byte       // veri anahtarı ID -> anahtar
+	active map[string]activeEntry // tenant -> aktif veri anahtarı
+	index  map[string][]byte      // tenant -> kör indeks anahtarı
+}
+
+type activeEntry struct {
+	id       int64
+	loadedAt time.Time
+}
+
+// ActiveKeyTTL: Aktif anahtarın bellekte tutulma süresi. Başka bir kopyada (CLI) yapılan rotasyon en geç bu süre
+// sonra görülür; yeniden şifreleme işi bu süre dolmadan bitmiş sayılmaz.
+const ActiveKeyTTL = 5 * time.Minute
+
+func NewKeyring(db *sql.DB, master fieldcrypt.MasterKey) *Keyring {
+	return &Keyring{db: db, master: master, keys: map[int64][]byte{}, active: map[string]activeEntry{}, index: map[string][]byte{}}
+}
+
+// MasterKeyID: Yeni sarmalamalarda kullanılan ana anahtar.
+func (k *Keyring) MasterKeyID() string {
+	return k.master.ID()
+}
+
+// forget: Rotasyondan sonra tenant'ın aktif anahtarı yeniden okunsun.
+func (k *Keyring) forget(tenantID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.active, tenantID)
+}
+
+// refresh: Aktif anahtar önbelleğini boşaltır; yeniden şifreleme işi başka kopyada yapılmış rotasyonu hemen görür.
+func (k *Keyring) refresh() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.active = map[string]activeEntry{}
+}
+
+// activeKey: Tenant'ın aktif veri anahtarı; yoksa oluşturulur. Eşzamanlı oluşturmada kısmi unique indeks
+// tek aktif anahtar bırakır, kaybeden kopya kazananınkini okur.
+func (k *Keyring) activeKey(ctx context.Context, tenantID string) (int64, []byte, error) {
+	k.mu.Lock()
+	entry, ok := k.active[tenantID]
+	key := k.keys[entry.id]
+	k.mu.Unlock()
+	if ok && time.Since(entry.loadedAt) < ActiveKeyTTL {
+		return entry.id, key, nil
+	}
+
+	var id int64
+	var wrapped, masterID string
+	err := k.db.QueryRowContext(ctx,
+		"SELECT id, wrapped_key, master_key_id FROM cdr_data_keys WHERE tenant_id = $1 AND state = 'ACTIVE'", tenantID).
+		Scan(&id, &wrapped, &masterID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := k.createDataKey(ctx, k.db, tenantID); err != nil {
+			return 0, nil, err
+		}
+		err = k.db.QueryRowContext(ctx,
+			"SELECT id, wrapped_key, master_key_id FROM cdr_data_keys WHERE tenant_id = $1 AND state = 'ACTIVE'", tenantID).
+			Scan(&id, &wrapped, &masterID)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if key, err = k.unwrap(ctx, id, masterID, wrapped); err != nil {
+		return 0, nil, err
+	}
+	k.mu.Lock()
+	k.active[tenantID] = activeEntry{id: id, loadedAt: time.Now()}
+	k.mu.Unlock()
+	return id, key, nil
+}
+
+// createDataKey: Yeni aktif veri anahtarı ekler; tenant'ın zaten aktif anahtarı varsa etkisizdir.
+func (k *Keyring) createDataKey(ctx context.Context, ex execer, tenantID string) error {
+	key, err := fieldcrypt.NewKey()
+	if err != nil {
+		return err
+	}
+	wrapped, err := k.master.Wrap(ctx, key)
+	if err != nil {
+		return err
+	}
+	_, err = ex.ExecContext(ctx, `
+		INSERT INTO cdr_data_keys (tenant_id, wrapped_key, master_key_id) VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id) WHERE state = 'ACTIVE' DO NOTHING`,
+		tenantID, wrapped, k.master.ID())
+	return err
+}
+
+// keyByID: Şifreli değerin anahtarı; eski (RETIRING/RETIRED) anahtarlar da açılır.
+func (k *Keyring) keyByID(ctx context.Context, id int64) ([]byte, error) {
+	k.mu.Lock()
+	key, ok := k.keys[id]
+	k.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	var wrapped, masterID string
+	err := k.db.QueryRowContext(ctx, "SELECT wrapped_key, master_key_id FROM cdr_data_keys WHERE id = $1", id).
+		Scan(&wrapped, &masterID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("veri anahtarı bulunamadı: %d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k.unwrap(ctx, id, masterID, wrapped)
+}
+
+// keyTenant: Veri anahtarının ait olduğu tenant.
+func (k *Keyring) keyTenant(ctx context.Context, id int64) (string, error) {
+	var tenantID string
+	err := k.db.QueryRowContext(ctx, "SELECT tenant_id FROM cdr_data_keys WHERE id = $1", id).Scan(&tenantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("veri anahtarı bulunamadı: %d", id)
+	}
+	return tenantID, err
+}
+
+func (k *Keyring) unwrap(ctx context.Context, id int64, masterID, wrapped string) ([]byte, error) {
+	key, err := k.master.Unwrap(ctx, masterID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("veri anahtarı %d açılamadı: %w", id, err)
+	}
+	k.mu.Lock()
+	k.keys[id] = key
+	k.mu.Unlock()
+	return key, nil
+}
+
+// indexKey: Tenant'ın kör indeks anahtarı. Veri anahtarı rotasyonundan etkilenmez; indeksler yeniden hesaplanmaz.
+func (k *Keyring) indexKey(ctx context.Context, tenantID string) ([]byte, error) {
+	k.mu.Lock()
+	key, ok := k.index[tenantID]
+	k.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	var wrapped, masterID string
+	err := k.db.QueryRowContext(ctx,
+		"SELECT wrapped_key, master_key_id FROM cdr_blind_index_keys WHERE tenant_id = $1", tenantID).Scan(&wrapped, &masterID)
+	if errors.Is(err, sql.ErrNoRows) {
+		fresh, err := fieldcrypt.NewKey()
+		if err != nil {
+			return nil, err
+		}
+		if wrapped, err = k.master.Wrap(ctx, fresh); err != nil {
+			return nil, err
+		}
+		err = k.db.QueryRowContext(ctx, `
+			INSERT INTO cdr_blind_index_keys (tenant_id, wrapped_key, master_key_id) VALUES ($1, $2, $3)
+			ON CONFLICT (tenant_id) DO UPDATE SET tenant_id = EXCLUDED.tenant_id
+			RETURNING wrapped_key, master_key_id`, tenantID, wrapped, k.master.ID()).Scan(&wrapped, &masterID)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if key, err = k.master.Unwrap(ctx, masterID, wrapped); err != nil {
+		return nil, fmt.Errorf("kör indeks anahtarı açılamadı (%s): %w", tenantID, err)
+	}
+	k.mu.Lock()
+	k.index[tenantID] = key
+	k.mu.Unlock()
+	return key, nil
+}
+
+// enabled: Alan şifrelemesinin açık olup olmadığını söyler.
+func (k *Keyring) enabled() bool {
+	return k != nil
+}
+
+// sealField: Değeri tenant'ın aktif anahtarıyla şifreler. Şifreleme kapalıysa veya tenant bilinmiyorsa değer aynen döner.
+func (k *Keyring) sealField(ctx context.Context, tenantID, field, rowID, value string) (string, error) {
+	if k == nil || tenantID == "" || value == "" {
+		return value, nil
+	}
+	id, key, err := k.activeKey(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	return fieldcrypt.Seal(key, id, field, rowID, value)
+}
+
+// openField: Şifreli değeri çözer; düz metin değer aynen döner.
+func (k *Keyring) openField(ctx context.Context, field, rowID, value string) (string, error) {
+	if !fieldcrypt.IsSealed(value) {
+		return value, nil
+	}
+	if k == nil {
+		return "", errors.New("şifreli alan var ama ana anahtar yapılandırılmamış")
+	}
+	id, err := fieldcrypt.KeyID(value)
+	if err != nil {
+		return "", err
+	}
+	key, err := k.keyByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return fieldcrypt.Open(key, field, rowID, value)
+}
+
+// numberIndex: Numaranın tenant kör indeksi; şifreleme kapalıysa veya numara boşsa boş döner.
+func (k *Keyring) numberIndex(ctx context.Context, tenantID, number string) (string, error) {
+	if k == nil || tenantID == "" || number == "" {
+		return "", nil
+	}
+	key, err := k.indexKey(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	return fieldcrypt.BlindIndex(key, privacy.NormalizeNumber(number)), nil
+}
+
+// sealedNumbers: Çağrı satırına yazılacak numaralar ve kör indeksleri; şifreleme kapalıysa indeksler NULL'dır.
+type sealedNumbers struct {
+	caller, callee           string
+	callerIndex, calleeIndex interface{}
+}
+
+// sealNumbers: Arayan/aranan numaralarını şifreler ve kör indekslerini hesaplar.
+func (k *Keyring) sealNumbers(ctx context.Context, tenantID, callID, caller, callee string) (sealedNumbers, error) {
+	var out sealedNumbers
+	for _, f := range []struct {
+		field, value string
+		sealed       *string
+		indexed      *interface{}
+	}{
+		{fieldcrypt.FieldCallerNumber, caller, &out.caller, &out.callerIndex},
+		{fieldcrypt.FieldCalleeNumber, callee, &out.callee, &out.calleeIndex},
+	} {
+		sealed, err := k.sealField(ctx, tenantID, f.field, callID, f.value)
+		if err != nil {
+			return out, err
+		}
+		index, err := k.numberIndex(ctx, tenantID, f.value)
+		if err != nil {
+			return out, err
+		}
+		*f.sealed, *f.indexed = sealed, nullIfEmpty(index)
+	}
+	return out, nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// openCallRecord: Zincir kaydındaki numaraları çözer; özet düz metin üzerinden hesaplanır.
+func (k *Keyring) openCallRecord(ctx context.Context, rec *chain.CallRecord) error {
+	var err error
+	if rec.CallerNumber, err = k.openField(ctx, fieldcrypt.FieldCallerNumber, rec.CallID, rec.CallerNumber); err != nil {
+		return err
+	}
+	rec.CalleeNumber, err = k.openField(ctx, fieldcrypt.FieldCalleeNumber, rec.CallID, rec.CalleeNumber)
+	return err
+}
+
+// sealPayload: Olay gövdesi jsonb kolonuna şifreli değer JSON metni olarak yazılır.
+func (k *Keyring) sealPayload(ctx context.Context, tenantID, callID, payload string) (string, error) {
+	sealed, err := k.sealField(ctx, tenantID, fieldcrypt.FieldPayload, callID, payload)
+	if err != nil || sealed == payload {
+		return sealed, err
+	}
+	b, err := json.Marshal(sealed)
+	return string(b), err
+}
+
+// openPayload: jsonb metin gösterimindeki gövdeyi çözer; şifreli değilse aynen döner.
+func (k *Keyring) openPayload(ctx context.Context, callID, payload string) (string, error) {
+	if len(payload) == 0 || payload[0] != '"' {
+		return payload, nil
+	}
+	var s string
+	if err := json.Unmarshal([]byte(payload), &s); err != nil || !fieldcrypt.IsSealed(s) {
+		return payload, nil
+	}
+	return k.openField(ctx, fieldcrypt.FieldPayload, callID, s)
+}
+
+// openEvents: IncludeEvents ile okunan olay dizisindeki şifreli gövdeleri çözer.
+func (k *Keyring) openEvents(ctx context.Context, callID string, events []byte) (json.RawMessage, error) {
+	if !bytes.Contains(events, []byte(fieldcrypt.Prefix)) {
+		return events, nil
+	}
+	var list []struct {
+		EventType      string          `json:"event_type"`
+		EventTimestamp json.RawMessage `json:"event_timestamp"`
+		Payload        json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(events, &list); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		plain, err := k.openPayload(ctx, callID, string(list[i].Payload))
+		if err != nil {
+			return nil, err
+		}
+		list[i].Payload = json.RawMessage(plain)
+	}
+	return json.Marshal(list)
+}
+
+// DataKey: Tenant veri anahtarının durumu; anahtar materyali içermez.
+type DataKey struct {
+	ID          int64      `json:"id"`
+	TenantID    string     `json:"tenant_id"`
+	MasterKeyID string     `json:"master_key_id"`
+	State       string     `json:"state"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+}
+
+// ListDataKeys: Veri anahtarları; tenantID boşsa tümü.
+func (k *Keyring) ListDataKeys(ctx context.Context, tenantID string) ([]DataKey, error) {
+	rows, err := k.db.QueryContext(ctx, `
+		SELECT id, tenant_id, master_key_id, state, created_at, retired_at FROM cdr_data_keys
+		WHERE $1 = '' OR tenant_id = $1
+		ORDER BY tenant_id, id`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []DataKey{}
+	for rows.Next() {
+		var d DataKey
+		if err := rows.Scan(&d.ID, &d.TenantID, &d.MasterKeyID, &d.State, &d.CreatedAt, &d.RetiredAt); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Rotate: Tenant'ın (tenantID boşsa anahtarı olan tüm tenant'ların) aktif anahtarını RETIRING yapar, yeni aktif
+// anahtar oluşturur ve eski anahtarla şifreli satırlar için yeniden şifreleme işi açar.
+func (k *Keyring) Rotate(ctx context.Context, tenantID string) (RekeyJob, error) {
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return RekeyJob{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE cdr_data_keys SET state = 'RETIRING'
+		WHERE state = 'ACTIVE' AND ($1 = '' OR tenant_id = $1)
+		RETURNING tenant_id`, tenantID)
+	if err != nil {
+		return RekeyJob{}, err
+	}
+	var tenants []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			rows.Close()
+			return RekeyJob{}, err
+		}
+		tenants = append(tenants, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return RekeyJob{}, err
+	}
+	if tenantID != "" && len(tenants) == 0 {
+		tenants = []string{tenantID}
+	}
+	for _, t := range tenants {
+		if err := k.createDataKey(ctx, tx, t); err != nil {
+			return RekeyJob{}, err
+		}
+	}
+	job, err := createRekeyJob(ctx, tx, tenantID)
+	if err != nil {
+		return job, err
+	}
+	if err := tx.Commit(); err != nil {
+		return job, err
+	}
+	for _, t := range tenants {
+		k.forget(t)
+	}
+	return job, nil
+}
+
+// Rewrap: Eski ana anahtarla sarmalanmış veri ve indeks anahtarlarını aktif ana anahtarla yeniden sarmalar.
+// Şifreli satırlara dokunulmaz. Yeniden sarmalanan anahtar sayısını döner.
+func (k *Keyring) Rewrap(ctx context.Context) (int, error) {
+	count := 0
+	for _, table := range []string{"cdr_data_keys", "cdr_blind_index_keys"} {
+		idCol := "id::text"
+		if table == "cdr_blind_index_keys" {
+			idCol = "tenant_id"
+		}
+		rows, err := k.db.QueryContext(ctx, fmt.Sprintf(
+			"SELECT %s, wrapped_key, master_key_id FROM %s WHERE master_key_id <> $1", idCol, table), k.master.ID())
+		if err != nil {
+			return count, err
+		}
+		type stale struct{ id, wrapped, masterID string }
+		var list []stale
+		for rows.Next() {
+			var s stale
+			if err := rows.Scan(&s.id, &s.wrapped, &s.masterID); err != nil {
+				rows.Close()
+				return count, err
+			}
+			list = append(list, s)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return count, err
+		}
+
+		for _, s := range list {
+			key, err := k.master.Unwrap(ctx, s.masterID, s.wrapped)
+			if err != nil {
+				return count, fmt.Errorf("%s %s açılamadı: %w", table, s.id, err)
+			}
+			wrapped, err := k.master.Wrap(ctx, key)
+			if err != nil {
+				return count, err
+			}
+			_, err = k.db.ExecContext(ctx, fmt.Sprintf(
+				"UPDATE %s SET wrapped_key = $1, master_key_id = $2 WHERE %s = $3 AND master_key_id = $4", table, idCol),
+				wrapped, k.master.ID(), s.id, s.masterID)
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
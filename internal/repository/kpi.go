@@ -0,0 +1,345 @@
+// sentiric-cdr-service/internal/repository/kpi.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KPI rollup çözünürlükleri.
+const (
+	Granularity5m = "5m"
+	Granularity1h = "1h"
+	Granularity1d = "1d"
+)
+
+// Granularities: Her çağrının eklendiği çözünürlükler ve kova uzunlukları. Sıra sabittir; eşzamanlı
+// güncellemeler kovaları aynı sırayla kilitler.
+var Granularities = []struct {
+	Name   string
+	Bucket time.Duration
+}{
+	{Granularity5m, 5 * time.Minute},
+	{Granularity1h, time.Hour},
+	{Granularity1d, 24 * time.Hour},
+}
+
+// kpiContribution: Tek çağrının rollup sayaçlarına katkısı; calls.kpi_snapshot'ta saklanır.
+type kpiContribution struct {
+	TenantID        string    `json:"tenant_id"`
+	StartTime       time.Time `json:"start_time"`
+	Direction       string    `json:"direction"`
+	UserID          string    `json:"user_id"`
+	Answered        int64     `json:"answered"`
+	NetworkOK       int64     `json:"network_ok"`
+	Inbound         int64     `json:"inbound"`
+	Abandoned       int64     `json:"abandoned"`
+	RingCalls       int64     `json:"ring_calls"`
+	RingSeconds     int64     `json:"ring_seconds"`
+	AnsweredSeconds int64     `json:"answered_seconds"`
+	BillableSeconds int64     `json:"billable_seconds"`
+	// Kalite: Çağrının en kötü yönü (en düşük MOS, en yüksek jitter ve kayıp). Kalite özeti yoksa sıfırdır.
+	QualityCalls     int64   `json:"quality_calls,omitempty"`
+	LowMOSCalls      int64   `json:"low_mos_calls,omitempty"`
+	MOSSum           float64 `json:"mos_sum,omitempty"`
+	JitterMsSum      float64 `json:"jitter_ms_sum,omitempty"`
+	PacketLossPctSum float64 `json:"packet_loss_pct_sum,omitempty"`
+}
+
+func (c kpiContribution) equal(o kpiContribution) bool {
+	if !c.StartTime.Equal(o.StartTime) {
+		return false
+	}
+	c.StartTime = o.StartTime
+	return c == o
+}
+
+// KPIBucket: Bir kovanın sayaçları ve bunlardan hesaplanan oranlar.
+type KPIBucket struct {
+	BucketStart     time.Time `json:"bucket_start"`
+	Direction       string    `json:"direction,omitempty"`
+	UserID          string    `json:"user_id,omitempty"`
+	Attempts        int64     `json:"attempts"`
+	Answered        int64     `json:"answered"`
+	Inbound         int64     `json:"inbound"`
+	Abandoned       int64     `json:"abandoned"`
+	BillableMinutes float64   `json:"billable_minutes"`
+	// ASR: Cevaplanan / toplam deneme.
+	ASR float64 `json:"asr"`
+	// ACD: Cevaplanan çağrıların ortalama süresi (saniye).
+	ACD float64 `json:"acd_seconds"`
+	// NER: Ağ hatasıyla (FAILED) sonuçlanmayan / toplam deneme.
+	NER float64 `json:"ner"`
+	// AbandonRate: Arayanın cevaplanmadan kapattığı gelen çağrılar / gelen çağrılar.
+	AbandonRate float64 `json:"abandon_rate"`
+	// AvgRing: Çalma aşamasına geçen çağrıların ortalama çalma süresi (saniye).
+	AvgRing float64 `json:"avg_ring_seconds"`
+	// QualityCalls: Kalite özeti bulunan çağrılar. Kalite ortalamaları bu çağrılar üzerindendir.
+	QualityCalls int64 `json:"quality_calls"`
+	// AvgMOS, AvgJitterMs, AvgPacketLossPct: Çağrıların en kötü yönünün ortalamaları.
+	AvgMOS           float64 `json:"avg_mos"`
+	AvgJitterMs      float64 `json:"avg_jitter_ms"`
+	AvgPacketLossPct float64 `json:"avg_packet_loss_pct"`
+	// LowMOSRate: low_mos işaretli çağrılar / kalite özeti bulunan çağrılar.
+	LowMOSRate float64 `json:"low_mos_rate"`
+}
+
+// KPIFilter: KPI sorgusu. GroupBy boş, "direction" veya "user" olabilir.
+type KPIFilter struct {
+	TenantID    string
+	Granularity string
+	From, To    time.Time
+	Direction   string
+	UserID      string
+	GroupBy     string
+}
+
+type KPIRepository struct {
+	db *sql.DB
+}
+
+func NewKPIRepository(db *sql.DB) *KPIRepository {
+	return &KPIRepository{db: db}
+}
+
+// RefreshCall: Çağrının KPI ve temsilci rollup katkılarını güncel CDR'a göre yeniler.
+func (r *KPIRepository) RefreshCall(ctx context.Context, callID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := refreshCallKPI(ctx, tx, callID); err != nil {
+		return err
+	}
+	if err := refreshAgentHandling(ctx, tx, callID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Rebuild: Aralıkta başlamış tamamlanmış çağrıların katkılarını yeniler (geçmiş verinin rollup'a alınması
+// veya elle düzeltilen CDR'lar için). Katkısı değişmeyen çağrılar rollup'a dokunmaz.
+func (r *KPIRepository) Rebuild(ctx context.Context, tenantID string, from, to time.Time, fn func(callID string)) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT call_id FROM calls
+		WHERE start_time >= $1 AND start_time < $2 AND end_time IS NOT NULL AND ($3 = '' OR tenant_id = $3)
+		ORDER BY start_time`, from, to, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		if err := r.RefreshCall(ctx, id); err != nil {
+			return i, fmt.Errorf("%s: %w", id, err)
+		}
+		if fn != nil {
+			fn(id)
+		}
+	}
+	return len(ids), nil
+}
+
+// refreshCallKPI: Çağrı satırını kilitler, kayıtlı katkıyı rollup'lardan çıkarır ve güncel katkıyı ekler.
+// Bitmemiş çağrının katkısı yoktur.
+func refreshCallKPI(ctx context.Context, tx *sql.Tx, callID string) error {
+	var (
+		c                kpiContribution
+		ended, rang      bool
+		disposition, src string
+		duration         int64
+		snapshot         []byte
+	)
+	var mos, jitter, loss sql.NullFloat64
+	var lowMOS sql.NullBool
+	err := tx.QueryRowContext(ctx, `
+		SELECT tenant_id, start_time, COALESCE(direction, ''), COALESCE(user_id::text, ''),
+			COALESCE(disposition, ''), COALESCE(hangup_source, ''), end_time IS NOT NULL, ringing_time IS NOT NULL,
+			COALESCE(ring_seconds, 0), COALESCE(duration_seconds, 0), COALESCE(billable_seconds, 0), kpi_snapshot,
+			LEAST(mos_inbound, mos_outbound), GREATEST(jitter_inbound_ms, jitter_outbound_ms),
+			GREATEST(packet_loss_inbound_pct, packet_loss_outbound_pct), low_mos
+		FROM calls WHERE call_id = $1
+		ORDER BY start_time DESC LIMIT 1
+		FOR UPDATE`, callID).Scan(&c.TenantID, &c.StartTime, &c.Direction, &c.UserID, &disposition, &src,
+		&ended, &rang, &c.RingSeconds, &duration, &c.BillableSeconds, &snapshot, &mos, &jitter, &loss, &lowMOS)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var next *kpiContribution
+	if ended {
+		if disposition == "ANSWERED" {
+			c.Answered, c.AnsweredSeconds = 1, duration
+		}
+		if disposition != "FAILED" {
+			c.NetworkOK = 1
+		}
+		if c.Direction == "INBOUND" {
+			c.Inbound = 1
+			if disposition != "ANSWERED" && src == "CALLER" {
+				c.Abandoned = 1
+			}
+		}
+		if rang {
+			c.RingCalls = 1
+		} else {
+			c.RingSeconds = 0
+		}
+		if mos.Valid {
+			c.QualityCalls, c.MOSSum, c.JitterMsSum, c.PacketLossPctSum = 1, mos.Float64, jitter.Float64, loss.Float64
+			if lowMOS.Bool {
+				c.LowMOSCalls = 1
+			}
+		}
+		c.StartTime = c.StartTime.UTC()
+		next = &c
+	}
+
+	var prev *kpiContribution
+	if len(snapshot) > 0 {
+		prev = &kpiContribution{}
+		if err := json.Unmarshal(snapshot, prev); err != nil {
+			return fmt.Errorf("kpi_snapshot çözülemedi: %w", err)
+		}
+	}
+	if prev == nil && next == nil || prev != nil && next != nil && prev.equal(*next) {
+		return nil
+	}
+
+	if prev != nil {
+		if err := applyKPI(ctx, tx, *prev, -1); err != nil {
+			return err
+		}
+	}
+	var value interface{}
+	if next != nil {
+		if err := applyKPI(ctx, tx, *next, 1); err != nil {
+			return err
+		}
+		b, err := json.Marshal(next)
+		if err != nil {
+			return err
+		}
+		value = string(b)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE calls SET kpi_snapshot = $2 WHERE call_id = $1", callID, value)
+	return err
+}
+
+// applyKPI: Katkıyı sign (+1/-1) ile her çözünürlükteki kovaya ekler. Boşalan kova silinir.
+func applyKPI(ctx context.Context, tx *sql.Tx, c kpiContribution, sign int64) error {
+	for _, gr := range Granularities {
+		g, bucket := gr.Name, c.StartTime.Truncate(gr.Bucket)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO cdr_kpi_rollups AS k (tenant_id, granularity, bucket_start, direction, user_id, attempts, answered,
+				network_ok, inbound, abandoned, ring_calls, ring_seconds, answered_seconds, billable_seconds, quality_calls,
+				low_mos_calls, mos_sum, jitter_ms_sum, packet_loss_pct_sum)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17::numeric, $18::numeric,
+				$19::numeric)
+			ON CONFLICT (tenant_id, granularity, bucket_start, direction, user_id) DO UPDATE SET
+				attempts = k.attempts + EXCLUDED.attempts, answered = k.answered + EXCLUDED.answered,
+				network_ok = k.network_ok + EXCLUDED.network_ok, inbound = k.inbound + EXCLUDED.inbound,
+				abandoned = k.abandoned + EXCLUDED.abandoned, ring_calls = k.ring_calls + EXCLUDED.ring_calls,
+				ring_seconds = k.ring_seconds + EXCLUDED.ring_seconds,
+				answered_seconds = k.answered_seconds + EXCLUDED.answered_seconds,
+				billable_seconds = k.billable_seconds + EXCLUDED.billable_seconds,
+				quality_calls = k.quality_calls + EXCLUDED.quality_calls,
+				low_mos_calls = k.low_mos_calls + EXCLUDED.low_mos_calls, mos_sum = k.mos_sum + EXCLUDED.mos_sum,
+				jitter_ms_sum = k.jitter_ms_sum + EXCLUDED.jitter_ms_sum,
+				packet_loss_pct_sum = k.packet_loss_pct_sum + EXCLUDED.packet_loss_pct_sum, updated_at = NOW()`,
+			c.TenantID, g, bucket, c.Direction, c.UserID, sign, sign*c.Answered, sign*c.NetworkOK, sign*c.Inbound,
+			sign*c.Abandoned, sign*c.RingCalls, sign*c.RingSeconds, sign*c.AnsweredSeconds, sign*c.BillableSeconds,
+			sign*c.QualityCalls, sign*c.LowMOSCalls, float64(sign)*c.MOSSum, float64(sign)*c.JitterMsSum,
+			float64(sign)*c.PacketLossPctSum)
+		if err != nil {
+			return err
+		}
+		if sign < 0 {
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM cdr_kpi_rollups
+				WHERE tenant_id = $1 AND granularity = $2 AND bucket_start = $3 AND direction = $4 AND user_id = $5
+				  AND attempts = 0`, c.TenantID, g, bucket, c.Direction, c.UserID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListKPIs: Filtredeki kovaları, seçilen boyuta göre gruplanmış olarak döner.
+func (r *KPIRepository) ListKPIs(ctx context.Context, f KPIFilter) ([]KPIBucket, error) {
+	dims := "'', ''"
+	switch f.GroupBy {
+	case "direction":
+		dims = "direction, ''"
+	case "user":
+		dims = "'', user_id"
+	}
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT bucket_start, %s, SUM(attempts), SUM(answered), SUM(network_ok), SUM(inbound), SUM(abandoned),
+			SUM(ring_calls), SUM(ring_seconds), SUM(answered_seconds), SUM(billable_seconds), SUM(quality_calls),
+			SUM(low_mos_calls), SUM(mos_sum)::float8, SUM(jitter_ms_sum)::float8, SUM(packet_loss_pct_sum)::float8
+		FROM cdr_kpi_rollups
+		WHERE tenant_id = $1 AND granularity = $2 AND bucket_start >= $3 AND bucket_start < $4
+		  AND ($5 = '' OR direction = $5) AND ($6 = '' OR user_id = $6)
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3`, dims), f.TenantID, f.Granularity, f.From, f.To, f.Direction, f.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []KPIBucket{}
+	for rows.Next() {
+		var b KPIBucket
+		var networkOK, ringCalls, ringSeconds, answeredSeconds, billableSeconds, lowMOSCalls int64
+		var mosSum, jitterSum, lossSum float64
+		if err := rows.Scan(&b.BucketStart, &b.Direction, &b.UserID, &b.Attempts, &b.Answered, &networkOK, &b.Inbound,
+			&b.Abandoned, &ringCalls, &ringSeconds, &answeredSeconds, &billableSeconds, &b.QualityCalls, &lowMOSCalls,
+			&mosSum, &jitterSum, &lossSum); err != nil {
+			return nil, err
+		}
+		b.ASR = ratio(b.Answered, b.Attempts)
+		b.ACD = ratio(answeredSeconds, b.Answered)
+		b.NER = ratio(networkOK, b.Attempts)
+		b.AbandonRate = ratio(b.Abandoned, b.Inbound)
+		b.AvgRing = ratio(ringSeconds, ringCalls)
+		b.BillableMinutes = float64(billableSeconds) / 60
+		b.LowMOSRate = ratio(lowMOSCalls, b.QualityCalls)
+		if b.QualityCalls > 0 {
+			n := float64(b.QualityCalls)
+			b.AvgMOS, b.AvgJitterMs, b.AvgPacketLossPct = mosSum/n, jitterSum/n, lossSum/n
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
@@ -0,0 +1,63 @@
+// sentiric-cdr-service/internal/repository/masking.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/masking"
+)
+
+// ErrMaskingPolicyNotFound: Silinmek istenen politika yok.
+var ErrMaskingPolicyNotFound = errors.New("maskeleme politikası bulunamadı")
+
+type MaskingRepository struct {
+	db *sql.DB
+}
+
+func NewMaskingRepository(db *sql.DB) *MaskingRepository {
+	return &MaskingRepository{db: db}
+}
+
+// ListPolicies: Tüm maskeleme politikaları.
+func (r *MaskingRepository) ListPolicies(ctx context.Context) ([]masking.Policy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, role, mode, keep_last, updated_at FROM cdr_masking_policies
+		ORDER BY tenant_id, role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []masking.Policy{}
+	for rows.Next() {
+		var p masking.Policy
+		if err := rows.Scan(&p.TenantID, &p.Role, &p.Mode, &p.KeepLast, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetPolicy: Tenant ve rol için politikayı ekler veya günceller.
+func (r *MaskingRepository) SetPolicy(ctx context.Context, p masking.Policy) (masking.Policy, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO cdr_masking_policies (tenant_id, role, mode, keep_last) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, role) DO UPDATE SET mode = EXCLUDED.mode, keep_last = EXCLUDED.keep_last, updated_at = NOW()
+		RETURNING updated_at`, p.TenantID, p.Role, p.Mode, p.KeepLast).Scan(&p.UpdatedAt)
+	return p, err
+}
+
+// DeletePolicy: Politikayı kaldırır; tenant ve rol bir üst varsayılana düşer.
+func (r *MaskingRepository) DeletePolicy(ctx context.Context, tenantID, role string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM cdr_masking_policies WHERE tenant_id = $1 AND role = $2", tenantID, role)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMaskingPolicyNotFound
+	}
+	return nil
+}
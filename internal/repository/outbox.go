@@ -0,0 +1,99 @@
+// sentiric-cdr-service/internal/repository/outbox.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// execer: *sql.DB ve *sql.Tx için ortak arayüz; outbox kaydı iş verisiyle aynı transaction'a yazılabilsin.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// enqueueOutboxEvent: Olayı yayınlanmak üzere outbox'a yazar.
+func enqueueOutboxEvent(ctx context.Context, ex execer, eventType, tenantID string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = ex.ExecContext(ctx,
+		"INSERT INTO cdr_outbox_events (event_type, tenant_id, payload) VALUES ($1, $2, $3::jsonb)",
+		eventType, tenantID, string(body))
+	return err
+}
+
+// OutboxEvent: Relay tarafından yayınlanmayı bekleyen olay.
+type OutboxEvent struct {
+	ID        int64
+	EventType string
+	TenantID  string
+	Payload   string
+	CreatedAt time.Time
+}
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Enqueue: Bağımsız (transaction dışı) bir olayı outbox'a yazar.
+func (r *OutboxRepository) Enqueue(ctx context.Context, eventType, tenantID string, payload interface{}) error {
+	return enqueueOutboxEvent(ctx, r.db, eventType, tenantID, payload)
+}
+
+// PublishPending: Yayınlanmamış olayları sırasıyla kilitleyip publish fonksiyonuna verir.
+// SKIP LOCKED sayesinde birden fazla replika aynı olayı iki kez yayınlamaz.
+// Yayınlanamayan olay işaretlenir ve sıradaki turda tekrar denenir; sıra korunması için tur orada kesilir.
+func (r *OutboxRepository) PublishPending(ctx context.Context, limit int, publish func(OutboxEvent) error) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, event_type, tenant_id, payload::text, created_at
+		FROM cdr_outbox_events
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var events []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		if err := rows.Scan(&e.ID, &e.EventType, &e.TenantID, &e.Payload, &e.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, e := range events {
+		if pubErr := publish(e); pubErr != nil {
+			_, err = tx.ExecContext(ctx, "UPDATE cdr_outbox_events SET attempts = attempts + 1, last_error = $1 WHERE id = $2", pubErr.Error(), e.ID)
+			if err != nil {
+				return published, err
+			}
+			break
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE cdr_outbox_events SET published_at = NOW(), attempts = attempts + 1 WHERE id = $1", e.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, tx.Commit()
+}
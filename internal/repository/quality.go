@@ -0,0 +1,52 @@
+// sentiric-cdr-service/internal/repository/quality.go
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// Medya yönleri.
+const (
+	MediaInbound  = "inbound"
+	MediaOutbound = "outbound"
+)
+
+// LowMOSThreshold: En kötü yönün MOS'u bu değerin altındaysa çağrı low_mos olarak işaretlenir.
+const LowMOSThreshold = 3.5
+
+// MediaQuality: Bir yönün RTP/RTCP kalite özeti. RTT ve codec yoksa kayıtlı değer korunur.
+type MediaQuality struct {
+	Direction     string
+	Codec         string
+	MOS           float64
+	JitterMs      float64
+	PacketLossPct float64
+	RTTMs         *float64
+}
+
+// SetMediaQuality: Yönün kalite özetini çağrıya yazar ve low_mos bayrağını iki yönün en kötüsüne göre yeniler.
+// Aynı yön için gelen sonraki özet öncekinin yerine geçer.
+func (r *CallRepository) SetMediaQuality(ctx context.Context, callID string, q MediaQuality) error {
+	var mos, jitter, loss, otherMOS string
+	switch q.Direction {
+	case MediaInbound:
+		mos, jitter, loss, otherMOS = "mos_inbound", "jitter_inbound_ms", "packet_loss_inbound_pct", "mos_outbound"
+	case MediaOutbound:
+		mos, jitter, loss, otherMOS = "mos_outbound", "jitter_outbound_ms", "packet_loss_outbound_pct", "mos_inbound"
+	default:
+		return fmt.Errorf("geçersiz medya yönü: %q", q.Direction)
+	}
+	query := fmt.Sprintf(`
+		UPDATE calls SET
+			%[1]s = $2::numeric,
+			%[2]s = $3::numeric,
+			%[3]s = $4::numeric,
+			rtt_ms = COALESCE($5::numeric, rtt_ms),
+			codec = COALESCE(NULLIF($6, ''), codec),
+			low_mos = LEAST($2::numeric, %[4]s) < $7::numeric,
+			updated_at = NOW()
+		WHERE call_id = $1`, mos, jitter, loss, otherMOS)
+	return r.execExpectingCall(ctx, callID, query, callID, q.MOS, q.JitterMs, q.PacketLossPct, q.RTTMs, q.Codec,
+		LowMOSThreshold)
+}
@@ -0,0 +1,142 @@
+// sentiric-cdr-service/internal/repository/rating.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+)
+
+// GetRoundingPolicy: Tenant'ın yuvarlama politikasını okur; kayıt yoksa varsayılanı döner.
+func (r *CallRepository) GetRoundingPolicy(ctx context.Context, tenantID string) (billing.RoundingPolicy, error) {
+	var p billing.RoundingPolicy
+	var mode string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT rounding_mode, increment_seconds, minimum_seconds
+		FROM tenant_rating_policies WHERE tenant_id = $1`, tenantID).
+		Scan(&mode, &p.IncrementSeconds, &p.MinimumSeconds)
+	if err == sql.ErrNoRows {
+		return billing.DefaultRoundingPolicy(), nil
+	}
+	if err != nil {
+		return p, err
+	}
+	p.Mode = billing.RoundingMode(mode)
+	return p, p.Validate()
+}
+
+// ErrRoundingPolicyNotFound: Tenant için tanımlı yuvarlama politikası yok.
+var ErrRoundingPolicyNotFound = errors.New("yuvarlama politikası bulunamadı")
+
+// TenantRoundingPolicy: Tenant'a tanımlanmış yuvarlama politikası.
+type TenantRoundingPolicy struct {
+	TenantID string `json:"tenant_id"`
+	billing.RoundingPolicy
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListRoundingPolicies: Tanımlı tenant politikaları; listede olmayan tenant'lara varsayılan uygulanır.
+func (r *CallRepository) ListRoundingPolicies(ctx context.Context) ([]TenantRoundingPolicy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tenant_id, rounding_mode, increment_seconds, minimum_seconds, updated_at
+		FROM tenant_rating_policies ORDER BY tenant_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []TenantRoundingPolicy{}
+	for rows.Next() {
+		var p TenantRoundingPolicy
+		var mode string
+		if err := rows.Scan(&p.TenantID, &mode, &p.IncrementSeconds, &p.MinimumSeconds, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Mode = billing.RoundingMode(mode)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetRoundingPolicy: Tenant'ın politikasını ekler veya günceller. Yeni politika bundan sonra derecelendirilen
+// çağrılara (ve rerate'e) uygulanır; mevcut usage kayıtları değişmez.
+func (r *CallRepository) SetRoundingPolicy(ctx context.Context, tenantID string, p billing.RoundingPolicy) (TenantRoundingPolicy, error) {
+	out := TenantRoundingPolicy{TenantID: tenantID, RoundingPolicy: p}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO tenant_rating_policies (tenant_id, rounding_mode, increment_seconds, minimum_seconds)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			rounding_mode = EXCLUDED.rounding_mode, increment_seconds = EXCLUDED.increment_seconds,
+			minimum_seconds = EXCLUDED.minimum_seconds, updated_at = NOW()
+		RETURNING updated_at`, tenantID, string(p.Mode), p.IncrementSeconds, p.MinimumSeconds).Scan(&out.UpdatedAt)
+	return out, err
+}
+
+// DeleteRoundingPolicy: Tenant'ın politikasını kaldırır; tenant varsayılana (saniyeye kesme) düşer.
+func (r *CallRepository) DeleteRoundingPolicy(ctx context.Context, tenantID string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM tenant_rating_policies WHERE tenant_id = $1", tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRoundingPolicyNotFound
+	}
+	return nil
+}
+
+// GetUnitPrice: Kaynağın verilen andaki birim fiyatını bulur.
+// Öncelik: tenant'a özel fiyat, platform geneli fiyat, gömülü varsayılan fiyat.
+func (r *CallRepository) GetUnitPrice(ctx context.Context, tenantID, resourceType string, at time.Time) (float64, string, error) {
+	var price float64
+	var version string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT unit_price::float8, rate_version FROM rate_cards
+		WHERE resource_type = $1
+		  AND (tenant_id = $2 OR tenant_id IS NULL)
+		  AND valid_from <= $3 AND valid_to > $3
+		ORDER BY tenant_id NULLS LAST, valid_from DESC
+		LIMIT 1`, resourceType, tenantID, at).
+		Scan(&price, &version)
+	if err == sql.ErrNoRows {
+		return billing.DefaultUnitPrice(resourceType), billing.DefaultRateVersion, nil
+	}
+	return price, version, err
+}
+
+// CallUsageLine: Bir çağrının maliyet kırılımındaki tek satır.
+type CallUsageLine struct {
+	ServiceName  string    `json:"service_name"`
+	ResourceType string    `json:"resource_type"`
+	Quantity     float64   `json:"quantity"`
+	UnitPrice    float64   `json:"unit_price"`
+	Cost         float64   `json:"cost"`
+	RateVersion  string    `json:"rate_version,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ListCallUsage: Çağrının kaynak bazlı maliyet kırılımını döner.
+func (r *CallRepository) ListCallUsage(ctx context.Context, tenantID, callID string) ([]CallUsageLine, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT service_name, resource_type, quantity::float8, COALESCE(unit_price, 0)::float8,
+			calculated_cost::float8, COALESCE(rate_version, ''), created_at
+		FROM usage_records
+		WHERE tenant_id = $1 AND call_id = $2
+		ORDER BY created_at, resource_type`, tenantID, callID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []CallUsageLine{}
+	for rows.Next() {
+		var u CallUsageLine
+		if err := rows.Scan(&u.ServiceName, &u.ResourceType, &u.Quantity, &u.UnitPrice, &u.Cost, &u.RateVersion, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		lines = append(lines, u)
+	}
+	return lines, rows.Err()
+}
@@ -0,0 +1,362 @@
+// sentiric-cdr-service/internal/repository/recording.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/recording"
+)
+
+// Recording: Çağrının bir kayıt dosyası (segment ve kanal).
+type Recording struct {
+	ID           int64      `json:"id"`
+	CallID       string     `json:"call_id"`
+	TenantID     string     `json:"tenant_id,omitempty"`
+	Segment      int        `json:"segment"`
+	Channel      string     `json:"channel"`
+	URI          string     `json:"uri"`
+	PublicURL    string     `json:"public_url,omitempty"`
+	Format       string     `json:"format,omitempty"`
+	DurationMs   *int64     `json:"duration_ms,omitempty"`
+	SizeBytes    *int64     `json:"size_bytes,omitempty"`
+	Checksum     string     `json:"checksum,omitempty"`
+	StorageClass string     `json:"storage_class"`
+	Status       string     `json:"status"`
+	ReceivedAt   time.Time  `json:"received_at"`
+	AttachedAt   *time.Time `json:"attached_at,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	DeleteReason string     `json:"delete_reason,omitempty"`
+}
+
+// RecordingOutcome: Gelen kaydın akıbeti.
+type RecordingOutcome int
+
+const (
+	// RecordingAttached: Kayıt çağrıya bağlandı.
+	RecordingAttached RecordingOutcome = iota
+	// RecordingPending: Çağrı henüz yok; kayıt çağrı oluşana kadar bekletiliyor.
+	RecordingPending
+	// RecordingDiscarded: Çağrı silme talebiyle anonimleştirilmiş veya kayıt daha önce silinmiş; bağlanmadı.
+	RecordingDiscarded
+)
+
+// recordingNotice: Depolama katmanına tenant ve parti başına yayınlanan kayıt olayının gövdesi.
+type recordingNotice struct {
+	TenantID   string                 `json:"tenant_id"`
+	Reason     string                 `json:"reason"`
+	Recordings []recordingNoticeEntry `json:"recordings"`
+}
+
+type recordingNoticeEntry struct {
+	RecordingID int64  `json:"recording_id"`
+	CallID      string `json:"call_id"`
+	Segment     int    `json:"segment"`
+	Channel     string `json:"channel"`
+	URI         string `json:"uri"`
+}
+
+const recordingColumns = `
+	id, call_id, COALESCE(tenant_id, ''), segment, channel, uri, COALESCE(public_url, ''), COALESCE(format, ''),
+	duration_ms, size_bytes, COALESCE(checksum, ''), storage_class, status, received_at, attached_at, deleted_at,
+	COALESCE(delete_reason, '')`
+
+type RecordingRepository struct {
+	db *sql.DB
+}
+
+func NewRecordingRepository(db *sql.DB) *RecordingRepository {
+	return &RecordingRepository{db: db}
+}
+
+// lockCallRecordings: Kayıt ekleme ile bekleyen kayıtların bağlanmasını aynı çağrı için sıraya sokar; çağrıdan
+// hemen önce gelen kayıt beklemede unutulmaz.
+func lockCallRecordings(ctx context.Context, tx *sql.Tx, callID string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('call_recordings:' || $1))", callID)
+	return err
+}
+
+// AddRecording: Kaydı çağrıya bağlar; çağrı yoksa bekletir. Aynı segment ve kanal için gelen yeni kayıt öncekinin
+// yerine geçer; silinmiş kayıt geri getirilmez. Bağlanan kaydın tenant'ı rec.TenantID'ye yazılır.
+func (r *RecordingRepository) AddRecording(ctx context.Context, rec *Recording) (RecordingOutcome, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockCallRecordings(ctx, tx, rec.CallID); err != nil {
+		return 0, err
+	}
+	var tenantID string
+	var startTime time.Time
+	var erased bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT tenant_id, start_time, erased_at IS NOT NULL FROM calls WHERE call_id = $1
+		ORDER BY start_time DESC LIMIT 1`, rec.CallID).Scan(&tenantID, &startTime, &erased)
+	outcome := RecordingAttached
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		outcome, tenantID, startTime = RecordingPending, "", time.Now().UTC()
+	case err != nil:
+		return 0, err
+	case erased:
+		return RecordingDiscarded, nil
+	}
+
+	status := recording.StatusActive
+	if outcome == RecordingPending {
+		status = recording.StatusPending
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO call_recordings AS r (call_id, tenant_id, segment, channel, uri, public_url, format, duration_ms,
+			size_bytes, checksum, storage_class, status, retain_from, attached_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, NULLIF($10, ''), $11, $12, $13,
+			CASE WHEN $12 = 'ACTIVE' THEN NOW() END)
+		ON CONFLICT (call_id, segment, channel) DO UPDATE SET
+			uri = EXCLUDED.uri, public_url = EXCLUDED.public_url, format = EXCLUDED.format,
+			duration_ms = EXCLUDED.duration_ms, size_bytes = EXCLUDED.size_bytes, checksum = EXCLUDED.checksum,
+			storage_class = EXCLUDED.storage_class, received_at = NOW(), status = EXCLUDED.status,
+			tenant_id = EXCLUDED.tenant_id, retain_from = EXCLUDED.retain_from,
+			attached_at = COALESCE(r.attached_at, EXCLUDED.attached_at)
+		WHERE r.status <> 'DELETED'
+		RETURNING id, status, received_at`,
+		rec.CallID, tenantID, rec.Segment, rec.Channel, rec.URI, rec.PublicURL, rec.Format, rec.DurationMs,
+		rec.SizeBytes, rec.Checksum, rec.StorageClass, status, startTime).Scan(&rec.ID, &rec.Status, &rec.ReceivedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RecordingDiscarded, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	rec.TenantID = tenantID
+	if outcome == RecordingAttached {
+		if err := syncRecordingURL(ctx, tx, rec.CallID); err != nil {
+			return 0, err
+		}
+	}
+	return outcome, tx.Commit()
+}
+
+// AttachPending: Çağrıdan önce gelip bekleyen kayıtları çağrıya bağlar ve bağlanan kayıtları döner.
+func (r *RecordingRepository) AttachPending(ctx context.Context, callID string) ([]Recording, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := lockCallRecordings(ctx, tx, callID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE call_recordings r SET
+			status = 'ACTIVE', tenant_id = c.call_tenant_id, retain_from = c.call_start_time, attached_at = NOW()
+		FROM (
+			SELECT tenant_id AS call_tenant_id, start_time AS call_start_time FROM calls
+			WHERE call_id = $1 AND erased_at IS NULL
+			ORDER BY start_time DESC LIMIT 1
+		) c
+		WHERE r.call_id = $1 AND r.status = 'PENDING'
+		RETURNING `+recordingColumns, callID)
+	if err != nil {
+		return nil, err
+	}
+	attached, err := scanRecordings(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(attached) == 0 {
+		return nil, nil
+	}
+	if err := syncRecordingURL(ctx, tx, callID); err != nil {
+		return nil, err
+	}
+	sort.Slice(attached, func(i, j int) bool {
+		if attached[i].Segment != attached[j].Segment {
+			return attached[i].Segment < attached[j].Segment
+		}
+		return attached[i].Channel < attached[j].Channel
+	})
+	return attached, tx.Commit()
+}
+
+// syncRecordingURL: calls.recording_url'ü çağrının birincil kaydına (karışık kanal, en küçük segment) eşitler.
+func syncRecordingURL(ctx context.Context, tx *sql.Tx, callID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE calls SET recording_url = (
+			SELECT uri FROM call_recordings
+			WHERE call_id = $1 AND status = 'ACTIVE'
+			ORDER BY channel = 'mixed' DESC, segment, channel
+			LIMIT 1
+		), updated_at = NOW()
+		WHERE call_id = $1 AND erased_at IS NULL`, callID)
+	return err
+}
+
+// ListRecordings: Tenant'ın çağrısına bağlı kayıtlar, segment ve kanal sırasıyla. Silinmiş kayıtlar da döner.
+func (r *RecordingRepository) ListRecordings(ctx context.Context, tenantID, callID string) ([]Recording, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recordingColumns+`
+		FROM call_recordings
+		WHERE tenant_id = $1 AND call_id = $2
+		ORDER BY segment, channel`, tenantID, callID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRecordings(rows)
+}
+
+// scanRecordings: recordingColumns ile seçilen satırları okur ve kapatır.
+func scanRecordings(rows *sql.Rows) ([]Recording, error) {
+	defer rows.Close()
+
+	out := []Recording{}
+	for rows.Next() {
+		var rec Recording
+		var duration, size sql.NullInt64
+		var attached, deleted sql.NullTime
+		if err := rows.Scan(&rec.ID, &rec.CallID, &rec.TenantID, &rec.Segment, &rec.Channel, &rec.URI, &rec.PublicURL,
+			&rec.Format, &duration, &size, &rec.Checksum, &rec.StorageClass, &rec.Status, &rec.ReceivedAt, &attached,
+			&deleted, &rec.DeleteReason); err != nil {
+			return nil, err
+		}
+		if duration.Valid {
+			rec.DurationMs = &duration.Int64
+		}
+		if size.Valid {
+			rec.SizeBytes = &size.Int64
+		}
+		rec.AttachedAt = nullTimePtr(attached)
+		rec.DeletedAt = nullTimePtr(deleted)
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// expiredRecordings: Tenant politikasına göre saklama süresi dolmuş kayıtlar. Bekleyen kayıtların tenant'ı
+// bilinmediğinden varsayılan ('*') politikaya girer. Arşivde zaten arşiv sınıfındaki kayıtlar seçilmez.
+var expiredRecordings = `
+	SELECT r.id FROM call_recordings r
+	WHERE ` + tenantPolicyMatch("r.tenant_id") + ` AND r.retain_from < $2 AND r.status <> 'DELETED'
+	  AND ($4::boolean = false OR r.storage_class <> 'ARCHIVE')
+	  AND ` + notHeld("r.tenant_id", "r.retain_from") + `
+	ORDER BY r.id
+	LIMIT $3`
+
+// CountExpiredRecordings: Kuru çalışmada saklama süresi dolan kayıt sayısı.
+func (r *RecordingRepository) CountExpiredRecordings(ctx context.Context, tenantID string, cutoff time.Time, archive bool) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+expiredRecordings+") v", tenantID, cutoff, nil,
+		archive).Scan(&n)
+	return n, err
+}
+
+// ExpireRecordings: Saklama süresi dolan en fazla limit kaydı işler ve depolama katmanını aynı transaction'da
+// outbox üzerinden bilgilendirir. archive ise kayıt arşiv sınıfına alınır (cdr.recording.retention), değilse
+// DELETED olur (cdr.recording.deleted). İşlenen kayıt sayısını döner.
+func (r *RecordingRepository) ExpireRecordings(ctx context.Context, tenantID string, cutoff time.Time, limit int, archive bool) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	set := "status = 'DELETED', deleted_at = NOW(), delete_reason = '" + recording.ReasonRetention + "'"
+	eventType := recording.EventDeleted
+	if archive {
+		set = "storage_class = '" + recording.StorageArchive + "'"
+		eventType = recording.EventRetention
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		UPDATE call_recordings r SET %s
+		WHERE r.id IN (%s)
+		RETURNING r.id, COALESCE(r.tenant_id, ''), r.call_id, r.segment, r.channel, r.uri`, set, expiredRecordings),
+		tenantID, cutoff, limit, archive)
+	if err != nil {
+		return 0, err
+	}
+	notices, n, err := collectRecordingNotices(rows, recording.ReasonRetention)
+	if err != nil {
+		return 0, err
+	}
+	if err := enqueueRecordingNotices(ctx, tx, eventType, notices); err != nil {
+		return 0, err
+	}
+	// Silinen birincil kaydın yerine varsa diğer aktif kayıt geçer.
+	if !archive {
+		for _, notice := range notices {
+			for _, e := range notice.Recordings {
+				if err := syncRecordingURL(ctx, tx, e.CallID); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	return n, tx.Commit()
+}
+
+// eraseRecordings: Silme talebine giren çağrıların kayıtlarını DELETED yapar ve cdr.recording.deleted yayınlar.
+// Çağrı ve tenant başına silinen kayıt URI'lerini döner.
+func eraseRecordings(ctx context.Context, tx *sql.Tx, callIDs string) (map[string][]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE call_recordings SET status = 'DELETED', deleted_at = NOW(), delete_reason = $2
+		WHERE call_id = ANY(string_to_array($1, ',')) AND status <> 'DELETED'
+		RETURNING id, COALESCE(tenant_id, ''), call_id, segment, channel, uri`, callIDs, recording.ReasonErasure)
+	if err != nil {
+		return nil, err
+	}
+	notices, _, err := collectRecordingNotices(rows, recording.ReasonErasure)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueRecordingNotices(ctx, tx, recording.EventDeleted, notices); err != nil {
+		return nil, err
+	}
+	uris := map[string][]string{}
+	for t, notice := range notices {
+		for _, e := range notice.Recordings {
+			uris[t] = append(uris[t], e.URI)
+		}
+	}
+	return uris, nil
+}
+
+func collectRecordingNotices(rows *sql.Rows, reason string) (map[string]*recordingNotice, int64, error) {
+	defer rows.Close()
+	notices := map[string]*recordingNotice{}
+	var n int64
+	for rows.Next() {
+		var tenantID string
+		var e recordingNoticeEntry
+		if err := rows.Scan(&e.RecordingID, &tenantID, &e.CallID, &e.Segment, &e.Channel, &e.URI); err != nil {
+			return nil, 0, err
+		}
+		notice := notices[tenantID]
+		if notice == nil {
+			notice = &recordingNotice{TenantID: tenantID, Reason: reason}
+			notices[tenantID] = notice
+		}
+		notice.Recordings = append(notice.Recordings, e)
+		n++
+	}
+	return notices, n, rows.Err()
+}
+
+func enqueueRecordingNotices(ctx context.Context, tx *sql.Tx, eventType string, notices map[string]*recordingNotice) error {
+	tenants := make([]string, 0, len(notices))
+	for t := range notices {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+	for _, t := range tenants {
+		if err := enqueueOutboxEvent(ctx, tx, eventType, t, notices[t]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
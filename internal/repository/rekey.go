@@ -0,0 +1,369 @@
+// sentiric-cdr-service/internal/repository/rekey.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+)
+
+// rekeyLockKey: Aynı anda tek bir yeniden şifreleme çalışanına izin veren advisory lock anahtarı.
+const rekeyLockKey = 72054
+
+// ErrRekeyBusy: Başka bir kopya yeniden şifreleme işini yürütüyor.
+var ErrRekeyBusy = errors.New("başka bir yeniden şifreleme çalışanı aktif")
+
+// RekeyJob: Kapsamdaki satırları aktif veri anahtarıyla yeniden şifreleyen iş. Düz metin kalmış satırlar da
+// şifrelenir. Çağrılar call_id sırasıyla gezilir; Cursor kaldığı yeri tutar.
+type RekeyJob struct {
+	ID                int64      `json:"id"`
+	TenantID          string     `json:"tenant_id,omitempty"`
+	Status            string     `json:"status"`
+	Cursor            string     `json:"-"`
+	PassStartedAt     *time.Time `json:"pass_started_at,omitempty"`
+	CallsReencrypted  int64      `json:"calls_reencrypted"`
+	EventsReencrypted int64      `json:"events_reencrypted"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	FinishedAt        *time.Time `json:"finished_at,omitempty"`
+}
+
+const rekeyColumns = `
+	id, COALESCE(tenant_id, ''), status, last_call_id, pass_started_at, calls_reencrypted, events_reencrypted,
+	COALESCE(error, ''), created_at, started_at, finished_at`
+
+func scanRekeyJob(row rowScanner) (RekeyJob, error) {
+	var j RekeyJob
+	err := row.Scan(&j.ID, &j.TenantID, &j.Status, &j.Cursor, &j.PassStartedAt, &j.CallsReencrypted,
+		&j.EventsReencrypted, &j.Error, &j.CreatedAt, &j.StartedAt, &j.FinishedAt)
+	return j, err
+}
+
+func createRekeyJob(ctx context.Context, tx *sql.Tx, tenantID string) (RekeyJob, error) {
+	return scanRekeyJob(tx.QueryRowContext(ctx,
+		"INSERT INTO cdr_rekey_jobs (tenant_id) VALUES (NULLIF($1, '')) RETURNING"+rekeyColumns, tenantID))
+}
+
+type RekeyRepository struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+func NewRekeyRepository(db *sql.DB, keys *Keyring) *RekeyRepository {
+	return &RekeyRepository{db: db, keys: keys}
+}
+
+// Lock: Çalışan için oturum düzeyinde advisory lock alır. Kilit başka kopyadaysa ErrRekeyBusy döner.
+func (r *RekeyRepository) Lock(ctx context.Context) (func(), error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", rekeyLockKey).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, ErrRekeyBusy
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", rekeyLockKey)
+		conn.Close()
+	}, nil
+}
+
+// CreateJob: Anahtar değiştirmeden yeniden şifreleme işi açar (örn: şifreleme açılmadan önce yazılmış satırlar için).
+func (r *RekeyRepository) CreateJob(ctx context.Context, tenantID string) (RekeyJob, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return RekeyJob{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	job, err := createRekeyJob(ctx, tx, tenantID)
+	if err != nil {
+		return job, err
+	}
+	return job, tx.Commit()
+}
+
+// ListJobs: İşleri en yeniden eskiye listeler.
+func (r *RekeyRepository) ListJobs(ctx context.Context, limit int) ([]RekeyJob, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT"+rekeyColumns+" FROM cdr_rekey_jobs ORDER BY id DESC LIMIT NULLIF($1, 0)", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []RekeyJob{}
+	for rows.Next() {
+		j, err := scanRekeyJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// NextJob: Bitmemiş en eski işi RUNNING olarak döner; iş yoksa ok false.
+func (r *RekeyRepository) NextJob(ctx context.Context) (RekeyJob, bool, error) {
+	j, err := scanRekeyJob(r.db.QueryRowContext(ctx, `
+		UPDATE cdr_rekey_jobs SET status = 'RUNNING', started_at = COALESCE(started_at, NOW()),
+			pass_started_at = COALESCE(pass_started_at, NOW())
+		WHERE id = (SELECT id FROM cdr_rekey_jobs WHERE status IN ('PENDING', 'RUNNING') ORDER BY id LIMIT 1)
+		RETURNING`+rekeyColumns))
+	if errors.Is(err, sql.ErrNoRows) {
+		return j, false, nil
+	}
+	if err == nil && r.keys != nil {
+		r.keys.refresh()
+	}
+	return j, err == nil, err
+}
+
+// saveProgress: İmleci ve sayaçları yazar; çalışan yeniden başlarsa kaldığı yerden devam eder.
+func (r *RekeyRepository) saveProgress(ctx context.Context, j RekeyJob) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE cdr_rekey_jobs SET last_call_id = $1, pass_started_at = $2, calls_reencrypted = $3, events_reencrypted = $4
+		WHERE id = $5`,
+		j.Cursor, j.PassStartedAt, j.CallsReencrypted, j.EventsReencrypted, j.ID)
+	return err
+}
+
+// RestartPass: İmleci başa alır. Rotasyondan sonra ActiveKeyTTL boyunca diğer kopyalar eski anahtarla yazmış
+// olabileceğinden, bu süre dolmadan başlamış tur işi bitirmez.
+func (r *RekeyRepository) RestartPass(ctx context.Context, j *RekeyJob) error {
+	now := time.Now().UTC()
+	j.Cursor, j.PassStartedAt = "", &now
+	return r.saveProgress(ctx, *j)
+}
+
+// FinishJob: İşi tamamlar ve kapsamdaki RETIRING anahtarları RETIRED yapar. Arşivdeki (cdr_archive) satırlar
+// yeniden şifrelenmediği için anahtarlar silinmez.
+func (r *RekeyRepository) FinishJob(ctx context.Context, j RekeyJob) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE cdr_data_keys SET state = 'RETIRED', retired_at = NOW()
+		WHERE state = 'RETIRING' AND ($1 = '' OR tenant_id = $1) AND created_at < $2`,
+		j.TenantID, j.CreatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE cdr_rekey_jobs SET status = 'COMPLETED', last_call_id = '', calls_reencrypted = $1, events_reencrypted = $2,
+			finished_at = NOW()
+		WHERE id = $3`, j.CallsReencrypted, j.EventsReencrypted, j.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RekeyRepository) FailJob(ctx context.Context, id int64, cause error) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE cdr_rekey_jobs SET status = 'FAILED', error = $1, finished_at = NOW() WHERE id = $2", cause.Error(), id)
+	return err
+}
+
+// rekeyCall: Yeniden şifrelenecek çağrı satırı.
+type rekeyCall struct {
+	callID, tenantID, caller, callee, summary string
+}
+
+// ReencryptBatch: İmleçten sonraki en fazla limit çağrıyı ve olaylarını aktif anahtarla yeniden şifreler.
+// Tur bittiyse (imleçten sonra çağrı kalmadıysa) true döner. Güncellemeler, okunduktan sonra değişmiş satırı ezmez.
+func (r *RekeyRepository) ReencryptBatch(ctx context.Context, j *RekeyJob, limit int) (bool, error) {
+	if r.keys == nil {
+		return false, errors.New("alan şifrelemesi yapılandırılmamış")
+	}
+	active, err := r.activeKeyIDs(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT call_id, tenant_id, COALESCE(caller_number, ''), COALESCE(callee_number, ''), COALESCE(ai_summary, '')
+		FROM calls
+		WHERE tenant_id IS NOT NULL AND tenant_id <> '' AND ($1 = '' OR tenant_id = $1) AND call_id > $2
+		ORDER BY call_id
+		LIMIT $3`, j.TenantID, j.Cursor, limit)
+	if err != nil {
+		return false, err
+	}
+	var calls []rekeyCall
+	for rows.Next() {
+		var c rekeyCall
+		if err := rows.Scan(&c.callID, &c.tenantID, &c.caller, &c.callee, &c.summary); err != nil {
+			rows.Close()
+			return false, err
+		}
+		calls = append(calls, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if len(calls) == 0 {
+		return true, nil
+	}
+
+	tenants := map[string]string{}
+	ids := make([]string, 0, len(calls))
+	for _, c := range calls {
+		tenants[c.callID] = c.tenantID
+		ids = append(ids, c.callID)
+		if !needsRekey(c.caller, active) && !needsRekey(c.callee, active) && !needsRekey(c.summary, active) {
+			continue
+		}
+		ok, err := r.reencryptCall(ctx, c)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			j.CallsReencrypted++
+		}
+	}
+
+	n, err := r.reencryptEvents(ctx, ids, tenants, active)
+	if err != nil {
+		return false, err
+	}
+	j.EventsReencrypted += n
+	j.Cursor = calls[len(calls)-1].callID
+	return false, r.saveProgress(ctx, *j)
+}
+
+func (r *RekeyRepository) activeKeyIDs(ctx context.Context) (map[int64]bool, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM cdr_data_keys WHERE state = 'ACTIVE'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
+
+// needsRekey: Değer düz metinse veya aktif olmayan anahtarla şifrelenmişse true.
+func needsRekey(value string, active map[int64]bool) bool {
+	if value == "" {
+		return false
+	}
+	if !fieldcrypt.IsSealed(value) {
+		return true
+	}
+	id, err := fieldcrypt.KeyID(value)
+	return err == nil && !active[id]
+}
+
+func (r *RekeyRepository) reencryptCall(ctx context.Context, c rekeyCall) (bool, error) {
+	var sealed, index [2]string
+	for i, f := range []struct{ field, value string }{
+		{fieldcrypt.FieldCallerNumber, c.caller}, {fieldcrypt.FieldCalleeNumber, c.callee},
+	} {
+		plain, err := r.keys.openField(ctx, f.field, c.callID, f.value)
+		if err != nil {
+			return false, err
+		}
+		if sealed[i], err = r.keys.sealField(ctx, c.tenantID, f.field, c.callID, plain); err != nil {
+			return false, err
+		}
+		if index[i], err = r.keys.numberIndex(ctx, c.tenantID, plain); err != nil {
+			return false, err
+		}
+	}
+	summary, err := r.keys.openField(ctx, fieldcrypt.FieldSummary, c.callID, c.summary)
+	if err != nil {
+		return false, err
+	}
+	if summary, err = r.keys.sealField(ctx, c.tenantID, fieldcrypt.FieldSummary, c.callID, summary); err != nil {
+		return false, err
+	}
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE calls SET caller_number = NULLIF($1, ''), callee_number = NULLIF($2, ''),
+			caller_number_bidx = NULLIF($3, ''), callee_number_bidx = NULLIF($4, ''), ai_summary = NULLIF($8, '')
+		WHERE call_id = $5 AND COALESCE(caller_number, '') = $6 AND COALESCE(callee_number, '') = $7
+		  AND COALESCE(ai_summary, '') = $9`,
+		sealed[0], sealed[1], index[0], index[1], c.callID, c.caller, c.callee, summary, c.summary)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *RekeyRepository) reencryptEvents(ctx context.Context, ids []string, tenants map[string]string, active map[int64]bool) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT call_id, event_type, event_timestamp, payload::text FROM call_events
+		WHERE call_id = ANY(string_to_array($1, ',')) AND payload IS NOT NULL`, strings.Join(ids, ","))
+	if err != nil {
+		return 0, err
+	}
+	type event struct {
+		callID, eventType, payload string
+		ts                         *time.Time
+	}
+	var events []event
+	for rows.Next() {
+		var e event
+		if err := rows.Scan(&e.callID, &e.eventType, &e.ts, &e.payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if e.ts != nil {
+			events = append(events, e)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, e := range events {
+		plain, err := r.keys.openPayload(ctx, e.callID, e.payload)
+		if err != nil {
+			return count, err
+		}
+		if plain != e.payload && !needsRekey(sealedPayloadValue(e.payload), active) {
+			continue
+		}
+		sealed, err := r.keys.sealPayload(ctx, tenants[e.callID], e.callID, plain)
+		if err != nil {
+			return count, err
+		}
+		res, err := r.db.ExecContext(ctx, `
+			UPDATE call_events SET payload = $1::jsonb
+			WHERE call_id = $2 AND event_type = $3 AND event_timestamp = $4 AND payload = $5::jsonb`,
+			sealed, e.callID, e.eventType, *e.ts, e.payload)
+		if err != nil {
+			return count, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// sealedPayloadValue: jsonb metnindeki şifreli değerin kendisi (tırnaksız).
+func sealedPayloadValue(payload string) string {
+	return strings.Trim(payload, `"`)
+}
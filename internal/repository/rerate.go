@@ -0,0 +1,148 @@
+// sentiric-cdr-service/internal/repository/rerate.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RerateCall: Yeniden derecelendirilecek tamamlanmış çağrı.
+type RerateCall struct {
+	CallID    string
+	TenantID  string
+	StartTime time.Time
+}
+
+// ListCallsForRerate: Tarih aralığındaki tamamlanmış çağrıları tenant sırasıyla döner. tenantID boşsa tüm tenant'lar.
+func (r *CallRepository) ListCallsForRerate(ctx context.Context, tenantID string, from, to time.Time) ([]RerateCall, error) {
+	query := `
+		SELECT call_id, tenant_id, start_time FROM calls
+		WHERE start_time >= $1 AND start_time < $2 AND status = 'COMPLETED'
+		  AND ($3 = '' OR tenant_id = $3)
+		ORDER BY tenant_id, start_time, call_id`
+	rows, err := r.db.QueryContext(ctx, query, from, to, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []RerateCall
+	for rows.Next() {
+		var c RerateCall
+		if err := rows.Scan(&c.CallID, &c.TenantID, &c.StartTime); err != nil {
+			return nil, err
+		}
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
+
+// ActiveUsage: Geçerli (ters kaydı yapılmamış) bir usage satırı.
+type ActiveUsage struct {
+	ID string
+	UsageRecord
+}
+
+// ListActiveUsage: Çağrının geçerli usage satırlarını döner.
+func (r *CallRepository) ListActiveUsage(ctx context.Context, callID string) ([]ActiveUsage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id::text, tenant_id, call_id, service_name, resource_type, quantity::float8,
+			COALESCE(unit_price, 0)::float8, calculated_cost::float8, COALESCE(rate_version, ''),
+			raw_duration_ms, COALESCE(metadata::text, '')
+		FROM usage_records
+		WHERE call_id = $1 AND superseded_at IS NULL AND reversal_of IS NULL
+		ORDER BY id`, callID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []ActiveUsage
+	for rows.Next() {
+		var u ActiveUsage
+		var metadata string
+		var rawMs sql.NullInt64
+		err := rows.Scan(&u.ID, &u.TenantID, &u.CallID, &u.ServiceName, &u.ResourceType, &u.Quantity,
+			&u.UnitPrice, &u.Cost, &u.RateVersion, &rawMs, &metadata)
+		if err != nil {
+			return nil, err
+		}
+		if rawMs.Valid {
+			u.RawDurationMs = rawMs.Int64
+		}
+		if metadata != "" {
+			u.Metadata = metadata
+		}
+		usages = append(usages, u)
+	}
+	return usages, rows.Err()
+}
+
+// GetVersionUnitPrice: Belirli bir fiyat listesi sürümündeki birim fiyatı, geçerlilik tarihine bakmadan okur.
+// Sürümde kaynak için fiyat yoksa found=false döner.
+func (r *CallRepository) GetVersionUnitPrice(ctx context.Context, rateVersion, tenantID, resourceType string) (float64, bool, error) {
+	var price float64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT unit_price::float8 FROM rate_cards
+		WHERE rate_version = $1 AND resource_type = $2 AND (tenant_id = $3 OR tenant_id IS NULL)
+		ORDER BY tenant_id NULLS LAST, valid_from DESC
+		LIMIT 1`, rateVersion, resourceType, tenantID).Scan(&price)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return price, err == nil, err
+}
+
+// SupersedeUsage: Orijinal satırları silmeden ters kaydını ve yerine geçen satırları yazar,
+// ardından çağrının toplam maliyetini aynı transaction içinde yeniden hesaplar.
+func (r *CallRepository) SupersedeUsage(ctx context.Context, callID, batchID string, originals []ActiveUsage, replacements []UsageRecord) error {
+	if len(originals) != len(replacements) {
+		return fmt.Errorf("orijinal (%d) ve yeni (%d) satır sayıları eşleşmiyor", len(originals), len(replacements))
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, orig := range originals {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE usage_records SET superseded_at = NOW() WHERE id::text = $1 AND superseded_at IS NULL", orig.ID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("usage satırı %s eşzamanlı olarak değiştirildi", orig.ID)
+		}
+
+		reversal := orig.UsageRecord
+		reversal.Quantity = -orig.Quantity
+		reversal.Cost = -orig.Cost
+		reversal.SourceEventID = nil
+		reversal.ReversalOf = orig.ID
+		reversal.RerateBatchID = batchID
+		if _, err := insertUsage(ctx, tx, reversal); err != nil {
+			return err
+		}
+
+		replacement := replacements[i]
+		replacement.SourceEventID = nil
+		replacement.RerateBatchID = batchID
+		if _, err := insertUsage(ctx, tx, replacement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE calls SET
+			total_cost = (SELECT COALESCE(SUM(calculated_cost), 0) FROM usage_records WHERE call_id = $1),
+			updated_at = NOW()
+		WHERE call_id = $1`, callID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,669 @@
+// sentiric-cdr-service/internal/repository/retention.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/chain"
+)
+
+// Saklama aksiyonları.
+const (
+	RetentionArchive = "ARCHIVE"
+	RetentionDelete  = "DELETE"
+)
+
+// DefaultPolicyTenant: Kendi politikası olmayan tenant'lara uygulanan platform varsayılanı.
+const DefaultPolicyTenant = "*"
+
+// PartitionKeys: Aylık partition'lanabilen tablolar ve partition anahtarları.
+var PartitionKeys = map[string]string{
+	"calls":         "start_time",
+	"call_events":   "event_timestamp",
+	"usage_records": "created_at",
+}
+
+// retentionLockKey: Aynı anda tek bir saklama çalışmasına izin veren advisory lock anahtarı.
+const retentionLockKey = 72053
+
+var (
+	// ErrRetentionBusy: Başka bir kopya saklama çalışmasını yürütüyor.
+	ErrRetentionBusy = errors.New("başka bir saklama çalışması sürüyor")
+	// ErrAlreadyPartitioned: Tablo zaten partition'lanmış.
+	ErrAlreadyPartitioned = errors.New("tablo zaten partition'lanmış")
+	// ErrHoldNotFound: Yasal saklama kaydı yok veya zaten kaldırılmış.
+	ErrHoldNotFound = errors.New("aktif yasal saklama kaydı bulunamadı")
+)
+
+type RetentionPolicy struct {
+	TenantID      string    `json:"tenant_id"`
+	RetentionDays int       `json:"retention_days"`
+	Action        string    `json:"action"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type LegalHold struct {
+	ID         int64      `json:"id"`
+	TenantID   string     `json:"tenant_id,omitempty"`
+	HoldFrom   *time.Time `json:"hold_from,omitempty"`
+	HoldTo     *time.Time `json:"hold_to,omitempty"`
+	Reason     string     `json:"reason"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
+// Partition: Katalogdaki aylık (veya legacy) partition.
+type Partition struct {
+	TableName  string
+	Name       string
+	RangeStart time.Time
+	RangeEnd   time.Time
+}
+
+// RetentionDetail: Bir saklama çalışmasında partition veya tenant bazında yapılan (ya da atlanan) iş.
+type RetentionDetail struct {
+	Table     string `json:"table"`
+	Partition string `json:"partition,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	Action    string `json:"action"`
+	Rows      int64  `json:"rows,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// RetentionRun: Saklama çalışmasının özeti; cdr_retention_runs tablosuna yazılır.
+type RetentionRun struct {
+	ID                 int64             `json:"id"`
+	StartedAt          time.Time         `json:"started_at"`
+	FinishedAt         *time.Time        `json:"finished_at,omitempty"`
+	Status             string            `json:"status"`
+	DryRun             bool              `json:"dry_run"`
+	PartitionsCreated  int               `json:"partitions_created"`
+	PartitionsArchived int               `json:"partitions_archived"`
+	PartitionsDropped  int               `json:"partitions_dropped"`
+	RowsDeleted        int64             `json:"rows_deleted"`
+	Details            []RetentionDetail `json:"details"`
+	Error              string            `json:"error,omitempty"`
+}
+
+type RetentionRepository struct {
+	db *sql.DB
+}
+
+func NewRetentionRepository(db *sql.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// Lock: Saklama çalışması için oturum düzeyinde advisory lock alır. Kilit başka kopyadaysa ErrRetentionBusy döner.
+func (r *RetentionRepository) Lock(ctx context.Context) (func(), error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", retentionLockKey).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, ErrRetentionBusy
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", retentionLockKey)
+		conn.Close()
+	}, nil
+}
+
+func (r *RetentionRepository) StartRun(ctx context.Context, dryRun bool) (RetentionRun, error) {
+	run := RetentionRun{Status: "RUNNING", DryRun: dryRun, Details: []RetentionDetail{}}
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO cdr_retention_runs (dry_run) VALUES ($1) RETURNING id, started_at", dryRun).
+		Scan(&run.ID, &run.StartedAt)
+	return run, err
+}
+
+func (r *RetentionRepository) FinishRun(ctx context.Context, run RetentionRun) error {
+	details, err := json.Marshal(run.Details)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE cdr_retention_runs SET
+			finished_at = NOW(), status = $1, partitions_created = $2, partitions_archived = $3,
+			partitions_dropped = $4, rows_deleted = $5, details = $6::jsonb, error = NULLIF($7, '')
+		WHERE id = $8`,
+		run.Status, run.PartitionsCreated, run.PartitionsArchived, run.PartitionsDropped,
+		run.RowsDeleted, string(details), run.Error, run.ID)
+	return err
+}
+
+func (r *RetentionRepository) ListPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT tenant_id, retention_days, action, updated_at FROM cdr_retention_policies ORDER BY tenant_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RetentionPolicy
+	for rows.Next() {
+		var p RetentionPolicy
+		if err := rows.Scan(&p.TenantID, &p.RetentionDays, &p.Action, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *RetentionRepository) UpsertPolicy(ctx context.Context, p RetentionPolicy) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO cdr_retention_policies (tenant_id, retention_days, action) VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			retention_days = EXCLUDED.retention_days, action = EXCLUDED.action, updated_at = NOW()`,
+		p.TenantID, p.RetentionDays, p.Action)
+	return err
+}
+
+func (r *RetentionRepository) DeletePolicy(ctx context.Context, tenantID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM cdr_retention_policies WHERE tenant_id = $1", tenantID)
+	return err
+}
+
+func (r *RetentionRepository) CreateHold(ctx context.Context, h LegalHold) (LegalHold, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO cdr_legal_holds (tenant_id, hold_from, hold_to, reason)
+		VALUES (NULLIF($1, ''), COALESCE($2, '-infinity'::timestamptz), $3, $4)
+		RETURNING id, created_at`,
+		h.TenantID, h.HoldFrom, h.HoldTo, h.Reason).Scan(&h.ID, &h.CreatedAt)
+	return h, err
+}
+
+func (r *RetentionRepository) ReleaseHold(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE cdr_legal_holds SET released_at = NOW() WHERE id = $1 AND released_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrHoldNotFound
+	}
+	return nil
+}
+
+// IsPartitioned: Tablonun (arama yolundaki) partition'lanmış tablo olup olmadığını söyler.
+func (r *RetentionRepository) IsPartitioned(ctx context.Context, table string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass($1))", table).Scan(&ok)
+	return ok, err
+}
+
+// ListPartitions: Tablonun bağlı (ATTACHED) partition'ları, başlangıca göre.
+func (r *RetentionRepository) ListPartitions(ctx context.Context, table string) ([]Partition, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT table_name, partition_name, range_start, range_end FROM cdr_partitions
+		WHERE table_name = $1 AND state = 'ATTACHED'
+		ORDER BY range_start`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Partition
+	for rows.Next() {
+		var p Partition
+		if err := rows.Scan(&p.TableName, &p.Name, &p.RangeStart, &p.RangeEnd); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// CreatePartition: Aylık partition'ı oluşturur ve kataloğa ekler.
+func (r *RetentionRepository) CreatePartition(ctx context.Context, p Partition) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)",
+		quoteIdent(p.Name), quoteIdent(p.TableName), quoteTime(p.RangeStart), quoteTime(p.RangeEnd))
+	if _, err := tx.ExecContext(ctx, ddl); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cdr_partitions (table_name, partition_name, range_start, range_end) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (table_name, partition_name) DO NOTHING`,
+		p.TableName, p.Name, p.RangeStart, p.RangeEnd)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PartitionBlocker: Partition'ın kaldırılmasını engelleyen durum; boşsa kaldırılabilir.
+// Aktif yasal saklama ile kesişen veya henüz faturalanmamış kullanım içeren partition kaldırılmaz.
+func (r *RetentionRepository) PartitionBlocker(ctx context.Context, p Partition) (string, error) {
+	var held bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM cdr_legal_holds
+			WHERE released_at IS NULL AND hold_from < $2 AND (hold_to IS NULL OR hold_to > $1)
+		)`, p.RangeStart, p.RangeEnd).Scan(&held)
+	if err != nil {
+		return "", err
+	}
+	if held {
+		return "yasal saklama kapsamında", nil
+	}
+	if p.TableName == "usage_records" {
+		var unbilled bool
+		err := r.db.QueryRowContext(ctx, fmt.Sprintf(
+			"SELECT EXISTS(SELECT 1 FROM %s WHERE billing_period_id IS NULL)", quoteIdent(p.Name))).Scan(&unbilled)
+		if err != nil {
+			return "", err
+		}
+		if unbilled {
+			return "faturalanmamış kullanım içeriyor", nil
+		}
+	}
+	return "", nil
+}
+
+// CountPartitionRows: Kuru çalışmada kaldırılacak partition'ın satır sayısı.
+func (r *RetentionRepository) CountPartitionRows(ctx context.Context, p Partition) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+quoteIdent(p.Name)).Scan(&n)
+	return n, err
+}
+
+// RemovePartition: Partition'ı ana tablodan ayırır; arşivleniyorsa cdr_archive şemasına taşır, değilse siler.
+// İçindeki çağrı ve olayların zincir kayıtları önce budanmış olarak işaretlenir.
+func (r *RetentionRepository) RemovePartition(ctx context.Context, p Partition, archive bool) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	name := quoteIdent(p.Name)
+	var rows int64
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+name).Scan(&rows); err != nil {
+		return 0, err
+	}
+	if prune := pruneChainFrom(p.TableName, name); prune != "" {
+		if _, err := tx.ExecContext(ctx, prune); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", quoteIdent(p.TableName), name)); err != nil {
+		return 0, err
+	}
+	state := "DROPPED"
+	ddl := "DROP TABLE " + name
+	if archive {
+		state = "ARCHIVED"
+		ddl = fmt.Sprintf("ALTER TABLE %s SET SCHEMA cdr_archive", name)
+	}
+	if _, err := tx.ExecContext(ctx, ddl); err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE cdr_partitions SET state = $1, removed_at = NOW() WHERE table_name = $2 AND partition_name = $3",
+		state, p.TableName, p.Name)
+	if err != nil {
+		return 0, err
+	}
+	return rows, tx.Commit()
+}
+
+// pruneChainFrom: Kaynaktaki (partition veya silinen satırlar) çağrı/olayların zincir kayıtlarını işaretleyen sorgu.
+func pruneChainFrom(table, source string) string {
+	recordType := map[string]string{"calls": chain.RecordCall, "call_events": chain.RecordEvent}[table]
+	if recordType == "" {
+		return ""
+	}
+	return fmt.Sprintf(`
+			UPDATE cdr_hash_chain h SET pruned_at = NOW() FROM %s s
+			WHERE h.record_type = '%s' AND h.call_id = s.call_id AND h.seq = s.chain_seq AND h.pruned_at IS NULL`,
+		source, recordType)
+}
+
+// purgeVictims: Tenant politikasına göre süresi dolmuş satırları seçen sorgular. $1 tenant (veya '*'),
+// $2 kesim zamanı, $3 parti büyüklüğü. '*' yalnızca kendi politikası olmayan tenant'lara uygulanır.
+// Olaylarda tenant çağrı kaydından okunur; faturalanmamış kullanım silinmez.
+var purgeVictims = map[string]string{
+	"call_events": `
+		SELECT e.tableoid, e.ctid FROM call_events e JOIN calls c ON c.call_id = e.call_id
+		WHERE ` + tenantPolicyMatch("c.tenant_id") + ` AND e.event_timestamp < $2
+		  AND ` + notHeld("c.tenant_id", "e.event_timestamp") + `
+		LIMIT $3`,
+	"call_ivr_steps": `
+		SELECT s.tableoid, s.ctid FROM call_ivr_steps s
+		WHERE ` + tenantPolicyMatch("s.tenant_id") + ` AND s.occurred_at < $2
+		  AND ` + notHeld("s.tenant_id", "s.occurred_at") + `
+		LIMIT $3`,
+	"usage_records": `
+		SELECT u.tableoid, u.ctid FROM usage_records u
+		WHERE ` + tenantPolicyMatch("u.tenant_id") + ` AND u.created_at < $2 AND u.billing_period_id IS NOT NULL
+		  AND ` + notHeld("u.tenant_id", "u.created_at") + `
+		LIMIT $3`,
+	"calls": `
+		SELECT c.tableoid, c.ctid FROM calls c
+		WHERE ` + tenantPolicyMatch("c.tenant_id") + ` AND c.start_time < $2
+		  AND ` + notHeld("c.tenant_id", "c.start_time") + `
+		LIMIT $3`,
+}
+
+// PurgeOrder: Olaylar tenant'ı çağrı kaydından aldığı için çağrılardan önce silinir.
+var PurgeOrder = []string{"call_events", "call_ivr_steps", "usage_records", "calls"}
+
+func tenantPolicyMatch(col string) string {
+	return fmt.Sprintf(`(%[1]s = $1 OR ($1 = '*' AND NOT EXISTS (
+			SELECT 1 FROM cdr_retention_policies p WHERE p.tenant_id = %[1]s)))`, col)
+}
+
+func notHeld(tenantCol, timeCol string) string {
+	return fmt.Sprintf(`NOT EXISTS (
+			SELECT 1 FROM cdr_legal_holds h
+			WHERE h.released_at IS NULL AND (h.tenant_id IS NULL OR h.tenant_id = %s)
+			  AND %[2]s >= h.hold_from AND (h.hold_to IS NULL OR %[2]s < h.hold_to))`, tenantCol, timeCol)
+}
+
+// CountExpired: Kuru çalışmada silinecek satır sayısı.
+func (r *RetentionRepository) CountExpired(ctx context.Context, table, tenantID string, cutoff time.Time) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+purgeVictims[table]+") v", tenantID, cutoff, nil).Scan(&n)
+	return n, err
+}
+
+// PurgeBatch: Süresi dolmuş en fazla limit satırı siler (arşivleniyorsa önce cdr_archive'a kopyalar) ve
+// silinen çağrı/olayların zincir kayıtlarını budanmış olarak işaretler. Silinen satır sayısını döner.
+func (r *RetentionRepository) PurgeBatch(ctx context.Context, table, tenantID string, cutoff time.Time, limit int, archive bool) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := fmt.Sprintf(`
+		WITH victims AS (%s),
+		del AS (
+			DELETE FROM %[2]s t USING victims v WHERE t.tableoid = v.tableoid AND t.ctid = v.ctid
+			RETURNING t.*
+		)`, purgeVictims[table], table)
+	if archive {
+		cols, err := r.ensureArchiveTable(ctx, tx, table)
+		if err != nil {
+			return 0, err
+		}
+		query += fmt.Sprintf(`,
+		arch AS (INSERT INTO cdr_archive.%s (%[2]s) SELECT %[2]s FROM del)`, table, cols)
+	}
+	if prune := pruneChainFrom(table, "del"); prune != "" {
+		query += ",\n\t\tpruned AS (" + prune + ")"
+	}
+	query += "\n\t\tSELECT COUNT(*) FROM del"
+
+	var n int64
+	if err := tx.QueryRowContext(ctx, query, tenantID, cutoff, limit).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// ensureArchiveTable: Satır arşiv tablosunu oluşturur, ana tabloya sonradan eklenmiş kolonları ekler ve
+// kopyalanacak kolon listesini döner.
+func (r *RetentionRepository) ensureArchiveTable(ctx context.Context, tx *sql.Tx, table string) (string, error) {
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS cdr_archive.%s (LIKE %[1]s INCLUDING DEFAULTS)", quoteIdent(table))); err != nil {
+		return "", err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod),
+			EXISTS(SELECT 1 FROM pg_attribute x
+			       WHERE x.attrelid = ('cdr_archive.' || $1)::regclass AND x.attname = a.attname AND NOT x.attisdropped)
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, table)
+	if err != nil {
+		return "", err
+	}
+	type column struct {
+		name, typ string
+		exists    bool
+	}
+	var columns []column
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.name, &c.typ, &c.exists); err != nil {
+			rows.Close()
+			return "", err
+		}
+		columns = append(columns, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !c.exists {
+			ddl := fmt.Sprintf("ALTER TABLE cdr_archive.%s ADD COLUMN %s %s", quoteIdent(table), quoteIdent(c.name), c.typ)
+			if _, err := tx.ExecContext(ctx, ddl); err != nil {
+				return "", err
+			}
+		}
+		names = append(names, quoteIdent(c.name))
+	}
+	return strings.Join(names, ", "), nil
+}
+
+// ConvertToPartitioned: Normal tabloyu aylık range partition'lı tabloya dönüştürür. Mevcut tablo, veri
+// kopyalanmadan until'e kadar olan her şeyi kapsayan legacy partition olarak bağlanır. Unique indeksler ve
+// birincil anahtar partition anahtarını içerecek şekilde yeniden tanımlanır. Tek transaction'da ve
+// ACCESS EXCLUSIVE kilitle çalışır; bakım penceresinde çalıştırılmalıdır.
+func (r *RetentionRepository) ConvertToPartitioned(ctx context.Context, table string, until time.Time) error {
+	key, ok := PartitionKeys[table]
+	if !ok {
+		return fmt.Errorf("partition'lanamayan tablo: %q", table)
+	}
+	partitioned, err := r.IsPartitioned(ctx, table)
+	if err != nil {
+		return err
+	}
+	if partitioned {
+		return ErrAlreadyPartitioned
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	t := quoteIdent(table)
+	legacy := table + "_legacy"
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE "+t+" IN ACCESS EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var blocker string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(
+			(SELECT 'identity kolonu: ' || attname FROM pg_attribute
+			 WHERE attrelid = $1::regclass AND attidentity <> '' AND NOT attisdropped LIMIT 1),
+			(SELECT 'başka tablodan yabancı anahtar: ' || conrelid::regclass::text FROM pg_constraint
+			 WHERE confrelid = $1::regclass LIMIT 1),
+			'')`, table).Scan(&blocker)
+	if err != nil {
+		return err
+	}
+	if blocker != "" {
+		return fmt.Errorf("%s dönüştürülemez (%s)", table, blocker)
+	}
+
+	// Tanımlar yeniden adlandırmadan önce okunur; metinler yeni ana tabloda olduğu gibi çalışır.
+	type indexDef struct {
+		name, def, constraintDef string
+		unique                   bool
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT i.indexrelid::regclass::text, pg_get_indexdef(i.indexrelid), i.indisunique,
+			COALESCE(pg_get_constraintdef(con.oid), '')
+		FROM pg_index i
+		LEFT JOIN pg_constraint con ON con.conindid = i.indexrelid AND con.conrelid = i.indrelid AND con.contype IN ('p', 'u')
+		WHERE i.indrelid = $1::regclass`, table)
+	if err != nil {
+		return err
+	}
+	var indexes []indexDef
+	for rows.Next() {
+		var d indexDef
+		if err := rows.Scan(&d.name, &d.def, &d.unique, &d.constraintDef); err != nil {
+			rows.Close()
+			return err
+		}
+		indexes = append(indexes, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var foreignKeys, grants []string
+	fkRows, err := tx.QueryContext(ctx, `
+		SELECT 'ALTER TABLE ' || $2 || ' ADD CONSTRAINT ' || quote_ident(conname) || ' ' || pg_get_constraintdef(oid)
+		FROM pg_constraint WHERE conrelid = $1::regclass AND contype = 'f'`, table, t)
+	if err != nil {
+		return err
+	}
+	for fkRows.Next() {
+		var s string
+		if err := fkRows.Scan(&s); err != nil {
+			fkRows.Close()
+			return err
+		}
+		foreignKeys = append(foreignKeys, s)
+	}
+	fkRows.Close()
+	grantRows, err := tx.QueryContext(ctx, `
+		SELECT 'GRANT ' || privilege_type || ' ON ' || $2 || ' TO ' || quote_ident(grantee)
+		FROM information_schema.role_table_grants
+		WHERE table_name = $1 AND table_schema = current_schema() AND grantee <> current_user`, table, t)
+	if err != nil {
+		return err
+	}
+	for grantRows.Next() {
+		var s string
+		if err := grantRows.Scan(&s); err != nil {
+			grantRows.Close()
+			return err
+		}
+		grants = append(grants, strings.Replace(s, "TO \"PUBLIC\"", "TO PUBLIC", 1))
+	}
+	grantRows.Close()
+
+	stmts := []string{fmt.Sprintf("ALTER TABLE %s RENAME TO %s", t, quoteIdent(legacy))}
+	for _, d := range indexes {
+		stmts = append(stmts, fmt.Sprintf("ALTER INDEX %s RENAME TO %s", d.name, quoteIdent(strings.Trim(d.name, `"`)+"_legacy")))
+	}
+	stmts = append(stmts, fmt.Sprintf(
+		"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING STORAGE INCLUDING COMMENTS) PARTITION BY RANGE (%s)",
+		t, quoteIdent(legacy), quoteIdent(key)))
+	for _, d := range indexes {
+		switch {
+		case strings.HasPrefix(d.constraintDef, "PRIMARY KEY"):
+			stmts = append(stmts,
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", quoteIdent(legacy), quoteIdent(key)),
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", t, quoteIdent(key)),
+				fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", t, d.name, withKey(d.constraintDef, key)))
+		case d.constraintDef != "":
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", t, d.name, withKey(d.constraintDef, key)))
+		case d.unique:
+			stmts = append(stmts, withKey(d.def, key))
+		default:
+			stmts = append(stmts, d.def)
+		}
+	}
+	stmts = append(stmts, foreignKeys...)
+	stmts = append(stmts, grants...)
+	stmts = append(stmts, fmt.Sprintf(`
+		DO $$
+		DECLARE s TEXT;
+		BEGIN
+			FOR s IN
+				SELECT pg_get_serial_sequence(%[1]s, attname) || ' OWNED BY ' || %[2]s || '.' || quote_ident(attname)
+				FROM pg_attribute
+				WHERE attrelid = %[1]s::regclass AND attnum > 0 AND NOT attisdropped
+				  AND pg_get_serial_sequence(%[1]s, attname) IS NOT NULL
+			LOOP
+				EXECUTE 'ALTER SEQUENCE ' || s;
+			END LOOP;
+		END $$`, quoteLiteral(legacy), quoteLiteral(t)))
+	stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM (MINVALUE) TO (%s)",
+		t, quoteIdent(legacy), quoteTime(until)))
+
+	for _, s := range stmts {
+		if _, err := tx.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("%s: %w", strings.TrimSpace(s), err)
+		}
+	}
+	// Legacy partition'ın alt sınırı MINVALUE'dur; katalogda Go time.Time'a okunabilmesi için epoch yazılır.
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cdr_partitions (table_name, partition_name, range_start, range_end)
+		VALUES ($1, $2, 'epoch', $3)`, table, legacy, until)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withKey: İndeks veya kısıt tanımındaki ilk kolon listesine, yoksa partition anahtarını ekler.
+// "UNIQUE (a)" -> "UNIQUE (a, key)", "CREATE UNIQUE INDEX x ON t USING btree (a) WHERE ..." -> "... (a, key) WHERE ...".
+func withKey(def, key string) string {
+	open := strings.Index(def, "(")
+	if open < 0 {
+		return def
+	}
+	depth := 0
+	for i := open; i < len(def); i++ {
+		switch def[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				for _, col := range strings.Split(def[open+1:i], ",") {
+					if strings.Trim(strings.TrimSpace(col), `"`) == key {
+						return def
+					}
+				}
+				return def[:i] + ", " + quoteIdent(key) + def[i:]
+			}
+		}
+	}
+	return def
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func quoteTime(t time.Time) string {
+	return quoteLiteral(t.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,173 @@
+// sentiric-cdr-service/internal/repository/stream.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/fieldcrypt"
+)
+
+// StreamChange: Olay işleyicinin canlı akışa bildirdiği yaşam döngüsü değişikliği.
+type StreamChange struct {
+	EventType  string
+	CallID     string
+	OccurredAt time.Time
+}
+
+// StreamEvent: Canlı akış olayı; değişiklik anındaki çağrı kaydının özeti.
+type StreamEvent struct {
+	ID              int64      `json:"id"`
+	EventType       string     `json:"event_type"`
+	TenantID        string     `json:"tenant_id"`
+	CallID          string     `json:"call_id"`
+	UserID          string     `json:"user_id,omitempty"`
+	Direction       string     `json:"direction"`
+	CallerNumber    string     `json:"caller_number"`
+	CalleeNumber    string     `json:"callee_number"`
+	Status          string     `json:"status,omitempty"`
+	Disposition     string     `json:"disposition,omitempty"`
+	StartTime       *time.Time `json:"start_time,omitempty"`
+	AnswerTime      *time.Time `json:"answer_time,omitempty"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+	DurationSeconds int        `json:"duration_seconds"`
+	OccurredAt      time.Time  `json:"occurred_at"`
+}
+
+// StreamFilter: Akış aboneliği süzgeci. Boş alanlar süzülmez.
+type StreamFilter struct {
+	TenantID  string
+	UserID    string
+	Direction string
+}
+
+// Match: Olayın süzgece uyup uymadığı.
+func (f StreamFilter) Match(e StreamEvent) bool {
+	return (f.TenantID == "" || f.TenantID == e.TenantID) &&
+		(f.UserID == "" || f.UserID == e.UserID) &&
+		(f.Direction == "" || f.Direction == e.Direction)
+}
+
+type StreamRepository struct {
+	db   *sql.DB
+	keys *Keyring
+}
+
+func NewStreamRepository(db *sql.DB, keys *Keyring) *StreamRepository {
+	return &StreamRepository{db: db, keys: keys}
+}
+
+// Append: Değişiklikleri çağrı kayıtlarının anlık görüntüsüyle günlüğe yazar. Yazımlar tüm kopyalarda
+// advisory lock ile sıraya girer; böylece id sırası çoğunlukla commit sırasıdır. Okuyucu yine de sırasız görünür
+// olan id'lere karşı atladığı id'leri kısa bir süre yeniden sorgular (bkz. stream.Hub). Kaydı bulunamayan çağrılar
+// atlanır.
+func (r *StreamRepository) Append(ctx context.Context, changes []StreamChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('call_stream'))"); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO call_stream_events (event_type, tenant_id, call_id, user_id, direction, caller_number,
+				callee_number, status, disposition, start_time, answer_time, end_time, duration_seconds, occurred_at)
+			SELECT $1, tenant_id, call_id, user_id::text, direction, caller_number, callee_number, status,
+				disposition, start_time, answer_time, end_time, duration_seconds, $3
+			FROM calls WHERE call_id = $2
+			ORDER BY start_time DESC LIMIT 1`, c.EventType, c.CallID, c.OccurredAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListAfter: afterID'den sonraki olaylar, id sırasıyla. Numaralar çözülmüş döner.
+func (r *StreamRepository) ListAfter(ctx context.Context, f StreamFilter, afterID int64, limit int) ([]StreamEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+streamEventColumns+`
+		FROM call_stream_events
+		WHERE id > $1 AND ($2 = '' OR tenant_id = $2) AND ($3 = '' OR user_id = $3) AND ($4 = '' OR direction = $4)
+		ORDER BY id LIMIT $5`, afterID, f.TenantID, f.UserID, f.Direction, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.scanStreamEvents(ctx, rows)
+}
+
+// ListByIDs: Verilen id'lerden günlükte görünür olanlar, id sırasıyla. Okuyucunun atladığı (henüz commit
+// edilmemiş) id'leri yeniden sorgulamak için kullanılır.
+func (r *StreamRepository) ListByIDs(ctx context.Context, ids []int64) ([]StreamEvent, error) {
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.FormatInt(id, 10)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+streamEventColumns+`
+		FROM call_stream_events
+		WHERE id = ANY(string_to_array($1, ',')::bigint[])
+		ORDER BY id`, strings.Join(list, ","))
+	if err != nil {
+		return nil, err
+	}
+	return r.scanStreamEvents(ctx, rows)
+}
+
+const streamEventColumns = `id, event_type, tenant_id, call_id, COALESCE(user_id, ''), COALESCE(direction, ''),
+			COALESCE(caller_number, ''), COALESCE(callee_number, ''), COALESCE(status, ''), COALESCE(disposition, ''),
+			start_time, answer_time, end_time, COALESCE(duration_seconds, 0), occurred_at`
+
+func (r *StreamRepository) scanStreamEvents(ctx context.Context, rows *sql.Rows) ([]StreamEvent, error) {
+	defer rows.Close()
+
+	var out []StreamEvent
+	for rows.Next() {
+		var e StreamEvent
+		if err := rows.Scan(&e.ID, &e.EventType, &e.TenantID, &e.CallID, &e.UserID, &e.Direction,
+			&e.CallerNumber, &e.CalleeNumber, &e.Status, &e.Disposition,
+			&e.StartTime, &e.AnswerTime, &e.EndTime, &e.DurationSeconds, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	for i := range out {
+		e := &out[i]
+		if e.CallerNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCallerNumber, e.CallID, e.CallerNumber); err != nil {
+			return nil, err
+		}
+		if e.CalleeNumber, err = r.keys.openField(ctx, fieldcrypt.FieldCalleeNumber, e.CallID, e.CalleeNumber); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Bounds: Günlükteki en eski ve en yeni olay id'si; günlük boşsa en yeni id sıralayıcının son değeridir.
+func (r *StreamRepository) Bounds(ctx context.Context) (oldest, latest int64, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MIN(id), 0),
+			COALESCE(MAX(id), (SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM call_stream_events_id_seq))
+		FROM call_stream_events`).Scan(&oldest, &latest)
+	return oldest, latest, err
+}
+
+// Prune: before'dan önce yazılmış olayları siler.
+func (r *StreamRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM call_stream_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
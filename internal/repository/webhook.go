@@ -0,0 +1,288 @@
+// sentiric-cdr-service/internal/repository/webhook.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Webhook teslim durumları.
+const (
+	WebhookPending   = "PENDING"
+	WebhookDelivered = "DELIVERED"
+	WebhookFailed    = "FAILED"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook aboneliği bulunamadı")
+	ErrWebhookDeliveryNotFound = errors.New("webhook teslimi bulunamadı")
+)
+
+// WebhookSubscription: Tenant'ın webhook aboneliği. Secret yalnızca oluşturma yanıtında döner.
+type WebhookSubscription struct {
+	ID         int64     `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookDelivery: Bir olayın bir aboneliğe teslim kaydı.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	TenantID       string          `json:"tenant_id"`
+	EventType      string          `json:"event_type"`
+	EventID        string          `json:"event_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+
+	// Teslim için abonelikten okunan alanlar; API yanıtlarına yazılmaz.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookAttempt: Tek bir HTTP denemesinin sonucu.
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	DurationMs  int       `json:"duration_ms"`
+	Error       string    `json:"error,omitempty"`
+}
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription: Yeni aboneliği kaydeder.
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, s WebhookSubscription) (WebhookSubscription, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (tenant_id, url, secret, event_types, enabled)
+		VALUES ($1, $2, $3, string_to_array($4, ','), $5)
+		RETURNING id, created_at`,
+		s.TenantID, s.URL, s.Secret, strings.Join(s.EventTypes, ","), s.Enabled).Scan(&s.ID, &s.CreatedAt)
+	return s, err
+}
+
+// ListSubscriptions: Tenant'ın aboneliklerini secret olmadan döner.
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, tenantID string) ([]WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, tenant_id, url, array_to_string(event_types, ','), enabled, created_at
+		FROM webhook_subscriptions WHERE tenant_id = $1 ORDER BY id`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []WebhookSubscription{}
+	for rows.Next() {
+		var s WebhookSubscription
+		var eventTypes string
+		if err := rows.Scan(&s.ID, &s.TenantID, &s.URL, &eventTypes, &s.Enabled, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.EventTypes = strings.Split(eventTypes, ",")
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+// DeleteSubscription: Aboneliği ve teslim geçmişini siler.
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, tenantID string, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// EnqueueEvent: Olayı, tenant'ın bu olay tipine abone olan her etkin aboneliği için teslim kuyruğuna ekler.
+// eventID aynı olayın tekrar işlenmesinde ikinci teslim oluşmasını engeller. Eklenen teslim sayısını döner.
+func (r *WebhookRepository) EnqueueEvent(ctx context.Context, tenantID, eventType, eventID string, payload []byte) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, tenant_id, event_type, event_id, payload)
+		SELECT s.id, s.tenant_id, $2, $3, $4::jsonb
+		FROM webhook_subscriptions s
+		WHERE s.tenant_id = $1 AND s.enabled AND $2 = ANY(s.event_types)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`, tenantID, eventType, eventID, string(payload))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+const webhookDeliveryColumns = `
+	d.id, d.subscription_id, d.tenant_id, d.event_type, d.event_id, d.payload, d.status, d.attempts, d.next_attempt_at,
+	COALESCE(d.last_status_code, 0), COALESCE(d.last_error, ''), d.created_at, d.delivered_at`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }, extra ...interface{}) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	var payload []byte
+	var deliveredAt sql.NullTime
+	dest := append([]interface{}{&d.ID, &d.SubscriptionID, &d.TenantID, &d.EventType, &d.EventID, &payload, &d.Status,
+		&d.Attempts, &d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &deliveredAt}, extra...)
+	err := row.Scan(dest...)
+	d.Payload = payload
+	d.DeliveredAt = nullTimePtr(deliveredAt)
+	return d, err
+}
+
+// ClaimDeliveries: Denemesi gelen en fazla limit kadar teslimi alır; deneme sayısını artırır ve lease
+// süresince başka kopyaların almaması için bir sonraki deneme anını ileri atar.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = NOW() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				FOR UPDATE SKIP LOCKED
+				LIMIT $1
+			)
+			RETURNING *
+		)
+		SELECT`+webhookDeliveryColumns+`, s.url, s.secret
+		FROM claimed d JOIN webhook_subscriptions s ON s.id = d.subscription_id`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []WebhookDelivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		d.URL, d.Secret = url, secret
+		claimed = append(claimed, d)
+	}
+	return claimed, rows.Err()
+}
+
+// RecordAttempt: HTTP denemesini kaydeder ve teslimin durumunu günceller. Başarılıysa DELIVERED olur;
+// değilse retryAt verilmişse o anda tekrar denenir, verilmemişse kalıcı olarak FAILED olur.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, deliveryID int64, a WebhookAttempt, success bool, retryAt *time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempted_at, status_code, duration_ms, error)
+		VALUES ($1, $2, NULLIF($3, 0), $4, NULLIF($5, ''))`,
+		deliveryID, a.AttemptedAt, a.StatusCode, a.DurationMs, a.Error)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case success:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE webhook_deliveries SET status = 'DELIVERED', last_status_code = $2, last_error = NULL, delivered_at = NOW()
+			WHERE id = $1`, deliveryID, a.StatusCode)
+	case retryAt != nil:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE webhook_deliveries SET last_status_code = NULLIF($2, 0), last_error = $3, next_attempt_at = $4
+			WHERE id = $1`, deliveryID, a.StatusCode, a.Error, *retryAt)
+	default:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE webhook_deliveries SET status = 'FAILED', last_status_code = NULLIF($2, 0), last_error = $3
+			WHERE id = $1`, deliveryID, a.StatusCode, a.Error)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplayDelivery: Teslimi durumundan bağımsız olarak yeniden kuyruğa alır. Deneme geçmişi korunur,
+// deneme sayacı sıfırlanır; alıcı aynı event_id ile ikinci kez çağrılabilir.
+func (r *WebhookRepository) ReplayDelivery(ctx context.Context, tenantID string, id int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = 'PENDING', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// ListDeliveries: Tenant'ın webhook teslimlerini en yeniden eskiye döner. status boşsa tüm durumlar.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, tenantID, status string, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT`+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.tenant_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id DESC LIMIT $3`, tenantID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ListAttempts: Teslimin deneme geçmişini zaman sırasıyla döner.
+func (r *WebhookRepository) ListAttempts(ctx context.Context, tenantID string, deliveryID int64) ([]WebhookAttempt, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM webhook_deliveries WHERE id = $1 AND tenant_id = $2)`,
+		deliveryID, tenantID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT attempted_at, COALESCE(status_code, 0), duration_ms, COALESCE(error, '')
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempted_at, id`, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []WebhookAttempt{}
+	for rows.Next() {
+		var a WebhookAttempt
+		if err := rows.Scan(&a.AttemptedAt, &a.StatusCode, &a.DurationMs, &a.Error); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
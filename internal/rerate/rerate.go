@@ -0,0 +1,166 @@
+// AÇIKLAMA: Bu paket, fiyat listesi düzeltildiğinde geçmiş çağrıların usage satırlarını
+// seçilen fiyat sürümüyle yeniden derecelendirir. Eski satırlar silinmez; ters kayıtla kapatılır.
+package rerate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/billing"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// costEpsilon: Bu farkın altındaki maliyet değişiklikleri yeniden yazılmaz.
+const costEpsilon = 1e-9
+
+type Options struct {
+	TenantID    string // Boşsa tüm tenant'lar
+	From        time.Time
+	To          time.Time
+	RateVersion string
+	DryRun      bool
+}
+
+// TenantSummary: Bir tenant için yeniden derecelendirme sonucu.
+type TenantSummary struct {
+	TenantID     string
+	CallsScanned int
+	CallsChanged int
+	LinesChanged int
+	OldCost      float64
+	NewCost      float64
+}
+
+func (s TenantSummary) Delta() float64 {
+	return s.NewCost - s.OldCost
+}
+
+type Rerater struct {
+	repo     *repository.CallRepository
+	log      zerolog.Logger
+	policies map[string]billing.RoundingPolicy
+}
+
+func New(db *sql.DB, keys *repository.Keyring, log zerolog.Logger) *Rerater {
+	return &Rerater{
+		repo:     repository.NewCallRepository(db, keys, log),
+		log:      log,
+		policies: make(map[string]billing.RoundingPolicy),
+	}
+}
+
+// Run: Seçilen aralıktaki çağrıları tek tek yeniden derecelendirir. Her çağrı kendi transaction'ında yazılır;
+// yarıda kesilen bir çalıştırma tekrar başlatıldığında yalnızca hâlâ farklı olan satırları düzeltir.
+func (r *Rerater) Run(ctx context.Context, opts Options) (string, []TenantSummary, error) {
+	batchID := fmt.Sprintf("rerate-%s-%d", opts.RateVersion, time.Now().UTC().Unix())
+
+	calls, err := r.repo.ListCallsForRerate(ctx, opts.TenantID, opts.From, opts.To)
+	if err != nil {
+		return batchID, nil, fmt.Errorf("çağrılar okunamadı: %w", err)
+	}
+
+	summaries := make(map[string]*TenantSummary)
+	for _, call := range calls {
+		if err := ctx.Err(); err != nil {
+			return batchID, sortedSummaries(summaries), err
+		}
+
+		sum, ok := summaries[call.TenantID]
+		if !ok {
+			sum = &TenantSummary{TenantID: call.TenantID}
+			summaries[call.TenantID] = sum
+		}
+		sum.CallsScanned++
+
+		if err := r.rerateCall(ctx, call, batchID, opts, sum); err != nil {
+			return batchID, sortedSummaries(summaries), fmt.Errorf("çağrı %s yeniden derecelendirilemedi: %w", call.CallID, err)
+		}
+	}
+	return batchID, sortedSummaries(summaries), nil
+}
+
+func (r *Rerater) rerateCall(ctx context.Context, call repository.RerateCall, batchID string, opts Options, sum *TenantSummary) error {
+	usages, err := r.repo.ListActiveUsage(ctx, call.CallID)
+	if err != nil {
+		return err
+	}
+
+	var originals []repository.ActiveUsage
+	var replacements []repository.UsageRecord
+	for _, u := range usages {
+		price, found, err := r.repo.GetVersionUnitPrice(ctx, opts.RateVersion, u.TenantID, u.ResourceType)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		next := u.UsageRecord
+		next.UnitPrice = price
+		next.RateVersion = opts.RateVersion
+
+		// Telefon dakikası ham süreden, tenant'ın güncel yuvarlama politikasıyla yeniden hesaplanır.
+		if rawMs, ok := u.RawDurationMs.(int64); ok && u.ResourceType == billing.ResourceTelephonyMinute {
+			policy, err := r.policy(ctx, u.TenantID)
+			if err != nil {
+				return err
+			}
+			rated := policy.BillableSeconds(rawMs)
+			next.Quantity = float64(rated) / 60.0
+			next.RatedSeconds = rated
+			next.RoundingPolicy = policy.String()
+		}
+		next.Cost = next.Quantity * price
+
+		if math.Abs(next.Cost-u.Cost) < costEpsilon && math.Abs(next.Quantity-u.Quantity) < costEpsilon {
+			continue
+		}
+		originals = append(originals, u)
+		replacements = append(replacements, next)
+		sum.OldCost += u.Cost
+		sum.NewCost += next.Cost
+	}
+
+	if len(originals) == 0 {
+		return nil
+	}
+	sum.CallsChanged++
+	sum.LinesChanged += len(originals)
+
+	if opts.DryRun {
+		return nil
+	}
+	if err := r.repo.SupersedeUsage(ctx, call.CallID, batchID, originals, replacements); err != nil {
+		return err
+	}
+	r.log.Debug().Str("call_id", call.CallID).Int("lines", len(originals)).Msg("Çağrı yeniden derecelendirildi.")
+	return nil
+}
+
+func (r *Rerater) policy(ctx context.Context, tenantID string) (billing.RoundingPolicy, error) {
+	if p, ok := r.policies[tenantID]; ok {
+		return p, nil
+	}
+	p, err := r.repo.GetRoundingPolicy(ctx, tenantID)
+	if err != nil {
+		return p, err
+	}
+	r.policies[tenantID] = p
+	return p, nil
+}
+
+func sortedSummaries(m map[string]*TenantSummary) []TenantSummary {
+	out := make([]TenantSummary, 0, len(m))
+	for _, s := range m {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TenantID < out[j].TenantID })
+	return out
+}
@@ -0,0 +1,314 @@
+// AÇIKLAMA: Bu paket, calls, call_events ve usage_records için aylık partition'ları önceden oluşturur ve
+// tenant saklama politikalarına göre süresi dolan veriyi ve ses kayıtlarını arşivler veya siler. Yasal saklama
+// altındaki veri korunur.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+const (
+	runInterval = 6 * time.Hour
+	// PartitionsAhead: Mevcut aydan sonra önceden oluşturulan aylık partition sayısı.
+	PartitionsAhead = 3
+	purgeBatchSize  = 5000
+	recordingsTable = "call_recordings"
+)
+
+type Manager struct {
+	repo       *repository.RetentionRepository
+	recordings *repository.RecordingRepository
+	log        zerolog.Logger
+}
+
+func NewManager(db *sql.DB, log zerolog.Logger) *Manager {
+	return &Manager{
+		repo:       repository.NewRetentionRepository(db),
+		recordings: repository.NewRecordingRepository(db),
+		log:        log,
+	}
+}
+
+// Run: Context iptal edilene kadar periyodik olarak saklama çalışması yürütür.
+func (m *Manager) Run(ctx context.Context) {
+	m.log.Info().Msg("🗄️ Veri saklama yöneticisi aktif")
+	ticker := time.NewTicker(runInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := m.RunOnce(ctx, false)
+		if err != nil && !errors.Is(err, repository.ErrRetentionBusy) && ctx.Err() == nil {
+			m.log.Error().Err(err).Msg("Saklama çalışması başarısız oldu.")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce: Partition'ları hazırlar, süresi dolan partition'ları kaldırır ve tenant bazlı satır temizliği yapar.
+// dryRun ise hiçbir şey değiştirilmez; yapılacak işler ve satır sayıları raporlanır.
+func (m *Manager) RunOnce(ctx context.Context, dryRun bool) (repository.RetentionRun, error) {
+	unlock, err := m.repo.Lock(ctx)
+	if err != nil {
+		return repository.RetentionRun{}, err
+	}
+	defer unlock()
+
+	run, err := m.repo.StartRun(ctx, dryRun)
+	if err != nil {
+		return run, err
+	}
+
+	now := time.Now().UTC()
+	err = m.ensurePartitions(ctx, &run, now)
+	if err == nil {
+		err = m.applyPolicies(ctx, &run, now)
+	}
+
+	run.Status = "SUCCEEDED"
+	if err != nil {
+		run.Status, run.Error = "FAILED", err.Error()
+	}
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	if finishErr := m.repo.FinishRun(context.WithoutCancel(ctx), run); finishErr != nil {
+		m.log.Error().Err(finishErr).Int64("run_id", run.ID).Msg("Saklama çalışması kaydı güncellenemedi.")
+	}
+
+	metrics.RetentionRuns.WithLabelValues(run.Status).Inc()
+	log := m.log.With().Int64("run_id", run.ID).Bool("dry_run", dryRun).
+		Int("partitions_created", run.PartitionsCreated).Int("partitions_archived", run.PartitionsArchived).
+		Int("partitions_dropped", run.PartitionsDropped).Int64("rows_deleted", run.RowsDeleted).Logger()
+	if err != nil {
+		return run, err
+	}
+	if !dryRun {
+		metrics.RetentionLastSuccess.Set(float64(finished.Unix()))
+	}
+	log.Info().Msg("🗄️ Saklama çalışması tamamlandı.")
+	return run, nil
+}
+
+// ensurePartitions: Partition'lanmış tablolarda mevcut ay ve sonraki PartitionsAhead ay için partition açar.
+func (m *Manager) ensurePartitions(ctx context.Context, run *repository.RetentionRun, now time.Time) error {
+	for _, table := range repository.PurgeOrder {
+		partitioned, err := m.repo.IsPartitioned(ctx, table)
+		if err != nil {
+			return err
+		}
+		if !partitioned {
+			continue
+		}
+		existing, err := m.repo.ListPartitions(ctx, table)
+		if err != nil {
+			return err
+		}
+		for _, p := range MonthlyPartitions(table, existing, now, PartitionsAhead) {
+			if !run.DryRun {
+				if err := m.repo.CreatePartition(ctx, p); err != nil {
+					return fmt.Errorf("%s oluşturulamadı: %w", p.Name, err)
+				}
+				metrics.RetentionPartitions.WithLabelValues(table, "created").Inc()
+			}
+			run.PartitionsCreated++
+			run.Details = append(run.Details, repository.RetentionDetail{Table: table, Partition: p.Name, Action: "CREATE"})
+		}
+	}
+	return nil
+}
+
+// MonthlyPartitions: Mevcut partition'ların bittiği aydan now'dan ahead ay sonrasına kadar eksik aylık partition'lar.
+func MonthlyPartitions(table string, existing []repository.Partition, now time.Time, ahead int) []repository.Partition {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range existing {
+		if p.RangeEnd.After(start) {
+			start = p.RangeEnd.UTC()
+		}
+	}
+	limit := time.Date(now.Year(), now.Month()+time.Month(ahead)+1, 1, 0, 0, 0, 0, time.UTC)
+
+	var out []repository.Partition
+	for start.Before(limit) {
+		end := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		out = append(out, repository.Partition{
+			TableName:  table,
+			Name:       fmt.Sprintf("%s_p%s", table, start.Format("200601")),
+			RangeStart: start,
+			RangeEnd:   end,
+		})
+		start = end
+	}
+	return out
+}
+
+// applyPolicies: Önce tüm tenant'lar için süresi dolmuş partition'ları, sonra tenant bazında satırları temizler.
+func (m *Manager) applyPolicies(ctx context.Context, run *repository.RetentionRun, now time.Time) error {
+	policies, err := m.repo.ListPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.expirePartitions(ctx, run, policies, now); err != nil {
+		return err
+	}
+	for _, p := range policies {
+		cutoff := now.AddDate(0, 0, -p.RetentionDays)
+		for _, table := range repository.PurgeOrder {
+			rows, err := m.purge(ctx, table, p, cutoff, run.DryRun)
+			if err != nil {
+				return fmt.Errorf("%s (%s) temizlenemedi: %w", table, p.TenantID, err)
+			}
+			if rows == 0 {
+				continue
+			}
+			run.RowsDeleted += rows
+			run.Details = append(run.Details, repository.RetentionDetail{
+				Table: table, TenantID: p.TenantID, Action: p.Action, Rows: rows,
+			})
+		}
+		n, err := m.expireRecordings(ctx, p, cutoff, run.DryRun)
+		if err != nil {
+			return fmt.Errorf("%s (%s) işlenemedi: %w", recordingsTable, p.TenantID, err)
+		}
+		if n > 0 {
+			run.Details = append(run.Details, repository.RetentionDetail{
+				Table: recordingsTable, TenantID: p.TenantID, Action: p.Action, Rows: n,
+			})
+		}
+	}
+	return nil
+}
+
+// expireRecordings: Saklama süresi dolan ses kayıtlarını partiler halinde işler. Kayıt satırı silinmez; ARCHIVE
+// politikasında arşiv sınıfına alınır, DELETE politikasında DELETED olur ve depolama katmanı olayla bilgilendirilir.
+func (m *Manager) expireRecordings(ctx context.Context, p repository.RetentionPolicy, cutoff time.Time, dryRun bool) (int64, error) {
+	archive := p.Action == repository.RetentionArchive
+	if dryRun {
+		return m.recordings.CountExpiredRecordings(ctx, p.TenantID, cutoff, archive)
+	}
+	var total int64
+	for ctx.Err() == nil {
+		n, err := m.recordings.ExpireRecordings(ctx, p.TenantID, cutoff, purgeBatchSize, archive)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		metrics.RetentionRows.WithLabelValues(recordingsTable, p.Action).Add(float64(n))
+		if n < purgeBatchSize {
+			break
+		}
+	}
+	return total, ctx.Err()
+}
+
+// expirePartitions: Bir partition tüm tenant'ların verisini içerdiğinden, yalnızca varsayılan politika tanımlıyken
+// ve partition en uzun saklama süresinden de eskiyse kaldırılır. Politikalardan herhangi biri ARCHIVE ise partition
+// arşivlenir; arşiv isteyen bir tenant'ın verisi başka bir politikanın DELETE aksiyonuyla silinmez.
+func (m *Manager) expirePartitions(ctx context.Context, run *repository.RetentionRun, policies []repository.RetentionPolicy, now time.Time) error {
+	cutoff, action, ok := partitionExpiry(policies, now)
+	if !ok {
+		return nil
+	}
+	archive := action == repository.RetentionArchive
+
+	for _, table := range repository.PurgeOrder {
+		partitions, err := m.repo.ListPartitions(ctx, table)
+		if err != nil {
+			return err
+		}
+		for _, p := range partitions {
+			if p.RangeEnd.After(cutoff) {
+				continue
+			}
+			detail := repository.RetentionDetail{Table: table, Partition: p.Name, Action: action}
+			reason, err := m.repo.PartitionBlocker(ctx, p)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				detail.Action, detail.Reason = "SKIP", reason
+				run.Details = append(run.Details, detail)
+				metrics.RetentionPartitions.WithLabelValues(table, "skipped").Inc()
+				m.log.Warn().Str("partition", p.Name).Str("reason", reason).Msg("Süresi dolan partition kaldırılmadı.")
+				continue
+			}
+
+			if run.DryRun {
+				detail.Rows, err = m.repo.CountPartitionRows(ctx, p)
+			} else {
+				detail.Rows, err = m.repo.RemovePartition(ctx, p, archive)
+			}
+			if err != nil {
+				return fmt.Errorf("%s kaldırılamadı: %w", p.Name, err)
+			}
+			run.RowsDeleted += detail.Rows
+			run.Details = append(run.Details, detail)
+			result := "dropped"
+			if archive {
+				run.PartitionsArchived++
+				result = "archived"
+			} else {
+				run.PartitionsDropped++
+			}
+			if !run.DryRun {
+				metrics.RetentionPartitions.WithLabelValues(table, result).Inc()
+				metrics.RetentionRows.WithLabelValues(table, action).Add(float64(detail.Rows))
+				m.log.Info().Str("partition", p.Name).Str("action", action).Int64("rows", detail.Rows).
+					Msg("Süresi dolan partition kaldırıldı.")
+			}
+		}
+	}
+	return nil
+}
+
+// partitionExpiry: Bitişi cutoff'tan sonra olmayan partition'ların kaldırılma aksiyonunu döner. Varsayılan politika
+// yoksa partition'lar kaldırılmaz (ok=false).
+func partitionExpiry(policies []repository.RetentionPolicy, now time.Time) (cutoff time.Time, action string, ok bool) {
+	hasDefault, archive := false, false
+	longest := 0
+	for _, p := range policies {
+		hasDefault = hasDefault || p.TenantID == repository.DefaultPolicyTenant
+		archive = archive || p.Action == repository.RetentionArchive
+		longest = max(longest, p.RetentionDays)
+	}
+	if !hasDefault {
+		return time.Time{}, "", false
+	}
+	action = repository.RetentionDelete
+	if archive {
+		action = repository.RetentionArchive
+	}
+	return now.AddDate(0, 0, -longest), action, true
+}
+
+// purge: Politikanın kapsadığı süresi dolmuş satırları partiler halinde siler/arşivler.
+func (m *Manager) purge(ctx context.Context, table string, p repository.RetentionPolicy, cutoff time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return m.repo.CountExpired(ctx, table, p.TenantID, cutoff)
+	}
+	archive := p.Action == repository.RetentionArchive
+	var total int64
+	for ctx.Err() == nil {
+		n, err := m.repo.PurgeBatch(ctx, table, p.TenantID, cutoff, purgeBatchSize, archive)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		metrics.RetentionRows.WithLabelValues(table, p.Action).Add(float64(n))
+		if n < purgeBatchSize {
+			break
+		}
+	}
+	return total, ctx.Err()
+}
@@ -0,0 +1,87 @@
+// sentiric-cdr-service/internal/retention/retention_test.go
+package retention
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func month(y int, m time.Month) time.Time {
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMonthlyPartitions(t *testing.T) {
+	now := time.Date(2025, 11, 15, 12, 0, 0, 0, time.UTC)
+	existing := func(ends ...time.Time) []repository.Partition {
+		var out []repository.Partition
+		for _, end := range ends {
+			out = append(out, repository.Partition{TableName: "calls", RangeStart: end.AddDate(0, -1, 0), RangeEnd: end})
+		}
+		return out
+	}
+
+	cases := []struct {
+		name     string
+		existing []repository.Partition
+		ahead    int
+		want     []string
+	}{
+		{"hiç partition yok", nil, 2, []string{"calls_p202511", "calls_p202512", "calls_p202601"}},
+		{"yıl dönümü", existing(month(2025, 12)), 2, []string{"calls_p202512", "calls_p202601"}},
+		{"hepsi var", existing(month(2025, 12), month(2026, 2)), 2, nil},
+		{"yalnızca eski partition'lar", existing(month(2025, 3)), 0, []string{"calls_p202511"}},
+		{"ileri tarihli son partition", existing(month(2026, 6)), 1, nil},
+	}
+	for _, c := range cases {
+		got := MonthlyPartitions("calls", c.existing, now, c.ahead)
+		var names []string
+		for i, p := range got {
+			names = append(names, p.Name)
+			if p.TableName != "calls" || !p.RangeEnd.Equal(p.RangeStart.AddDate(0, 1, 0)) || p.RangeStart.Day() != 1 {
+				t.Errorf("%s: geçersiz aralık %s: %v - %v", c.name, p.Name, p.RangeStart, p.RangeEnd)
+			}
+			if i > 0 && !p.RangeStart.Equal(got[i-1].RangeEnd) {
+				t.Errorf("%s: %s önceki partition'a bitişik değil", c.name, p.Name)
+			}
+		}
+		if !slices.Equal(names, c.want) {
+			t.Errorf("%s: partition'lar = %v, beklenen %v", c.name, names, c.want)
+		}
+	}
+}
+
+func TestPartitionExpiry(t *testing.T) {
+	now := time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC)
+	policy := func(tenant string, days int, action string) repository.RetentionPolicy {
+		return repository.RetentionPolicy{TenantID: tenant, RetentionDays: days, Action: action}
+	}
+	cases := []struct {
+		name       string
+		policies   []repository.RetentionPolicy
+		wantOK     bool
+		wantAction string
+		wantCutoff time.Time
+	}{
+		{"politika yok", nil, false, "", time.Time{}},
+		{"yalnızca tenant politikası", []repository.RetentionPolicy{policy("acme", 30, repository.RetentionDelete)}, false, "", time.Time{}},
+		{"varsayılan silme", []repository.RetentionPolicy{policy(repository.DefaultPolicyTenant, 90, repository.RetentionDelete)},
+			true, repository.RetentionDelete, now.AddDate(0, 0, -90)},
+		{"tenant arşiv ister", []repository.RetentionPolicy{
+			policy(repository.DefaultPolicyTenant, 90, repository.RetentionDelete),
+			policy("acme", 30, repository.RetentionArchive),
+		}, true, repository.RetentionArchive, now.AddDate(0, 0, -90)},
+		{"en uzun süre", []repository.RetentionPolicy{
+			policy(repository.DefaultPolicyTenant, 90, repository.RetentionDelete),
+			policy("acme", 400, repository.RetentionDelete),
+		}, true, repository.RetentionDelete, now.AddDate(0, 0, -400)},
+	}
+	for _, c := range cases {
+		cutoff, action, ok := partitionExpiry(c.policies, now)
+		if ok != c.wantOK || action != c.wantAction || !cutoff.Equal(c.wantCutoff) {
+			t.Errorf("%s: (%v, %q, %v), beklenen (%v, %q, %v)", c.name, cutoff, action, ok, c.wantCutoff, c.wantAction, c.wantOK)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// AÇIKLAMA: Bu paket, çağrı yaşam döngüsü değişikliklerinin süpervizör panolarına canlı yayınını içerir.
+// Olay işleyici değişiklikleri bekletmeden Publisher'a bırakır; Publisher bunları kısa ömürlü olay günlüğüne
+// yazar, her servis kopyasındaki Hub günlüğü okuyup bağlı abonelere dağıtır.
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/metrics"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+const (
+	// queueSize: Yazılmayı bekleyen en fazla değişiklik; dolarsa yeni değişiklikler düşürülür, tüketici beklemez.
+	queueSize = 4096
+	// batchSize / batchWait: Günlüğe tek transaction'da yazılan en fazla değişiklik ve toplama süresi.
+	batchSize = 200
+	batchWait = 200 * time.Millisecond
+	// pollInterval: Hub'ın günlükte yeni olay arama sıklığı.
+	pollInterval = 500 * time.Millisecond
+	pollLimit    = 500
+	// gapGrace / maxGaps: Okunan id'ler arasındaki boşluklar, geç commit edilen yazımlar için bu süre boyunca
+	// yeniden sorgulanır; süre dolan boşluk geri alınmış yazım sayılır. İzlenen boşluk sayısı sınırlıdır.
+	gapGrace = 10 * time.Second
+	maxGaps  = 1000
+	// subscriberBuffer: Aboneye gönderilmeyi bekleyen en fazla olay; dolan abone yavaş sayılıp düşürülür.
+	subscriberBuffer = 256
+	// Retention: Olayların günlükte tutulma süresi; daha eski Last-Event-ID'den devam edilemez.
+	Retention     = time.Hour
+	pruneInterval = 5 * time.Minute
+)
+
+// Publisher: Olay işleyicinin değişiklikleri bıraktığı yazıcı.
+type Publisher struct {
+	repo  *repository.StreamRepository
+	log   zerolog.Logger
+	queue chan repository.StreamChange
+}
+
+func NewPublisher(db *sql.DB, keys *repository.Keyring, log zerolog.Logger) *Publisher {
+	return &Publisher{
+		repo:  repository.NewStreamRepository(db, keys),
+		log:   log,
+		queue: make(chan repository.StreamChange, queueSize),
+	}
+}
+
+// Publish: Değişikliği yazılmak üzere kuyruğa bırakır; hiçbir zaman beklemez. Kuyruk doluysa değişiklik
+// düşürülür: canlı akış en iyi çaba ile çalışır, CDR'ın kendisi etkilenmez.
+func (p *Publisher) Publish(eventType, callID string, occurredAt time.Time) {
+	select {
+	case p.queue <- repository.StreamChange{EventType: eventType, CallID: callID, OccurredAt: occurredAt}:
+	default:
+		metrics.StreamDropped.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// Run: Context iptal edilene kadar kuyruktaki değişiklikleri toplu halde günlüğe yazar.
+func (p *Publisher) Run(ctx context.Context) {
+	batch := make([]repository.StreamChange, 0, batchSize)
+	timer := time.NewTimer(batchWait)
+	defer timer.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.repo.Append(context.WithoutCancel(ctx), batch); err != nil {
+			metrics.StreamDropped.WithLabelValues("write_error").Add(float64(len(batch)))
+			p.log.Error().Err(err).Int("changes", len(batch)).Msg("Canlı akış olayları yazılamadı.")
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush()
+			return
+		case c := <-p.queue:
+			batch = append(batch, c)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(batchWait)
+		}
+	}
+}
+
+// Subscription: Hub aboneliği. C kapandığında Lagged, aboneliğin yavaşlık nedeniyle düşürüldüğünü söyler.
+type Subscription struct {
+	C      <-chan repository.StreamEvent
+	c      chan repository.StreamEvent
+	filter repository.StreamFilter
+	lagged bool
+}
+
+// Lagged: Abonelik olaylara yetişemediği için kapatıldı mı. Yalnızca C kapandıktan sonra okunmalıdır.
+func (s *Subscription) Lagged() bool {
+	return s.lagged
+}
+
+// Hub: Olay günlüğünü okuyup bu kopyadaki abonelere dağıtır.
+type Hub struct {
+	repo *repository.StreamRepository
+	log  zerolog.Logger
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	last int64
+	// gaps: last'tan küçük olup henüz görülmemiş id'ler ve ilk fark edildikleri an.
+	gaps map[int64]time.Time
+}
+
+func NewHub(db *sql.DB, keys *repository.Keyring, log zerolog.Logger) *Hub {
+	return &Hub{
+		repo: repository.NewStreamRepository(db, keys),
+		log:  log,
+		subs: map[*Subscription]struct{}{},
+		gaps: map[int64]time.Time{},
+	}
+}
+
+// Run: Context iptal edilene kadar günlüğü okur ve eski olayları siler. Okuma, açılış anındaki son olaydan başlar.
+func (h *Hub) Run(ctx context.Context) {
+	h.log.Info().Msg("📡 Canlı çağrı akışı aktif")
+	for ctx.Err() == nil {
+		_, latest, err := h.repo.Bounds(ctx)
+		if err == nil {
+			h.mu.Lock()
+			h.last = latest
+			h.mu.Unlock()
+			break
+		}
+		h.log.Error().Err(err).Msg("Canlı akış başlangıç konumu okunamadı.")
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case <-poll.C:
+			if err := h.poll(ctx); err != nil && ctx.Err() == nil {
+				h.log.Error().Err(err).Msg("Canlı akış olayları okunamadı.")
+			}
+		case <-prune.C:
+			if _, err := h.repo.Prune(ctx, time.Now().Add(-Retention)); err != nil && ctx.Err() == nil {
+				h.log.Error().Err(err).Msg("Eski canlı akış olayları silinemedi.")
+			}
+		}
+	}
+}
+
+// poll: Yeni olayları ve daha önce atlanmış id'leri okur. Id'ler insert anında alınıp commit anında görünür
+// olduğundan, başka kopyanın yazımı okunan bir id'den sonra commit edilebilir; atlanan id'ler gapGrace boyunca
+// yeniden sorgulanır ve görünür olunca (sırası geçmiş olsa da) dağıtılır.
+func (h *Hub) poll(ctx context.Context) error {
+	for {
+		h.mu.Lock()
+		last := h.last
+		h.mu.Unlock()
+
+		events, err := h.repo.ListAfter(ctx, repository.StreamFilter{}, last, pollLimit)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			h.dispatch(events, time.Now())
+		}
+		if len(events) < pollLimit {
+			break
+		}
+	}
+
+	h.mu.Lock()
+	ids := make([]int64, 0, len(h.gaps))
+	for id := range h.gaps {
+		ids = append(ids, id)
+	}
+	h.mu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+	late, err := h.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(late) > 0 {
+		metrics.StreamLateEvents.Add(float64(len(late)))
+	}
+	h.dispatch(late, time.Now())
+	return nil
+}
+
+// dispatch: Olayları abonelere gönderir. Tamponu dolu abone beklenmez; kapatılır ve istemci
+// Last-Event-ID ile yeniden bağlanarak kaldığı yerden devam eder.
+func (h *Hub) dispatch(events []repository.StreamEvent, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range events {
+		if e.ID > h.last {
+			for id := h.last + 1; id < e.ID && len(h.gaps) < maxGaps; id++ {
+				h.gaps[id] = now
+			}
+			h.last = e.ID
+		} else if _, ok := h.gaps[e.ID]; ok {
+			delete(h.gaps, e.ID)
+		} else {
+			continue // Zaten dağıtılmış.
+		}
+		for s := range h.subs {
+			if !s.filter.Match(e) {
+				continue
+			}
+			select {
+			case s.c <- e:
+			default:
+				s.lagged = true
+				h.remove(s)
+				metrics.StreamSlowClients.Inc()
+			}
+		}
+	}
+	for id, seen := range h.gaps {
+		if now.Sub(seen) > gapGrace {
+			delete(h.gaps, id)
+		}
+	}
+}
+
+// Subscribe: Süzgece uyan yeni olaylar için abonelik açar.
+func (h *Hub) Subscribe(f repository.StreamFilter) *Subscription {
+	c := make(chan repository.StreamEvent, subscriberBuffer)
+	s := &Subscription{C: c, c: c, filter: f}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	metrics.StreamSubscribers.Inc()
+	return s
+}
+
+// Unsubscribe: Aboneliği kapatır; birden fazla çağrılabilir.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	close(s.c)
+	metrics.StreamSubscribers.Dec()
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		h.remove(s)
+	}
+}
+
+// Replay: afterID'den sonraki, süzgece uyan olayları sayfa sayfa okur. reset true ise afterID günlükten
+// silinmiş bir konumdur; istemci aradaki olayları kaçırmıştır ve durumu sorgu API'sinden yeniden kurmalıdır.
+func (h *Hub) Replay(ctx context.Context, f repository.StreamFilter, afterID int64, fn func(repository.StreamEvent) error) (reset bool, err error) {
+	oldest, latest, err := h.repo.Bounds(ctx)
+	if err != nil {
+		return false, err
+	}
+	if (oldest == 0 && afterID < latest) || (oldest > 0 && afterID < oldest-1) {
+		return true, nil
+	}
+	for {
+		events, err := h.repo.ListAfter(ctx, f, afterID, pollLimit)
+		if err != nil {
+			return false, err
+		}
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return false, err
+			}
+			afterID = e.ID
+		}
+		if len(events) < pollLimit {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// sentiric-cdr-service/internal/stream/stream_test.go
+package stream
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func TestHubDispatchGaps(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	cases := []struct {
+		name      string
+		batches   [][]int64
+		at        []time.Duration
+		wantIDs   []int64
+		wantGaps  []int64
+		wantLast  int64
+		startLast int64
+	}{
+		{
+			name:     "sıralı",
+			batches:  [][]int64{{11, 12, 13}},
+			at:       []time.Duration{0},
+			wantIDs:  []int64{11, 12, 13},
+			wantLast: 13, startLast: 10,
+		},
+		{
+			name:     "boşluk izlenir",
+			batches:  [][]int64{{11, 14}},
+			at:       []time.Duration{0},
+			wantIDs:  []int64{11, 14},
+			wantGaps: []int64{12, 13},
+			wantLast: 14, startLast: 10,
+		},
+		{
+			name:     "geç commit edilen olay dağıtılır",
+			batches:  [][]int64{{11, 13}, {12}},
+			at:       []time.Duration{0, time.Second},
+			wantIDs:  []int64{11, 13, 12},
+			wantLast: 13, startLast: 10,
+		},
+		{
+			name:     "tekrar gelen olay atlanır",
+			batches:  [][]int64{{11, 12}, {12}},
+			at:       []time.Duration{0, time.Second},
+			wantIDs:  []int64{11, 12},
+			wantLast: 12, startLast: 10,
+		},
+		{
+			name:     "süresi dolan boşluk bırakılır",
+			batches:  [][]int64{{11, 13}, {}, {12}},
+			at:       []time.Duration{0, gapGrace + time.Second, gapGrace + 2*time.Second},
+			wantIDs:  []int64{11, 13},
+			wantLast: 13, startLast: 10,
+		},
+	}
+	for _, c := range cases {
+		h := NewHub(nil, nil, zerolog.Nop())
+		h.last = c.startLast
+		sub := h.Subscribe(repository.StreamFilter{TenantID: "t1"})
+		for i, batch := range c.batches {
+			events := make([]repository.StreamEvent, len(batch))
+			for j, id := range batch {
+				events[j] = repository.StreamEvent{ID: id, TenantID: "t1"}
+			}
+			h.dispatch(events, start.Add(c.at[i]))
+		}
+		h.Unsubscribe(sub)
+
+		var got []int64
+		for e := range sub.C {
+			got = append(got, e.ID)
+		}
+		if !slices.Equal(got, c.wantIDs) {
+			t.Errorf("%s: dağıtılan = %v, beklenen %v", c.name, got, c.wantIDs)
+		}
+		if h.last != c.wantLast {
+			t.Errorf("%s: last = %d, beklenen %d", c.name, h.last, c.wantLast)
+		}
+		if len(h.gaps) != len(c.wantGaps) {
+			t.Errorf("%s: boşluklar = %v, beklenen %v", c.name, h.gaps, c.wantGaps)
+		}
+		for _, id := range c.wantGaps {
+			if _, ok := h.gaps[id]; !ok {
+				t.Errorf("%s: %d boşluk olarak izlenmiyor", c.name, id)
+			}
+		}
+	}
+}
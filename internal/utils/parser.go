@@ -44,6 +44,34 @@ func ParseSipUri(uri string) string {
 	return cleaned
 }
 
+// ParseSipHost: "Alice <sip:1001@10.0.0.1:5060;transport=udp>" -> "10.0.0.1". Host yoksa boş döner.
+func ParseSipHost(uri string) string {
+	s := uri
+	if idx := strings.Index(s, "sip:"); idx != -1 {
+		s = s[idx+4:]
+	} else if idx := strings.Index(s, "sips:"); idx != -1 {
+		s = s[idx+5:]
+	}
+	idx := strings.Index(s, "@")
+	if idx == -1 {
+		return ""
+	}
+	s = s[idx+1:]
+	if end := strings.IndexAny(s, ";>? "); end != -1 {
+		s = s[:end]
+	}
+	// IPv6 "[::1]:5060" biçimi köşeli parantezle gelir.
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end != -1 {
+			return strings.ToLower(s[1:end])
+		}
+	}
+	if idx := strings.LastIndex(s, ":"); idx != -1 {
+		s = s[:idx]
+	}
+	return strings.ToLower(s)
+}
+
 // DetermineDirection: Numara uzunluğuna ve içeriğine göre çağrı yönünü tahmin eder.
 func DetermineDirection(caller, callee string) string {
 	// Basit kural: Arayan numara uzunsa (905...) ve aranan kısaysa (1001) -> INBOUND
@@ -66,3 +94,49 @@ func DetermineDirection(caller, callee string) string {
 	// Varsayılan
 	return "INBOUND"
 }
+
+// DestinationGroup: Faturada satırları gruplamak için aranan numaranın tarife grubunu belirler.
+// Türkiye numaralandırma planı esas alınır: 90 + 5xx mobil, 90 + 800 ücretsiz, 90 + diğer sabit hat.
+func DestinationGroup(direction, callee string) string {
+	switch direction {
+	case "INTERNAL":
+		return "INTERNAL"
+	case "INBOUND":
+		return "INBOUND"
+	}
+
+	n := strings.TrimPrefix(callee, "+")
+	if strings.HasPrefix(n, "00") {
+		n = n[2:]
+	} else if strings.HasPrefix(n, "0") {
+		n = "90" + n[1:]
+	}
+
+	switch {
+	case len(n) <= 5:
+		return "INTERNAL"
+	case !strings.HasPrefix(n, "90"):
+		return "INTERNATIONAL"
+	case strings.HasPrefix(n, "90800"):
+		return "TOLL_FREE"
+	case strings.HasPrefix(n, "905"):
+		return "MOBILE"
+	default:
+		return "LANDLINE"
+	}
+}
+
+// MaskNumber: Numaranın son 4 hanesi dışındaki karakterlerini '*' ile maskeler ("905321234567" -> "********4567").
+// 4 karakter veya daha kısa numaralar (dahili hatlar) olduğu gibi bırakılır.
+func MaskNumber(number string) string {
+	r := []rune(number)
+	if len(r) <= 4 {
+		return number
+	}
+	for i := 0; i < len(r)-4; i++ {
+		if r[i] != '+' {
+			r[i] = '*'
+		}
+	}
+	return string(r)
+}
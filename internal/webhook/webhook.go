@@ -0,0 +1,270 @@
+// AÇIKLAMA: Bu paket, tenant'ların webhook aboneliklerine çağrı olaylarını HMAC imzalı HTTP POST
+// istekleriyle teslim eder. Teslimler veritabanında kalıcıdır; başarısız olanlar artan beklemeyle tekrar denenir.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/netguard"
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+// Abone olunabilen olay tipleri.
+const (
+	EventCallCompleted          = "call.completed"
+	EventCallRecordingAvailable = "call.recording.available"
+)
+
+// EventTypes: Abonelik oluşturulurken kabul edilen olay tipleri.
+var EventTypes = []string{EventCallCompleted, EventCallRecordingAvailable}
+
+// İstek başlıkları. İmza, "<timestamp>.<gövde>" dizisinin abonelik secret'ıyla HMAC-SHA256'sıdır.
+const (
+	HeaderEvent     = "X-Sentiric-Event"
+	HeaderEventID   = "X-Sentiric-Event-Id"
+	HeaderDelivery  = "X-Sentiric-Delivery"
+	HeaderTimestamp = "X-Sentiric-Timestamp"
+	HeaderSignature = "X-Sentiric-Signature"
+)
+
+const (
+	pollInterval   = time.Second
+	batchSize      = 20
+	requestTimeout = 10 * time.Second
+	// claimLease: İstek zaman aşımından uzun olmalıdır; süre dolarsa teslim başka kopya tarafından alınabilir.
+	claimLease   = time.Minute
+	maxAttempts  = 8
+	maxRetryWait = 6 * time.Hour
+)
+
+// Envelope: Webhook isteğinin gövdesi.
+type Envelope struct {
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	TenantID   string          `json:"tenant_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Sign: Alıcıların doğrulaması gereken imzayı üretir: hex(HMAC-SHA256(secret, timestamp + "." + body)).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret: Yeni abonelik için rastgele imza anahtarı üretir.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ValidateSubscription: Abonelik URL'sini ve olay tiplerini doğrular. Yalnızca https kabul edilir; adres IP
+// olarak verilmişse iç ağ adresi olmamalıdır (alan adları bağlantı anında ayrıca denetlenir).
+func ValidateSubscription(s repository.WebhookSubscription) error {
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("geçersiz url (https zorunludur): %q", s.URL)
+	}
+	if u.User != nil {
+		return fmt.Errorf("url kullanıcı bilgisi içeremez: %q", s.URL)
+	}
+	if netguard.PrivateLiteral(u.Hostname()) {
+		return fmt.Errorf("url iç ağ adresine yönlendirilemez: %q", s.URL)
+	}
+	if len(s.EventTypes) == 0 {
+		return fmt.Errorf("en az bir olay tipi gerekir: %v", EventTypes)
+	}
+	for _, t := range s.EventTypes {
+		known := false
+		for _, k := range EventTypes {
+			known = known || t == k
+		}
+		if !known {
+			return fmt.Errorf("bilinmeyen olay tipi %q (desteklenenler: %v)", t, EventTypes)
+		}
+	}
+	return nil
+}
+
+// Enqueue: Olayı tenant'ın ilgili aboneliklerine teslim edilmek üzere kalıcı kuyruğa yazar.
+func Enqueue(ctx context.Context, repo *repository.WebhookRepository, tenantID, eventType, eventID string, occurredAt time.Time, data interface{}) (int, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(Envelope{
+		EventID:    eventID,
+		EventType:  eventType,
+		TenantID:   tenantID,
+		OccurredAt: occurredAt.UTC(),
+		Data:       raw,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return repo.EnqueueEvent(ctx, tenantID, eventType, eventID, body)
+}
+
+// newClient: Webhook istemcisi. Ortamdaki proxy ayarları kullanılmaz, yönlendirmeler izlenmez (3xx başarısız
+// sayılır) ve yalnızca genel adreslere bağlanılır.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: netguard.DialControl}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: requestTimeout,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+type Dispatcher struct {
+	repo   *repository.WebhookRepository
+	client *http.Client
+	log    zerolog.Logger
+}
+
+func NewDispatcher(db *sql.DB, log zerolog.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repository.NewWebhookRepository(db),
+		client: newClient(),
+		log:    log,
+	}
+}
+
+// Run: Context iptal edilene kadar bekleyen teslimleri alır ve paralel olarak gönderir.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.log.Info().Msg("🔔 Webhook dağıtıcısı aktif")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				batch, err := d.repo.ClaimDeliveries(ctx, batchSize, claimLease)
+				if err != nil {
+					if ctx.Err() == nil {
+						d.log.Error().Err(err).Msg("Bekleyen webhook teslimleri alınamadı.")
+					}
+					break
+				}
+
+				var wg sync.WaitGroup
+				for _, del := range batch {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						d.deliver(ctx, del)
+					}()
+				}
+				wg.Wait()
+
+				if len(batch) < batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, del repository.WebhookDelivery) {
+	log := d.log.With().Int64("webhook_delivery_id", del.ID).Str("tenant_id", del.TenantID).
+		Str("event_type", del.EventType).Int("attempt", del.Attempts).Logger()
+
+	attempt, success := d.send(ctx, del)
+	if ctx.Err() != nil {
+		return // Kapanışta yarıda kalan deneme lease sonunda tekrar alınır.
+	}
+
+	var retryAt *time.Time
+	if !success && del.Attempts < maxAttempts {
+		t := time.Now().Add(retryBackoff(del.Attempts))
+		retryAt = &t
+	}
+	if err := d.repo.RecordAttempt(ctx, del.ID, attempt, success, retryAt); err != nil {
+		log.Error().Err(err).Msg("Webhook denemesi kaydedilemedi.")
+		return
+	}
+
+	switch {
+	case success:
+		log.Debug().Int("status", attempt.StatusCode).Msg("Webhook teslim edildi.")
+	case retryAt != nil:
+		log.Warn().Int("status", attempt.StatusCode).Str("error", attempt.Error).Time("retry_at", *retryAt).
+			Msg("Webhook teslimi başarısız, tekrar denenecek.")
+	default:
+		log.Error().Int("status", attempt.StatusCode).Str("error", attempt.Error).
+			Msg("Webhook teslimi kalıcı olarak başarısız oldu.")
+	}
+}
+
+// send: İmzalı isteği gönderir. Yalnızca 2xx yanıt başarılı sayılır. Yanıt gövdesi okunmaz ve saklanmaz;
+// denemeye yalnızca durum satırı yazılır.
+func (d *Dispatcher) send(ctx context.Context, del repository.WebhookDelivery) (repository.WebhookAttempt, bool) {
+	attempt := repository.WebhookAttempt{AttemptedAt: time.Now().UTC()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt, false
+	}
+	ts := attempt.AttemptedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sentiric-cdr-service-webhook")
+	req.Header.Set(HeaderEvent, del.EventType)
+	req.Header.Set(HeaderEventID, del.EventID)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(del.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(del.Secret, ts, del.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMs = int(time.Since(attempt.AttemptedAt).Milliseconds())
+		return attempt, false
+	}
+	resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	attempt.DurationMs = int(time.Since(attempt.AttemptedAt).Milliseconds())
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = "HTTP " + resp.Status
+		return attempt, false
+	}
+	return attempt, true
+}
+
+// retryBackoff: 30 sn, 1 dk, 2 dk, ... en fazla 6 saat.
+func retryBackoff(attempt int) time.Duration {
+	wait := 30 * time.Second << min(attempt-1, 16)
+	return min(wait, maxRetryWait)
+}
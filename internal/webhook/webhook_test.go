@@ -0,0 +1,55 @@
+// sentiric-cdr-service/internal/webhook/webhook_test.go
+package webhook
+
+import (
+	"testing"
+
+	"github.com/sentiric/sentiric-cdr-service/internal/repository"
+)
+
+func TestValidateSubscriptionURL(t *testing.T) {
+	cases := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://hooks.example.com/cdr", false},
+		{"https://hooks.example.com:8443/cdr", false},
+		{"https://93.184.216.34/cdr", false},
+		{"http://hooks.example.com/cdr", true},
+		{"ftp://hooks.example.com/cdr", true},
+		{"https://user:pw@hooks.example.com/cdr", true},
+		{"https://127.0.0.1/cdr", true},
+		{"https://10.0.0.5/cdr", true},
+		{"https://169.254.169.254/latest/meta-data", true},
+		{"https://[::1]/cdr", true},
+		{"https://[::ffff:192.168.1.1]/cdr", true},
+		{"https:///cdr", true},
+	}
+	for _, c := range cases {
+		err := ValidateSubscription(repository.WebhookSubscription{URL: c.url, EventTypes: []string{EventCallCompleted}})
+		if (err != nil) != c.wantErr {
+			t.Errorf("ValidateSubscription(%q) hata = %v, hata bekleniyor = %v", c.url, err, c.wantErr)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event_id":"e1"}`)
+	cases := []struct {
+		name   string
+		secret string
+		ts     int64
+		body   []byte
+		want   string
+	}{
+		{"imza", "whsec_test", 1700000000, body, "v1=8a9b910184f1d9ed3590e106c991e230a182f3b9356c7c1ed91c013d17ed0775"},
+		{"başka zaman damgası", "whsec_test", 1700000001, body, "v1=f554b6cd1c97cacd5e9ddfef9f60088c3d4089416857dfe6aa21eca712ee3338"},
+		{"başka anahtar", "other", 1700000000, body, "v1=79a89c77f1aef468f541a55cad1d61080ac148b922fa46cc89b3862ebe45abb8"},
+		{"boş gövde", "whsec_test", 1700000000, nil, "v1=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc"},
+	}
+	for _, c := range cases {
+		if got := Sign(c.secret, c.ts, c.body); got != c.want {
+			t.Errorf("%s: Sign = %s, beklenen %s", c.name, got, c.want)
+		}
+	}
+}